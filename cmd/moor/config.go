@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default options can be put in a config file, one per line, without the
+// leading dashes:
+//
+//	# Comments start with a hash
+//	wrap
+//	tab-size = 4
+//	mousemode = select
+//
+// Options from the MOOR environment variable override the ones from the config
+// file, and command line options override both.
+//
+// Key bindings can't be changed from the config file, except for the keys that
+// have options of their own, like "invert-key".
+
+// Returns the path to the config file, whether or not it exists. Empty if we
+// don't know where the user's config files go.
+func configFilePath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configHome, "moor", "moorrc")
+}
+
+// A config file option, like "--tab-size=4", and the line it came from
+type configOption struct {
+	line   int
+	option string
+}
+
+// Applies the options from the config file to the flag set. A missing config
+// file means no options.
+//
+// Errors are prefixed with the config file path and line number.
+func applyConfigFile(flagSet *flag.FlagSet, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	options, err := parseConfig(path, string(contents))
+	if err != nil {
+		return err
+	}
+
+	// One at a time, so that we know which line any error came from
+	for _, option := range options {
+		err = flagSet.Parse([]string{option.option})
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, option.line, err)
+		}
+	}
+
+	return nil
+}
+
+// Turns config file contents into command line options like "--wrap" and
+// "--tab-size=4". The path is only used in error messages.
+func parseConfig(path string, contents string) ([]configOption, error) {
+	options := []configOption{}
+	for i, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(line, "=")
+		name = strings.TrimLeft(strings.TrimSpace(name), "-")
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("%s:%d: Expected an option name, optionally followed by \"= value\", got: %s", path, i+1, line)
+		}
+
+		option := "--" + name
+		if hasValue {
+			option += "=" + strings.TrimSpace(value)
+		}
+		options = append(options, configOption{line: i + 1, option: option})
+	}
+
+	return options, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+// Don't let the config file of whoever runs the tests affect the tests
+func TestMain(m *testing.M) {
+	configHome, err := os.MkdirTemp("", "moor-test-config")
+	if err != nil {
+		panic(err)
+	}
+	err = os.Setenv("XDG_CONFIG_HOME", configHome)
+	if err != nil {
+		panic(err)
+	}
+
+	exitCode := m.Run()
+
+	_ = os.RemoveAll(configHome)
+	os.Exit(exitCode)
+}
+
+func TestParseConfig(t *testing.T) {
+	options, err := parseConfig("moorrc", `
+# Comments and blank lines are ignored
+wrap
+
+  tab-size = 4
+--mousemode=select
+not-found-message = Nope: %s
+`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, options, []configOption{
+		{line: 3, option: "--wrap"},
+		{line: 5, option: "--tab-size=4"},
+		{line: 6, option: "--mousemode=select"},
+		{line: 7, option: "--not-found-message=Nope: %s"},
+	}, cmp.AllowUnexported(configOption{}))
+
+	_, err = parseConfig("moorrc", "wrap\ntab size = 4\n")
+	assert.Error(t, err, "moorrc:2: Expected an option name, optionally followed by \"= value\", got: tab size = 4")
+
+	_, err = parseConfig("moorrc", "= 4\n")
+	assert.ErrorContains(t, err, "moorrc:1: ")
+}
+
+// Invalid values should say where in the config file they are
+func TestConfigFileInvalidValue(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "moorrc")
+	assert.NilError(t, os.WriteFile(configFile, []byte("wrap\n\ntab-size = many\n"), 0o600))
+
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	wrap := flagSet.Bool("wrap", false, "")
+	flagSet.Int("tab-size", 8, "")
+
+	err := applyConfigFile(flagSet, configFile)
+	assert.ErrorContains(t, err, configFile+":3: invalid value \"many\" for flag -tab-size")
+	assert.Assert(t, *wrap)
+}
+
+func TestConfigFilePrecedence(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	assert.Equal(t, configFilePath(), filepath.Join(configHome, "moor", "moorrc"))
+
+	assert.NilError(t, os.MkdirAll(filepath.Join(configHome, "moor"), 0o700))
+	assert.NilError(t, os.WriteFile(configFilePath(), []byte("wrap\ntab-size = 4\nshift = 5\n"), 0o600))
+
+	t.Setenv("MOOR", "--shift=7 --tab-size=3")
+
+	pager, _, _, _, _, err := pagerFromArgs(
+		[]string{"", "--tab-size=2", "config_test.go"},
+		func(_ twin.MouseMode, _ twin.ColorCount, _ twin.ScreenOptions) (twin.Screen, error) {
+			return twin.NewFakeScreen(80, 24), nil
+		},
+		false, // stdin is redirected
+		false, // stdout is redirected
+	)
+	assert.NilError(t, err)
+
+	// Only in the config file
+	assert.Assert(t, pager.WrapLongLines)
+
+	// The environment overrides the config file
+	assert.Equal(t, pager.SideScrollAmount, 7)
+
+	// The command line overrides both
+	assert.Equal(t, pager.TabSize, 2)
+}
+
+func TestMissingConfigFile(t *testing.T) {
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	wrap := flagSet.Bool("wrap", false, "")
+
+	assert.NilError(t, applyConfigFile(flagSet, filepath.Join(t.TempDir(), "does-not-exist")))
+	assert.Assert(t, !*wrap)
+}
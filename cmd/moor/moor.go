@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"runtime"
 	"runtime/debug"
 	"strconv"
@@ -138,6 +139,34 @@ func parseStatusBarStyle(styleOption string) (internal.StatusBarOption, error) {
 	return 0, fmt.Errorf("Good ones are inverse, plain and bold")
 }
 
+func parseNotFoundAlert(alertOption string) (internal.NotFoundAlertOption, error) {
+	if alertOption == "none" {
+		return internal.NOT_FOUND_ALERT_NONE, nil
+	}
+	if alertOption == "beep" {
+		return internal.NOT_FOUND_ALERT_BEEP, nil
+	}
+	if alertOption == "flash" {
+		return internal.NOT_FOUND_ALERT_FLASH, nil
+	}
+
+	return 0, fmt.Errorf("Good ones are none, beep and flash")
+}
+
+func parseSearchCase(caseOption string) (internal.SearchCaseOption, error) {
+	if caseOption == "auto" {
+		return internal.SEARCH_CASE_AUTO, nil
+	}
+	if caseOption == "sensitive" {
+		return internal.SEARCH_CASE_SENSITIVE, nil
+	}
+	if caseOption == "insensitive" {
+		return internal.SEARCH_CASE_INSENSITIVE, nil
+	}
+
+	return 0, fmt.Errorf("Good ones are auto, sensitive and insensitive")
+}
+
 func parseUnprintableStyle(styleOption string) (textstyles.UnprintableStyleT, error) {
 	if styleOption == "highlight" {
 		return textstyles.UnprintableStyleHighlight, nil
@@ -149,6 +178,25 @@ func parseUnprintableStyle(styleOption string) (textstyles.UnprintableStyleT, er
 	return 0, fmt.Errorf("Good ones are highlight or whitespace")
 }
 
+func parseStripPrefix(pattern string) (*regexp.Regexp, error) {
+	// Only ever match at the start of the line
+	return regexp.Compile("^(?:" + pattern + ")")
+}
+
+func parseTsvTable(tableOption string) (internal.TsvTableOption, error) {
+	if tableOption == "off" {
+		return internal.TSV_TABLE_OFF, nil
+	}
+	if tableOption == "aligned" {
+		return internal.TSV_TABLE_ALIGNED, nil
+	}
+	if tableOption == "separated" {
+		return internal.TSV_TABLE_SEPARATED, nil
+	}
+
+	return 0, fmt.Errorf("Good ones are off, aligned and separated")
+}
+
 func parseScrollHint(scrollHint string) (textstyles.CellWithMetadata, error) {
 	scrollHint = strings.ReplaceAll(scrollHint, "ESC", "\x1b")
 	hintAsLine := reader.NewLine(scrollHint)
@@ -175,6 +223,71 @@ func parseShiftAmount(shiftAmount string) (uint, error) {
 	return uint(value), nil
 }
 
+func parseDuration(duration string) (time.Duration, error) {
+	value, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("Duration must not be negative: %s", duration)
+	}
+
+	return value, nil
+}
+
+func parseWrapMargin(wrapMargin string) (uint, error) {
+	value, err := strconv.ParseUint(wrapMargin, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(value), nil
+}
+
+func parseMaxWrapRows(rows string) (uint, error) {
+	value, err := strconv.ParseUint(rows, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Expected a number of rows, got: %s", rows)
+	}
+
+	return uint(value), nil
+}
+
+func parseStickyLines(lines string) (uint, error) {
+	value, err := strconv.ParseUint(lines, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Expected a number of lines, got: %s", lines)
+	}
+
+	return uint(value), nil
+}
+
+func parseScrollAcceleration(maxStep string) (uint, error) {
+	value, err := strconv.ParseUint(maxStep, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	if value < 1 {
+		return 0, fmt.Errorf("Scroll acceleration must be at least 1")
+	}
+
+	return uint(value), nil
+}
+
+func parseInvertKey(invertKey string) (rune, error) {
+	runes := []rune(invertKey)
+	if len(runes) != 1 {
+		return 0, fmt.Errorf("Expected exactly one character, got <%s>", invertKey)
+	}
+
+	if internal.IsViewingModeRune(runes[0]) {
+		return 0, fmt.Errorf("<%s> already does something else, pick another key", invertKey)
+	}
+
+	return runes[0], nil
+}
+
 func parseTabAmount(tabAmount string) (uint, error) {
 	value, err := strconv.ParseUint(tabAmount, 10, 32)
 	if err != nil {
@@ -198,9 +311,68 @@ func parseMouseMode(mouseMode string) (twin.MouseMode, error) {
 		return twin.MouseModeSelect, nil
 	case "scroll":
 		return twin.MouseModeScroll, nil
+	case "hover":
+		return twin.MouseModeHover, nil
+	}
+
+	return twin.MouseModeAuto, fmt.Errorf("Valid modes are auto, select, scroll and hover")
+}
+
+func parseMouseEncoding(mouseEncoding string) (twin.MouseEncoding, error) {
+	switch mouseEncoding {
+	case "sgr":
+		return twin.MouseEncodingSGR, nil
+	case "x10":
+		return twin.MouseEncodingX10, nil
+	}
+
+	return twin.MouseEncodingSGR, fmt.Errorf("Valid encodings are sgr and x10")
+}
+
+func parseWideRuneAtEdge(wideRuneAtEdge string) (twin.WideRuneAtEdgeOption, error) {
+	switch wideRuneAtEdge {
+	case "space":
+		return twin.WideRuneAtEdgeSpace, nil
+	case "blank":
+		return twin.WideRuneAtEdgeBlank, nil
+	case "hint":
+		return twin.WideRuneAtEdgeHint, nil
+	}
+
+	return twin.WideRuneAtEdgeSpace, fmt.Errorf("Good ones are space, blank and hint")
+}
+
+func parseTrailerBackground(trailerBackground string) (twin.TrailerBackgroundOption, error) {
+	switch trailerBackground {
+	case "inherit":
+		return twin.TrailerBackgroundInherit, nil
+	case "default":
+		return twin.TrailerBackgroundDefault, nil
+	}
+
+	return twin.TrailerBackgroundInherit, fmt.Errorf("Good ones are inherit and default")
+}
+
+// Parses colors like "#ff8000" or "208", the latter being an entry in the 256
+// color palette
+func parseColor(color string) (*twin.Color, error) {
+	if hex, isHex := strings.CutPrefix(color, "#"); isHex {
+		value, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return nil, fmt.Errorf("Expected six hex digits after the #, like #ff8000")
+		}
+
+		parsed := twin.NewColorHex(uint32(value))
+		return &parsed, nil
 	}
 
-	return twin.MouseModeAuto, fmt.Errorf("Valid modes are auto, select and scroll")
+	value, err := strconv.ParseUint(color, 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("Expected a color like #ff8000, or a 256 color palette number like 208")
+	}
+
+	parsed := twin.NewColor256(uint8(value))
+	return &parsed, nil
 }
 
 func pumpToStdout(inputFilenames ...string) error {
@@ -288,6 +460,26 @@ func getTargetLine(args []string) (*linemetadata.Index, []string) {
 	return nil, args
 }
 
+// Parses an argument like "+/pattern" anywhere on the command line into a
+// search string, and returns the remaining args.
+//
+// Returns an empty string on no search specified.
+func getInitialSearch(args []string) (string, []string) {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "+/") || len(arg) == len("+/") {
+			continue
+		}
+
+		remainingArgs := make([]string, 0)
+		remainingArgs = append(remainingArgs, args[:i]...)
+		remainingArgs = append(remainingArgs, args[i+1:]...)
+
+		return arg[len("+/"):], remainingArgs
+	}
+
+	return "", args
+}
+
 func russiaNotSupported() {
 	if !strings.HasPrefix(strings.ToLower(os.Getenv("LANG")), "ru_ru") {
 		// Not russia
@@ -357,7 +549,7 @@ func getVersion() string {
 // Can return a nil pager on --help or --version, or if pumping to stdout.
 func pagerFromArgs(
 	args []string,
-	newScreen func(mouseMode twin.MouseMode, terminalColorCount twin.ColorCount) (twin.Screen, error),
+	newScreen func(mouseMode twin.MouseMode, terminalColorCount twin.ColorCount, options twin.ScreenOptions) (twin.Screen, error),
 	stdinIsRedirected bool,
 	stdoutIsRedirected bool,
 ) (
@@ -375,7 +567,13 @@ func pagerFromArgs(
 	trace := flagSet.Bool("trace", false, "Print trace logs after exiting")
 
 	wrap := flagSet.Bool("wrap", false, "Wrap long lines")
+	wrapMargin := flagSetFunc(flagSet, "wrap-margin", 0, "Number of empty `columns` to the right of wrapped lines", parseWrapMargin)
+	wrapAsNeeded := flagSet.Bool("wrap-as-needed", false, "Wrap only lines that don't fit on screen, and never scroll sideways while wrapping")
+	maxWrapRows := flagSetFunc(flagSet, "max-wrap-rows", 0, "When wrapping, show at most this many `rows` of each line, defaults to 0 (no limit)", parseMaxWrapRows)
 	follow := flagSet.Bool("follow", false, "Follow piped input just like \"tail -f\"")
+	appendStdin := flagSet.Bool("append-stdin", false, "Show piped input after the contents of the one input file, and follow it")
+	command := flagSet.String("command", "", "Run this shell `command` and page its live output")
+	commandRestart := flagSet.Bool("command-restart", false, "Run --command again every time it exits")
 	styleOption := flagSetFunc(flagSet,
 		"style", nil,
 		"Highlighting `style` from https://xyproto.github.io/splash/docs/longer/all.html", parseStyleOption)
@@ -391,35 +589,107 @@ func pagerFromArgs(
 	terminalColorsCount := flagSetFunc(flagSet,
 		"colors", defaultFormatter, "Highlighting palette size: 8, 16, 256, 16M, auto", parseColorsOption)
 
+	asciiLines := flagSet.Bool("ascii-lines", false, "Show box drawing characters as ASCII, for fonts lacking them")
+	scrollPastEnd := flagSet.Bool("scroll-past-end", false, "Allow scrolling down until the last line is at the top of the screen")
+	detectURLs := flagSet.Bool("detect-urls", false, "Make http(s) URLs in the text into clickable hyperlinks")
+	idleTimeout := flagSetFunc(flagSet, "idle-timeout", time.Duration(0),
+		"Exit after this `duration` without any key presses, like 10m. Default is to never exit.", parseDuration)
+	idleTimeoutFollowKeepsAlive := flagSet.Bool("idle-timeout-follow-keeps-alive", false, "With --idle-timeout, new lines arriving while following count as activity")
+	newLinesMarker := flagSetFunc(flagSet, "new-lines-marker", time.Duration(0),
+		"Mark lines added or changed by reloading or following with a + for this `duration`, like 5s", parseDuration)
+	noAlternateScroll := flagSet.Bool("no-alternate-scroll", false, "Don't make the mouse wheel send arrow keys, for terminals that get confused by that")
+	noSynchronizedOutput := flagSet.Bool("no-synchronized-output", false, "Don't ask the terminal to show screen updates all at once, for terminals that get confused by that")
+	queryPalette := flagSet.Bool("query-palette", false, "Ask the terminal for its palette colors, for more accurate downsampling to 256 or fewer colors")
+	kittyKeyboard := flagSet.Bool("kitty-keyboard", false, "Use the Kitty keyboard protocol if the terminal supports it, so that Alt-letter and Ctrl-I don't act like the letter and Tab")
 	noLineNumbers := flagSet.Bool("no-linenumbers", noLineNumbersDefault(), "Hide line numbers on startup, press left arrow key to show")
+	zeroBasedLineNumbers := flagSet.Bool("zero-based-linenumbers", false, "Count lines from 0 rather than from 1, both when showing line numbers and when going to a line")
+	clearSearchOnEscape := flagSet.Bool("clear-search-on-escape", false, "Stop highlighting search hits when leaving the search prompt using ESC")
+	noSearchHighlight := flagSet.Bool("no-search-highlight", false, "Don't highlight search hits, toggle with 'H'")
+	multilineSearch := flagSet.Bool("multiline-search", false, "Let search hits span multiple lines, use \\n in the search to match line breaks")
+	searchHitLineBackground := flagSet.Bool("search-line-background", false, "Tint the background of lines with search hits")
+	searchHitFg := flagSetFunc(flagSet, "search-hit-fg", nil,
+		"Search hit text `color`, like #ff8000 or 208 from the 256 color palette. Defaults to the style's.", parseColor)
+	searchHitBg := flagSetFunc(flagSet, "search-hit-bg", nil,
+		"Search hit background `color`, like #ff8000 or 208 from the 256 color palette. Defaults to the style's.", parseColor)
+	searchMinimap := flagSet.Bool("search-minimap", false, "Show where in the input the search hits are in the rightmost screen column")
+	minimalSearchScroll := flagSet.Bool("search-minimal-scroll", false, "While typing a search, scroll sideways only as far as needed to show the closest hit")
+	searchContext := flagSet.Bool("search-context", false, "Show the search pattern and the first hit on screen with some surrounding text in the status bar")
+	searchLineTimeout := flagSetFunc(flagSet, "search-line-timeout", time.Second,
+		"Skip lines taking longer than this `duration` to search, 0 means never skip", parseDuration)
+	pattern := flagSet.String("pattern", "", "Start at the first line matching this search `pattern`, like less -p. Also available as +/pattern.")
+	searchHistoryFile := flagSet.String("search-history-file", "", "Remember searches between runs in this `file`, browse them using the arrow keys in the search prompt")
+	searchCase := flagSetFunc(flagSet, "search-case", internal.SEARCH_CASE_AUTO,
+		"Search `case` sensitivity: auto, sensitive or insensitive. auto is case sensitive only for patterns with upper case.", parseSearchCase)
+	searchCommaSeparated := flagSet.Bool("search-comma-separated", false, "Search for any of several comma separated terms, like \"foo, bar\". Toggle with ','")
+	searchLiteral := flagSet.Bool("search-literal", false, "Search for the text as typed rather than for a regexp")
+	searchAndOr := flagSet.Bool("search-and-or", false, "Combine search patterns using \" && \" and \" || \", like \"error && disk || panic\". Not with --search-literal.")
+	noSearchWrap := flagSet.Bool("no-search-wrap", false, "Stop searching at the end of the input rather than continuing from the start")
 	noStatusBar := flagSet.Bool("no-statusbar", false, "Hide the status bar, toggle with '='")
 	reFormat := flagSet.Bool("reformat", false, "Reformat some input files (JSON)")
 	flagSet.Bool("no-reformat", true, "No effect, kept for compatibility. See --reformat")
 	quitIfOneScreen := flagSet.Bool("quit-if-one-screen", false, "Don't page if contents fits on one screen. Affected by --no-clear-on-exit-margin.")
 	noClearOnExit := flagSet.Bool("no-clear-on-exit", false, "Retain screen contents when exiting moor")
+	inline := flagSet.Bool("inline", false, "Page below the shell prompt rather than on the alternate screen, and leave the last page there on exit")
 	noClearOnExitMargin := flagSet.Int("no-clear-on-exit-margin", 1,
 		"Number of lines to leave for your shell prompt, defaults to 1")
+	perFileView := flagSet.Bool("per-file-view", false, "Remember wrapping, line numbers and sideways scrolling separately for each file")
+	printAllOnExit := flagSet.Int("print-all-on-exit", 0,
+		"Print all input after exiting if it has at most this many `lines`, defaults to 0 (never)")
+	clipboardMaxBytes := flagSet.Int("clipboard-max-bytes", 100_000,
+		"Refuse copying to the clipboard if it is larger than this many `bytes`, 0 means no limit")
+	copyANSI := flagSet.Bool("copy-ansi", false, "Keep colors when copying mouse selections or the current line ('T') to the clipboard")
+	notFoundMessage := flagSet.String("not-found-message", "Not found: %s", "Status bar `message` when a search fails, %s is replaced by the search string")
+	notFoundAlert := flagSetFunc(flagSet, "not-found-alert", internal.NOT_FOUND_ALERT_NONE,
+		"When a search fails, also: none, beep or flash", parseNotFoundAlert)
+	statusBarSegments := flagSet.Bool("statusbar-segments", false, "Show the file name, position and mode indicators to the left, center and right of the status bar")
 	statusBarStyle := flagSetFunc(flagSet, "statusbar", internal.STATUSBAR_STYLE_INVERSE,
 		"Status bar `style`: inverse, plain or bold", parseStatusBarStyle)
 	unprintableStyle := flagSetFunc(flagSet, "render-unprintable", textstyles.UnprintableStyleHighlight,
 		"How unprintable characters are rendered: highlight or whitespace", parseUnprintableStyle)
+	resetUnderlineColor := flagSet.Bool("reset-underline-color", false, "Use the terminal's default underline color for underlines without a color of their own")
 	scrollLeftHint := flagSetFunc(flagSet, "scroll-left-hint",
 		textstyles.CellWithMetadata{Rune: '<', Style: twin.StyleDefault.WithAttr(twin.AttrReverse)},
 		"Shown when view can scroll left. One character with optional ANSI highlighting.", parseScrollHint)
 	scrollRightHint := flagSetFunc(flagSet, "scroll-right-hint",
 		textstyles.CellWithMetadata{Rune: '>', Style: twin.StyleDefault.WithAttr(twin.AttrReverse)},
 		"Shown when view can scroll right. One character with optional ANSI highlighting.", parseScrollHint)
+	searchHitGutterMarker := flagSetFunc(flagSet, "search-hit-gutter-marker", textstyles.CellWithMetadata{},
+		"Shown after the line number on lines with search hits. One character with optional ANSI highlighting.", parseScrollHint)
+	wrapContinuationMarker := flagSetFunc(flagSet, "wrap-continuation-marker", textstyles.CellWithMetadata{},
+		"Shown in the line number column on wrapped lines' continuation rows. One character with optional ANSI highlighting.", parseScrollHint)
+	smoothScroll := flagSet.Bool("smooth-scroll", false, "Animate long jumps like page down or search hits rather than jumping instantly")
+	scrollAcceleration := flagSetFunc(flagSet, "scroll-acceleration", 1,
+		"Max `lines` per press when holding up / down arrow, defaults to 1 (off)", parseScrollAcceleration)
 	shift := flagSetFunc(flagSet, "shift", 16, "Horizontal scroll `amount` >=1, defaults to 16", parseShiftAmount)
 	tabSize := flagSetFunc(flagSet, "tab-size", 8, "Number of spaces per tab stop, defaults to 8", parseTabAmount)
+	invertKey := flagSetFunc(flagSet, "invert-key", 'i', "`Key` for toggling inverted colors, defaults to 'i'", parseInvertKey)
+	stripPrefix := flagSetFunc(flagSet, "strip-prefix", nil,
+		"Hide the start of each line matching this `regexp`, searching still sees it", parseStripPrefix)
+	sideBySide := flagSet.Bool("side-by-side", false, "Show the first two files next to each other, TAB switches pane")
+	stripPrefixMarker := flagSet.Bool("strip-prefix-marker", false, "Mark lines where --strip-prefix hid something")
+	dedent := flagSet.Bool("dedent", false, "Hide indentation shared by all lines on screen")
+	stickyHeader := flagSetFunc(flagSet, "sticky-header", 0,
+		"Number of `lines` at the start of the input to keep at the top of the screen, like a frozen table header", parseStickyLines)
+	stickyFooter := flagSetFunc(flagSet, "sticky-footer", 0,
+		"Number of `lines` at the end of the input to keep at the bottom of the screen", parseStickyLines)
+	tsvTable := flagSetFunc(flagSet, "tsv-table", internal.TSV_TABLE_OFF,
+		"Render .tsv files as tables: off, aligned or separated", parseTsvTable)
 	mouseMode := flagSetFunc(
 		flagSet,
 		"mousemode",
 		twin.MouseModeAuto,
-		"Mouse `mode`: auto, select or scroll: https://github.com/walles/moor/blob/master/MOUSE.md",
+		"Mouse `mode`: auto, select, scroll or hover: https://github.com/walles/moor/blob/master/MOUSE.md",
 		parseMouseMode,
 	)
-
-	// Combine flags from environment and from command line
+	mouseEncoding := flagSetFunc(flagSet, "mouse-encoding", twin.MouseEncodingSGR,
+		"Mouse reporting `encoding`: sgr or x10. Use x10 for very old terminals.", parseMouseEncoding)
+	wideRuneAtEdge := flagSetFunc(flagSet, "wide-rune-at-edge", twin.WideRuneAtEdgeSpace,
+		"How to `fill` the last column when a wide character doesn't fit there: space, blank or hint", parseWideRuneAtEdge)
+	trailerBackground := flagSetFunc(flagSet, "trailer-background", twin.TrailerBackgroundInherit,
+		"Background `color` after the end of each line: inherit from trailing whitespace, or terminal default", parseTrailerBackground)
+
+	// Apply flags from the config file first, then from environment and from
+	// command line. Later ones take precedence.
 	flags := args[1:]
 	moorEnv := strings.TrimSpace(os.Getenv(moorEnvVarName()))
 	if len(moorEnv) > 0 {
@@ -427,15 +697,22 @@ func pagerFromArgs(
 		// but logging is not yet set up and depends on command line parameters.
 		flags = append(strings.Fields(moorEnv), flags...)
 	}
+	err = applyConfigFile(flagSet, configFilePath())
 
-	targetLine, remainingArgs := getTargetLine(flags)
+	initialSearch, remainingArgs := getInitialSearch(flags)
+	targetLine, remainingArgs := getTargetLine(remainingArgs)
 
-	err = flagSet.Parse(remainingArgs)
+	if err == nil {
+		err = flagSet.Parse(remainingArgs)
+	}
 
 	if err == nil {
 		if *noClearOnExitMargin < 0 {
 			err = fmt.Errorf("Invalid --no-clear-on-exit-margin %d, must be 0 or higher", *noClearOnExitMargin)
 		}
+		if *printAllOnExit < 0 {
+			err = fmt.Errorf("Invalid --print-all-on-exit %d, must be 0 or higher", *printAllOnExit)
+		}
 	}
 
 	if err != nil {
@@ -445,8 +722,9 @@ func pagerFromArgs(
 		}
 
 		errorText := err.Error()
-		if strings.HasPrefix(errorText, "invalid value") {
-			errorText = strings.Replace(errorText, ": ", "\n\n", 1)
+		if prefix, invalidValue, found := strings.Cut(errorText, "invalid value"); found {
+			// The prefix is the config file location if the error came from there
+			errorText = prefix + "invalid value" + strings.Replace(invalidValue, ": ", "\n\n", 1)
 		}
 
 		boldErrorMessage := "\x1b[1m" + errorText + "\x1b[m"
@@ -477,7 +755,7 @@ func pagerFromArgs(
 	})
 
 	flagSetArgs := flagSet.Args()
-	if stdinIsRedirected && len(flagSetArgs) == 0 {
+	if stdinIsRedirected && len(flagSetArgs) == 0 && *command == "" {
 		// "-" is special if stdin is redirected, means "read from stdin"
 		//
 		// Ref: https://github.com/walles/moor/issues/162
@@ -499,7 +777,11 @@ func pagerFromArgs(
 		}
 	}
 
-	if len(flagSetArgs) == 0 && !stdinIsRedirected {
+	if *appendStdin && (!stdinIsRedirected || len(flagSetArgs) != 1 || flagSetArgs[0] == "-" || *command != "") {
+		return nil, nil, chroma.Style{}, nil, logsRequested, fmt.Errorf("--append-stdin requires piped input and exactly one input file")
+	}
+
+	if len(flagSetArgs) == 0 && !stdinIsRedirected && *command == "" {
 		fmt.Fprintln(os.Stderr, "ERROR: Filename(s) or input pipe required (\"moor file.txt\")")
 		fmt.Fprintln(os.Stderr)
 		printCommandline(os.Stderr)
@@ -507,6 +789,19 @@ func pagerFromArgs(
 		os.Exit(1)
 	}
 
+	if stdoutIsRedirected && *command != "" {
+		commandReader, err := reader.NewFromCommand(*command, false, nil, reader.ReaderOptions{})
+		if err != nil {
+			return nil, nil, chroma.Style{}, nil, logsRequested, err
+		}
+		commandReader.PumpToStdout()
+
+		if len(flagSetArgs) == 0 {
+			// Only the command, no files to pump
+			return nil, nil, chroma.Style{}, nil, logsRequested, nil
+		}
+	}
+
 	if stdoutIsRedirected {
 		err := pumpToStdout(flagSetArgs...)
 		if err != nil {
@@ -542,6 +837,25 @@ func pagerFromArgs(
 		stdinName = "<stdin>"
 	}
 
+	if *command != "" {
+		readerImpl, err := reader.NewFromCommand(*command, *commandRestart, formatter, readerOptions)
+		if err != nil {
+			return nil, nil, chroma.Style{}, nil, logsRequested, err
+		}
+		readerImpls = append(readerImpls, readerImpl)
+	}
+
+	if *appendStdin {
+		readerImpl, err := reader.NewFromFilenameAndStream(flagSetArgs[0], os.Stdin, formatter, readerOptions)
+		if err != nil {
+			return nil, nil, chroma.Style{}, nil, logsRequested, err
+		}
+		readerImpls = append(readerImpls, readerImpl)
+
+		// The file has been taken care of already
+		flagSetArgs = nil
+	}
+
 	// Display the input file(s) contents
 	stdinDone := false
 	for _, inputFilename := range flagSetArgs {
@@ -576,7 +890,19 @@ func pagerFromArgs(
 
 	// We got the first byte, this means sudo is done (if it was used) and we
 	// can set up the UI.
-	screen, err := newScreen(*mouseMode, *terminalColorsCount)
+	screenOptions := twin.DefaultScreenOptions()
+	screenOptions.AlternateScroll = !*noAlternateScroll
+	screenOptions.SynchronizedOutput = !*noSynchronizedOutput
+	screenOptions.MouseEncoding = *mouseEncoding
+	screenOptions.WideRuneAtEdge = *wideRuneAtEdge
+	screenOptions.TrailerBackground = *trailerBackground
+	screenOptions.QueryTerminalPalette = *queryPalette
+	screenOptions.KittyKeyboard = *kittyKeyboard
+	screenOptions.ResetUnderlineColor = *resetUnderlineColor
+	if *inline {
+		newScreen = twin.NewInlineScreenWithOptions
+	}
+	screen, err := newScreen(*mouseMode, *terminalColorsCount, screenOptions)
 	if err != nil {
 		// Ref: https://github.com/walles/moor/issues/149
 		log.Info("Failed to set up screen for paging, pumping to stdout instead: ", err)
@@ -600,22 +926,73 @@ func pagerFromArgs(
 	}
 
 	pager := internal.NewPager(readerImpls...)
-	pager.WrapLongLines = *wrap
+	pager.WrapLongLines = *wrap || *wrapAsNeeded
+	pager.WrapAsNeeded = *wrapAsNeeded
+	pager.MaxWrapRows = int(*maxWrapRows)
+	pager.WrapMargin = int(*wrapMargin)
+	pager.WrapSearch = !*noSearchWrap
+	pager.KeepSearchOnEscape = !*clearSearchOnEscape
+	pager.NoSearchHighlight = *noSearchHighlight
+	pager.SearchHitLineBackground = *searchHitLineBackground
+	pager.SearchHitForeground = *searchHitFg
+	pager.SearchHitBackground = *searchHitBg
+	pager.MultilineSearch = *multilineSearch
+	pager.MinimalSearchScroll = *minimalSearchScroll
+	pager.SearchMinimap = *searchMinimap
+	pager.SearchCommaSeparated = *searchCommaSeparated
+	pager.SearchLiteral = *searchLiteral
+	pager.SearchAndOr = *searchAndOr
+	pager.ShowSearchContext = *searchContext
+	pager.SearchLineTimeout = *searchLineTimeout
+	pager.SearchCaseMode = *searchCase
+	pager.InitialSearch = *pattern
+	if initialSearch != "" {
+		pager.InitialSearch = initialSearch
+	}
+	pager.SearchHistoryFile = *searchHistoryFile
+	pager.RememberViewPerFile = *perFileView
+	pager.SourceCommand = *command
+	pager.IdleTimeout = *idleTimeout
+	pager.NewLinesMarkerDuration = *newLinesMarker
+	pager.ASCIILines = *asciiLines
+	pager.DetectURLs = *detectURLs
+	pager.ScrollPastEnd = *scrollPastEnd
+	pager.IdleTimeoutFollowKeepsAlive = *idleTimeoutFollowKeepsAlive
 	pager.ShowLineNumbers = !*noLineNumbers
+	pager.ZeroBasedLineNumbers = *zeroBasedLineNumbers
 	pager.ShowStatusBar = !*noStatusBar
-	pager.DeInit = !*noClearOnExit
+	pager.DeInit = !*noClearOnExit && !*inline
 	pager.DeInitFalseMargin = *noClearOnExitMargin
+	pager.ReprintAllMaxLines = *printAllOnExit
+	pager.ClipboardMaxBytes = *clipboardMaxBytes
+	pager.CopyWithANSI = *copyANSI
 	pager.QuitIfOneScreen = *quitIfOneScreen
 	pager.StatusBarStyle = *statusBarStyle
+	pager.SegmentedStatusBar = *statusBarSegments
+	pager.NotFoundMessage = *notFoundMessage
+	pager.NotFoundAlert = *notFoundAlert
 	pager.UnprintableStyle = *unprintableStyle
 	pager.WithTerminalFg = *terminalFg
 	pager.ScrollLeftHint = *scrollLeftHint
 	pager.ScrollRightHint = *scrollRightHint
+	pager.WideRuneAtEdge = *wideRuneAtEdge
+	pager.SearchHitGutterMarker = *searchHitGutterMarker
+	pager.WrapContinuationMarker = *wrapContinuationMarker
 	pager.SideScrollAmount = int(*shift)
+	pager.ScrollAcceleration.MaxStep = int(*scrollAcceleration)
+	pager.SmoothScroll = *smoothScroll
 	pager.TabSize = int(*tabSize)
+	pager.TsvTable = *tsvTable
+	pager.SideBySide = *sideBySide
+	pager.StripPrefix = *stripPrefix
+	pager.ShowStrippedPrefixMarker = *stripPrefixMarker
+	pager.Dedent = *dedent
+	pager.StickyHeaderLines = int(*stickyHeader)
+	pager.StickyFooterLines = int(*stickyFooter)
+	pager.InvertColorsKey = *invertKey
 
 	pager.TargetLine = targetLine
-	if *follow && pager.TargetLine == nil {
+	if (*follow || *command != "" || *appendStdin) && pager.TargetLine == nil {
 		reallyHigh := linemetadata.IndexMax()
 		pager.TargetLine = &reallyHigh
 	}
@@ -661,7 +1038,7 @@ func main() {
 
 	pager, screen, style, formatter, _logsRequested, err := pagerFromArgs(
 		os.Args,
-		twin.NewScreenWithMouseModeAndColorCount,
+		twin.NewScreenWithOptions,
 		stdinIsRedirected,
 		stdoutIsRedirected,
 	)
@@ -708,13 +1085,14 @@ func startPaging(pager *internal.Pager, screen twin.Screen, chromaStyle *chroma.
 			panic(err)
 		}
 
-		if !pager.DeInit {
-			err := pager.ReprintAfterExit()
-			if err != nil {
-				log.Error("Failed reprinting pager view after exit: ", err)
-			}
+		err := pager.ReprintAfterExit()
+		if err != nil {
+			log.Error("Failed reprinting pager view after exit: ", err)
 		}
 
+		// Stop any --command
+		pager.CloseReaders()
+
 		if pager.AfterExit != nil {
 			err := pager.AfterExit()
 			if err != nil {
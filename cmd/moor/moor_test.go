@@ -21,7 +21,7 @@ func TestParseScrollHint(t *testing.T) {
 func TestPageOneInputFile(t *testing.T) {
 	pager, screen, _, formatter, _, err := pagerFromArgs(
 		[]string{"", "moor_test.go"},
-		func(_ twin.MouseMode, _ twin.ColorCount) (twin.Screen, error) {
+		func(_ twin.MouseMode, _ twin.ColorCount, _ twin.ScreenOptions) (twin.Screen, error) {
 			return twin.NewFakeScreen(80, 24), nil
 		},
 		false, // stdin is redirected
@@ -52,3 +52,51 @@ func TestGetTargetLine(t *testing.T) {
 	assert.Equal(t, *index, linemetadata.IndexFromOneBased(1))
 	assert.DeepEqual(t, remaining, []string{})
 }
+
+func TestGetInitialSearch(t *testing.T) {
+	search, remaining := getInitialSearch([]string{"file.txt"})
+	assert.Equal(t, search, "")
+	assert.DeepEqual(t, remaining, []string{"file.txt"})
+
+	search, remaining = getInitialSearch([]string{"+/"})
+	assert.Equal(t, search, "")
+	assert.DeepEqual(t, remaining, []string{"+/"})
+
+	search, remaining = getInitialSearch([]string{"+/needle", "file.txt"})
+	assert.Equal(t, search, "needle")
+	assert.DeepEqual(t, remaining, []string{"file.txt"})
+}
+
+func TestParseColor(t *testing.T) {
+	color, err := parseColor("#ff8000")
+	assert.NilError(t, err)
+	assert.Equal(t, *color, twin.NewColorHex(0xff8000))
+
+	color, err = parseColor("208")
+	assert.NilError(t, err)
+	assert.Equal(t, *color, twin.NewColor256(208))
+
+	_, err = parseColor("#ff80")
+	assert.Assert(t, err != nil)
+
+	_, err = parseColor("256")
+	assert.Assert(t, err != nil)
+}
+
+func TestParseMaxWrapRows(t *testing.T) {
+	rows, err := parseMaxWrapRows("3")
+	assert.NilError(t, err)
+	assert.Equal(t, rows, uint(3))
+
+	_, err = parseMaxWrapRows("-1")
+	assert.Error(t, err, "Expected a number of rows, got: -1")
+}
+
+func TestParseInvertKey(t *testing.T) {
+	key, err := parseInvertKey("V")
+	assert.NilError(t, err)
+	assert.Equal(t, key, 'V')
+
+	_, err = parseInvertKey("q")
+	assert.Error(t, err, "<q> already does something else, pick another key")
+}
@@ -112,7 +112,12 @@ func printCommandline(output io.Writer) {
 
 	fmt.Fprintln(output, "Commandline: moor", strings.Join(os.Args[1:], " "))                 //nolint:errcheck
 	fmt.Fprintf(output, "Environment: %s=\"%v\"\n", envVarDescription, os.Getenv(envVarName)) //nolint:errcheck
-	fmt.Fprintln(output)                                                                      //nolint:errcheck
+	if configFile := configFilePath(); configFile != "" {
+		if _, err := os.Stat(configFile); err == nil {
+			fmt.Fprintln(output, "Config file:", configFile) //nolint:errcheck
+		}
+	}
+	fmt.Fprintln(output) //nolint:errcheck
 }
 
 func heading(text string, colors twin.ColorCount) string {
@@ -141,6 +146,16 @@ func printUsage(flagSet *flag.FlagSet, colors twin.ColorCount) {
 	fmt.Println("More information + source code:")
 	fmt.Println("  <https://github.com/walles/moor#readme>")
 	fmt.Println()
+	fmt.Println(heading("Config file", colors))
+	configFile := configFilePath()
+	if configFile == "" {
+		fmt.Println("  No config file, unable to find your home directory.")
+	} else {
+		fmt.Println("  Default options are read from " + configFile + " if it exists. Put one")
+		fmt.Println("  option per line without the leading dashes, like \"wrap\" or \"tab-size = 4\".")
+		fmt.Println("  The MOOR environment variable and the command line override these.")
+	}
+	fmt.Println()
 	fmt.Println(heading("Environment", colors))
 
 	envVarName := moorEnvVarName()
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/textstyles"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Copy the command that produced the input to the clipboard, so that the user
+// can run it again later
+func (p *Pager) copySourceCommand() {
+	if p.SourceCommand == "" {
+		p.mode = PagerModeMessage{pager: p, message: "Source command unknown, nothing to copy"}
+		return
+	}
+
+	log.Debug("Copying source command to clipboard: ", p.SourceCommand)
+	if !p.copyToClipboard(p.SourceCommand) {
+		return
+	}
+	p.mode = PagerModeMessage{pager: p, message: "Copied to clipboard: " + p.SourceCommand}
+}
+
+// Copy all input lines to the clipboard, either as plain text or with their
+// styling as ANSI escape codes.
+func (p *Pager) copyAllLines(withANSI bool) {
+	p.readerLock.Lock()
+	r := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	lineCount := r.GetLineCount()
+	if lineCount == 0 {
+		p.mode = PagerModeMessage{pager: p, message: "No input, nothing to copy"}
+		return
+	}
+	lines := r.GetLines(linemetadata.Index{}, lineCount).Lines
+
+	// Check the plain text size first, styling will only make it larger
+	plainLines := make([]string, 0, len(lines))
+	byteCount := 0
+	for i, line := range lines {
+		plain := line.Plain()
+		byteCount += len(plain)
+		if i > 0 {
+			byteCount++ // For the newline
+		}
+		if p.isTooLargeForClipboard(byteCount) {
+			return
+		}
+
+		plainLines = append(plainLines, plain)
+	}
+
+	text := strings.Join(plainLines, "\n")
+	if withANSI {
+		rows := make([][]twin.StyledRune, 0, len(lines))
+		for _, line := range lines {
+			rows = append(rows, styledClipboardRow(line))
+		}
+
+		// We don't know where this will be pasted, so don't downsample the
+		// colors
+		text = twin.ClipboardText(rows, true, twin.ColorCount24bit)
+		if p.isTooLargeForClipboard(len(text)) {
+			return
+		}
+	}
+
+	log.Debug("Copying ", len(lines), " lines, ", len(text), " bytes, to clipboard")
+	if !p.copyToClipboard(text) {
+		return
+	}
+
+	message := "Copied " + linemetadata.NumberFromLength(len(lines)).Format() + " lines to clipboard"
+	if len(lines) == 1 {
+		message = "Copied one line to clipboard"
+	}
+	if withANSI {
+		message += " with colors"
+	}
+	if !r.Done.Load() {
+		message += ", input not fully read yet"
+	}
+	p.mode = PagerModeMessage{pager: p, message: message}
+}
+
+// Copy the current search hit line to the clipboard. With no search hit on
+// screen, copy the top line.
+func (p *Pager) copyCurrentLine() {
+	lineIndex, _ := p.searchHitOnScreen()
+	if lineIndex == nil {
+		lineIndex = p.lineIndex()
+	}
+	if lineIndex == nil {
+		p.mode = PagerModeMessage{pager: p, message: "No input, nothing to copy"}
+		return
+	}
+
+	line := p.Reader().GetLine(*lineIndex)
+	if line == nil {
+		p.mode = PagerModeMessage{pager: p, message: "No input, nothing to copy"}
+		return
+	}
+
+	text := line.Plain()
+	if p.CopyWithANSI {
+		text = twin.ClipboardText([][]twin.StyledRune{styledClipboardRow(line)}, true, twin.ColorCount24bit)
+	}
+	if p.isTooLargeForClipboard(len(text)) {
+		return
+	}
+
+	log.Debug("Copying line ", p.formatLineIndex(*lineIndex), ", ", len(text), " bytes, to clipboard")
+	if !p.copyToClipboard(text) {
+		return
+	}
+	message := "Copied line " + p.formatLineIndex(*lineIndex) + " to clipboard"
+	if p.CopyWithANSI {
+		message += " with colors"
+	}
+	p.mode = PagerModeMessage{pager: p, message: message}
+}
+
+// The line with its own styling, without any search highlighting
+func styledClipboardRow(line *reader.NumberedLine) []twin.StyledRune {
+	cells := line.HighlightedTokens(plainTextStyle, searchHitStyle, nil, nil).StyledRunes
+
+	row := make([]twin.StyledRune, 0, len(cells))
+	for _, cell := range cells {
+		row = appendClipboardCell(row, cell)
+	}
+	return row
+}
+
+// Wide runes are followed by a cell they hide, just like on screen. That's
+// what twin.ClipboardText() expects.
+func appendClipboardCell(row []twin.StyledRune, cell textstyles.CellWithMetadata) []twin.StyledRune {
+	row = append(row, cell.ToStyledRune())
+	if cell.Width() == 2 {
+		row = append(row, twin.NewStyledRune(' ', cell.Style))
+	}
+	return row
+}
+
+// Put text on the clipboard. If the screen can't do that, tell the user and
+// return false.
+func (p *Pager) copyToClipboard(text string) bool {
+	clipboard, ok := p.screen.(twin.ClipboardWriter)
+	if !ok {
+		p.mode = PagerModeMessage{pager: p, message: "Copying to the clipboard is not supported"}
+		return false
+	}
+
+	clipboard.CopyToClipboard(text)
+	return true
+}
+
+// If byteCount is over ClipboardMaxBytes, tell the user and return true
+func (p *Pager) isTooLargeForClipboard(byteCount int) bool {
+	if p.ClipboardMaxBytes <= 0 || byteCount <= p.ClipboardMaxBytes {
+		return false
+	}
+
+	p.mode = PagerModeMessage{
+		pager:   p,
+		message: fmt.Sprintf("Not copying, input is larger than the %d bytes limit", p.ClipboardMaxBytes),
+	}
+	return true
+}
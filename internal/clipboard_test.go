@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestCopySourceCommand(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "hello"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.SourceCommand = "git log -p"
+
+	pager.mode.onRune('C')
+	assert.Equal(t, screen.ClipboardContents(), "git log -p")
+	assert.Equal(t, "Message", modeName(pager))
+
+	// Any key gets us back to viewing
+	pager.mode.onKey(twin.KeyDown)
+	assert.Equal(t, "Viewing", modeName(pager))
+}
+
+func TestCopyUnknownSourceCommand(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "hello"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+
+	pager.mode.onRune('C')
+	assert.Equal(t, screen.ClipboardContents(), "")
+	assert.Equal(t, "Message", modeName(pager))
+}
+
+func TestCopyAllLines(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "first\n\x1b[31msecond\x1b[m\nthird"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+
+	pager.mode.onRune('Y')
+	assert.Equal(t, screen.ClipboardContents(), "first\nsecond\nthird")
+	assert.Equal(t, "Message", modeName(pager))
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "Copied 3 lines to clipboard")
+}
+
+func TestCopyAllLinesWithColors(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "first\n\x1b[31msecond\x1b[m"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+
+	pager.mode.onRune('S')
+	assert.Equal(t, screen.ClipboardContents(), "\x1b[mfirst\n\x1b[m\x1b[31msecond\x1b[m")
+}
+
+func TestCopyAllLinesWithColorsWideChars(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "日本x"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+
+	pager.mode.onRune('S')
+	assert.Equal(t, screen.ClipboardContents(), "\x1b[m日本x")
+}
+
+func TestCopyAllLinesTooLarge(t *testing.T) {
+	screen := twin.NewFakeScreen(60, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "first\nsecond"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+
+	// "first\nsecond" is 12 bytes
+	pager.ClipboardMaxBytes = 11
+	pager.mode.onRune('Y')
+	assert.Equal(t, screen.ClipboardContents(), "")
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "Not copying, input is larger than the 11 bytes limit")
+
+	pager.ClipboardMaxBytes = 12
+	pager.mode.onRune('Y')
+	assert.Equal(t, screen.ClipboardContents(), "first\nsecond")
+}
+
+func TestCopyCurrentLine(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "first\n\x1b[31msecond\x1b[m\nthird"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+
+	// No search hit, copy the top line
+	pager.mode.onRune('T')
+	assert.Equal(t, screen.ClipboardContents(), "first")
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "Copied line 1 to clipboard")
+
+	// Search hit on screen, copy that line
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.searchPattern = toPattern("sec", SEARCH_CASE_AUTO)
+	pager.mode.onRune('T')
+	assert.Equal(t, screen.ClipboardContents(), "second")
+}
+
+func TestCopyCurrentLineTooLarge(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "0123456789"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.ClipboardMaxBytes = 5
+
+	pager.mode.onRune('T')
+	assert.Equal(t, screen.ClipboardContents(), "")
+	assert.Equal(t, "Message", modeName(pager))
+}
+
+func TestCopyCurrentLineWithColors(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "\x1b[31mfirst\x1b[m"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.CopyWithANSI = true
+
+	pager.mode.onRune('T')
+	assert.Equal(t, screen.ClipboardContents(), "\x1b[m\x1b[31mfirst\x1b[m")
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "Copied line 1 to clipboard with colors")
+}
@@ -1,9 +1,65 @@
 package internal
 
 import (
+	"time"
+
 	log "github.com/sirupsen/logrus"
 )
 
+// View settings that can be remembered per file, see
+// Pager.RememberViewPerFile
+type fileViewSettings struct {
+	wrapLongLines       bool
+	showLineNumbers     bool
+	leftColumnZeroBased int
+}
+
+func (p *Pager) currentFileViewSettings() fileViewSettings {
+	return fileViewSettings{
+		wrapLongLines:       p.WrapLongLines,
+		showLineNumbers:     p.showLineNumbers,
+		leftColumnZeroBased: p.leftColumnZeroBased,
+	}
+}
+
+// Switch to another file, saving and restoring the per file view settings if
+// we should. Must be called with readerLock held.
+//
+// In side by side mode, switching to the file in the other pane moves the file
+// we're leaving to the other pane, so that both panes never show the same file.
+func (p *Pager) switchToFileLocked(newIndex int) {
+	if newIndex == p.currentReader {
+		return
+	}
+
+	if p.RememberViewPerFile {
+		if p.fileViewSettings == nil {
+			p.fileViewSettings = make(map[int]fileViewSettings)
+		}
+		p.fileViewSettings[p.currentReader] = p.currentFileViewSettings()
+	}
+
+	if p.isSideBySide() && newIndex == p.otherPane.readerIndex {
+		p.otherPane.readerIndex = p.currentReader
+		p.otherPane.scrollPosition, p.scrollPosition = p.scrollPosition, p.otherPane.scrollPosition
+		p.otherPane.leftColumnZeroBased, p.leftColumnZeroBased = p.leftColumnZeroBased, p.otherPane.leftColumnZeroBased
+	}
+
+	p.currentReader = newIndex
+	if !p.RememberViewPerFile {
+		return
+	}
+
+	settings, found := p.fileViewSettings[newIndex]
+	if !found {
+		// First visit, start out with the global defaults
+		settings = p.defaultFileViewSettings
+	}
+	p.WrapLongLines = settings.wrapLongLines
+	p.showLineNumbers = settings.showLineNumbers
+	p.leftColumnZeroBased = settings.leftColumnZeroBased
+}
+
 func (p *Pager) previousFile() {
 	p.readerLock.Lock()
 	defer p.readerLock.Unlock()
@@ -12,7 +68,7 @@ func (p *Pager) previousFile() {
 	if newIndex < 0 {
 		newIndex = 0
 	}
-	p.currentReader = newIndex
+	p.switchToFileLocked(newIndex)
 	log.Tracef("Switched to previous file, index %d", p.currentReader)
 
 	select {
@@ -29,7 +85,7 @@ func (p *Pager) nextFile() {
 	if newIndex >= len(p.readers) {
 		newIndex = len(p.readers) - 1
 	}
-	p.currentReader = newIndex
+	p.switchToFileLocked(newIndex)
 	log.Tracef("Switched to next file, index %d", p.currentReader)
 
 	select {
@@ -42,7 +98,7 @@ func (p *Pager) firstFile() {
 	p.readerLock.Lock()
 	defer p.readerLock.Unlock()
 
-	p.currentReader = 0
+	p.switchToFileLocked(0)
 	log.Tracef("Switched to first file, index %d", p.currentReader)
 
 	select {
@@ -50,3 +106,26 @@ func (p *Pager) firstFile() {
 	default:
 	}
 }
+
+// Replace the current reader with a new one, reading the same file from the
+// start.
+func (p *Pager) reloadFile() {
+	p.readerLock.Lock()
+	defer p.readerLock.Unlock()
+
+	reloaded, err := p.readers[p.currentReader].Reopen()
+	if err != nil {
+		log.Info("Failed to reload file: ", err)
+		return
+	}
+
+	p.trackReload(p.readers[p.currentReader], reloaded, time.Now())
+	p.readers[p.currentReader] = reloaded
+	p.fileChangedOnDisk.Store(false)
+	log.Tracef("Reloaded file, index %d", p.currentReader)
+
+	select {
+	case p.readerSwitched <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func createMultiFilePager(rememberViewPerFile bool) *Pager {
+	pager := NewPager(
+		reader.NewFromTextForTesting("first", "first file"),
+		reader.NewFromTextForTesting("second", "second file"),
+	)
+	pager.RememberViewPerFile = rememberViewPerFile
+
+	// Tell our Pager to quit immediately
+	pager.Quit()
+
+	// Except for just quitting, this also associates our FakeScreen with the Pager
+	pager.StartPaging(twin.NewFakeScreen(20, 10), nil, nil)
+
+	return pager
+}
+
+func TestViewSettingsPerFile(t *testing.T) {
+	pager := createMultiFilePager(true)
+
+	// Change everything in the first file
+	pager.WrapLongLines = true
+	pager.showLineNumbers = false
+	pager.leftColumnZeroBased = 5
+
+	// The second file should start out with the defaults
+	pager.nextFile()
+	assert.Equal(t, pager.currentReader, 1)
+	assert.Equal(t, pager.WrapLongLines, false)
+	assert.Equal(t, pager.showLineNumbers, true)
+	assert.Equal(t, pager.leftColumnZeroBased, 0)
+
+	pager.leftColumnZeroBased = 2
+
+	// Back to the first file, its settings should be back
+	pager.previousFile()
+	assert.Equal(t, pager.currentReader, 0)
+	assert.Equal(t, pager.WrapLongLines, true)
+	assert.Equal(t, pager.showLineNumbers, false)
+	assert.Equal(t, pager.leftColumnZeroBased, 5)
+
+	// And the second file should remember its own settings
+	pager.nextFile()
+	assert.Equal(t, pager.leftColumnZeroBased, 2)
+	assert.Equal(t, pager.WrapLongLines, false)
+}
+
+func TestViewSettingsShared(t *testing.T) {
+	pager := createMultiFilePager(false)
+
+	pager.WrapLongLines = true
+	pager.leftColumnZeroBased = 5
+
+	pager.nextFile()
+	assert.Equal(t, pager.currentReader, 1)
+	assert.Equal(t, pager.WrapLongLines, true)
+	assert.Equal(t, pager.leftColumnZeroBased, 5)
+}
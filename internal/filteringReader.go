@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"runtime"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -29,8 +31,8 @@ type FilteringReader struct {
 	filteredLinesCache *[]*reader.NumberedLine
 
 	// This is what the reader's line count was when we filtered. If the
-	// reader's current line count doesn't match, then our cache needs to be
-	// rebuilt.
+	// reader has more lines than this, only the new lines need filtering. If
+	// it has fewer, our cache needs to be rebuilt.
 	unfilteredLineCountWhenCaching int
 
 	// This is the pattern that was used when we cached the lines. If it
@@ -38,38 +40,103 @@ type FilteringReader struct {
 	filterPatternWhenCaching *regexp.Regexp
 }
 
+// Lines to filter per goroutine, fewer than this and we don't bother going
+// parallel
+const filterChunkMinSize = 10_000
+
 // Please hold the lock when calling this method.
 func (f *FilteringReader) rebuildCache() {
+	cache := make([]*reader.NumberedLine, 0)
+	f.filteredLinesCache = &cache
+	f.unfilteredLineCountWhenCaching = 0
+	f.filterPatternWhenCaching = *f.FilterPattern
+
+	f.extendCache()
+}
+
+// Filter the lines that have arrived since the cache was last updated, and add
+// the matching ones to the cache.
+//
+// Please hold the lock when calling this method.
+func (f *FilteringReader) extendCache() {
 	t0 := time.Now()
 
-	cache := make([]*reader.NumberedLine, 0)
-	filterPattern := *f.FilterPattern
-
-	// Mark cache base conditions
-	f.unfilteredLineCountWhenCaching = f.BackingReader.GetLineCount()
-	f.filterPatternWhenCaching = filterPattern
-
-	// Repopulate the cache
-	allBaseLines := f.BackingReader.GetLines(linemetadata.Index{}, math.MaxInt)
-	resultIndex := 0
-	for _, line := range allBaseLines.Lines {
-		if filterPattern != nil && len(filterPattern.String()) > 0 && !filterPattern.MatchString(line.Line.Plain(&line.Index)) {
-			// We have a pattern but it doesn't match
-			continue
+	firstNewLine := linemetadata.IndexFromZeroBased(f.unfilteredLineCountWhenCaching)
+	newLineCount := f.BackingReader.GetLineCount() - f.unfilteredLineCountWhenCaching
+	if newLineCount <= 0 {
+		return
+	}
+
+	newLines := f.BackingReader.GetLines(firstNewLine, newLineCount).Lines
+	if len(newLines) == 0 || newLines[0].Index != firstNewLine {
+		// The backing reader changed under our feet, start over
+		log.Debugf("Expected lines starting at %s, rebuilding filter cache", firstNewLine.Format())
+		if f.unfilteredLineCountWhenCaching > 0 {
+			f.rebuildCache()
 		}
+		return
+	}
+
+	accepted := filterLines(newLines, f.filterPatternWhenCaching)
 
+	cache := *f.filteredLinesCache
+	for _, line := range accepted {
 		cache = append(cache, &reader.NumberedLine{
 			Line:   line.Line,
-			Index:  linemetadata.IndexFromZeroBased(resultIndex),
+			Index:  linemetadata.IndexFromZeroBased(len(cache)),
 			Number: line.Number,
 		})
-		resultIndex++
 	}
-
 	f.filteredLinesCache = &cache
+	f.unfilteredLineCountWhenCaching += len(newLines)
 
-	log.Debugf("Filtered out %d/%d lines in %s",
-		len(allBaseLines.Lines)-len(cache), len(allBaseLines.Lines), time.Since(t0))
+	log.Debugf("Filtered out %d/%d new lines in %s",
+		len(newLines)-len(accepted), len(newLines), time.Since(t0))
+}
+
+// Returns the lines matching the filter pattern, in order. Large inputs are
+// split into chunks and filtered in parallel, like in findFirstHit().
+func filterLines(lines []*reader.NumberedLine, filterPattern *regexp.Regexp) []*reader.NumberedLine {
+	if filterPattern == nil || len(filterPattern.String()) == 0 {
+		return lines
+	}
+
+	chunkCount := min(runtime.NumCPU(), len(lines)/filterChunkMinSize)
+	if chunkCount <= 1 {
+		return filterChunk(lines, filterPattern)
+	}
+	chunkSize := (len(lines) + chunkCount - 1) / chunkCount
+
+	// One result per chunk
+	results := make([]chan []*reader.NumberedLine, chunkCount)
+	for i := range results {
+		results[i] = make(chan []*reader.NumberedLine, 1)
+
+		chunk := lines[i*chunkSize : min((i+1)*chunkSize, len(lines))]
+		go func(i int) {
+			defer func() {
+				PanicHandler("filterLines()/filterChunk", recover(), debug.Stack())
+			}()
+
+			results[i] <- filterChunk(chunk, filterPattern)
+		}(i)
+	}
+
+	accepted := make([]*reader.NumberedLine, 0)
+	for _, result := range results {
+		accepted = append(accepted, <-result...)
+	}
+	return accepted
+}
+
+func filterChunk(lines []*reader.NumberedLine, filterPattern *regexp.Regexp) []*reader.NumberedLine {
+	accepted := make([]*reader.NumberedLine, 0)
+	for _, line := range lines {
+		if filterPattern.MatchString(line.Line.Plain(&line.Index)) {
+			accepted = append(accepted, line)
+		}
+	}
+	return accepted
 }
 
 func (f *FilteringReader) getAllLines() []*reader.NumberedLine {
@@ -81,11 +148,6 @@ func (f *FilteringReader) getAllLines() []*reader.NumberedLine {
 		return *f.filteredLinesCache
 	}
 
-	if f.unfilteredLineCountWhenCaching != f.BackingReader.GetLineCount() {
-		f.rebuildCache()
-		return *f.filteredLinesCache
-	}
-
 	var currentFilterPattern string
 	if *f.FilterPattern != nil {
 		currentFilterPattern = (*f.FilterPattern).String()
@@ -99,6 +161,17 @@ func (f *FilteringReader) getAllLines() []*reader.NumberedLine {
 		return *f.filteredLinesCache
 	}
 
+	unfilteredLineCount := f.BackingReader.GetLineCount()
+	if unfilteredLineCount < f.unfilteredLineCountWhenCaching {
+		// Lines went away, start over
+		f.rebuildCache()
+		return *f.filteredLinesCache
+	}
+	if unfilteredLineCount > f.unfilteredLineCountWhenCaching {
+		// More lines arrived, filter only those
+		f.extendCache()
+	}
+
 	return *f.filteredLinesCache
 }
 
@@ -141,6 +214,11 @@ func (f *FilteringReader) GetLine(index linemetadata.Index) *reader.NumberedLine
 	return allLines[index.Index()]
 }
 
+// The indices are in the filtered lines, see reader.Reader.FindMatches()
+func (f *FilteringReader) FindMatches(pattern *regexp.Regexp, from linemetadata.Index, limit int) []linemetadata.Index {
+	return reader.FindMatchesIn(f, pattern, from, limit)
+}
+
 func (f *FilteringReader) GetLines(firstLine linemetadata.Index, wantedLineCount int) *reader.InputLines {
 	if f.shouldPassThrough() {
 		return f.BackingReader.GetLines(firstLine, wantedLineCount)
@@ -149,9 +227,7 @@ func (f *FilteringReader) GetLines(firstLine linemetadata.Index, wantedLineCount
 	acceptedLines := f.getAllLines()
 
 	if len(acceptedLines) == 0 || wantedLineCount == 0 {
-		return &reader.InputLines{
-			StatusText: f.createStatus(nil),
-		}
+		return f.createStatus(nil)
 	}
 
 	lastLine := firstLine.NonWrappingAdd(wantedLineCount - 1)
@@ -168,18 +244,29 @@ func (f *FilteringReader) GetLines(firstLine linemetadata.Index, wantedLineCount
 		return f.GetLines(firstLine, firstLine.CountLinesTo(lastLine))
 	}
 
+	returnMe := f.createStatus(&lastLine)
+	returnMe.Lines = acceptedLines[firstLine.Index() : firstLine.Index()+wantedLineCount]
+	return returnMe
+}
+
+// Create InputLines without any lines, with a status text like "Filtered:
+// 1234/5678 lines  22%"
+func (f *FilteringReader) createStatus(lastLine *linemetadata.Index) *reader.InputLines {
+	name := "Filtered"
+	position := f.createStatusPosition(lastLine)
 	return &reader.InputLines{
-		Lines:      acceptedLines[firstLine.Index() : firstLine.Index()+wantedLineCount],
-		StatusText: f.createStatus(&lastLine),
+		StatusText:     name + ": " + position,
+		StatusName:     name,
+		StatusPosition: position,
 	}
 }
 
 // In the general case, this will return a text like this:
-// "Filtered: 1234/5678 lines  22%"
-func (f *FilteringReader) createStatus(lastLine *linemetadata.Index) string {
+// "1234/5678 lines  22%"
+func (f *FilteringReader) createStatusPosition(lastLine *linemetadata.Index) string {
 	baseCount := f.BackingReader.GetLineCount()
 	if baseCount == 0 {
-		return "Filtered: No input lines"
+		return "No input lines"
 	}
 
 	baseCountString := "/" + linemetadata.IndexFromLength(baseCount).Format()
@@ -189,7 +276,7 @@ func (f *FilteringReader) createStatus(lastLine *linemetadata.Index) string {
 
 	if lastLine == nil {
 		// 100% because we're showing all 0 lines
-		return "Filtered: 0" + baseCountString + " lines  100%"
+		return "0" + baseCountString + " lines  100%"
 	}
 
 	acceptedCount := f.GetLineCount()
@@ -202,7 +289,7 @@ func (f *FilteringReader) createStatus(lastLine *linemetadata.Index) string {
 		lineString += "s"
 	}
 
-	return fmt.Sprintf("Filtered: %s%s %s  %d%%",
+	return fmt.Sprintf("%s%s %s  %d%%",
 		acceptedCountString, baseCountString, lineString, percent)
 }
 
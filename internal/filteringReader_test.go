@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"gotest.tools/v3/assert"
+)
+
+// Enough lines to make filterLines() go parallel
+func TestFilterLargeInput(t *testing.T) {
+	lines := []string{}
+	for i := range 5 * filterChunkMinSize {
+		lines = append(lines, fmt.Sprint("line ", i))
+	}
+	backingReader := reader.NewFromTextForTesting("TestFilterLargeInput", strings.Join(lines, "\n"))
+	assert.NilError(t, backingReader.Wait())
+
+	filterPattern := regexp.MustCompile("7$")
+	filteringReader := FilteringReader{
+		BackingReader: backingReader,
+		FilterPattern: &filterPattern,
+	}
+
+	assert.Equal(t, filteringReader.GetLineCount(), len(lines)/10)
+	for i := range len(lines) / 10 {
+		line := filteringReader.GetLine(linemetadata.IndexFromZeroBased(i))
+		assert.Equal(t, line.Plain(), fmt.Sprint("line ", i*10+7))
+		assert.Equal(t, line.Index, linemetadata.IndexFromZeroBased(i))
+
+		// Line numbers should be from the unfiltered input
+		assert.Equal(t, line.Number, linemetadata.NumberFromZeroBased(i*10+7))
+	}
+}
+
+// When more lines arrive, only the new lines should need filtering
+func TestFilterGrowingInput(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+
+	// The reader looks at the first bytes before returning, so this needs to
+	// happen in the background
+	writeErr := make(chan error)
+	go func() {
+		_, err := pipeWriter.Write([]byte("match 1\nother\n"))
+		writeErr <- err
+	}()
+
+	backingReader, err := reader.NewFromStream("", pipeReader, nil, reader.ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+	assert.NilError(t, <-writeErr)
+	awaitLineCount(t, backingReader, 2)
+
+	filterPattern := regexp.MustCompile("match")
+	filteringReader := FilteringReader{
+		BackingReader: backingReader,
+		FilterPattern: &filterPattern,
+	}
+	assert.Equal(t, filteringReader.GetLineCount(), 1)
+	firstLine := filteringReader.GetLine(linemetadata.Index{})
+
+	_, err = pipeWriter.Write([]byte("match 2\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, pipeWriter.Close())
+	assert.NilError(t, backingReader.Wait())
+
+	assert.Equal(t, filteringReader.GetLineCount(), 2)
+	assert.Equal(t, filteringReader.GetLine(linemetadata.Index{}), firstLine, "First line should not have been refiltered")
+
+	secondLine := filteringReader.GetLine(linemetadata.IndexFromZeroBased(1))
+	assert.Equal(t, secondLine.Plain(), "match 2")
+	assert.Equal(t, secondLine.Number, linemetadata.NumberFromOneBased(3))
+
+	// Changing the pattern should start over
+	filterPattern = regexp.MustCompile("other")
+	assert.Equal(t, filteringReader.GetLineCount(), 1)
+	assert.Equal(t, filteringReader.GetLine(linemetadata.Index{}).Plain(), "other")
+}
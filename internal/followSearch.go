@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+// Keeps track of which lines have been searched while following, so that only
+// newly arrived lines get searched
+type followSearchTracker struct {
+	reader    reader.Reader
+	lineCount int
+}
+
+// Start or stop following the end of the input when the user presses 'F'
+func (p *Pager) toggleFollow() {
+	if p.isFollowing() {
+		p.setTargetLine(nil)
+		p.mode = PagerModeMessage{pager: p, message: "Stopped following, press 'F' to follow again"}
+		return
+	}
+
+	// This starts following
+	p.scrollToEnd()
+	p.mode = PagerModeMessage{pager: p, message: "Following, press 'F' to stop"}
+}
+
+// Called when more lines have arrived. While following, look for search hits
+// in the new lines and show the last one.
+//
+// When not following we don't scroll. The search hit count in the status bar
+// gets updated anyway, see updateSearchHitCount().
+func (p *Pager) searchNewLines() {
+	r := p.Reader()
+	lineCount := r.GetLineCount()
+	firstNewLine := p.followSearch.lineCount
+	if p.followSearch.reader != r {
+		// New reader, whatever it has so far is not news
+		firstNewLine = lineCount
+	}
+	p.followSearch = followSearchTracker{reader: r, lineCount: lineCount}
+
+	if p.searchPattern == nil || !p.isFollowing() || firstNewLine >= lineCount {
+		return
+	}
+
+	// Find the last hit among the new lines
+	lastLine := *linemetadata.IndexFromLength(lineCount)
+	var beforeIndex *linemetadata.Index
+	if firstNewLine > 0 {
+		before := linemetadata.IndexFromZeroBased(firstNewLine - 1)
+		beforeIndex = &before
+	}
+	hit, _ := p.findFirstHit(lastLine, beforeIndex, true)
+	if hit == nil {
+		return
+	}
+	p.currentSearchHit = hit
+
+	for _, line := range p.renderLines().lines {
+		if line.inputLineIndex == *hit {
+			// Following shows it, keep following
+			return
+		}
+	}
+
+	// The hit has scrolled off the top, stop following to show it
+	p.scrollPosition = NewScrollPositionFromIndex(*hit, "searchNewLines")
+	p.setTargetLine(nil)
+	p.centerSearchHitsVertically()
+}
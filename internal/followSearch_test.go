@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchNewLinesWhileFollowing(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+	writeDone := make(chan error, 1)
+	go func() {
+		_, err := pipeWriter.Write([]byte("old hit\n"))
+		writeDone <- err
+	}()
+
+	r, err := reader.NewFromStream("TestSearchNewLinesWhileFollowing", pipeReader, formatters.TTY16m, reader.ReaderOptions{})
+	assert.NilError(t, err)
+	assert.NilError(t, <-writeDone)
+	awaitLineCount(t, r, 1)
+
+	// Three lines of contents plus the status bar
+	pager := NewPager(r)
+	pager.screen = twin.NewFakeScreen(20, 4)
+	pager.searchPattern = regexp.MustCompile("hit")
+	pager.scrollToEnd()
+	assert.Assert(t, pager.isFollowing())
+
+	// Whatever is there to begin with is not new
+	pager.searchNewLines()
+	assert.Assert(t, pager.currentSearchHit == nil)
+
+	// A new hit that's on screen, keep following
+	_, err = pipeWriter.Write([]byte("new hit\nx\n"))
+	assert.NilError(t, err)
+	awaitLineCount(t, r, 3)
+	pager.scrollToEnd()
+	pager.searchNewLines()
+	assert.Equal(t, pager.currentSearchHit.Index(), 1)
+	assert.Assert(t, pager.isFollowing())
+
+	// A new hit that would scroll off screen, stop following to show it
+	_, err = pipeWriter.Write([]byte("last hit\na\nb\nc\nd\n"))
+	assert.NilError(t, err)
+	awaitLineCount(t, r, 8)
+	pager.scrollToEnd()
+	pager.searchNewLines()
+	assert.Equal(t, pager.currentSearchHit.Index(), 3)
+	assert.Assert(t, !pager.isFollowing())
+	assert.Equal(t, pager.lineIndex().Index(), 2, "Hit should be centered")
+
+	// Not following, no scrolling
+	_, err = pipeWriter.Write([]byte("another hit\na\nb\nc\n"))
+	assert.NilError(t, err)
+	awaitLineCount(t, r, 12)
+	pager.searchNewLines()
+	assert.Equal(t, pager.currentSearchHit.Index(), 3)
+	assert.Equal(t, pager.lineIndex().Index(), 2)
+
+	assert.NilError(t, pipeWriter.Close())
+}
+
+func TestToggleFollow(t *testing.T) {
+	r := reader.NewFromTextForTesting("TestToggleFollow", "a\nb\nc\nd\ne")
+	assert.NilError(t, r.Wait())
+
+	pager := NewPager(r)
+	pager.screen = twin.NewFakeScreen(20, 3)
+	assert.Assert(t, !pager.isFollowing())
+
+	pager.mode.onRune('F')
+	assert.Assert(t, pager.isFollowing())
+	assert.Equal(t, pager.lineIndex().Index(), 3)
+	assert.Equal(t, pager.createFooterSegments("", "", "").right, "[F]")
+
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.mode.onRune('F')
+	assert.Assert(t, !pager.isFollowing())
+	assert.Equal(t, pager.lineIndex().Index(), 3)
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Minimum number of spaces between two footer segments
+const footerSegmentGap = 2
+
+// A status bar with parts aligned to the left, center and right, see
+// SegmentedStatusBar
+type footerSegments struct {
+	left   string // File name
+	center string // Position and percentage
+	right  string // Mode indicators
+}
+
+// Collect the footer segments for the viewing mode
+func (p *Pager) createFooterSegments(name string, position string, spinner string) footerSegments {
+	prefix := ""
+	p.readerLock.Lock()
+	if len(p.readers) > 1 && !p.isShowingHelp {
+		prefix = fmt.Sprintf("[%d/%d] ", p.currentReader+1, len(p.readers))
+	}
+	p.readerLock.Unlock()
+
+	indicators := []string{}
+	if p.isFollowing() {
+		indicators = append(indicators, "[F]")
+	}
+	if p.WrapLongLines {
+		indicators = append(indicators, "wrap")
+	}
+	if p.filterPattern != nil {
+		indicators = append(indicators, "filter")
+	}
+	if mouseTracker, ok := p.screen.(twin.MouseTracker); ok && p.mouseModeToggled {
+		if mouseTracker.MouseTrackingEnabled() {
+			indicators = append(indicators, "mouse scroll")
+		} else {
+			indicators = append(indicators, "mouse select")
+		}
+	}
+	if p.fileChangedOnDisk.Load() {
+		indicators = append(indicators, "changed on disk")
+	}
+	if len(spinner) > 0 {
+		indicators = append(indicators, spinner)
+	}
+
+	return footerSegments{
+		left:   prefix + name,
+		center: position,
+		right:  strings.Join(indicators, " "),
+	}
+}
+
+// Lay out the segments on a line this many columns wide. The center segment is
+// centered if there is room for that, otherwise it is pushed to the side.
+//
+// If not everything fits, the right segment is truncated first, then the left
+// one, and the center segment last.
+func (f footerSegments) layout(width int) string {
+	left, center, right := f.left, f.center, f.right
+	right = truncateToWidth(right, uniseg.StringWidth(right)-footerOverflow(left, center, right, width))
+	left = truncateToWidth(left, uniseg.StringWidth(left)-footerOverflow(left, center, right, width))
+	center = truncateToWidth(center, uniseg.StringWidth(center)-footerOverflow(left, center, right, width))
+
+	leftWidth := uniseg.StringWidth(left)
+	centerWidth := uniseg.StringWidth(center)
+	rightWidth := uniseg.StringWidth(right)
+
+	// Leftmost and rightmost columns the center segment can start at
+	firstCenterColumn := leftWidth
+	if leftWidth > 0 {
+		firstCenterColumn += footerSegmentGap
+	}
+	lastCenterColumn := width - rightWidth - centerWidth
+	if rightWidth > 0 {
+		lastCenterColumn -= footerSegmentGap
+	}
+
+	centerColumn := max(min((width-centerWidth)/2, lastCenterColumn), firstCenterColumn)
+	if centerWidth == 0 {
+		centerColumn = leftWidth
+	}
+	rightColumn := max(width-rightWidth, centerColumn+centerWidth)
+
+	return left +
+		strings.Repeat(" ", centerColumn-leftWidth) +
+		center +
+		strings.Repeat(" ", rightColumn-centerColumn-centerWidth) +
+		right
+}
+
+// By how many columns the segments would overflow the width, with gaps between
+// the non-empty ones. Zero or less means they fit.
+func footerOverflow(left string, center string, right string, width int) int {
+	needed := 0
+	segmentCount := 0
+	for _, segment := range []string{left, center, right} {
+		if segment == "" {
+			continue
+		}
+		needed += uniseg.StringWidth(segment)
+		segmentCount++
+	}
+
+	if segmentCount > 1 {
+		needed += (segmentCount - 1) * footerSegmentGap
+	}
+
+	return needed - width
+}
+
+// Cut the text to at most this many columns, ending it with an ellipsis if it
+// had to be cut. Texts that can't keep at least one character are dropped
+// completely.
+func truncateToWidth(text string, width int) string {
+	if uniseg.StringWidth(text) <= width {
+		return text
+	}
+	if width < 2 {
+		return ""
+	}
+
+	result := strings.Builder{}
+	column := 0
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() {
+		clusterWidth := graphemes.Width()
+		if column+clusterWidth > width-1 {
+			break
+		}
+		result.WriteString(graphemes.Str())
+		column += clusterWidth
+	}
+
+	return result.String() + "…"
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestFooterLayout(t *testing.T) {
+	footer := footerSegments{left: "file.txt", center: "10 lines  50%", right: "wrap"}
+
+	// Plenty of room, center should be centered
+	assert.Equal(t, footer.layout(41), "file.txt      10 lines  50%          wrap")
+
+	// Center pushed right by the left segment
+	long := footerSegments{left: "a-long-file-name.txt", center: "50%", right: "wrap"}
+	assert.Equal(t, long.layout(31), "a-long-file-name.txt  50%  wrap")
+
+	// Empty segments don't need any gaps
+	assert.Equal(t, footerSegments{center: "50%"}.layout(9), "   50%   ")
+	assert.Equal(t, footerSegments{left: "file.txt", right: "wrap"}.layout(14), "file.txt  wrap")
+}
+
+func TestFooterLayoutTruncation(t *testing.T) {
+	footer := footerSegments{left: "file.txt", center: "50%", right: "follow wrap"}
+
+	// Exactly fits
+	assert.Equal(t, footer.layout(26), "file.txt  50%  follow wrap")
+
+	// The right segment should be truncated first
+	assert.Equal(t, footer.layout(20), "file.txt  50%  foll…")
+
+	// Then dropped completely, including its gap
+	assert.Equal(t, footer.layout(15), "file.txt  50%  ")
+	assert.Equal(t, footer.layout(13), "file.txt  50%")
+
+	// Then the left segment is truncated
+	assert.Equal(t, footer.layout(10), "file…  50%")
+
+	// And finally the center segment
+	assert.Equal(t, footer.layout(2), "5…")
+	assert.Equal(t, footer.layout(1), " ")
+}
+
+func TestFooterLayoutWide(t *testing.T) {
+	// Each of these characters is two columns wide
+	footer := footerSegments{left: "午午午", center: "50%"}
+	assert.Equal(t, footer.layout(11), "午午午  50%")
+	assert.Equal(t, footer.layout(10), "午午…  50%")
+}
+
+func TestSegmentedStatusBar(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 3)
+	pager := NewPager(reader.NewFromTextForTesting("file.txt", "a\nb\nc\nd"))
+	pager.screen = screen
+	pager.SegmentedStatusBar = true
+	pager.WrapLongLines = true
+	assert.NilError(t, pager.readers[0].Wait())
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "file.txt      4 lines  50%          wrap")
+}
+
+// Wide characters in the status bar should take up two columns each, without
+// pushing the rest of the status bar out of place.
+func TestStatusBarWideFileName(t *testing.T) {
+	screen := twin.NewFakeScreen(30, 3)
+	pager := NewPager(reader.NewFromTextForTesting("午午.txt", "a\nb\nc\nd"))
+	pager.screen = screen
+	assert.NilError(t, pager.readers[0].Wait())
+
+	pager.redraw("")
+	statusBar := screen.GetRow(2)
+	assert.Equal(t, rowToString(statusBar), "午午.txt: 4 lines  50%  Press")
+
+	// GetRow() leaves out the cells covered by wide characters, so the status
+	// bar should fill the screen width exactly
+	usedColumns := 0
+	for _, cell := range statusBar {
+		usedColumns += cell.Width()
+	}
+	assert.Equal(t, usedColumns, 30)
+}
@@ -0,0 +1,66 @@
+package internal
+
+import "time"
+
+// Hovering redraws at most this often, so that moving the mouse quickly across
+// hyperlinks doesn't redraw on every motion event
+const hoverRedrawInterval = 50 * time.Millisecond
+
+// Returns the hyperlink target of the cell at the given screen position, or
+// nil if there is no hyperlink there.
+func hyperlinkAt(lines []renderedLine, column int, row int) *string {
+	if row < 0 || row >= len(lines) || column < 0 {
+		return nil
+	}
+
+	screenColumn := 0
+	for _, cell := range lines[row].cells {
+		width := cell.Width()
+		if column < screenColumn+width {
+			return cell.Style.HyperlinkURL()
+		}
+		screenColumn += width
+	}
+
+	// Past the end of the line
+	return nil
+}
+
+// Update which hyperlink target to show for the given mouse position.
+//
+// Returns true if the hovered hyperlink changed, meaning we should redraw.
+func (p *Pager) updateHoveredHyperlink(column int, row int) bool {
+	hovered := hyperlinkAt(p.renderedLines, column, row)
+
+	changed := false
+	if hovered == nil || p.hoveredHyperlink == nil {
+		changed = hovered != p.hoveredHyperlink
+	} else {
+		changed = *hovered != *p.hoveredHyperlink
+	}
+
+	p.hoveredHyperlink = hovered
+	return changed
+}
+
+// Handle the mouse moving to the given position.
+//
+// Returns true if we should redraw now. If the hovered hyperlink changed too
+// soon after the last redraw for that, a redraw is scheduled for later instead.
+func (p *Pager) onMouseMotion(column int, row int, now time.Time) bool {
+	if !p.updateHoveredHyperlink(column, row) {
+		return false
+	}
+
+	sinceLastRedraw := now.Sub(p.lastHoverRedraw)
+	if sinceLastRedraw < hoverRedrawInterval {
+		if !p.hoverRedrawScheduled {
+			p.hoverRedrawScheduled = true
+			p.redrawAfter(hoverRedrawInterval - sinceLastRedraw)
+		}
+		return false
+	}
+
+	p.lastHoverRedraw = now
+	return true
+}
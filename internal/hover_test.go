@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestHyperlinkAt(t *testing.T) {
+	url := "http://example.com"
+
+	// "日" is two cells wide, so the link starts at screen column 3
+	pager := NewPager(reader.NewFromTextForTesting("", "a日\x1b]8;;"+url+"\x1b\\link\x1b]8;;\x1b\\ b\nsecond line"))
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(20, 5)
+	pager.redraw("")
+
+	assert.Assert(t, hyperlinkAt(pager.renderedLines, 0, 0) == nil)
+	assert.Assert(t, hyperlinkAt(pager.renderedLines, 2, 0) == nil)
+	assert.Equal(t, *hyperlinkAt(pager.renderedLines, 3, 0), url)
+	assert.Equal(t, *hyperlinkAt(pager.renderedLines, 6, 0), url)
+	assert.Assert(t, hyperlinkAt(pager.renderedLines, 7, 0) == nil)
+
+	// Outside of the rendered cells
+	assert.Assert(t, hyperlinkAt(pager.renderedLines, 15, 0) == nil)
+	assert.Assert(t, hyperlinkAt(pager.renderedLines, 3, 1) == nil)
+	assert.Assert(t, hyperlinkAt(pager.renderedLines, 3, 4) == nil)
+}
+
+func TestUpdateHoveredHyperlink(t *testing.T) {
+	url := "http://example.com"
+
+	pager := NewPager(reader.NewFromTextForTesting("", "\x1b]8;;"+url+"\x1b\\link\x1b]8;;\x1b\\ text"))
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(20, 5)
+	pager.redraw("")
+
+	assert.Assert(t, !pager.updateHoveredHyperlink(10, 0), "Nothing hovered before or after")
+	assert.Assert(t, pager.updateHoveredHyperlink(1, 0), "Started hovering the link")
+	assert.Assert(t, !pager.updateHoveredHyperlink(2, 0), "Still hovering the same link")
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(pager.screen.(*twin.FakeScreen).GetRow(4)), url)
+
+	assert.Assert(t, pager.updateHoveredHyperlink(10, 0), "Stopped hovering the link")
+}
+
+func TestMouseMotionDebounce(t *testing.T) {
+	url := "http://example.com"
+
+	pager := NewPager(reader.NewFromTextForTesting("", "\x1b]8;;"+url+"\x1b\\link\x1b]8;;\x1b\\ text"))
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(20, 5)
+	pager.redraw("")
+
+	start := time.Now()
+	assert.Assert(t, pager.onMouseMotion(1, 0, start), "Started hovering the link")
+	assert.Assert(t, !pager.onMouseMotion(2, 0, start), "Nothing changed")
+
+	// Too soon after the last redraw, should be postponed
+	assert.Assert(t, !pager.onMouseMotion(10, 0, start.Add(10*time.Millisecond)))
+	assert.Assert(t, pager.hoverRedrawScheduled)
+
+	assert.Assert(t, pager.onMouseMotion(1, 0, start.Add(hoverRedrawInterval)))
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"runtime/debug"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Something happened that should keep the pager from exiting because of
+// IdleTimeout. Safe to call from any goroutine.
+func (p *Pager) noteActivity(now time.Time) {
+	p.lastActivity.Store(now.UnixNano())
+}
+
+// How long until IdleTimeout says we should exit. Zero or less means now.
+func (p *Pager) idleTimeRemaining(now time.Time) time.Duration {
+	lastActivity := time.Unix(0, p.lastActivity.Load())
+	return p.IdleTimeout - now.Sub(lastActivity)
+}
+
+// Are we following the end of the input?
+func (p *Pager) isFollowing() bool {
+	return p.TargetLine != nil && *p.TargetLine == linemetadata.IndexMax()
+}
+
+// Lines arriving while following count as activity if the user wants that
+func (p *Pager) noteMoreLinesAvailable(now time.Time) {
+	if p.IdleTimeoutFollowKeepsAlive && p.isFollowing() {
+		p.noteActivity(now)
+	}
+}
+
+// Post an exit event after IdleTimeout without any activity. Does nothing if
+// IdleTimeout is not set.
+//
+// Call the returned function to stop waiting, like when the pager exits.
+func (p *Pager) startIdleTimeout(screen twin.Screen) (stop func()) {
+	if p.IdleTimeout <= 0 {
+		return func() {}
+	}
+
+	p.noteActivity(time.Now())
+
+	stopped := make(chan struct{})
+	go func() {
+		defer func() {
+			PanicHandler("startIdleTimeout()/goroutine", recover(), debug.Stack())
+		}()
+
+		for {
+			remaining := p.idleTimeRemaining(time.Now())
+			if remaining <= 0 {
+				select {
+				case screen.Events() <- twin.EventExit{}:
+					log.Info("Exiting after being idle for ", p.IdleTimeout)
+					return
+				default:
+					// Event queue full, try again in a bit
+					remaining = 100 * time.Millisecond
+				}
+			}
+
+			timer := time.NewTimer(remaining)
+			select {
+			case <-timer.C:
+			case <-stopped:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopped)
+	}
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestIdleTimeoutExits(t *testing.T) {
+	screen := twin.NewFakeScreen(20, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "hello"))
+	pager.IdleTimeout = 10 * time.Millisecond
+
+	stop := pager.startIdleTimeout(screen)
+	defer stop()
+
+	select {
+	case event := <-screen.Events():
+		_, isExit := event.(twin.EventExit)
+		assert.Assert(t, isExit, "Expected an exit event, got %v", event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the idle timeout to fire")
+	}
+}
+
+// After stopping, no exit event should be posted
+func TestIdleTimeoutStopped(t *testing.T) {
+	screen := twin.NewFakeScreen(20, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "hello"))
+	pager.IdleTimeout = 10 * time.Millisecond
+
+	stop := pager.startIdleTimeout(screen)
+	stop()
+
+	select {
+	case event := <-screen.Events():
+		t.Fatalf("Expected no event, got %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestIdleTimeoutResetByActivity(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "hello"))
+	pager.IdleTimeout = time.Minute
+
+	start := time.Now()
+	pager.noteActivity(start)
+	assert.Equal(t, pager.idleTimeRemaining(start.Add(50*time.Second)), 10*time.Second)
+
+	pager.noteActivity(start.Add(50 * time.Second))
+	assert.Equal(t, pager.idleTimeRemaining(start.Add(80*time.Second)), 30*time.Second)
+}
+
+func TestIdleTimeoutWhileFollowing(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "hello"))
+	pager.IdleTimeout = time.Minute
+	following := linemetadata.IndexMax()
+	pager.TargetLine = &following
+
+	start := time.Now()
+	pager.noteActivity(start)
+
+	// Incoming lines don't count by default...
+	pager.noteMoreLinesAvailable(start.Add(time.Minute))
+	assert.Assert(t, pager.idleTimeRemaining(start.Add(time.Minute)) <= 0)
+
+	// ... unless we ask for it
+	pager.IdleTimeoutFollowKeepsAlive = true
+	pager.noteMoreLinesAvailable(start.Add(time.Minute))
+	assert.Equal(t, pager.idleTimeRemaining(start.Add(time.Minute)), time.Minute)
+
+	// And only while following
+	pager.TargetLine = nil
+	pager.noteMoreLinesAvailable(start.Add(2 * time.Minute))
+	assert.Equal(t, pager.idleTimeRemaining(start.Add(2*time.Minute)), 0*time.Second)
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"github.com/walles/moor/v2/internal/linemetadata"
+)
+
+// Set up searching for InitialSearch, see continueInitialSearch()
+func (p *Pager) startInitialSearch() {
+	if p.InitialSearch == "" {
+		return
+	}
+
+	p.searchString = p.InitialSearch
+	p.searchPattern, p.searchMatcher = p.searchSyntax().toSearch(p.InitialSearch)
+	p.searchDirection = SearchDirectionForward
+	p.initialSearchPending = true
+	p.initialSearchFrom = linemetadata.Index{}
+	p.initialSearchSkippedLines = 0
+}
+
+// Look for the first InitialSearch hit among the lines that have arrived since
+// last time, and scroll to it if found. Called by the main loop before each
+// redraw until we find a hit or the input is done without any hits.
+func (p *Pager) continueInitialSearch() {
+	if !p.initialSearchPending {
+		return
+	}
+
+	p.readerLock.Lock()
+	done := p.readers[p.currentReader].Done.Load()
+	p.readerLock.Unlock()
+
+	// Count lines after the done check, so that if we're done, we search all
+	// lines before giving up
+	lineCount := p.Reader().GetLineCount()
+
+	var firstHitIndex *linemetadata.Index
+	if p.initialSearchFrom.IsWithinLength(lineCount) {
+		var skippedLines int
+		firstHitIndex, skippedLines = p.findFirstHit(p.initialSearchFrom, linemetadata.IndexFromLength(lineCount+1), false)
+		p.initialSearchSkippedLines += skippedLines
+	}
+
+	if firstHitIndex == nil {
+		if done {
+			p.initialSearchPending = false
+			p.enterNotFoundMode(p.initialSearchSkippedLines)
+			return
+		}
+
+		// Continue from here when more lines arrive
+		p.initialSearchFrom = linemetadata.IndexFromZeroBased(lineCount)
+		return
+	}
+
+	p.initialSearchPending = false
+	p.scrollPosition = NewScrollPositionFromIndex(*firstHitIndex, "continueInitialSearch")
+	p.currentSearchHit = firstHitIndex
+
+	// Don't let the search hit scroll out of sight
+	p.setTargetLine(nil)
+
+	p.leftColumnZeroBased = 0
+	p.showLineNumbers = p.ShowLineNumbers
+	if !p.searchHitIsVisible() {
+		p.scrollRightToSearchHits()
+	}
+	p.centerSearchHitsVertically()
+}
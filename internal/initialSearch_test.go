@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func startPagingWithInitialSearch(t *testing.T, initialSearch string) (*Pager, *twin.FakeScreen) {
+	lines := []string{}
+	for i := range 100 {
+		lines = append(lines, fmt.Sprint("line ", i))
+	}
+	lines[60] = "needle"
+
+	reader := reader.NewFromTextForTesting("TestInitialSearch", strings.Join(lines, "\n"))
+	assert.NilError(t, reader.Wait())
+
+	screen := twin.NewFakeScreen(20, 11) // 10 lines plus the footer
+	pager := NewPager(reader)
+	pager.ShowLineNumbers = false
+	pager.InitialSearch = initialSearch
+
+	// Exit immediately
+	pager.Quit()
+	pager.StartPaging(screen, nil, nil)
+
+	// Like the main loop does before redrawing
+	pager.continueInitialSearch()
+	pager.redraw("")
+
+	return pager, screen
+}
+
+func TestInitialSearch(t *testing.T) {
+	pager, screen := startPagingWithInitialSearch(t, "needle")
+
+	assert.Assert(t, pager.searchHitIsVisible())
+	assert.Assert(t, pager.isViewing())
+	assert.Assert(t, pager.lineIndex().Index() > 0, "Should have scrolled down to the hit")
+
+	found := false
+	for row := range 10 {
+		if rowToString(screen.GetRow(row)) == "needle" {
+			found = true
+		}
+	}
+	assert.Assert(t, found, "Search hit should be on screen")
+}
+
+func TestInitialSearchNotFound(t *testing.T) {
+	pager, _ := startPagingWithInitialSearch(t, "haystack")
+
+	assert.Assert(t, pager.isNotFound())
+	assert.Equal(t, pager.lineIndex().Index(), 0)
+}
@@ -26,13 +26,18 @@ type InputBox struct {
 	// len(runes) == after last rune).
 	cursorPos int
 
+	// overwrite is toggled by the Insert key. When set, typed runes replace
+	// the rune at the cursor rather than being inserted before it.
+	overwrite bool
+
 	// onTextChanged is an optional callback which is triggered when the text
 	// of the InputBox changes.
 	onTextChanged InputBoxOnTextChanged
 }
 
 // draw renders the input box at the bottom line of the screen, showing a
-// simple prompt and the current text with a reverse attribute cursor.
+// simple prompt and the current text, with the terminal cursor at the
+// insertion point.
 func (b *InputBox) draw(screen twin.Screen, prompt string) {
 	width, height := screen.Size()
 	pos := 0
@@ -51,32 +56,23 @@ func (b *InputBox) draw(screen twin.Screen, prompt string) {
 		b.cursorPos = len(textRunes)
 	}
 
-	// Draw left side (before cursor)
+	cursorColumn := pos
 	for i, ch := range textRunes {
 		if i == b.cursorPos {
-			break
+			cursorColumn = pos
 		}
 		pos += screen.SetCell(pos, height-1, twin.NewStyledRune(ch, twin.StyleDefault))
 	}
-
-	// If cursor is on a rune, invert that rune. If cursor is at the end,
-	// show an inverted blank cell.
-	if b.cursorPos < len(textRunes) {
-		pos += screen.SetCell(pos, height-1, twin.NewStyledRune(textRunes[b.cursorPos], twin.StyleDefault.WithAttr(twin.AttrReverse)))
-
-		// Draw right side after the cursor rune
-		for i := b.cursorPos + 1; i < len(textRunes); i++ {
-			pos += screen.SetCell(pos, height-1, twin.NewStyledRune(textRunes[i], twin.StyleDefault))
-		}
-	} else {
-		// Cursor at end -> reverse blank
-		pos += screen.SetCell(pos, height-1, twin.NewStyledRune(' ', twin.StyleDefault.WithAttr(twin.AttrReverse)))
+	if b.cursorPos == len(textRunes) {
+		cursorColumn = pos
 	}
 
 	// Clear the rest of the line
 	for pos < width {
 		pos += screen.SetCell(pos, height-1, twin.NewStyledRune(' ', twin.StyleDefault))
 	}
+
+	screen.ShowCursorAt(cursorColumn, height-1)
 }
 
 // handleRune appends runes to the text of the InputBox and returns if those have been processed.
@@ -96,15 +92,58 @@ func (b *InputBox) handleRune(char rune) bool {
 		b.moveCursorEnd()
 		return true
 	}
+	if char == '\x0b' {
+		// Ctrl-K, delete from the cursor to the end
+		b.killToEnd()
+		return true
+	}
+	if char == '\x17' {
+		// Ctrl-W, delete the word before the cursor
+		b.deleteWordBackward()
+		return true
+	}
+
+	if !b.accepts(char) {
+		return false
+	}
+
+	b.insertRunes([]rune{char})
+	return true
+}
+
+// handlePaste inserts pasted text at the cursor. Line breaks become spaces
+// since the text is a single line, and runes the box doesn't accept are
+// dropped.
+func (b *InputBox) handlePaste(text string) {
+	chars := []rune{}
+	for _, char := range text {
+		if char == '\n' {
+			char = ' '
+		}
+		if unicode.IsControl(char) || !b.accepts(char) {
+			continue
+		}
+		chars = append(chars, char)
+	}
 
+	if len(chars) == 0 {
+		return
+	}
+	b.insertRunes(chars)
+}
+
+// accepts tells whether the rune may be typed into this box
+func (b *InputBox) accepts(char rune) bool {
 	// If configured to accept numbers only, drop any non-digit rune.
 	if b.accept == INPUTBOX_ACCEPT_POSITIVE_NUMBERS {
-		if !unicode.IsDigit(char) {
-			return false
-		}
+		return unicode.IsDigit(char)
 	}
+	return true
+}
 
-	// Insert at cursor position
+// insertRunes inserts (or in overwrite mode, overwrites with) the runes at the
+// cursor and moves the cursor past them.
+func (b *InputBox) insertRunes(chars []rune) {
 	runes := []rune(b.text)
 	if b.cursorPos < 0 {
 		b.cursorPos = 0
@@ -113,21 +152,19 @@ func (b *InputBox) handleRune(char rune) bool {
 		b.cursorPos = len(runes)
 	}
 
-	// Build a new rune slice with the inserted rune
-	newRunes := make([]rune, 0, len(runes)+1)
-	newRunes = append(newRunes, runes[:b.cursorPos]...)
-	newRunes = append(newRunes, char)
-	if b.cursorPos < len(runes) {
-		newRunes = append(newRunes, runes[b.cursorPos:]...)
+	// Build a new rune slice with the inserted runes
+	after := b.cursorPos
+	if b.overwrite {
+		after = min(after+len(chars), len(runes))
 	}
-	b.text = string(newRunes)
-	b.cursorPos++
+	newRunes := make([]rune, 0, len(runes)+len(chars))
+	newRunes = append(newRunes, runes[:b.cursorPos]...)
+	newRunes = append(newRunes, chars...)
+	newRunes = append(newRunes, runes[after:]...)
+	b.cursorPos += len(chars)
 
 	// finally let's tell someone that the text has changed
-	if b.onTextChanged != nil {
-		b.onTextChanged(b.text)
-	}
-	return true
+	b.updateText(newRunes)
 }
 
 // handleKey processes special keys like backspace, delete, arrow keys, home and end.
@@ -150,6 +187,18 @@ func (b *InputBox) handleKey(key twin.KeyCode) bool {
 		b.moveCursorEnd()
 		return true
 
+	case twin.KeyCtrlLeft, twin.KeyAltLeft:
+		b.moveCursorWordLeft()
+		return true
+
+	case twin.KeyCtrlRight, twin.KeyAltRight:
+		b.moveCursorWordRight()
+		return true
+
+	case twin.KeyInsert:
+		b.overwrite = !b.overwrite
+		return true
+
 	case twin.KeyBackspace:
 		b.backspace()
 		return true
@@ -162,6 +211,13 @@ func (b *InputBox) handleKey(key twin.KeyCode) bool {
 	return false
 }
 
+// replaceText replaces the text and moves the cursor to its end, without calling
+// onTextChanged.
+func (b *InputBox) replaceText(text string) {
+	b.text = text
+	b.moveCursorEnd()
+}
+
 // moveCursorLeft moves the cursor one rune to the left.
 func (b *InputBox) moveCursorLeft() {
 	if b.cursorPos > 0 {
@@ -186,16 +242,53 @@ func (b *InputBox) moveCursorEnd() {
 	b.cursorPos = len([]rune(b.text))
 }
 
+// Is this rune part of a word when moving the cursor word by word?
+func isWordRune(char rune) bool {
+	return char == '_' || unicode.IsLetter(char) || unicode.IsDigit(char)
+}
+
+// moveCursorWordLeft moves the cursor to the start of the word before it.
+func (b *InputBox) moveCursorWordLeft() {
+	runes := []rune(b.text)
+	pos := min(b.cursorPos, len(runes))
+	for pos > 0 && !isWordRune(runes[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordRune(runes[pos-1]) {
+		pos--
+	}
+	b.cursorPos = pos
+}
+
+// moveCursorWordRight moves the cursor to the end of the word after it.
+func (b *InputBox) moveCursorWordRight() {
+	runes := []rune(b.text)
+	pos := max(b.cursorPos, 0)
+	for pos < len(runes) && !isWordRune(runes[pos]) {
+		pos++
+	}
+	for pos < len(runes) && isWordRune(runes[pos]) {
+		pos++
+	}
+	b.cursorPos = pos
+}
+
+// updateText replaces the text and calls onTextChanged. The caller is responsible
+// for updating cursorPos.
+func (b *InputBox) updateText(runes []rune) {
+	b.text = string(runes)
+	if b.onTextChanged != nil {
+		b.onTextChanged(b.text)
+	}
+}
+
 // backspace removes the rune before the cursor and moves the cursor left.
 func (b *InputBox) backspace() {
 	runes := []rune(b.text)
 	if b.cursorPos > 0 && len(runes) > 0 {
 		runes = append(runes[:b.cursorPos-1], runes[b.cursorPos:]...)
 		b.cursorPos--
-		b.text = string(runes)
-		if b.onTextChanged != nil {
-			b.onTextChanged(b.text)
-		}
+		b.updateText(runes)
 	}
 }
 
@@ -204,9 +297,37 @@ func (b *InputBox) delete() {
 	runes := []rune(b.text)
 	if b.cursorPos < len(runes) {
 		runes = append(runes[:b.cursorPos], runes[b.cursorPos+1:]...)
-		b.text = string(runes)
-		if b.onTextChanged != nil {
-			b.onTextChanged(b.text)
-		}
+		b.updateText(runes)
+	}
+}
+
+// deleteWordBackward removes everything from the cursor back to the start of
+// the previous whitespace separated word, like Ctrl-W in a shell.
+func (b *InputBox) deleteWordBackward() {
+	runes := []rune(b.text)
+	end := min(b.cursorPos, len(runes))
+	start := end
+	for start > 0 && unicode.IsSpace(runes[start-1]) {
+		start--
 	}
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start--
+	}
+	if start == end {
+		return
+	}
+
+	runes = append(runes[:start], runes[end:]...)
+	b.cursorPos = start
+	b.updateText(runes)
+}
+
+// killToEnd removes everything from the cursor to the end of the text.
+func (b *InputBox) killToEnd() {
+	runes := []rune(b.text)
+	if b.cursorPos >= len(runes) {
+		return
+	}
+
+	b.updateText(runes[:b.cursorPos])
 }
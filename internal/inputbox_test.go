@@ -89,3 +89,135 @@ func TestUnicodeRunes(t *testing.T) {
 	// We expect prompt + two runes
 	assert.Equal(t, "U: 你午", row)
 }
+
+func TestWordMovement(t *testing.T) {
+	b := &InputBox{accept: INPUTBOX_ACCEPT_ALL}
+	b.replaceText("foo bar_baz  42")
+	assert.Equal(t, 15, b.cursorPos)
+
+	assert.Assert(t, b.handleKey(twin.KeyCtrlLeft))
+	assert.Equal(t, 13, b.cursorPos)
+	assert.Assert(t, b.handleKey(twin.KeyCtrlLeft))
+	assert.Equal(t, 4, b.cursorPos)
+	assert.Assert(t, b.handleKey(twin.KeyAltLeft))
+	assert.Equal(t, 0, b.cursorPos)
+	assert.Assert(t, b.handleKey(twin.KeyCtrlLeft))
+	assert.Equal(t, 0, b.cursorPos)
+
+	assert.Assert(t, b.handleKey(twin.KeyCtrlRight))
+	assert.Equal(t, 3, b.cursorPos)
+	assert.Assert(t, b.handleKey(twin.KeyAltRight))
+	assert.Equal(t, 11, b.cursorPos)
+	assert.Assert(t, b.handleKey(twin.KeyCtrlRight))
+	assert.Equal(t, 15, b.cursorPos)
+	assert.Assert(t, b.handleKey(twin.KeyCtrlRight))
+	assert.Equal(t, 15, b.cursorPos)
+}
+
+func TestDeleteWordBackward(t *testing.T) {
+	changes := 0
+	b := &InputBox{
+		accept:        INPUTBOX_ACCEPT_ALL,
+		onTextChanged: func(_ string) { changes++ },
+	}
+	b.replaceText("error in /var/log  ")
+
+	// Ctrl-W
+	assert.Assert(t, b.handleRune('\x17'))
+	assert.Equal(t, "error in ", b.text)
+	assert.Equal(t, 9, b.cursorPos)
+	assert.Equal(t, 1, changes)
+
+	// In the middle of the text, only what's before the cursor goes
+	b.moveCursorLeft()
+	b.moveCursorLeft()
+	assert.Assert(t, b.handleRune('\x17'))
+	assert.Equal(t, "error n ", b.text)
+	assert.Equal(t, 6, b.cursorPos)
+
+	// Nothing to delete at the start
+	b.moveCursorHome()
+	assert.Assert(t, b.handleRune('\x17'))
+	assert.Equal(t, "error n ", b.text)
+	assert.Equal(t, 2, changes)
+}
+
+func TestKillToEnd(t *testing.T) {
+	b := &InputBox{accept: INPUTBOX_ACCEPT_ALL}
+	b.replaceText("午前 and after")
+	b.moveCursorHome()
+	b.moveCursorWordRight()
+	assert.Equal(t, 2, b.cursorPos)
+
+	// Ctrl-K
+	assert.Assert(t, b.handleRune('\x0b'))
+	assert.Equal(t, "午前", b.text)
+	assert.Equal(t, 2, b.cursorPos)
+
+	// Nothing more to kill
+	assert.Assert(t, b.handleRune('\x0b'))
+	assert.Equal(t, "午前", b.text)
+}
+
+func TestOverwrite(t *testing.T) {
+	b := &InputBox{accept: INPUTBOX_ACCEPT_ALL}
+	b.replaceText("abcd")
+	b.moveCursorHome()
+	b.moveCursorRight()
+
+	assert.Assert(t, b.handleKey(twin.KeyInsert))
+	assert.Assert(t, b.handleRune('X'))
+	assert.Assert(t, b.handleRune('Y'))
+	assert.Equal(t, "aXYd", b.text)
+
+	// Past the end, overwriting appends
+	assert.Assert(t, b.handleRune('Z'))
+	assert.Assert(t, b.handleRune('W'))
+	assert.Equal(t, "aXYZW", b.text)
+
+	// Back to inserting
+	b.moveCursorHome()
+	assert.Assert(t, b.handleKey(twin.KeyInsert))
+	assert.Assert(t, b.handleRune('>'))
+	assert.Equal(t, ">aXYZW", b.text)
+}
+
+func TestPaste(t *testing.T) {
+	changes := 0
+	b := &InputBox{accept: INPUTBOX_ACCEPT_ALL, onTextChanged: func(string) { changes++ }}
+	b.replaceText("<>")
+	b.moveCursorLeft()
+
+	b.handlePaste("q\r\nj")
+	assert.Equal(t, "<q j>", b.text)
+	assert.Equal(t, 4, b.cursorPos)
+	assert.Equal(t, 1, changes)
+}
+
+func TestPasteNumbers(t *testing.T) {
+	b := &InputBox{accept: INPUTBOX_ACCEPT_POSITIVE_NUMBERS}
+	b.handlePaste("12a3")
+	assert.Equal(t, "123", b.text)
+}
+
+func TestDrawShowsCursor(t *testing.T) {
+	screen := twin.NewFakeScreen(20, 2)
+	b := &InputBox{accept: INPUTBOX_ACCEPT_ALL}
+	b.replaceText("午x")
+
+	b.draw(screen, "P: ")
+	column, row, ok := screen.CursorAt()
+	assert.Assert(t, ok)
+	assert.Equal(t, 1, row)
+	assert.Equal(t, 6, column, "After the prompt, the wide rune and the x")
+
+	b.moveCursorLeft()
+	b.draw(screen, "P: ")
+	column, _, _ = screen.CursorAt()
+	assert.Equal(t, 5, column, "On the x, after the wide rune")
+
+	b.moveCursorHome()
+	b.draw(screen, "P: ")
+	column, _, _ = screen.CursorAt()
+	assert.Equal(t, 3, column, "Right after the prompt")
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+// Replaces the space after the line number for new lines, see
+// NewLinesMarkerDuration
+const newLineMarker = '+'
+
+// Lines that showed up together, from firstIndex up to the next batch
+type newLinesBatch struct {
+	firstIndex linemetadata.Index
+	added      time.Time
+}
+
+// Keeps track of which lines are new since the last reader update
+type newLinesTracker struct {
+	// The reader we are tracking lines for. Switching to another one starts
+	// over without marking anything.
+	reader *reader.ReaderImpl
+
+	// How many lines we have seen so far. Lines past this are new.
+	knownLineCount int
+
+	batches []newLinesBatch
+
+	// After a reload, lines differing from the ones in the previous reader
+	// are new as well
+	previousReader *reader.ReaderImpl
+	reloaded       time.Time
+}
+
+// Start marking lines differing from the ones in the previous reader, or not
+// present in it.
+//
+// Must be called with readerLock held.
+func (p *Pager) trackReload(previous *reader.ReaderImpl, reloaded *reader.ReaderImpl, now time.Time) {
+	if p.NewLinesMarkerDuration <= 0 {
+		return
+	}
+
+	p.newLines = newLinesTracker{
+		reader:         reloaded,
+		knownLineCount: previous.GetLineCount(),
+		previousReader: previous,
+		reloaded:       now,
+	}
+	p.redrawWhenNewLinesExpire()
+}
+
+// Mark any lines that arrived since last time as new
+func (p *Pager) trackNewLines(now time.Time) {
+	if p.NewLinesMarkerDuration <= 0 {
+		return
+	}
+
+	p.readerLock.Lock()
+	current := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	lineCount := current.GetLineCount()
+	if p.newLines.reader != current {
+		// New reader, whatever it has so far is not news
+		p.newLines = newLinesTracker{
+			reader:         current,
+			knownLineCount: lineCount,
+		}
+		return
+	}
+
+	if p.newLines.previousReader != nil && now.Sub(p.newLines.reloaded) >= p.NewLinesMarkerDuration {
+		// No need to compare with the previous reader anymore
+		p.newLines.previousReader = nil
+	}
+
+	if lineCount <= p.newLines.knownLineCount {
+		return
+	}
+
+	// Lines from a file's initial load are not news. After a reload, those are
+	// marked by comparing with the previous reader instead.
+	if !current.InitialLoad() {
+		p.newLines.batches = append(p.newLines.batches, newLinesBatch{
+			firstIndex: linemetadata.IndexFromZeroBased(p.newLines.knownLineCount),
+			added:      now,
+		})
+		p.redrawWhenNewLinesExpire()
+	}
+	p.newLines.knownLineCount = lineCount
+}
+
+// Should this line have a new line marker at this time?
+func (p *Pager) isNewLine(line *reader.NumberedLine, now time.Time) bool {
+	if p.NewLinesMarkerDuration <= 0 || p.isShowingHelp || p.renderingOtherPane {
+		return false
+	}
+
+	// When filtering, the index is into the filtered lines, but the number is
+	// still from the input
+	index := linemetadata.IndexFromZeroBased(line.Number.AsZeroBased())
+
+	tracker := &p.newLines
+	if tracker.previousReader != nil && now.Sub(tracker.reloaded) < p.NewLinesMarkerDuration {
+		if !index.IsWithinLength(tracker.previousReader.GetLineCount()) {
+			return true
+		}
+
+		previousLine := tracker.previousReader.GetLine(index)
+		if previousLine == nil || previousLine.Plain() != line.Plain() {
+			return true
+		}
+	}
+
+	// Find the last batch starting at or before this line
+	for i := len(tracker.batches) - 1; i >= 0; i-- {
+		batch := tracker.batches[i]
+		if index.IsBefore(batch.firstIndex) {
+			continue
+		}
+
+		return now.Sub(batch.added) < p.NewLinesMarkerDuration
+	}
+
+	return false
+}
+
+// Make the markers go away on time, even if nothing else happens
+func (p *Pager) redrawWhenNewLinesExpire() {
+	p.redrawAfter(p.NewLinesMarkerDuration)
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestNewLinesMarkerAfterReload(t *testing.T) {
+	file, err := os.CreateTemp("", "moor-TestNewLinesMarkerAfterReload-*.txt")
+	assert.NilError(t, err)
+	defer os.Remove(file.Name()) //nolint:errcheck
+
+	_, err = file.WriteString("old\nold\n")
+	assert.NilError(t, err)
+
+	r, err := reader.NewFromFilename(file.Name(), formatters.TTY16m, reader.ReaderOptions{Style: styles.Get("native")})
+	assert.NilError(t, err)
+	assert.NilError(t, r.Wait())
+
+	screen := twin.NewFakeScreen(20, 5)
+	pager := NewPager(r)
+	pager.screen = screen
+	pager.NewLinesMarkerDuration = time.Hour
+
+	_, err = file.WriteString("new\n")
+	assert.NilError(t, err)
+	assert.NilError(t, file.Close())
+
+	pager.reloadFile()
+	reloaded := pager.readers[0]
+	assert.NilError(t, reloaded.Wait())
+
+	// Normally done by the reader switching goroutine in StartPaging()
+	pager.filteringReader.SetBackingReader(reloaded)
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "  1 old")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "  2 old")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "  3+new")
+
+	// The marker should go away after a while
+	newLine := reloaded.GetLine(linemetadata.IndexFromZeroBased(2))
+	assert.Assert(t, pager.isNewLine(newLine, time.Now()))
+	assert.Assert(t, !pager.isNewLine(newLine, time.Now().Add(2*time.Hour)))
+}
+
+func TestNewLinesMarkerWhileFollowing(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+	writeDone := make(chan error, 1)
+	go func() {
+		_, err := pipeWriter.Write([]byte("old\n"))
+		writeDone <- err
+	}()
+
+	r, err := reader.NewFromStream("TestNewLinesMarkerWhileFollowing", pipeReader, formatters.TTY16m, reader.ReaderOptions{})
+	assert.NilError(t, err)
+	assert.NilError(t, <-writeDone)
+	awaitLineCount(t, r, 1)
+
+	screen := twin.NewFakeScreen(20, 5)
+	pager := NewPager(r)
+	pager.screen = screen
+	pager.NewLinesMarkerDuration = time.Hour
+
+	// Whatever is there to begin with is not new
+	pager.trackNewLines(time.Now())
+
+	_, err = pipeWriter.Write([]byte("new\n"))
+	assert.NilError(t, err)
+	awaitLineCount(t, r, 2)
+	pager.trackNewLines(time.Now())
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "  1 old")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "  2+new")
+
+	assert.NilError(t, pipeWriter.Close())
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/alecthomas/chroma/v2"
@@ -13,6 +14,7 @@ import (
 	"github.com/walles/moor/v2/internal/linemetadata"
 	"github.com/walles/moor/v2/internal/reader"
 	"github.com/walles/moor/v2/internal/textstyles"
+	"github.com/walles/moor/v2/internal/util"
 	"github.com/walles/moor/v2/twin"
 )
 
@@ -22,6 +24,11 @@ type PagerMode interface {
 	drawFooter(statusText string, spinner string)
 }
 
+// Implemented by modes that take pasted text, other modes ignore pastes
+type pasteHandler interface {
+	onPaste(text string)
+}
+
 type StatusBarOption int
 
 const (
@@ -33,6 +40,43 @@ const (
 	STATUSBAR_STYLE_BOLD
 )
 
+// What to do in addition to showing NotFoundMessage when a search fails
+type NotFoundAlertOption int
+
+const (
+	//revive:disable-next-line:var-naming
+	NOT_FOUND_ALERT_NONE NotFoundAlertOption = iota
+	//revive:disable-next-line:var-naming
+	NOT_FOUND_ALERT_BEEP
+	//revive:disable-next-line:var-naming
+	NOT_FOUND_ALERT_FLASH
+)
+
+// Whether searches are case sensitive, see toPattern()
+type SearchCaseOption int
+
+const (
+	// Smart case, case sensitive only if the search contains upper case
+	//revive:disable-next-line:var-naming
+	SEARCH_CASE_AUTO SearchCaseOption = iota
+	//revive:disable-next-line:var-naming
+	SEARCH_CASE_SENSITIVE
+	//revive:disable-next-line:var-naming
+	SEARCH_CASE_INSENSITIVE
+)
+
+// How to render tab separated .tsv files
+type TsvTableOption int
+
+const (
+	//revive:disable-next-line:var-naming
+	TSV_TABLE_OFF TsvTableOption = iota
+	//revive:disable-next-line:var-naming
+	TSV_TABLE_ALIGNED
+	//revive:disable-next-line:var-naming
+	TSV_TABLE_SEPARATED
+)
+
 type eventSpinnerUpdate struct {
 	spinner string
 }
@@ -43,11 +87,24 @@ type eventMoreLinesAvailable struct{}
 // reader.HighlightingDone() for details.
 type eventMaybeDone struct{}
 
+// The current file changed on disk, or was reloaded
+type eventFileChangedOnDisk struct{}
+
+// Nothing happened, but the screen should be redrawn anyway since something
+// shown depends on time. See redrawAfter().
+type eventRedraw struct{}
+
 // Pager is the main on-screen pager
 type Pager struct {
-	readers       []*reader.ReaderImpl // Immutable since startup, no locking needed
+	readers       []*reader.ReaderImpl // Replaced by reloadFile(), otherwise immutable since startup
 	currentReader int                  // Index into the readers slice
-	readerLock    sync.Mutex           // Protects currentReader
+	readerLock    sync.Mutex           // Protects currentReader and reloading readers
+
+	// True if the current file has changed on disk since we read it
+	fileChangedOnDisk atomic.Bool
+
+	// What we last put in the terminal window title, see updateTitle()
+	shownTitle string
 
 	readerSwitched chan struct{}
 
@@ -66,8 +123,91 @@ type Pager struct {
 
 	searchString  string
 	searchPattern *regexp.Regexp
+
+	// Searching a line taking longer than this skips that line, so that huge
+	// lines can't stall searching. Zero means no timeout.
+	SearchLineTimeout time.Duration
+
+	// Decides which lines are search hits when combining multiple patterns,
+	// see toSearch(). If nil, lines matching searchPattern are hits.
+	searchMatcher lineMatcher
 	filterPattern *regexp.Regexp
 
+	// Direction of the last search. Decides which way 'n' and 'N' go.
+	searchDirection SearchDirection
+
+	// Previous searches, browse using the arrow keys in the search prompt
+	searchHistory searchHistory
+
+	// If set, remember searches between runs in this file
+	SearchHistoryFile string
+
+	// The line search hit navigation last took us to, for the "match 12 of
+	// 340" status bar text
+	currentSearchHit *linemetadata.Index
+
+	// Latest search hit count, may be out of date, see searchHitCountKey
+	searchHitCount *searchHitCount
+
+	// Non-nil while counting search hits in the background
+	searchHitCountStarted *searchHitCountKey
+
+	// If set, search for this on startup and scroll to the first hit, like
+	// "less -p pattern"
+	InitialSearch string
+
+	// True until we have found the first InitialSearch hit, or given up
+	initialSearchPending bool
+
+	// Lines before this have already been searched for InitialSearch
+	initialSearchFrom linemetadata.Index
+
+	// Lines skipped so far by the InitialSearch because they took too long to
+	// search
+	initialSearchSkippedLines int
+
+	// Whether searching and filtering is case sensitive. Press 'I' to cycle
+	// through the options.
+	SearchCaseMode SearchCaseOption
+
+	// If true, searching for "foo, bar" finds both "foo" and "bar". Press ','
+	// to toggle.
+	SearchCommaSeparated bool
+
+	// If true, searches are for the text as typed rather than for regexps
+	SearchLiteral bool
+
+	// If true, " && " and " || " combine search patterns, like "error && disk
+	// || panic". Has no effect with SearchLiteral.
+	SearchAndOr bool
+
+	// If true, search hits on screen are not highlighted. Press 'H' to toggle.
+	// Search hit navigation works the same either way.
+	NoSearchHighlight bool
+
+	// If true, search hits can span multiple lines, up to
+	// multilineSearchWindow of them. Lines are separated by "\n" when
+	// matching, and the hit is on the line where the match starts.
+	MultilineSearch bool
+
+	// If true, lines with search hits get a tinted background in addition to
+	// the search hits themselves being highlighted.
+	SearchHitLineBackground bool
+
+	// Search hit colors, overriding the ones from the style. If only one of
+	// them is set, the other one is picked to go with it.
+	SearchHitForeground *twin.Color
+	SearchHitBackground *twin.Color
+
+	// If true, typing a search scrolls sideways only as far as needed to show
+	// the hit closest to the current view, rather than jumping to the first
+	// hit to the right.
+	MinimalSearchScroll bool
+
+	// If true, the rightmost screen column shows where in the input the
+	// search hits are
+	SearchMinimap bool
+
 	// We used to have a "Following" field here. If you want to follow, set
 	// TargetLineNumber to LineNumberMax() instead, see below.
 
@@ -77,16 +217,153 @@ type Pager struct {
 	// User preference
 	ShowLineNumbers bool
 
+	// If true, line numbers are shown and entered counting from 0 rather than
+	// from 1
+	ZeroBasedLineNumbers bool
+
 	// Current state, initialized in StartPaging()
 	showLineNumbers bool
 
 	StatusBarStyle StatusBarOption
 	ShowStatusBar  bool
 
+	// Pressing this key toggles inverted colors. Zero means no key.
+	InvertColorsKey rune
+
+	// Current state, toggled using InvertColorsKey
+	invertColors bool
+
 	UnprintableStyle textstyles.UnprintableStyleT
 
+	// Render box drawing characters as ASCII, see textstyles.ToASCIILines()
+	ASCIILines bool
+
+	// Make hyperlinks out of URLs in the text, see textstyles.LinkifyURLs()
+	DetectURLs bool
+
 	WrapLongLines bool
 
+	// If true, scrolling down can go on until the last line is at the top of
+	// the screen, leaving empty space below it. If false, scrolling stops when
+	// the last line reaches the bottom of the screen.
+	ScrollPastEnd bool
+
+	// When wrapping, leave this many columns empty to the right
+	WrapMargin int
+
+	// When wrapping, show at most this many rows of each line, followed by a
+	// row saying that the line continues. Zero means no limit.
+	MaxWrapRows int
+
+	// When wrapping, only wrap lines that don't fit on screen, and never
+	// scroll sideways. This means nothing is ever cut off to the right.
+	WrapAsNeeded bool
+
+	// If set, the part of each line matching this pattern is hidden. This is
+	// for display only, searching still sees the whole line. The pattern is
+	// expected to be anchored at the start of the line.
+	StripPrefix *regexp.Regexp
+
+	// If true, lines with a hidden prefix start with a marker. Lines with
+	// search hits in the hidden prefix always get a marker.
+	ShowStrippedPrefixMarker bool
+
+	// If true, hide the indentation shared by all lines on screen. Like
+	// StripPrefix, this is for display only.
+	Dedent bool
+
+	// Number of leading spaces hidden by Dedent, updated for every render
+	dedentWidth int
+
+	// This many lines from the start of the input stay at the top of the
+	// screen while the rest scrolls below them, like a frozen table header
+	StickyHeaderLines int
+
+	// This many lines from the end of the input stay at the bottom of the
+	// screen while the rest scrolls above them
+	StickyFooterLines int
+
+	// Render .tsv files as tables with aligned columns
+	TsvTable TsvTableOption
+
+	// Column widths for the table on screen, computed by renderLines()
+	tableColumnWidths []int
+
+	// If true, search hits stay highlighted after pressing ESC in the search
+	// prompt, until cleared with 'c'. If false, ESC clears the search.
+	KeepSearchOnEscape bool
+
+	// Exit after this long without any key presses or mouse events. Zero means
+	// never.
+	IdleTimeout time.Duration
+
+	// If true, new lines arriving while following the end of the input count
+	// as activity for IdleTimeout.
+	IdleTimeoutFollowKeepsAlive bool
+
+	// When the user last did something, in Unix nanoseconds. See IdleTimeout.
+	lastActivity atomic.Int64
+
+	// The command line that produced the input, if known. Can be copied to the
+	// clipboard using 'C'.
+	SourceCommand string
+
+	// Copying to the clipboard is refused if it is larger than this many
+	// bytes, since terminals limit how much they accept. Zero means no
+	// limit.
+	ClipboardMaxBytes int
+
+	// If true, mouse selections and 'T' copy styling to the clipboard as ANSI
+	// escape codes. Use 'S' to copy all lines with styling.
+	CopyWithANSI bool
+
+	// If true, wrapping, line numbers and horizontal scrolling are remembered
+	// for each file when switching between files. If false, they are shared
+	// between all files.
+	RememberViewPerFile bool
+
+	// Per file view settings by reader index, see RememberViewPerFile
+	fileViewSettings map[int]fileViewSettings
+
+	// What files start out with, initialized in StartPaging()
+	defaultFileViewSettings fileViewSettings
+
+	// Lines added or changed since the last reload, or since the last time new
+	// lines arrived, are marked in the line numbers column for this long. Zero
+	// means never.
+	NewLinesMarkerDuration time.Duration
+
+	// Which lines are new, see NewLinesMarkerDuration
+	newLines newLinesTracker
+
+	// Which lines have been searched while following, see searchNewLines()
+	followSearch followSearchTracker
+
+	// If true, the status bar shows the file name to the left, the position in
+	// the middle and mode indicators to the right, rather than help texts
+	SegmentedStatusBar bool
+
+	// Status bar contents from the last redraw(), see SegmentedStatusBar
+	footerSegments footerSegments
+
+	// If true, the status bar shows the search pattern and the first search
+	// hit on screen with some surrounding text
+	ShowSearchContext bool
+
+	// Shown in the status bar when a search fails. The first %s is replaced by
+	// the search string.
+	NotFoundMessage string
+
+	// Beep or flash the status bar when a search fails
+	NotFoundAlert NotFoundAlertOption
+
+	// For NOT_FOUND_ALERT_FLASH, the status bar is flashing until this time
+	notFoundFlashUntil time.Time
+
+	// If true, searching past the end of the input continues from the start,
+	// and vice versa. If false, the search stops at the end.
+	WrapSearch bool
+
 	// Ref: https://github.com/walles/moor/issues/113
 	QuitIfOneScreen bool
 
@@ -94,8 +371,55 @@ type Pager struct {
 	ScrollLeftHint  textstyles.CellWithMetadata
 	ScrollRightHint textstyles.CellWithMetadata
 
+	// What to show instead of a wide rune that doesn't fit before the right
+	// edge of the screen. Should be the same as for the screen, see
+	// twin.ScreenOptions.
+	WideRuneAtEdge twin.WideRuneAtEdgeOption
+
+	// Replaces the space after the line number on lines with search hits, so
+	// that they can be found even when scrolled sideways away from the hits.
+	// Zero Rune means no marker.
+	SearchHitGutterMarker textstyles.CellWithMetadata
+
+	// Shown in the line number column on the continuation rows of wrapped
+	// lines, which would otherwise have an empty line number column. Zero Rune
+	// means no marker.
+	WrapContinuationMarker textstyles.CellWithMetadata
+
 	SideScrollAmount int // Left / right arrow keys scroll amount
 
+	// If true, identical scroll keys waiting in the event queue are handled
+	// together. Makes holding down an arrow key on a large file less laggy.
+	CoalesceScrollKeys bool
+
+	// Show the first two files next to each other. The current reader is in
+	// the focused pane.
+	SideBySide bool
+
+	// The pane that doesn't have focus in side by side mode
+	otherPane sideBySidePane
+
+	// True while rendering otherPane, see withOtherPane()
+	renderingOtherPane bool
+
+	// If true, both side by side panes scroll together, with the other pane
+	// scrollLockOffset lines ahead of the focused one
+	scrollLocked     bool
+	scrollLockOffset int
+
+	// Up / down arrow keys scroll faster while held down
+	ScrollAcceleration ScrollAcceleration
+
+	// For ScrollAcceleration, updated on every up / down arrow key press
+	scrollAccelerationState scrollAccelerationState
+
+	// Animate long jumps over a few frames rather than jumping instantly.
+	// Pressing any key finishes the animation immediately.
+	SmoothScroll bool
+
+	// The ongoing SmoothScroll animation, if any
+	smoothScroll smoothScrollState
+
 	TabSize int // Number of spaces per tab, default 8, should be positive
 
 	// If non-nil, scroll to this line as soon as possible. Set this value to
@@ -113,16 +437,51 @@ type Pager struct {
 	// exiting
 	DeInitFalseMargin int
 
+	// If the input has at most this many lines when exiting, all of it will
+	// be printed after exit, not just the last screen. Zero means never.
+	ReprintAllMaxLines int
+
 	WithTerminalFg bool // If true, don't set linePrefix
 
+	// Lines shown by the last redraw(), for finding what's under the mouse
+	// pointer
+	renderedLines []renderedLine
+
+	// Target of the hyperlink under the mouse pointer, if any
+	hoveredHyperlink *string
+
+	// When we last redrew because of a hovered hyperlink change, and whether
+	// a delayed redraw is coming, see onMouseMotion()
+	lastHoverRedraw      time.Time
+	hoverRedrawScheduled bool
+
+	// Text being selected with the mouse, if any
+	selection *textSelection
+
+	// True after the user has pressed 'M' to switch between the mouse
+	// scrolling and selecting. From then on, the status bar says which one it
+	// is.
+	mouseModeToggled bool
+
 	// Length of the longest line displayed. This is used for limiting scrolling to the right.
 	longestLineLength int
 
+	// For 'zz', 'zt' and 'zb', see PagerModeScrollCurrentLine. The current line
+	// is at the top of currentLine. It stays current as long as we're still
+	// at currentLineScrollPosition.
+	currentLine               scrollPosition
+	currentLineScrollPosition *scrollPosition
+
 	// Bookmarks that you can come back to.
 	//
 	// Ref: https://github.com/walles/moor/issues/175
 	bookmarks map[rune]scrollPosition
 
+	// If set, 'n' and 'N' only find search hits between these lines,
+	// inclusive. Set using '[' and ']'.
+	searchRegionStart *linemetadata.Index
+	searchRegionEnd   *linemetadata.Index
+
 	AfterExit func() error
 }
 
@@ -138,9 +497,22 @@ Welcome to Moor, the nice pager!
 Miscellaneous
 -------------
 * Press 'q' or 'ESC' to quit
+* Press CTRL-z to suspend, then type 'fg' to come back
+* Press CTRL-l to redraw the screen
 * Press 'w' to toggle wrapping of long lines
 * Press '=' to toggle showing the status bar at the bottom
 * Press 'v' to edit the file in your favorite editor
+* Press 'i' to invert the colors, or whatever key was set using --invert-key
+* Press 'R' to reload the file if it has changed on disk
+* Press 'c' to clear the search highlighting
+* Press 'C' to copy the command that produced the input, if known
+* Press 'Y' to copy all input to the clipboard, or 'S' to copy it with colors
+* Press 'T' to copy the current search hit line, or the top line, to the clipboard
+* Press TAB to switch pane when showing two files side by side
+* Press 'L' to make side by side panes scroll together, or not
+* Press 'x' to show the raw bytes of the top line, for debugging
+* Press 'M' to switch between scrolling and selecting text with the mouse
+* When the mouse scrolls, drag with it to copy text to the clipboard
 
 Moving around
 -------------
@@ -149,8 +521,13 @@ Moving around
 * Left / right can be used to hide / show line numbers
 * Home and End for start / end of the document
 * 'g' for going to a specific line number
+* A number followed by '%' goes to that percentage of the document, "50%" goes to the middle
+* 'P' for going to the line containing a specific byte offset
 * 'm' sets a mark, you will be asked for a letter to label it with
+* 'F' follows the end of the input as it grows, like tail -f, press again to stop
+* '[' and ']' make 'n' / 'N' search only from the top line / to the bottom line, '|' clears that
 * ' (single quote) jumps to the mark
+* 'l' jumps to the next line containing the label you type
 * CTRL-p moves to the previous line
 * CTRL-n moves to the next line
 * PageUp / 'b' and PageDown / 'f'
@@ -159,6 +536,7 @@ Moving around
 * > / 'G' to go to the end of the document
 * Half page 'u'p / 'd'own, or CTRL-u / CTRL-d
 * RETURN moves down one line
+* 'zz', 'zt' and 'zb' move the current line to the middle, top or bottom
 
 Switching files (if you opened multiple files)
 ----------------------------------------------
@@ -177,10 +555,17 @@ Searching
 * Type / to start searching, then type what you want to find
 * Type ? to search backwards, then type what you want to find
 * Type RETURN to stop searching, or ESC to skip back to where the search started
+* While searching, press the up and down arrow keys to browse previous searches
 * Find next by typing 'n' (for "next")
 * Find previous by typing SHIFT-N or 'p' (for "previous")
+* After searching backwards using ?, 'n' finds the previous hit and SHIFT-N the next one
 * Search is case sensitive if it contains any UPPER CASE CHARACTERS
-* Search is interpreted as a regexp if it is a valid one
+* Press 'I' to switch between smart case, case sensitive and case insensitive search
+* Press ',' to toggle searching for any of several comma separated terms, like "foo, bar"
+* Press 'H' to toggle highlighting of search hits
+* Press 'o' to list all search hits next to the text, pick one and press RETURN to go there
+* Search is interpreted as a regexp unless --search-literal is used, the prompt says if what you typed isn't a valid one
+* With --search-and-or, combine searches using " && " and " || ", like "error && disk || panic"
 
 Reporting bugs
 --------------
@@ -211,19 +596,35 @@ func NewPager(readers ...*reader.ReaderImpl) *Pager {
 	}
 
 	pager := Pager{
-		readers:          readers,
-		currentReader:    0,
-		readerSwitched:   make(chan struct{}, 1),
-		quit:             false,
-		ShowLineNumbers:  true, // Constant throghout the lifetime of the pager
-		showLineNumbers:  true, // Will be updated over time
-		ShowStatusBar:    true,
-		DeInit:           true,
-		SideScrollAmount: 16,
-		TabSize:          8, // This is what less defaults to
-		ScrollLeftHint:   textstyles.CellWithMetadata{Rune: '<', Style: twin.StyleDefault.WithAttr(twin.AttrReverse)},
-		ScrollRightHint:  textstyles.CellWithMetadata{Rune: '>', Style: twin.StyleDefault.WithAttr(twin.AttrReverse)},
-		scrollPosition:   newScrollPosition(name),
+		readers:            readers,
+		currentReader:      0,
+		readerSwitched:     make(chan struct{}, 1),
+		quit:               false,
+		ShowLineNumbers:    true, // Constant throghout the lifetime of the pager
+		showLineNumbers:    true, // Will be updated over time
+		ShowStatusBar:      true,
+		DeInit:             true,
+		WrapSearch:         true,
+		KeepSearchOnEscape: true,
+		SearchLineTimeout:  time.Second,
+		NotFoundMessage:    "Not found: %s",
+		ClipboardMaxBytes:  100_000,
+		InvertColorsKey:    'i',
+		SideScrollAmount:   16,
+		CoalesceScrollKeys: true,
+		ScrollAcceleration: ScrollAcceleration{
+			RepeatInterval: 100 * time.Millisecond,
+			RepeatsPerLine: 5,
+			MaxStep:        1, // Disabled by default
+		},
+		TabSize:         8, // This is what less defaults to
+		ScrollLeftHint:  textstyles.CellWithMetadata{Rune: '<', Style: twin.StyleDefault.WithAttr(twin.AttrReverse)},
+		ScrollRightHint: textstyles.CellWithMetadata{Rune: '>', Style: twin.StyleDefault.WithAttr(twin.AttrReverse)},
+		scrollPosition:  newScrollPosition(name),
+		otherPane: sideBySidePane{
+			readerIndex:    1,
+			scrollPosition: newScrollPosition("Other pane"),
+		},
 	}
 
 	pager.mode = PagerModeViewing{pager: &pager}
@@ -238,16 +639,22 @@ func NewPager(readers ...*reader.ReaderImpl) *Pager {
 // How many lines are visible on screen? Depends on screen height and whether or
 // not the status bar is visible.
 func (p *Pager) visibleHeight() int {
+	header, footer := p.stickyLines()
+	return max(p.heightWithoutStatusBar()-len(header)-len(footer), 0)
+}
+
+// Screen height minus the status bar, if visible
+func (p *Pager) heightWithoutStatusBar() int {
 	_, height := p.screen.Size()
 
 	// Only the viewing mode can be without status bar
 	hasStatusBar := p.ShowStatusBar || !p.isViewing()
 
 	if hasStatusBar {
-		return height - 1
+		height--
 	}
 
-	return height
+	return max(height, 0)
 }
 
 // How many cells are needed for this line number? Includes padding.
@@ -258,7 +665,7 @@ func (p *Pager) getLineNumberPrefixLength(lineNumber linemetadata.Number) int {
 		return 0
 	}
 
-	length := len(lineNumber.Format()) + 1 // +1 for the space after the line number
+	length := len(p.formatLineNumber(lineNumber)) + 1 // +1 for the space after the line number
 
 	if length < 4 {
 		// 4 = space for 3 digits followed by one whitespace
@@ -270,6 +677,38 @@ func (p *Pager) getLineNumberPrefixLength(lineNumber linemetadata.Number) int {
 	return length
 }
 
+// Format a line number for showing, see ZeroBasedLineNumbers
+func (p *Pager) formatLineNumber(lineNumber linemetadata.Number) string {
+	if p.ZeroBasedLineNumbers {
+		return util.FormatInt(lineNumber.AsZeroBased())
+	}
+	return lineNumber.Format()
+}
+
+// Like formatLineNumber(), but for line indices
+func (p *Pager) formatLineIndex(index linemetadata.Index) string {
+	if p.ZeroBasedLineNumbers {
+		return util.FormatInt(index.Index())
+	}
+	return index.Format()
+}
+
+// Redraw the screen after some time, even if nothing else happens by then
+func (p *Pager) redrawAfter(delay time.Duration) {
+	screen := p.screen
+	if screen == nil {
+		return
+	}
+
+	time.AfterFunc(delay, func() {
+		select {
+		case screen.Events() <- eventRedraw{}:
+		default:
+			// Events are already waiting, we'll be redrawn anyway
+		}
+	})
+}
+
 // Draw the footer string at the bottom using the status bar style.
 //
 // Single quoted parts of the help text will be bolded.
@@ -277,31 +716,36 @@ func (p *Pager) getLineNumberPrefixLength(lineNumber linemetadata.Number) int {
 // footer example value: "file.txt: 123 lines  0%"
 // help example value: "Press 'h' for help, 'q' to quit"
 func (p *Pager) setFooter(footer string, help string) {
+	p.setStyledFooter(footer, help, statusbarStyle)
+}
+
+// Like setFooter(), but using the given style rather than the status bar style
+func (p *Pager) setStyledFooter(footer string, help string, baseStyle twin.Style) {
 	width, height := p.screen.Size()
 
 	pos := 0
 
 	// File name and percentage, no keyboard shortcut highlighting
 	for _, token := range footer + "  " {
-		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, statusbarStyle))
+		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, baseStyle))
 	}
 
 	// Help text, highlight keyboard shortcuts
 	highlightAttr := twin.AttrBold
-	if statusbarStyle.HasAttr(highlightAttr) {
+	if baseStyle.HasAttr(highlightAttr) {
 		highlightAttr = twin.AttrUnderline
 	}
-	if statusbarStyle.HasAttr(highlightAttr) {
+	if baseStyle.HasAttr(highlightAttr) {
 		highlightAttr = twin.AttrReverse
 	}
-	style := statusbarStyle
+	style := baseStyle
 	for _, token := range help {
 		if token == '\'' {
 			// Highlight things within single quotes
-			if style == statusbarStyle {
-				style = statusbarStyle.WithAttr(highlightAttr)
+			if style == baseStyle {
+				style = baseStyle.WithAttr(highlightAttr)
 			} else {
-				style = statusbarStyle
+				style = baseStyle
 			}
 			continue
 		}
@@ -309,7 +753,22 @@ func (p *Pager) setFooter(footer string, help string) {
 	}
 
 	for pos < width {
-		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(' ', statusbarStyle))
+		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(' ', baseStyle))
+	}
+}
+
+// Stop until continued by "fg", giving the terminal back to the shell in the
+// meantime
+func (p *Pager) suspend() {
+	suspender, ok := p.screen.(twin.ProcessSuspender)
+	if !ok {
+		p.mode = PagerModeMessage{pager: p, message: "Suspending is not supported"}
+		return
+	}
+
+	err := suspender.SuspendProcess()
+	if err != nil {
+		p.mode = PagerModeMessage{pager: p, message: err.Error()}
 	}
 }
 
@@ -328,6 +787,16 @@ func (p *Pager) Quit() {
 	p.preHelpState = nil
 }
 
+// Scroll sideways on horizontal mouse wheel events. With wrapped lines there's
+// nothing to scroll sideways to.
+func (p *Pager) wheelSideways(toTheRight bool) {
+	if p.WrapLongLines {
+		return
+	}
+
+	p.moveRight(p.sideScrollDelta(toTheRight))
+}
+
 // Negative deltas move left instead
 func (p *Pager) moveRight(delta int) {
 	if p.showLineNumbers && delta > 0 {
@@ -340,6 +809,12 @@ func (p *Pager) moveRight(delta int) {
 		return
 	}
 
+	if p.isWrappingAsNeeded() {
+		// Everything is visible already
+		p.leftColumnZeroBased = 0
+		return
+	}
+
 	result := p.leftColumnZeroBased + delta
 	if result < 0 {
 		p.leftColumnZeroBased = 0
@@ -358,6 +833,9 @@ func (p *Pager) Reader() reader.Reader {
 	if p.isShowingHelp {
 		return _HelpReader
 	}
+	if p.renderingOtherPane {
+		return p.renderedReader()
+	}
 	return &p.filteringReader
 }
 
@@ -375,6 +853,60 @@ func (p *Pager) handleScrolledDown() {
 	}
 }
 
+// Toggle line wrapping when the user presses 'w'. A search hit on screen stays
+// on screen, on the same screen row if possible.
+func (p *Pager) toggleWrapping() {
+	hit, hitRow := p.searchHitOnScreen()
+
+	p.WrapLongLines = !p.WrapLongLines
+	if p.isWrappingAsNeeded() {
+		// Nothing should be cut off to the left either
+		p.leftColumnZeroBased = 0
+	}
+
+	if hit == nil {
+		return
+	}
+
+	if p.WrapLongLines {
+		// Scrolling right doesn't help finding wrapped hits, and whatever we
+		// scrolled right to see is on screen anyway after wrapping
+		p.leftColumnZeroBased = 0
+	}
+
+	// With wrapping, the hit can be further down in its line than before. If
+	// that's below the screen, we can't scroll right to get there, so scroll
+	// down instead.
+	hitRow = min(hitRow, p.visibleHeight()-1)
+	hitPosition := scrollPositionFromWrapIndex("toggleWrapping", *hit, p.searchHitWrapIndex(*hit, false))
+	p.scrollPosition = hitPosition.PreviousLine(hitRow)
+
+	if !p.WrapLongLines && !p.searchHitIsVisible() {
+		// Not wrapping any more, the hit may be off to the right
+		p.scrollRightToSearchHits()
+	}
+}
+
+// Switch between the mouse scrolling and the mouse selecting text when the user
+// presses 'M'
+func (p *Pager) toggleMouseTracking() {
+	mouseTracker, ok := p.screen.(twin.MouseTracker)
+	if !ok {
+		p.mode = PagerModeMessage{pager: p, message: "Toggling mouse tracking is not supported"}
+		return
+	}
+
+	p.mouseModeToggled = true
+	if mouseTracker.MouseTrackingEnabled() {
+		mouseTracker.SetMouseTracking(false)
+		p.mode = PagerModeMessage{pager: p, message: "Mouse selects text, press 'M' to scroll with it instead"}
+		return
+	}
+
+	mouseTracker.SetMouseTracking(true)
+	p.mode = PagerModeMessage{pager: p, message: "Mouse scrolls, press 'M' to select text with it instead"}
+}
+
 // Except for setting TargetLine, this method also syncs with the reader so that
 // the reader knows how many lines it needs to fetch.
 func (p *Pager) setTargetLine(targetLine *linemetadata.Index) {
@@ -403,6 +935,57 @@ func (p *Pager) setTargetLine(targetLine *linemetadata.Index) {
 	r.SetPauseAfterLines(targetValue)
 }
 
+// Check whether the file has changed on disk. Returns true if that is news to
+// us, meaning the footer needs redrawing.
+func (p *Pager) updateFileChangedOnDisk(r *reader.ReaderImpl) bool {
+	changed := r.ChangedOnDisk()
+	if changed == p.fileChangedOnDisk.Load() {
+		return false
+	}
+
+	p.fileChangedOnDisk.Store(changed)
+	return true
+}
+
+func isScrollKey(keyCode twin.KeyCode) bool {
+	return keyCode == twin.KeyUp || keyCode == twin.KeyDown || keyCode == twin.KeyPgUp || keyCode == twin.KeyPgDown
+}
+
+// With the Kitty keyboard protocol, some key combinations are told apart from
+// the plain keys they would otherwise look like. We have no bindings for those,
+// so rather than having Alt-f page forward like f, or Ctrl-I switch panes like
+// Tab, they are ignored.
+func isUnboundModifiedRune(event twin.EventRune) bool {
+	if event.Modifiers()&twin.ModAlt != 0 {
+		return true
+	}
+
+	// Tab is '\t' without modifiers
+	return event.Rune() == '\t' && event.Modifiers()&twin.ModCtrl != 0
+}
+
+// Consume any events for the same key waiting in the queue, without blocking.
+//
+// Returns how many times the key was pressed in total, including the one the
+// caller already has, and the first different event from the queue, if any.
+// That event must be handled next.
+func coalesceKeyEvents(events chan twin.Event, keyCode twin.KeyCode) (int, twin.Event) {
+	count := 1
+	for {
+		select {
+		case event := <-events:
+			keyEvent, isKeyEvent := event.(twin.EventKeyCode)
+			if !isKeyEvent || keyEvent.KeyCode() != keyCode {
+				return count, event
+			}
+			count++
+		default:
+			// Nothing more queued
+			return count, nil
+		}
+	}
+}
+
 // StartPaging brings up the pager on screen
 func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chromaFormatter *chroma.Formatter) {
 	log.Info("Pager starting")
@@ -418,6 +1001,7 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 	}()
 
 	p.showLineNumbers = p.ShowLineNumbers
+	p.defaultFileViewSettings = p.currentFileViewSettings()
 
 	textstyles.UnprintableStyle = p.UnprintableStyle
 	if p.TabSize > 0 {
@@ -427,6 +1011,7 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 	}
 	consumeLessTermcapEnvs(screen.TerminalBackground(), chromaStyle, chromaFormatter)
 	styleUI(screen.TerminalBackground(), chromaStyle, chromaFormatter, p.StatusBarStyle, p.WithTerminalFg)
+	overrideSearchHitStyle(screen.TerminalBackground(), p.SearchHitForeground, p.SearchHitBackground)
 
 	p.screen = screen
 	p.mode = PagerModeViewing{pager: p}
@@ -435,6 +1020,9 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 	// Make sure the reader knows how many lines we want
 	p.setTargetLine(p.TargetLine)
 
+	p.searchHistory.load(p.SearchHistoryFile)
+	p.startInitialSearch()
+
 	go func() {
 		defer func() {
 			PanicHandler("StartPaging()/goroutine", recover(), debug.Stack())
@@ -443,6 +1031,7 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 		spinnerFrames := [...]string{"/.\\", "-o-", "\\O/", "| |"}
 		spinnerIndex := 0
 		spinnerTicker := time.NewTicker(200 * time.Millisecond)
+		fileChangeTicker := time.NewTicker(1 * time.Second)
 		lastSpinnerFrame := "UNSET" // Track the last spinner frame to avoid unnecessary redraws
 
 		// Support throttling of more-lines-available reads, see below
@@ -510,20 +1099,39 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 				screen.Events() <- eventSpinnerUpdate{currentSpinnerFrame}
 				lastSpinnerFrame = currentSpinnerFrame
 
+			case <-fileChangeTicker.C:
+				if p.updateFileChangedOnDisk(r) {
+					screen.Events() <- eventFileChangedOnDisk{}
+				}
+
 			case <-r.MaybeDone:
 				screen.Events() <- eventMaybeDone{}
 			}
 		}
 	}()
 
+	stopIdleTimeout := p.startIdleTimeout(screen)
+	defer stopIdleTimeout()
+	defer func() {
+		if searchHits, ok := p.mode.(*PagerModeSearchHits); ok {
+			// Nobody will be reading the hits any more
+			searchHits.list.cancel()
+		}
+	}()
+
 	log.Info("Entering pager main loop...")
 
 	// Main loop
 	spinner := ""
+	needsRedraw := true         // Cleared only by actually redrawing
+	var pendingEvent twin.Event // Read from the queue while coalescing, not handled yet
 	for !p.quit {
-		if len(screen.Events()) == 0 {
+		if len(screen.Events()) == 0 && pendingEvent == nil && needsRedraw {
 			// Nothing more to process for now, redraw the screen
+			p.continueInitialSearch()
+			p.updateSearchHitCount()
 			p.redraw(spinner)
+			needsRedraw = false
 
 			p.readerLock.Lock()
 			r := p.readers[p.currentReader]
@@ -555,17 +1163,64 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 			}
 		}
 
-		event := <-screen.Events()
+		var event twin.Event
+		if pendingEvent != nil {
+			event = pendingEvent
+			pendingEvent = nil
+		} else {
+			event = <-screen.Events()
+		}
+
+		eventNeedsRedraw := true
 		switch event := event.(type) {
 		case twin.EventKeyCode:
-			log.Tracef("Handling key event %d...", event.KeyCode())
-			p.mode.onKey(event.KeyCode())
+			p.noteActivity(time.Now())
+			p.selection = nil
+			p.finishSmoothScroll()
+			smoothScrollOrigin := p.smoothScrollOrigin()
+
+			viewing, isViewing := p.mode.(PagerModeViewing)
+			if p.CoalesceScrollKeys && isViewing && isScrollKey(event.KeyCode()) {
+				var count int
+				count, pendingEvent = coalesceKeyEvents(screen.Events(), event.KeyCode())
+				log.Tracef("Handling key event %d x %d...", event.KeyCode(), count)
+				viewing.onRepeatedKey(event.KeyCode(), count)
+			} else {
+				log.Tracef("Handling key event %d...", event.KeyCode())
+				p.mode.onKey(event.KeyCode())
+			}
+
+			p.startSmoothScroll(smoothScrollOrigin)
 
 		case twin.EventRune:
-			log.Tracef("Handling rune event '%c'/0x%04x...", event.Rune(), event.Rune())
-			p.mode.onRune(event.Rune())
+			p.noteActivity(time.Now())
+			p.selection = nil
+			p.finishSmoothScroll()
+			smoothScrollOrigin := p.smoothScrollOrigin()
+
+			if isUnboundModifiedRune(event) {
+				log.Debugf("Ignoring rune '%c'/0x%04x with modifiers %d", event.Rune(), event.Rune(), event.Modifiers())
+			} else {
+				log.Tracef("Handling rune event '%c'/0x%04x...", event.Rune(), event.Rune())
+				p.mode.onRune(event.Rune())
+			}
+
+			p.startSmoothScroll(smoothScrollOrigin)
+
+		case twin.EventPaste:
+			p.noteActivity(time.Now())
+			if handler, ok := p.mode.(pasteHandler); ok {
+				log.Tracef("Handling paste event of %d bytes...", len(event.Text()))
+				handler.onPaste(event.Text())
+			} else {
+				log.Debugf("Ignoring paste event of %d bytes", len(event.Text()))
+			}
 
 		case twin.EventMouse:
+			p.noteActivity(time.Now())
+			if event.Buttons() != twin.MouseMotion {
+				p.finishSmoothScroll()
+			}
 			log.Tracef("Handling mouse event %d...", event.Buttons())
 			switch event.Buttons() {
 			case twin.MouseWheelUp:
@@ -577,10 +1232,25 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 				p.scrollPosition = p.scrollPosition.NextLine(1)
 
 			case twin.MouseWheelLeft:
-				p.moveRight(-p.SideScrollAmount)
+				p.wheelSideways(false)
 
 			case twin.MouseWheelRight:
-				p.moveRight(p.SideScrollAmount)
+				p.wheelSideways(true)
+
+			case twin.MouseMotion:
+				// Motion events come in fast, redraw only if the hovered
+				// hyperlink changed
+				column, row := event.Position()
+				eventNeedsRedraw = p.onMouseMotion(column, row, time.Now())
+
+			case twin.MouseLeftPress:
+				p.startSelection(event.Position())
+
+			case twin.MouseLeftDrag:
+				p.extendSelection(event.Position())
+
+			case twin.MouseLeftRelease:
+				p.finishSelection(event.Position())
 			}
 
 		case twin.EventResize:
@@ -590,8 +1260,17 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 			log.Info("Got a Twin exit event, exiting")
 			return
 
+		case twin.EventSuspend:
+			log.Info("Got SIGTSTP, suspending")
+			p.suspend()
+
 		case eventMoreLinesAvailable:
+			p.noteMoreLinesAvailable(time.Now())
+			p.trackNewLines(time.Now())
 			if p.TargetLine != nil {
+				// Following takes over scrolling from any animation
+				p.finishSmoothScroll()
+
 				// The user wants to scroll down to a specific line number
 				if linemetadata.IndexFromLength(p.Reader().GetLineCount()).IsBefore(*p.TargetLine) {
 					// Not there yet, keep scrolling
@@ -602,6 +1281,7 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 					p.setTargetLine(nil)
 				}
 			}
+			p.searchNewLines()
 
 		case eventMaybeDone:
 			// Do nothing. We got this just so that we'll do the QuitIfOneScreen
@@ -610,9 +1290,29 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 		case eventSpinnerUpdate:
 			spinner = event.spinner
 
+		case eventFileChangedOnDisk:
+			// Do nothing. We got this just so that we'll redraw the footer.
+
+		case eventRedraw:
+			// Nothing else to do, we got this just so that we'll redraw
+			p.hoverRedrawScheduled = false
+
+		case eventSmoothScrollFrame:
+			p.showNextSmoothScrollFrame(event)
+
+		case eventSearchHitsCounted:
+			p.noteSearchHitsCounted(event)
+
+		case eventSearchHitsListed:
+			p.noteSearchHitsListed(event)
+
 		default:
 			log.Warnf("Unhandled event type: %v", event)
 		}
+
+		if eventNeedsRedraw {
+			needsRedraw = true
+		}
 	}
 }
 
@@ -653,6 +1353,9 @@ func (p *Pager) fitsOnOneScreenWrapped() bool {
 	fakePager.showLineNumbers = false
 
 	fakePager.WrapLongLines = p.WrapLongLines
+	fakePager.WrapMargin = p.WrapMargin
+	fakePager.WrapAsNeeded = p.WrapAsNeeded
+	fakePager.MaxWrapRows = p.MaxWrapRows
 	fakePager.ShowStatusBar = false // We are only interested in content lines
 	fakePager.TabSize = p.TabSize
 
@@ -694,10 +1397,35 @@ func (p *Pager) fitsOnOneScreen() bool {
 	return true
 }
 
+// CloseReaders stops any background work done by the readers, like running the
+// command of a reader.NewFromCommand() reader
+func (p *Pager) CloseReaders() {
+	p.readerLock.Lock()
+	defer p.readerLock.Unlock()
+
+	for _, r := range p.readers {
+		r.Close()
+	}
+}
+
 // After the pager has exited and the normal screen has been restored, you can
 // call this method to print the pager contents to screen again, faking
 // "leaving" pager contents on screen after exit.
+//
+// If the input is short enough for ReprintAllMaxLines, all of it will be
+// printed. Otherwise, the last screen will be printed unless DeInit is true.
 func (p *Pager) ReprintAfterExit() error {
+	rowPrinter, canPrintRows := p.screen.(twin.RowPrinter)
+	if allRows := p.reprintAllRows(); allRows != nil && canPrintRows {
+		rowPrinter.ShowRows(allRows)
+		return nil
+	}
+
+	if p.DeInit {
+		// Screen cleared on exit, nothing to reprint
+		return nil
+	}
+
 	// Figure out how many screen lines are used by pager contents
 	renderedScreen := p.renderLines()
 	screenLinesCount := len(renderedScreen.lines)
@@ -715,3 +1443,44 @@ func (p *Pager) ReprintAfterExit() error {
 
 	return nil
 }
+
+// Returns all input lines ready for printing, or nil if ReprintAllMaxLines
+// doesn't apply to the current input.
+func (p *Pager) reprintAllRows() [][]twin.StyledRune {
+	if p.ReprintAllMaxLines <= 0 {
+		return nil
+	}
+
+	p.readerLock.Lock()
+	reader := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	if !reader.Done.Load() {
+		// We don't know how long this is going to be
+		return nil
+	}
+
+	lineCount := reader.GetLineCount()
+	if lineCount == 0 || lineCount > p.ReprintAllMaxLines {
+		return nil
+	}
+
+	rows := make([][]twin.StyledRune, 0, lineCount)
+	lines := reader.GetLines(linemetadata.Index{}, lineCount)
+	for _, line := range lines.Lines {
+		cells := line.HighlightedTokens(plainTextStyle, searchHitStyle, nil, nil).StyledRunes
+
+		row := make([]twin.StyledRune, 0, len(cells))
+		for _, cell := range cells {
+			row = append(row, cell.ToStyledRune())
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Are we wrapping only the lines that need it, see WrapAsNeeded?
+func (p *Pager) isWrappingAsNeeded() bool {
+	return p.WrapLongLines && p.WrapAsNeeded
+}
@@ -2,12 +2,14 @@ package internal
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"regexp"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters"
@@ -332,23 +334,47 @@ func TestManPageFormatting(t *testing.T) {
 }
 
 func TestToPattern(t *testing.T) {
-	assert.Assert(t, toPattern("") == nil)
+	assert.Assert(t, toPattern("", SEARCH_CASE_AUTO) == nil)
 
 	// Test regexp matching
-	assert.Assert(t, toPattern("G.*S").MatchString("GRIIIS"))
-	assert.Assert(t, !toPattern("G.*S").MatchString("gRIIIS"))
+	assert.Assert(t, toPattern("G.*S", SEARCH_CASE_AUTO).MatchString("GRIIIS"))
+	assert.Assert(t, !toPattern("G.*S", SEARCH_CASE_AUTO).MatchString("gRIIIS"))
 
 	// Test case insensitive regexp matching
-	assert.Assert(t, toPattern("g.*s").MatchString("GRIIIS"))
-	assert.Assert(t, toPattern("g.*s").MatchString("gRIIIS"))
+	assert.Assert(t, toPattern("g.*s", SEARCH_CASE_AUTO).MatchString("GRIIIS"))
+	assert.Assert(t, toPattern("g.*s", SEARCH_CASE_AUTO).MatchString("gRIIIS"))
 
 	// Test non-regexp matching
-	assert.Assert(t, toPattern(")G").MatchString(")G"))
-	assert.Assert(t, !toPattern(")G").MatchString(")g"))
+	assert.Assert(t, toPattern(")G", SEARCH_CASE_AUTO).MatchString(")G"))
+	assert.Assert(t, !toPattern(")G", SEARCH_CASE_AUTO).MatchString(")g"))
 
 	// Test case insensitive non-regexp matching
-	assert.Assert(t, toPattern(")g").MatchString(")G"))
-	assert.Assert(t, toPattern(")g").MatchString(")g"))
+	assert.Assert(t, toPattern(")g", SEARCH_CASE_AUTO).MatchString(")G"))
+	assert.Assert(t, toPattern(")g", SEARCH_CASE_AUTO).MatchString(")g"))
+}
+
+func TestToPatternCaseModes(t *testing.T) {
+	assert.Assert(t, toPattern("g.*s", SEARCH_CASE_SENSITIVE).MatchString("gRIIIs"))
+	assert.Assert(t, !toPattern("g.*s", SEARCH_CASE_SENSITIVE).MatchString("GRIIIS"))
+
+	assert.Assert(t, toPattern("G.*S", SEARCH_CASE_INSENSITIVE).MatchString("gRIIIs"))
+	assert.Assert(t, toPattern(")G", SEARCH_CASE_INSENSITIVE).MatchString(")g"))
+
+	// Upper case characters in regexp escapes shouldn't disable smart case
+	assert.Assert(t, toPattern(`a\Sc`, SEARCH_CASE_AUTO).MatchString("ABC"))
+	assert.Assert(t, toPattern(`\p{Greek}x`, SEARCH_CASE_AUTO).MatchString("αX"))
+	assert.Assert(t, toPattern(`\PLx`, SEARCH_CASE_AUTO).MatchString("1X"))
+	assert.Assert(t, !toPattern(`\SX`, SEARCH_CASE_AUTO).MatchString("ax"))
+}
+
+func TestHasUppercase(t *testing.T) {
+	assert.Assert(t, !hasUppercase("abc"))
+	assert.Assert(t, hasUppercase("aBc"))
+	assert.Assert(t, !hasUppercase(`\S+\W\D\B`))
+	assert.Assert(t, !hasUppercase(`\p{Greek}\PL\pN`))
+	assert.Assert(t, hasUppercase(`\p{Greek}A`))
+	assert.Assert(t, hasUppercase(`\\S`), "Escaped backslash followed by an upper case S")
+	assert.Assert(t, !hasUppercase(`abc\`), "Trailing backslash")
 }
 
 func TestFindFirstHitSimple(t *testing.T) {
@@ -358,9 +384,9 @@ func TestFindFirstHitSimple(t *testing.T) {
 
 	assert.NilError(t, pager.readers[pager.currentReader].Wait())
 
-	pager.searchPattern = toPattern("AB")
+	pager.searchPattern = toPattern("AB", SEARCH_CASE_AUTO)
 
-	hit := pager.findFirstHit(linemetadata.Index{}, nil, false)
+	hit, _ := pager.findFirstHit(linemetadata.Index{}, nil, false)
 	assert.Assert(t, hit.IsZero())
 }
 
@@ -371,9 +397,9 @@ func TestFindFirstHitAnsi(t *testing.T) {
 
 	assert.NilError(t, pager.readers[pager.currentReader].Wait())
 
-	pager.searchPattern = toPattern("AB")
+	pager.searchPattern = toPattern("AB", SEARCH_CASE_AUTO)
 
-	hit := pager.findFirstHit(linemetadata.Index{}, nil, false)
+	hit, _ := pager.findFirstHit(linemetadata.Index{}, nil, false)
 	assert.Assert(t, hit.IsZero())
 }
 
@@ -384,9 +410,9 @@ func TestFindFirstHitNoMatch(t *testing.T) {
 
 	assert.NilError(t, pager.readers[pager.currentReader].Wait())
 
-	pager.searchPattern = toPattern("this pattern should not be found")
+	pager.searchPattern = toPattern("this pattern should not be found", SEARCH_CASE_AUTO)
 
-	hit := pager.findFirstHit(linemetadata.Index{}, nil, false)
+	hit, _ := pager.findFirstHit(linemetadata.Index{}, nil, false)
 	assert.Assert(t, hit == nil)
 }
 
@@ -397,10 +423,10 @@ func TestFindFirstHitNoMatchBackwards(t *testing.T) {
 
 	assert.NilError(t, pager.readers[pager.currentReader].Wait())
 
-	pager.searchPattern = toPattern("this pattern should not be found")
+	pager.searchPattern = toPattern("this pattern should not be found", SEARCH_CASE_AUTO)
 	theEnd := *linemetadata.IndexFromLength(reader.GetLineCount())
 
-	hit := pager.findFirstHit(theEnd, nil, true)
+	hit, _ := pager.findFirstHit(theEnd, nil, true)
 	assert.Assert(t, hit == nil)
 }
 
@@ -414,6 +440,60 @@ func rowToString(row []twin.StyledRune) string {
 	return strings.TrimRight(rowString, " ")
 }
 
+// Wait for the reader to have at least this many lines
+func awaitLineCount(t *testing.T, r *reader.ReaderImpl, lineCount int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for r.GetLineCount() < lineCount {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d lines, got %d", lineCount, r.GetLineCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Large inputs should be shown while they are still being read
+func TestRenderWhileLoading(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+
+	// The reader looks at the first bytes before returning, so this needs to
+	// happen in the background
+	writeErr := make(chan error)
+	go func() {
+		_, err := pipeWriter.Write([]byte("first\nsecond\n"))
+		writeErr <- err
+	}()
+
+	myReader, err := reader.NewFromStream("", pipeReader, nil, reader.ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+	assert.NilError(t, <-writeErr)
+	awaitLineCount(t, myReader, 2)
+
+	screen := twin.NewFakeScreen(30, 5)
+	pager := NewPager(myReader)
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+
+	pager.redraw("/.\\")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "first")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "second")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "/.\\", "Spinner should be shown after the last line")
+	assert.Assert(t, strings.HasPrefix(rowToString(screen.GetRow(4)), "2 lines  100%  loading…  /.\\"),
+		rowToString(screen.GetRow(4)))
+
+	_, err = pipeWriter.Write([]byte("third\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, pipeWriter.Close())
+	assert.NilError(t, myReader.Wait())
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "third")
+	assert.Equal(t, rowToString(screen.GetRow(3)), "---")
+	assert.Assert(t, strings.HasPrefix(rowToString(screen.GetRow(4)), "3 lines  100%"),
+		rowToString(screen.GetRow(4)))
+}
+
 func TestScrollToBottomWrapNextToLastLine(t *testing.T) {
 	reader := reader.NewFromTextForTesting("",
 		"first line\nline two will be wrapped\nhere's the last line")
@@ -782,7 +862,7 @@ func benchmarkSearch(b *testing.B, highlighted bool) {
 
 	for range b.N {
 		// This test will search through all the N copies we made of our file
-		hit := pager.findFirstHit(linemetadata.Index{}, nil, false)
+		hit, _ := pager.findFirstHit(linemetadata.Index{}, nil, false)
 
 		if hit != nil {
 			panic(fmt.Errorf("This test is meant to scan the whole file without finding anything"))
@@ -805,3 +885,212 @@ func BenchmarkHighlightedSearch(b *testing.B) {
 func BenchmarkPlainTextSearch(b *testing.B) {
 	benchmarkSearch(b, false)
 }
+
+func TestReprintAllRows(t *testing.T) {
+	lines := make([]string, 0)
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	reader := reader.NewFromTextForTesting("", strings.Join(lines, "\n"))
+	assert.NilError(t, reader.Wait())
+
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 10)
+
+	// Disabled by default
+	assert.Assert(t, pager.reprintAllRows() == nil)
+
+	// Longer than the limit
+	pager.ReprintAllMaxLines = 29
+	assert.Assert(t, pager.reprintAllRows() == nil)
+
+	// All lines should be printed, not only the ones fitting on screen
+	pager.ReprintAllMaxLines = 30
+	rows := pager.reprintAllRows()
+	assert.Equal(t, len(rows), 30)
+	for i, row := range rows {
+		assert.Equal(t, rowToString(row), lines[i])
+	}
+}
+
+func TestFileChangedOnDiskHint(t *testing.T) {
+	file, err := os.CreateTemp("", "moor-TestFileChangedOnDiskHint-*.txt")
+	assert.NilError(t, err)
+	defer os.Remove(file.Name()) //nolint:errcheck
+
+	_, err = file.WriteString("First line\n")
+	assert.NilError(t, err)
+
+	r, err := reader.NewFromFilename(file.Name(), formatters.TTY16m, reader.ReaderOptions{Style: styles.Get("native")})
+	assert.NilError(t, err)
+	assert.NilError(t, r.Wait())
+
+	// Wide enough for both the temp file name and the hint
+	screen := twin.NewFakeScreen(200, 5)
+	pager := NewPager(r)
+	pager.screen = screen
+
+	assert.Assert(t, !pager.updateFileChangedOnDisk(r))
+	pager.redraw("")
+	assert.Assert(t, !strings.Contains(rowToString(screen.GetRow(4)), "File changed on disk"))
+
+	// Simulate the file being modified
+	later := time.Now().Add(time.Hour)
+	assert.NilError(t, os.Chtimes(file.Name(), later, later))
+
+	assert.Assert(t, pager.updateFileChangedOnDisk(r))
+	pager.redraw("")
+	assert.Assert(t, strings.Contains(rowToString(screen.GetRow(4)), "File changed on disk, press R to reload"),
+		rowToString(screen.GetRow(4)))
+
+	// Reloading should make the hint go away
+	pager.reloadFile()
+	pager.redraw("")
+	assert.Assert(t, !strings.Contains(rowToString(screen.GetRow(4)), "File changed on disk"))
+}
+
+func TestCoalesceScrollKeys(t *testing.T) {
+	lines := strings.Repeat("line\n", 100)
+	reader := reader.NewFromTextForTesting("", lines)
+	assert.NilError(t, reader.Wait())
+
+	screen := twin.NewFakeScreen(20, 10)
+	pager := NewPager(reader)
+
+	for range 50 {
+		screen.Events() <- twin.NewEventKeyCode(twin.KeyDown)
+	}
+
+	// Setting a mark should see all the scrolling above, and quitting should
+	// still happen after that
+	screen.Events() <- twin.NewEventRune('m')
+	screen.Events() <- twin.NewEventRune('a')
+	screen.Events() <- twin.NewEventRune('q')
+
+	pager.StartPaging(screen, nil, nil)
+
+	assert.Equal(t, pager.lineIndex().Index(), 50)
+	mark := pager.bookmarks['a']
+	assert.Equal(t, mark.lineIndex(pager).Index(), 50)
+}
+
+func TestCoalesceKeyEvents(t *testing.T) {
+	events := make(chan twin.Event, 10)
+	events <- twin.NewEventKeyCode(twin.KeyDown)
+	events <- twin.NewEventKeyCode(twin.KeyDown)
+	events <- twin.NewEventKeyCode(twin.KeyUp)
+	events <- twin.NewEventKeyCode(twin.KeyDown)
+
+	count, next := coalesceKeyEvents(events, twin.KeyDown)
+	assert.Equal(t, count, 3)
+	assert.Equal(t, next, twin.Event(twin.NewEventKeyCode(twin.KeyUp)))
+
+	count, next = coalesceKeyEvents(events, twin.KeyDown)
+	assert.Equal(t, count, 2)
+	assert.Assert(t, next == nil)
+}
+
+// Piped lines after a file should be followed, see --append-stdin
+func TestFollowAppendedStream(t *testing.T) {
+	file, err := os.CreateTemp("", "moor-TestFollowAppendedStream-*.txt")
+	assert.NilError(t, err)
+	defer os.Remove(file.Name()) //nolint:errcheck
+
+	_, err = file.WriteString("file 1\nfile 2\nfile 3\n")
+	assert.NilError(t, err)
+	assert.NilError(t, file.Close())
+
+	pipeReader, pipeWriter := io.Pipe()
+	r, err := reader.NewFromFilenameAndStream(file.Name(), pipeReader, nil, reader.ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+
+	_, err = pipeWriter.Write([]byte("stdin 1\nstdin 2\n"))
+	assert.NilError(t, err)
+	awaitLineCount(t, r, 5)
+
+	screen := twin.NewFakeScreen(20, 3)
+	pager := NewPager(r)
+	reallyHigh := linemetadata.IndexMax()
+	pager.TargetLine = &reallyHigh
+
+	screen.Events() <- eventMoreLinesAvailable{}
+	screen.Events() <- twin.NewEventRune('q')
+	pager.StartPaging(screen, nil, nil)
+
+	// The last two lines should be on screen, still following
+	assert.Equal(t, pager.lineIndex().Index(), 3)
+	assert.Assert(t, pager.isFollowing())
+
+	// Exiting cleared the screen, get the contents back
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "  4 stdin 1")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "  5 stdin 2")
+
+	assert.NilError(t, pipeWriter.Close())
+}
+
+func TestToggleMouseTracking(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 3)
+	pager := NewPager(reader.NewFromTextForTesting("TestToggleMouseTracking", "a"))
+	pager.screen = screen
+	assert.Assert(t, !screen.MouseTrackingEnabled())
+
+	// No mouse indicator until the user has toggled the mode
+	assert.Equal(t, pager.createFooterSegments("", "", "").right, "")
+
+	pager.mode.onRune('M')
+	assert.Assert(t, screen.MouseTrackingEnabled())
+	assert.Equal(t, modeName(pager), "Message")
+	assert.Equal(t, pager.createFooterSegments("", "", "").right, "mouse scroll")
+
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.mode.onRune('M')
+	assert.Assert(t, !screen.MouseTrackingEnabled())
+	assert.Equal(t, pager.createFooterSegments("", "", "").right, "mouse select")
+}
+
+func TestWheelSideways(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", strings.Repeat("x", 100)))
+	pager.screen = twin.NewFakeScreen(20, 5)
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.SideScrollAmount = 8
+	pager.redraw("") // Finds the longest line, for limiting scrolling
+
+	pager.wheelSideways(true)
+	assert.Equal(t, 8, pager.leftColumnZeroBased)
+
+	pager.wheelSideways(false)
+	assert.Equal(t, 0, pager.leftColumnZeroBased)
+
+	// Nothing to scroll sideways to when wrapping
+	pager.WrapLongLines = true
+	pager.wheelSideways(true)
+	assert.Equal(t, 0, pager.leftColumnZeroBased)
+}
+
+func TestSuspendUnsupported(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 3)
+	pager := NewPager(reader.NewFromTextForTesting("", "hello"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+
+	// The fake screen can't stop the process
+	pager.mode.onRune('\x1a')
+	assert.Equal(t, "Message", modeName(pager))
+	assert.Assert(t, !screen.IsSuspended())
+}
+
+func TestUnboundModifiedRunes(t *testing.T) {
+	assert.Assert(t, !isUnboundModifiedRune(twin.NewEventRune('f')))
+	assert.Assert(t, !isUnboundModifiedRune(twin.NewEventRune('\t')))
+	assert.Assert(t, !isUnboundModifiedRune(twin.NewEventRuneWithModifiers('F', twin.ModShift)))
+
+	// CTRL-u pages up, with or without the Kitty keyboard protocol
+	assert.Assert(t, !isUnboundModifiedRune(twin.NewEventRuneWithModifiers('\x15', twin.ModCtrl)))
+
+	assert.Assert(t, isUnboundModifiedRune(twin.NewEventRuneWithModifiers('f', twin.ModAlt)))
+
+	// CTRL-i, not Tab
+	assert.Assert(t, isUnboundModifiedRune(twin.NewEventRuneWithModifiers('\t', twin.ModCtrl)))
+}
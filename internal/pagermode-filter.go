@@ -28,9 +28,10 @@ func (m PagerModeFilter) drawFooter(_ string, _ string) {
 }
 
 func (m *PagerModeFilter) updateFilterPattern(text string) {
-	m.pager.filterPattern = toPattern(text)
+	m.pager.filterPattern = toPattern(text, m.pager.SearchCaseMode)
 	m.pager.searchString = text
-	m.pager.searchPattern = toPattern(text)
+	m.pager.searchPattern = toPattern(text, m.pager.SearchCaseMode)
+	m.pager.searchMatcher = nil
 }
 
 func (m *PagerModeFilter) onKey(key twin.KeyCode) {
@@ -47,6 +48,7 @@ func (m *PagerModeFilter) onKey(key twin.KeyCode) {
 		m.pager.filterPattern = nil
 		m.pager.searchString = ""
 		m.pager.searchPattern = nil
+		m.pager.searchMatcher = nil
 
 	case twin.KeyUp, twin.KeyDown, twin.KeyPgUp, twin.KeyPgDown:
 		viewing := PagerModeViewing{pager: m.pager}
@@ -60,3 +62,7 @@ func (m *PagerModeFilter) onKey(key twin.KeyCode) {
 func (m *PagerModeFilter) onRune(char rune) {
 	m.inputBox.handleRune(char)
 }
+
+func (m *PagerModeFilter) onPaste(text string) {
+	m.inputBox.handlePaste(text)
+}
@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"fmt"
+	"math"
 	"strconv"
 
 	log "github.com/sirupsen/logrus"
@@ -25,7 +27,7 @@ func NewPagerModeGotoLine(p *Pager) *PagerModeGotoLine {
 }
 
 func (m *PagerModeGotoLine) drawFooter(_ string, _ string) {
-	m.inputBox.draw(m.pager.screen, "Go to line number: ")
+	m.inputBox.draw(m.pager.screen, "Go to line number, or percentage followed by %: ")
 }
 
 func (m *PagerModeGotoLine) updateLineNumber(text string) {
@@ -34,16 +36,65 @@ func (m *PagerModeGotoLine) updateLineNumber(text string) {
 		log.Debugf("Got non-number goto text '%s'", text)
 		return
 	}
-	if newLineNumber < 1 {
-		log.Debugf("Got non-positive goto line number: %d", newLineNumber)
+
+	p := m.pager
+	firstLineNumber := 1
+	if p.ZeroBasedLineNumbers {
+		firstLineNumber = 0
+	}
+	if newLineNumber < firstLineNumber {
+		log.Debugf("Got too low goto line number: %d", newLineNumber)
 		return
 	}
-	targetIndex := linemetadata.IndexFromOneBased(newLineNumber)
-	m.pager.scrollPosition = NewScrollPositionFromIndex(
+	targetIndex := linemetadata.IndexFromZeroBased(newLineNumber - firstLineNumber)
+	message := "Went to line " + p.formatLineIndex(targetIndex)
+	p.readerLock.Lock()
+	done := p.readers[p.currentReader].Done.Load()
+	p.readerLock.Unlock()
+
+	lastIndex := linemetadata.IndexFromLength(p.Reader().GetLineCount())
+	if done && lastIndex != nil && targetIndex.IsAfter(*lastIndex) {
+		// No more lines coming, go to the last one rather than waiting forever
+		message = "Line " + p.formatLineIndex(targetIndex) + " is past the end, went to the last line " + p.formatLineIndex(*lastIndex)
+		targetIndex = *lastIndex
+	} else if lastIndex == nil || targetIndex.IsAfter(*lastIndex) {
+		message = "Waiting for line " + p.formatLineIndex(targetIndex) + "..."
+	}
+
+	p.scrollPosition = NewScrollPositionFromIndex(
 		targetIndex,
 		"onGotoLineKey",
 	)
-	m.pager.setTargetLine(&targetIndex)
+	p.setTargetLine(&targetIndex)
+	p.mode = PagerModeMessage{pager: p, message: message}
+}
+
+// Go to the line at some percentage of the input, clamped to 0-100%
+func (m *PagerModeGotoLine) updatePercentage(text string) {
+	percentage, err := strconv.Atoi(text)
+	if err != nil {
+		log.Debugf("Got non-number goto percentage '%s'", text)
+		return
+	}
+	percentage = min(max(percentage, 0), 100)
+
+	p := m.pager
+	lineCount := p.Reader().GetLineCount()
+	if lineCount == 0 {
+		log.Debug("No lines to go to a percentage of")
+		return
+	}
+
+	// 0% is the first line and 100% the last one
+	targetIndex := linemetadata.IndexFromZeroBased(
+		int(math.Round(float64(lineCount-1) * float64(percentage) / 100.0)))
+
+	p.scrollPosition = NewScrollPositionFromIndex(
+		targetIndex,
+		"onGotoPercentage",
+	)
+	p.setTargetLine(nil)
+	p.mode = PagerModeMessage{pager: p, message: fmt.Sprintf("Went to %d%%, line %s", percentage, p.formatLineIndex(targetIndex))}
 }
 
 func (m *PagerModeGotoLine) onKey(key twin.KeyCode) {
@@ -53,8 +104,8 @@ func (m *PagerModeGotoLine) onKey(key twin.KeyCode) {
 
 	switch key {
 	case twin.KeyEnter:
-		m.updateLineNumber(m.inputBox.text)
 		m.pager.mode = PagerModeViewing{pager: m.pager}
+		m.updateLineNumber(m.inputBox.text)
 
 	case twin.KeyEscape:
 		m.pager.mode = PagerModeViewing{pager: m.pager}
@@ -81,5 +132,15 @@ func (m *PagerModeGotoLine) onRune(char rune) {
 		return
 	}
 
+	if char == '%' && m.inputBox.text != "" {
+		p.mode = PagerModeViewing{pager: p}
+		m.updatePercentage(m.inputBox.text)
+		return
+	}
+
 	m.inputBox.handleRune(char)
 }
+
+func (m *PagerModeGotoLine) onPaste(text string) {
+	m.inputBox.handlePaste(text)
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func testGotoLine(t *testing.T, lineNumber string, expectedIndex int, expectedMessage string) {
+	lines := []string{}
+	for i := range 100 {
+		lines = append(lines, fmt.Sprint("line ", i+1))
+	}
+	reader := reader.NewFromTextForTesting("TestGotoLine", strings.Join(lines, "\n"))
+	assert.NilError(t, reader.Wait())
+
+	screen := twin.NewFakeScreen(60, 5)
+	pager := NewPager(reader)
+	pager.screen = screen
+
+	pager.mode.onRune('g')
+	assert.Equal(t, "GotoLine", modeName(pager))
+
+	for _, char := range lineNumber {
+		pager.mode.onRune(char)
+	}
+	pager.mode.onKey(twin.KeyEnter)
+	pager.redraw("")
+
+	assert.Equal(t, expectedIndex, pager.lineIndex().Index(), "line=%s", lineNumber)
+	assert.Equal(t, rowToString(screen.GetRow(4)), expectedMessage)
+}
+
+func TestGotoLine(t *testing.T) {
+	testGotoLine(t, "48", 47, "Went to line 48")
+}
+
+func TestGotoLinePastEnd(t *testing.T) {
+	// Clamped to the last line. Since the screen fits four lines, that puts
+	// line 97 at the top.
+	testGotoLine(t, "48120", 96, "Line 48_120 is past the end, went to the last line 100")
+}
+
+func TestGotoLineZeroBased(t *testing.T) {
+	reader := reader.NewFromTextForTesting("", "a\nb\nc\nd\ne\nf\ng\nh")
+	assert.NilError(t, reader.Wait())
+
+	screen := twin.NewFakeScreen(20, 4)
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.ZeroBasedLineNumbers = true
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "  0 a")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "  2 c")
+
+	pager.mode.onRune('g')
+	pager.mode.onRune('5')
+	pager.mode.onKey(twin.KeyEnter)
+	pager.redraw("")
+	assert.Equal(t, 5, pager.lineIndex().Index())
+	assert.Equal(t, rowToString(screen.GetRow(0)), "  5 f")
+	assert.Equal(t, rowToString(screen.GetRow(3)), "Went to line 5")
+
+	pager.mode.onRune('g')
+	pager.mode.onRune('0')
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, 0, pager.lineIndex().Index(), "Line 0 is the first line")
+}
+
+func testGotoPercentage(t *testing.T, keys string, expectedIndex int, expectedMessage string) {
+	lines := []string{}
+	for i := range 101 {
+		lines = append(lines, fmt.Sprint("line ", i+1))
+	}
+	reader := reader.NewFromTextForTesting("TestGotoPercentage", strings.Join(lines, "\n"))
+	assert.NilError(t, reader.Wait())
+
+	screen := twin.NewFakeScreen(60, 5)
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(20), "testGotoPercentage")
+
+	for _, char := range keys {
+		pager.mode.onRune(char)
+	}
+	assert.Equal(t, "Message", modeName(pager))
+	pager.redraw("")
+
+	assert.Equal(t, expectedIndex, pager.lineIndex().Index(), "keys=%s", keys)
+	assert.Equal(t, rowToString(screen.GetRow(4)), expectedMessage)
+}
+
+func TestGotoPercentage(t *testing.T) {
+	testGotoPercentage(t, "50%", 50, "Went to 50%, line 51")
+	testGotoPercentage(t, "g25%", 25, "Went to 25%, line 26")
+	testGotoPercentage(t, "0%", 0, "Went to 0%, line 1")
+
+	// Clamped to 100%. Since the screen fits four lines, that puts line 98 at
+	// the top.
+	testGotoPercentage(t, "250%", 97, "Went to 100%, line 101")
+}
+
+// Going to a line should stop following
+func TestGotoLineStopsFollowing(t *testing.T) {
+	reader := reader.NewFromTextForTesting("", "a\nb\nc")
+	assert.NilError(t, reader.Wait())
+
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 4)
+	pager.mode = PagerModeViewing{pager: pager}
+
+	following := linemetadata.IndexMax()
+	pager.TargetLine = &following
+	pager.mode.onRune('g')
+	assert.Assert(t, pager.TargetLine == nil)
+}
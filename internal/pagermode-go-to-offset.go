@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/twin"
+)
+
+// PagerModeGotoOffset jumps to the line containing a given byte offset into
+// the input. Like "P" in less.
+type PagerModeGotoOffset struct {
+	pager    *Pager
+	inputBox InputBox
+}
+
+func NewPagerModeGotoOffset(p *Pager) *PagerModeGotoOffset {
+	m := &PagerModeGotoOffset{
+		pager: p,
+		inputBox: InputBox{
+			accept:        INPUTBOX_ACCEPT_POSITIVE_NUMBERS,
+			onTextChanged: nil,
+		},
+	}
+	return m
+}
+
+func (m *PagerModeGotoOffset) drawFooter(_ string, _ string) {
+	m.inputBox.draw(m.pager.screen, "Go to byte offset: ")
+}
+
+func (m *PagerModeGotoOffset) updateOffset(text string) {
+	p := m.pager
+
+	offset, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		log.Debugf("Got non-number goto offset text '%s'", text)
+		return
+	}
+
+	if p.filterPattern != nil {
+		// Filtered line indices don't match the unfiltered ones
+		log.Debug("Going to a byte offset is not supported while filtering")
+		return
+	}
+
+	p.readerLock.Lock()
+	targetIndex := p.readers[p.currentReader].IndexFromByteOffset(offset)
+	p.readerLock.Unlock()
+	if targetIndex == nil {
+		log.Debugf("No line found for byte offset %d", offset)
+		return
+	}
+
+	p.scrollPosition = NewScrollPositionFromIndex(*targetIndex, "onGotoOffsetKey")
+	p.setTargetLine(targetIndex)
+}
+
+func (m *PagerModeGotoOffset) onKey(key twin.KeyCode) {
+	if m.inputBox.handleKey(key) {
+		return
+	}
+
+	switch key {
+	case twin.KeyEnter:
+		m.updateOffset(m.inputBox.text)
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+
+	case twin.KeyEscape:
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+
+	default:
+		log.Tracef("Unhandled goto offset key event %v, treating as a viewing key event", key)
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+		m.pager.mode.onKey(key)
+	}
+}
+
+func (m *PagerModeGotoOffset) onRune(char rune) {
+	if char == 'q' {
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+		return
+	}
+
+	m.inputBox.handleRune(char)
+}
+
+func (m *PagerModeGotoOffset) onPaste(text string) {
+	m.inputBox.handlePaste(text)
+}
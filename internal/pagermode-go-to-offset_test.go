@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func testGotoOffset(t *testing.T, offset string, expectedIndex int) {
+	// Line starts: 0, 2, 5, 9, 10
+	reader := reader.NewFromTextForTesting("TestGotoOffset", "a\nbb\nccc\nd\ne\n")
+	assert.NilError(t, reader.Wait())
+
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 3)
+
+	pager.mode.onRune('P')
+	assert.Equal(t, "GotoOffset", modeName(pager))
+
+	for _, char := range offset {
+		pager.mode.onRune(char)
+	}
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, expectedIndex, pager.lineIndex().Index(), "offset=%s", offset)
+}
+
+func TestGotoOffset(t *testing.T) {
+	testGotoOffset(t, "0", 0)
+	testGotoOffset(t, "3", 1)
+	testGotoOffset(t, "5", 2)
+	testGotoOffset(t, "8", 2)
+	testGotoOffset(t, "9", 3)
+}
+
+func TestGotoOffsetPastEnd(t *testing.T) {
+	// Clamped to the last line. Since the screen fits two lines, that puts the
+	// second to last line at the top.
+	testGotoOffset(t, "1000", 3)
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"regexp"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/twin"
+)
+
+// PagerModeJumpToLabel jumps to the next line containing whatever the user
+// types, updating as they type. Think of it as an incremental search that
+// doesn't highlight anything and doesn't remember the search string.
+type PagerModeJumpToLabel struct {
+	pager                 *Pager
+	initialScrollPosition scrollPosition // Pager position before jumping started
+	inputBox              *InputBox
+}
+
+func NewPagerModeJumpToLabel(p *Pager, initialScrollPosition scrollPosition) *PagerModeJumpToLabel {
+	m := &PagerModeJumpToLabel{
+		pager:                 p,
+		initialScrollPosition: initialScrollPosition,
+	}
+	m.inputBox = &InputBox{
+		accept: INPUTBOX_ACCEPT_ALL,
+		onTextChanged: func(text string) {
+			m.updateLabel(text)
+		},
+	}
+	return m
+}
+
+func (m *PagerModeJumpToLabel) drawFooter(_ string, _ string) {
+	m.inputBox.draw(m.pager.screen, "Jump to label: ")
+}
+
+// toLabelPattern turns the label into a verbatim pattern. Like in toPattern(),
+// the pattern is case insensitive unless the label has upper case chars in it.
+func toLabelPattern(label string) *regexp.Regexp {
+	if len(label) == 0 {
+		return nil
+	}
+
+	prefix := "(?i)"
+	for _, char := range label {
+		if unicode.IsUpper(char) {
+			prefix = ""
+			break
+		}
+	}
+
+	return regexp.MustCompile(prefix + regexp.QuoteMeta(label))
+}
+
+// Jump to the first line after the initial top line containing the label,
+// wrapping at the end of the input.
+func (m *PagerModeJumpToLabel) updateLabel(label string) {
+	p := m.pager
+
+	// Empty or no hits, stay where we started
+	p.scrollPosition = m.initialScrollPosition
+
+	pattern := toLabelPattern(label)
+	if pattern == nil {
+		return
+	}
+
+	initialIndex := m.initialScrollPosition.lineIndex(p)
+	if initialIndex == nil {
+		// No lines to search
+		return
+	}
+
+	reader := p.Reader()
+	startIndex := initialIndex.NonWrappingAdd(1)
+	hitIndex, _ := _findFirstHit(reader, startIndex, pattern.MatchString, 1, p.SearchLineTimeout, nil, false)
+	if hitIndex == nil {
+		// Try again from the top, including the line we started on
+		hitIndex, _ = _findFirstHit(reader, linemetadata.Index{}, pattern.MatchString, 1, p.SearchLineTimeout, &startIndex, false)
+	}
+	if hitIndex == nil {
+		log.Tracef("Label not found: %q", label)
+		return
+	}
+
+	p.scrollPosition = NewScrollPositionFromIndex(*hitIndex, "updateLabel")
+}
+
+func (m *PagerModeJumpToLabel) onKey(key twin.KeyCode) {
+	if m.inputBox.handleKey(key) {
+		return
+	}
+
+	switch key {
+	case twin.KeyEnter:
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+
+	case twin.KeyEscape:
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+		m.pager.scrollPosition = m.initialScrollPosition
+
+	case twin.KeyUp, twin.KeyDown, twin.KeyPgUp, twin.KeyPgDown:
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+		m.pager.mode.onKey(key)
+
+	default:
+		log.Debugf("Unhandled jump-to-label key event %v", key)
+	}
+}
+
+func (m *PagerModeJumpToLabel) onRune(char rune) {
+	m.inputBox.handleRune(char)
+}
+
+func (m *PagerModeJumpToLabel) onPaste(text string) {
+	m.inputBox.handlePaste(text)
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func createJumpToLabelPager(t *testing.T) *Pager {
+	reader := reader.NewFromTextForTesting("TestJumpToLabel",
+		"[general]\nname=x\n[core]\neditor=vi\n[user]\nname=y\n[Core.extra]\nfoo=bar\n")
+	assert.NilError(t, reader.Wait())
+
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 3)
+
+	return pager
+}
+
+func TestJumpToLabelIncremental(t *testing.T) {
+	pager := createJumpToLabelPager(t)
+	pager.mode.onRune('l')
+	assert.Equal(t, "JumpToLabel", modeName(pager))
+
+	// "[" first matches the line after the one we're on
+	pager.mode.onRune('[')
+	assert.Equal(t, 2, pager.lineIndex().Index())
+
+	// Still on [core]
+	pager.mode.onRune('c')
+	assert.Equal(t, 2, pager.lineIndex().Index())
+
+	// Typing more moves on to the next matching line
+	pager.mode.onRune('o')
+	pager.mode.onRune('r')
+	pager.mode.onRune('e')
+	pager.mode.onRune('.')
+	assert.Equal(t, 6, pager.lineIndex().Index())
+
+	// Backspacing moves us back again
+	pager.mode.onKey(twin.KeyBackspace)
+	assert.Equal(t, 2, pager.lineIndex().Index())
+
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, 2, pager.lineIndex().Index())
+
+	// Jumping is not searching
+	assert.Assert(t, pager.searchPattern == nil)
+}
+
+func TestJumpToLabelIsNotARegexp(t *testing.T) {
+	pager := createJumpToLabelPager(t)
+	pager.mode.onRune('l')
+
+	// As a regexp this would have matched the first line
+	pager.mode.onRune('.')
+	assert.Equal(t, 6, pager.lineIndex().Index())
+}
+
+func TestJumpToLabelWraps(t *testing.T) {
+	pager := createJumpToLabelPager(t)
+	pager.scrollPosition = NewScrollPositionFromIndex(pager.lineIndex().NonWrappingAdd(5), "test")
+	pager.mode.onRune('l')
+
+	// Line 5 is "name=y", the next hit is on line 1 after wrapping to the top
+	for _, char := range "name" {
+		pager.mode.onRune(char)
+	}
+	assert.Equal(t, 1, pager.lineIndex().Index())
+}
+
+func TestJumpToLabelEscape(t *testing.T) {
+	pager := createJumpToLabelPager(t)
+	pager.mode.onRune('l')
+
+	for _, char := range "user" {
+		pager.mode.onRune(char)
+	}
+	assert.Equal(t, 4, pager.lineIndex().Index())
+
+	// Escape should take us back to where we started
+	pager.mode.onKey(twin.KeyEscape)
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, 0, pager.lineIndex().Index())
+}
+
+func TestJumpToLabelNotFound(t *testing.T) {
+	pager := createJumpToLabelPager(t)
+	pager.mode.onRune('l')
+
+	for _, char := range "nothere" {
+		pager.mode.onRune(char)
+	}
+	assert.Equal(t, 0, pager.lineIndex().Index())
+}
@@ -0,0 +1,24 @@
+package internal
+
+import "github.com/walles/moor/v2/twin"
+
+// PagerModeMessage shows a message in the status bar until the next key press,
+// which is then handled as usual.
+type PagerModeMessage struct {
+	pager   *Pager
+	message string
+}
+
+func (m PagerModeMessage) drawFooter(_ string, _ string) {
+	m.pager.setFooter(m.message, "")
+}
+
+func (m PagerModeMessage) onKey(key twin.KeyCode) {
+	m.pager.mode = PagerModeViewing{pager: m.pager}
+	m.pager.mode.onKey(key)
+}
+
+func (m PagerModeMessage) onRune(char rune) {
+	m.pager.mode = PagerModeViewing{pager: m.pager}
+	m.pager.mode.onRune(char)
+}
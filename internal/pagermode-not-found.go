@@ -1,33 +1,81 @@
 package internal
 
-import "github.com/walles/moor/v2/twin"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/walles/moor/v2/twin"
+)
+
+// How long NOT_FOUND_ALERT_FLASH flashes the status bar
+const notFoundFlashDuration = 200 * time.Millisecond
 
 type PagerModeNotFound struct {
 	pager *Pager
+
+	// Lines that might have had hits, but took too long to search
+	skippedLines int
+}
+
+// Switch to not found mode after a failed search, alerting the user as
+// configured by NotFoundAlert. skippedLines is the number of lines the search
+// gave up on because they took longer than SearchLineTimeout to search.
+func (p *Pager) enterNotFoundMode(skippedLines int) {
+	p.mode = PagerModeNotFound{pager: p, skippedLines: skippedLines}
+
+	switch p.NotFoundAlert {
+	case NOT_FOUND_ALERT_BEEP:
+		if beeper, ok := p.screen.(twin.Beeper); ok {
+			beeper.Beep()
+		}
+
+	case NOT_FOUND_ALERT_FLASH:
+		p.notFoundFlashUntil = time.Now().Add(notFoundFlashDuration)
+		p.redrawAfter(notFoundFlashDuration)
+	}
 }
 
 func (m PagerModeNotFound) drawFooter(_ string, _ string) {
-	m.pager.setFooter("Not found: "+m.pager.searchString, "")
+	message := strings.Replace(m.pager.NotFoundMessage, "%s", m.pager.searchString, 1)
+	if m.skippedLines == 1 {
+		message += fmt.Sprintf(", skipped 1 line taking more than %s to search", m.pager.SearchLineTimeout)
+	} else if m.skippedLines > 1 {
+		message += fmt.Sprintf(", skipped %d lines taking more than %s to search", m.skippedLines, m.pager.SearchLineTimeout)
+	}
+
+	if time.Now().Before(m.pager.notFoundFlashUntil) {
+		flashStyle := statusbarStyle.WithAttr(twin.AttrReverse)
+		if statusbarStyle.HasAttr(twin.AttrReverse) {
+			flashStyle = statusbarStyle.WithoutAttr(twin.AttrReverse)
+		}
+		m.pager.setStyledFooter(message, "", flashStyle)
+		return
+	}
+
+	m.pager.setFooter(message, "")
 }
 
 func (m PagerModeNotFound) onKey(key twin.KeyCode) {
-	m.pager.mode = PagerModeViewing(m)
+	m.pager.mode = PagerModeViewing{pager: m.pager}
 	m.pager.mode.onKey(key)
 }
 
 func (m PagerModeNotFound) onRune(char rune) {
 	switch char {
 
-	// Should match the pagermode-viewing.go next-search-hit bindings
+	// Should match the pagermode-viewing.go search-hit bindings
 	case 'n':
-		m.pager.scrollToNextSearchHit()
+		m.pager.repeatSearch()
+
+	case 'N':
+		m.pager.repeatSearchReversed()
 
-	// Should match the pagermode-viewing.go previous-search-hit bindings
-	case 'p', 'N':
+	case 'p':
 		m.pager.scrollToPreviousSearchHit()
 
 	default:
-		m.pager.mode = PagerModeViewing(m)
+		m.pager.mode = PagerModeViewing{pager: m.pager}
 		m.pager.mode.onRune(char)
 	}
 }
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"testing"
+	"time"
 
 	"github.com/walles/moor/v2/internal/reader"
 	"github.com/walles/moor/v2/twin"
@@ -17,7 +18,7 @@ func TestNotFoundFindPrevious(t *testing.T) {
 	assert.NilError(t, reader.Wait())
 
 	// Look for a hit on the second line
-	pager.searchPattern = toPattern("bepa")
+	pager.searchPattern = toPattern("bepa", SEARCH_CASE_AUTO)
 
 	// Press 'p' to find the previous hit
 	pager.mode = PagerModeNotFound{pager: pager}
@@ -36,7 +37,7 @@ func TestWrapSearchBackwards(t *testing.T) {
 	assert.NilError(t, reader.Wait())
 
 	// Looking for this should take us to the last line
-	pager.searchPattern = toPattern("gold")
+	pager.searchPattern = toPattern("gold", SEARCH_CASE_AUTO)
 
 	// Press 'p' to find the previous hit
 	pager.mode = PagerModeNotFound{pager: pager}
@@ -48,3 +49,55 @@ func TestWrapSearchBackwards(t *testing.T) {
 	assert.Equal(t, pager.scrollPosition.lineIndex(pager).Index(), 2)
 	assert.Assert(t, pager.isViewing())
 }
+
+func TestNotFoundMessageAndAlert(t *testing.T) {
+	reader := reader.NewFromTextForTesting("TestNotFoundMessageAndAlert", "apa\nbepa\ncepa\ndepa")
+	pager := NewPager(reader)
+	screen := twin.NewFakeScreen(40, 3)
+	pager.screen = screen
+	pager.NotFoundMessage = "No %s here"
+	pager.NotFoundAlert = NOT_FOUND_ALERT_BEEP
+
+	assert.NilError(t, reader.Wait())
+
+	pager.searchString = "gold"
+	pager.searchPattern = toPattern("gold", SEARCH_CASE_AUTO)
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, modeName(pager), "NotFound")
+	assert.Equal(t, screen.BeepCount(), 1)
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "No gold here")
+
+	// Searching for something else should get us back to viewing
+	pager.searchString = "depa"
+	pager.searchPattern = toPattern("depa", SEARCH_CASE_AUTO)
+	pager.mode.onRune('n')
+	assert.Assert(t, pager.isViewing())
+	assert.Equal(t, screen.BeepCount(), 1)
+}
+
+func TestNotFoundFlash(t *testing.T) {
+	reader := reader.NewFromTextForTesting("TestNotFoundFlash", "apa")
+	pager := NewPager(reader)
+	screen := twin.NewFakeScreen(40, 3)
+	pager.screen = screen
+	pager.NotFoundAlert = NOT_FOUND_ALERT_FLASH
+
+	assert.NilError(t, reader.Wait())
+
+	pager.searchString = "gold"
+	pager.searchPattern = toPattern("gold", SEARCH_CASE_AUTO)
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, modeName(pager), "NotFound")
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "Not found: gold")
+	flashing := screen.GetRow(2)[0].Style
+	assert.Assert(t, flashing != statusbarStyle)
+
+	// After the flash, the normal status bar style should be back
+	pager.notFoundFlashUntil = time.Time{}
+	pager.redraw("")
+	assert.Equal(t, screen.GetRow(2)[0].Style, statusbarStyle)
+}
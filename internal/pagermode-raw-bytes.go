@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/twin"
+)
+
+// How many bytes to show on each row of the raw bytes inspector
+//
+//revive:disable-next-line:var-naming
+const RAW_BYTES_PER_ROW = 16
+
+// PagerModeRawBytes shows the raw bytes of the top line on screen, for
+// debugging escape sequence issues. Any key closes it.
+type PagerModeRawBytes struct {
+	pager     *Pager
+	lineIndex linemetadata.Index
+	rows      []string
+}
+
+func NewPagerModeRawBytes(p *Pager) *PagerModeRawBytes {
+	m := &PagerModeRawBytes{
+		pager: p,
+	}
+
+	lineIndex := p.lineIndex()
+	if lineIndex == nil {
+		// Nothing to show
+		return m
+	}
+
+	lines := p.Reader().GetLines(*lineIndex, 1).Lines
+	if len(lines) == 0 {
+		return m
+	}
+
+	m.lineIndex = lines[0].Index
+	m.rows = formatRawBytes(lines[0].Line.Raw())
+	return m
+}
+
+// Format a string as a hex dump, with the HumanizeLowASCII() rendering of each
+// row's bytes to the right.
+//
+// Rows are only split between runes, so multi byte characters end up in one
+// piece on a single row.
+func formatRawBytes(raw string) []string {
+	rows := []string{}
+
+	rowStart := 0
+	for rowStart < len(raw) {
+		rowEnd := rowStart
+		for rowEnd < len(raw) {
+			_, size := utf8.DecodeRuneInString(raw[rowEnd:])
+			if rowEnd+size-rowStart > RAW_BYTES_PER_ROW && rowEnd > rowStart {
+				break
+			}
+			rowEnd += size
+		}
+
+		chunk := raw[rowStart:rowEnd]
+		hexBytes := make([]string, 0, len(chunk))
+		for i := 0; i < len(chunk); i++ {
+			hexBytes = append(hexBytes, fmt.Sprintf("%02x", chunk[i]))
+		}
+
+		rows = append(rows, fmt.Sprintf("%08x  %-*s  %s",
+			rowStart,
+			RAW_BYTES_PER_ROW*3-1, strings.Join(hexBytes, " "),
+			twin.HumanizeLowASCII(chunk)))
+
+		rowStart = rowEnd
+	}
+
+	return rows
+}
+
+func (m *PagerModeRawBytes) drawFooter(_ string, _ string) {
+	p := m.pager
+	width, height := p.screen.Size()
+
+	// Draw the rows bottom aligned just above the footer, covering whatever
+	// content was there
+	firstRow := height - 1 - len(m.rows)
+	if firstRow < 0 {
+		firstRow = 0
+	}
+	for i, row := range m.rows {
+		screenRow := firstRow + i
+		if screenRow >= height-1 {
+			break
+		}
+
+		column := 0
+		for _, char := range row {
+			column += p.screen.SetCell(column, screenRow, twin.NewStyledRune(char, plainTextStyle))
+		}
+		for column < width {
+			column += p.screen.SetCell(column, screenRow, twin.NewStyledRune(' ', plainTextStyle))
+		}
+	}
+
+	if len(m.rows) == 0 {
+		p.setFooter("Raw bytes: Line is empty", "Press any key to close")
+		return
+	}
+
+	p.setFooter("Raw bytes of line "+m.lineIndex.Format(), "Press any key to close")
+}
+
+func (m *PagerModeRawBytes) onKey(_ twin.KeyCode) {
+	m.pager.mode = PagerModeViewing{pager: m.pager}
+}
+
+func (m *PagerModeRawBytes) onRune(_ rune) {
+	m.pager.mode = PagerModeViewing{pager: m.pager}
+}
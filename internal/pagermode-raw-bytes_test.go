@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestFormatRawBytes(t *testing.T) {
+	assert.DeepEqual(t, formatRawBytes(""), []string{})
+
+	assert.DeepEqual(t, formatRawBytes("a\tb\x1b[1mc"), []string{
+		"00000000  61 09 62 1b 5b 31 6d 63                          a<0x09>b<0x1b>[1mc",
+	})
+}
+
+func TestFormatRawBytesMultipleRows(t *testing.T) {
+	// "é" is two bytes, and shouldn't be split between rows
+	assert.DeepEqual(t, formatRawBytes("0123456789abcdeé\x01"), []string{
+		"00000000  30 31 32 33 34 35 36 37 38 39 61 62 63 64 65     0123456789abcde",
+		"0000000f  c3 a9 01                                         é<0x01>",
+	})
+}
+
+func TestRawBytesMode(t *testing.T) {
+	reader := reader.NewFromTextForTesting("TestRawBytesMode", "\x1b[1mbold\x1b[m\nsecond")
+	assert.NilError(t, reader.Wait())
+
+	pager := NewPager(reader)
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	screen := twin.NewFakeScreen(80, 5)
+	pager.screen = screen
+
+	pager.mode.onRune('x')
+	assert.Equal(t, "RawBytes", modeName(pager))
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(3)),
+		"00000000  1b 5b 31 6d 62 6f 6c 64 1b 5b 6d                 <0x1b>[1mbold<0x1b>[m")
+
+	// Any key should bring back the normal view
+	pager.mode.onKey(twin.KeyDown)
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, pager.lineIndex().Index(), 0)
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "bold")
+}
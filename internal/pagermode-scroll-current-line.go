@@ -0,0 +1,67 @@
+package internal
+
+import "github.com/walles/moor/v2/twin"
+
+// Entered by pressing 'z'. Like in vim, a second 'z' moves the current line to
+// the middle of the screen, 't' to the top and 'b' to the bottom.
+//
+// The current line is the top one, or wherever the last of these commands put
+// it if we haven't scrolled since.
+type PagerModeScrollCurrentLine struct {
+	pager *Pager
+}
+
+func (m PagerModeScrollCurrentLine) drawFooter(_ string, _ string) {
+	m.pager.setFooter("Move current line:", "'z' to middle, 't' to top, 'b' to bottom")
+}
+
+func (m PagerModeScrollCurrentLine) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	switch key {
+	case twin.KeyEnter, twin.KeyEscape:
+		// Never mind I
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		// Never mind II
+		p.mode = PagerModeViewing{pager: p}
+		p.mode.onKey(key)
+	}
+}
+
+func (m PagerModeScrollCurrentLine) onRune(char rune) {
+	p := m.pager
+	p.mode = PagerModeViewing{pager: p}
+
+	switch char {
+	case 'z':
+		p.moveCurrentLineToRow(p.visibleHeight() / 2)
+
+	case 't':
+		p.moveCurrentLineToRow(0)
+
+	case 'b':
+		p.moveCurrentLineToRow(p.visibleHeight() - 1)
+
+	default:
+		// Never mind III
+		p.mode.onRune(char)
+	}
+}
+
+// Scroll so that the current line ends up on this screen row, counting from
+// the top. Rows are screen rows, so this works with wrapped lines as well.
+func (p *Pager) moveCurrentLineToRow(row int) {
+	if p.currentLineScrollPosition == nil || !p.ScrollPositionsEqual(p.scrollPosition, *p.currentLineScrollPosition) {
+		// We have scrolled since last time, start over from the top line
+		p.currentLine = p.scrollPosition
+	}
+
+	// Scrolling past the start gets clamped when the position is canonicalized
+	p.scrollPosition = p.currentLine.PreviousLine(row)
+	p.setTargetLine(nil)
+
+	currentLineScrollPosition := p.scrollPosition
+	p.currentLineScrollPosition = &currentLineScrollPosition
+}
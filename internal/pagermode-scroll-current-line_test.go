@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func createScrollCurrentLinePager(t *testing.T) *Pager {
+	lines := []string{}
+	for i := range 100 {
+		lines = append(lines, fmt.Sprint("line ", i))
+	}
+
+	pager := NewPager(reader.NewFromTextForTesting("TestScrollCurrentLine", strings.Join(lines, "\n")))
+	pager.screen = twin.NewFakeScreen(20, 11) // 10 lines plus the footer
+	assert.NilError(t, pager.readers[0].Wait())
+
+	return pager
+}
+
+func TestScrollCurrentLine(t *testing.T) {
+	pager := createScrollCurrentLinePager(t)
+	pager.scrollPosition = *scrollPositionFromIndex("TestScrollCurrentLine", linemetadata.IndexFromZeroBased(49))
+
+	pager.mode.onRune('z')
+	assert.Equal(t, modeName(pager), "ScrollCurrentLine")
+	pager.mode.onRune('z')
+	assert.Equal(t, modeName(pager), "Viewing")
+	assert.Equal(t, pager.lineIndex().Index(), 44)
+
+	// Line 49 should still be the current line
+	pager.mode.onRune('z')
+	pager.mode.onRune('t')
+	assert.Equal(t, pager.lineIndex().Index(), 49)
+
+	pager.mode.onRune('z')
+	pager.mode.onRune('b')
+	assert.Equal(t, pager.lineIndex().Index(), 40)
+
+	// After scrolling, the top line is the current line again
+	pager.scrollPosition = pager.scrollPosition.NextLine(1)
+	pager.mode.onRune('z')
+	pager.mode.onRune('t')
+	assert.Equal(t, pager.lineIndex().Index(), 41)
+}
+
+func TestScrollCurrentLineAtTop(t *testing.T) {
+	pager := createScrollCurrentLinePager(t)
+
+	pager.mode.onRune('z')
+	pager.mode.onRune('z')
+	assert.Equal(t, pager.lineIndex().Index(), 0)
+
+	pager.mode.onRune('z')
+	pager.mode.onRune('b')
+	assert.Equal(t, pager.lineIndex().Index(), 0)
+}
+
+func TestScrollCurrentLineOtherKey(t *testing.T) {
+	pager := createScrollCurrentLinePager(t)
+
+	// Anything other than z, t or b should be handled by the viewing mode
+	pager.mode.onRune('z')
+	pager.mode.onRune('G')
+	assert.Equal(t, modeName(pager), "Viewing")
+	assert.Equal(t, pager.lineIndex().Index(), 90)
+}
@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Lines to search per batch when listing search hits. The hits from each batch
+// are shown as soon as that batch is done.
+const searchHitsBatchSize = 100_000
+
+// Posted by the background search hits lister after each batch
+type eventSearchHitsListed struct {
+	list *searchHitsList
+	hits []int
+	done bool
+}
+
+// The lines listed in the search hits pane, filled in from the background
+type searchHitsList struct {
+	hits []int // Zero based line indices, in order
+	done bool
+
+	// Closed when the pane is closed or the pager exits, stops the background
+	// search
+	cancelled chan struct{}
+}
+
+// PagerModeSearchHits shows a pane to the right listing all lines matching the
+// search. Picking one jumps there.
+type PagerModeSearchHits struct {
+	pager *Pager
+	list  *searchHitsList
+
+	selected     int // Index into list.hits
+	firstVisible int // First list entry on screen
+
+	// Until the user moves the selection, we select the first hit at or after
+	// this line as it comes in
+	initialLineIndex int
+	hasSelected      bool
+}
+
+func NewPagerModeSearchHits(p *Pager) *PagerModeSearchHits {
+	initialLineIndex := 0
+	if lineIndex := p.lineIndex(); lineIndex != nil {
+		initialLineIndex = lineIndex.Index()
+	}
+
+	m := &PagerModeSearchHits{
+		pager:            p,
+		list:             &searchHitsList{cancelled: make(chan struct{})},
+		initialLineIndex: initialLineIndex,
+	}
+
+	go m.list.find(p.Reader(), p.searchLineMatcher(), p.searchWindowLines(), p.SearchLineTimeout, p.screen.Events())
+
+	return m
+}
+
+// Search all lines in batches, posting the hits from each batch as an
+// eventSearchHitsListed
+func (list *searchHitsList) find(r reader.Reader, matches lineMatcher, windowLines int, lineTimeout time.Duration, events chan twin.Event) {
+	defer func() {
+		PanicHandler("searchHitsList.find()", recover(), debug.Stack())
+	}()
+
+	lineCount := r.GetLineCount()
+	for first := 0; ; first += searchHitsBatchSize {
+		select {
+		case <-list.cancelled:
+			return
+		default:
+		}
+
+		end := min(first+searchHitsBatchSize, lineCount)
+		hits := findSearchHits(r, first, end, matches, windowLines, lineTimeout)
+		done := end >= lineCount
+		select {
+		case events <- eventSearchHitsListed{list: list, hits: hits, done: done}:
+		case <-list.cancelled:
+			return
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// Stop the background search. Safe to call more than once.
+func (list *searchHitsList) cancel() {
+	select {
+	case <-list.cancelled:
+		// Already cancelled
+	default:
+		close(list.cancelled)
+	}
+}
+
+// Called by the main loop when another batch of search hits has been found
+func (p *Pager) noteSearchHitsListed(event eventSearchHitsListed) {
+	m, ok := p.mode.(*PagerModeSearchHits)
+	if !ok || m.list != event.list {
+		// The pane has been closed
+		return
+	}
+
+	m.list.hits = append(m.list.hits, event.hits...)
+	m.list.done = event.done
+	if m.hasSelected {
+		return
+	}
+
+	for i, hit := range m.list.hits {
+		if hit >= m.initialLineIndex {
+			m.selected = i
+			m.hasSelected = true
+			return
+		}
+	}
+	if m.list.done {
+		// Nothing after where we started, go for the last one
+		m.selected = max(len(m.list.hits)-1, 0)
+	}
+}
+
+// How many screen columns the search hits pane uses, including its divider.
+// Zero if it isn't showing.
+func (p *Pager) searchHitsPaneWidth() int {
+	if _, ok := p.mode.(*PagerModeSearchHits); !ok {
+		return 0
+	}
+
+	width, _ := p.screen.Size()
+	return width / 3
+}
+
+// How many list entries fit in the pane, not counting the header
+func (m *PagerModeSearchHits) listHeight() int {
+	return max(m.pager.visibleHeight()-1, 1)
+}
+
+func (m *PagerModeSearchHits) drawPane() {
+	p := m.pager
+	width, _ := p.screen.Size()
+	divider := p.contentWidth()
+	for row := 0; row < p.visibleHeight(); row++ {
+		p.screen.SetCell(divider, row, twin.NewStyledRune('│', lineNumbersStyle))
+	}
+
+	header := fmt.Sprintf("%d hits", len(m.list.hits))
+	if len(m.list.hits) == 1 {
+		header = "1 hit"
+	}
+	if !m.list.done {
+		header += "…"
+	}
+	m.drawText(header, 0, twin.StyleDefault.WithAttr(twin.AttrBold), width)
+
+	listHeight := m.listHeight()
+	if m.selected < m.firstVisible {
+		m.firstVisible = m.selected
+	}
+	if m.selected >= m.firstVisible+listHeight {
+		m.firstVisible = m.selected - listHeight + 1
+	}
+
+	for row := 0; row < listHeight; row++ {
+		hitIndex := m.firstVisible + row
+		if hitIndex >= len(m.list.hits) {
+			break
+		}
+
+		text := ""
+		line := p.Reader().GetLine(linemetadata.IndexFromZeroBased(m.list.hits[hitIndex]))
+		if line != nil {
+			text = p.formatLineNumber(line.Number) + " " + strings.TrimSpace(line.Plain())
+		}
+
+		style := plainTextStyle
+		if hitIndex == m.selected {
+			style = statusbarStyle
+		}
+		m.drawText(text, row+1, style, width)
+	}
+}
+
+// Draw the text in the pane, cut off at the right edge of the screen
+func (m *PagerModeSearchHits) drawText(text string, row int, style twin.Style, width int) {
+	column := m.pager.contentWidth() + 1
+	for _, char := range text {
+		if column >= width {
+			return
+		}
+		column += m.pager.screen.SetCell(column, row, twin.NewStyledRune(char, style))
+	}
+
+	for ; column < width; column++ {
+		m.pager.screen.SetCell(column, row, twin.NewStyledRune(' ', style))
+	}
+}
+
+func (m *PagerModeSearchHits) drawFooter(_ string, _ string) {
+	m.pager.setFooter("Search hits", "Arrow keys to select, 'RETURN' to jump, 'ESC' to close")
+}
+
+func (m *PagerModeSearchHits) moveSelection(delta int) {
+	if len(m.list.hits) == 0 {
+		return
+	}
+
+	m.selected = max(0, min(m.selected+delta, len(m.list.hits)-1))
+	m.hasSelected = true
+}
+
+// Scroll to the selected search hit, keeping the pane open
+func (m *PagerModeSearchHits) jumpToSelected() {
+	if len(m.list.hits) == 0 {
+		return
+	}
+
+	p := m.pager
+	hit := linemetadata.IndexFromZeroBased(m.list.hits[m.selected])
+	p.scrollPosition = NewScrollPositionFromIndex(hit, "jumpToSelected")
+	p.currentSearchHit = &hit
+	p.setTargetLine(nil)
+	p.centerSearchHitsVertically()
+}
+
+func (m *PagerModeSearchHits) close() {
+	m.list.cancel()
+	m.pager.mode = PagerModeViewing{pager: m.pager}
+}
+
+func (m *PagerModeSearchHits) onKey(key twin.KeyCode) {
+	switch key {
+	case twin.KeyUp:
+		m.moveSelection(-1)
+
+	case twin.KeyDown:
+		m.moveSelection(1)
+
+	case twin.KeyPgUp:
+		m.moveSelection(-m.listHeight())
+
+	case twin.KeyPgDown:
+		m.moveSelection(m.listHeight())
+
+	case twin.KeyHome:
+		m.moveSelection(-len(m.list.hits))
+
+	case twin.KeyEnd:
+		m.moveSelection(len(m.list.hits))
+
+	case twin.KeyEnter:
+		m.jumpToSelected()
+
+	case twin.KeyEscape:
+		m.close()
+	}
+}
+
+func (m *PagerModeSearchHits) onRune(char rune) {
+	switch char {
+	case 'k':
+		m.moveSelection(-1)
+
+	case 'j':
+		m.moveSelection(1)
+
+	case 'o', 'q':
+		m.close()
+	}
+}
+
+// Open the search hits pane when the user presses 'o'
+func (p *Pager) showSearchHits() {
+	if p.searchPattern == nil {
+		p.mode = PagerModeMessage{pager: p, message: "Search for something first, then press 'o' to list the hits"}
+		return
+	}
+	if p.isSideBySide() {
+		p.mode = PagerModeMessage{pager: p, message: "Search hits can't be listed side by side"}
+		return
+	}
+
+	p.mode = NewPagerModeSearchHits(p)
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+// Wait for the background search to list all hits
+func awaitSearchHitsListed(t *testing.T, pager *Pager, screen *twin.FakeScreen) {
+	for {
+		event := <-screen.Events()
+		listed, ok := event.(eventSearchHitsListed)
+		assert.Assert(t, ok, "Unexpected event: %v", event)
+		pager.noteSearchHitsListed(listed)
+		if listed.done {
+			return
+		}
+	}
+}
+
+func TestSearchHitsPane(t *testing.T) {
+	r := reader.NewFromTextForTesting("TestSearchHitsPane", "a\nhit 1\nb\n  hit 2\nc\nd\ne")
+	assert.NilError(t, r.Wait())
+
+	screen := twin.NewFakeScreen(30, 4)
+	pager := NewPager(r)
+	pager.screen = screen
+	pager.searchPattern = regexp.MustCompile("hit")
+
+	pager.mode.onRune('o')
+	assert.Equal(t, modeName(pager), "SearchHits")
+	awaitSearchHitsListed(t, pager, screen)
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "  1 a               │2 hits")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "  2 hit 1           │2 hit 1")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "  3 b               │4 hit 2")
+
+	// The first hit should be selected
+	assert.Equal(t, screen.GetRow(1)[21].Style, statusbarStyle)
+
+	pager.mode.onKey(twin.KeyDown)
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, pager.currentSearchHit.Index(), 3)
+	assert.Equal(t, modeName(pager), "SearchHits")
+
+	pager.mode.onKey(twin.KeyEscape)
+	assert.Equal(t, modeName(pager), "Viewing")
+	assert.Equal(t, pager.contentWidth(), 30)
+}
+
+func TestSearchHitsPaneStartsAtTopLine(t *testing.T) {
+	r := reader.NewFromTextForTesting("TestSearchHitsPaneStartsAtTopLine", "hit\nx\nhit\nx\nhit")
+	assert.NilError(t, r.Wait())
+
+	screen := twin.NewFakeScreen(30, 3)
+	pager := NewPager(r)
+	pager.screen = screen
+	pager.searchPattern = regexp.MustCompile("hit")
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(1), "test")
+
+	pager.mode.onRune('o')
+	awaitSearchHitsListed(t, pager, screen)
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, pager.currentSearchHit.Index(), 2)
+}
+
+func TestSearchHitsPaneNeedsSearch(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("TestSearchHitsPaneNeedsSearch", "a"))
+	pager.screen = twin.NewFakeScreen(30, 3)
+
+	pager.mode.onRune('o')
+	assert.Equal(t, modeName(pager), "Message")
+}
+
+// A cancelled search must not stay blocked posting to a full event queue
+func TestSearchHitsListCancelled(t *testing.T) {
+	r := reader.NewFromTextForTesting("TestSearchHitsListCancelled", "hit")
+	assert.NilError(t, r.Wait())
+
+	list := &searchHitsList{cancelled: make(chan struct{})}
+	fullEvents := make(chan twin.Event) // Unbuffered, nobody reading
+	returned := make(chan struct{})
+	go func() {
+		list.find(r, func(line string) bool { return true }, 1, time.Second, fullEvents)
+		close(returned)
+	}()
+
+	list.cancel()
+	list.cancel() // Should be harmless
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("find() still blocked after cancel()")
+	}
+}
@@ -20,34 +20,70 @@ type PagerModeSearch struct {
 	initialScrollPosition scrollPosition // Pager position before search started
 	direction             SearchDirection
 	inputBox              *InputBox
+
+	// Sideways scrolling before search started, restored together with
+	// initialScrollPosition
+	initialLeftColumn      int
+	initialShowLineNumbers bool
+
+	// Which searchHistory entry is in the input box. Equal to the number of
+	// entries when the user isn't browsing the history.
+	historyIndex int
+
+	// What the user had typed before starting to browse the history
+	typedText string
+
+	// Why the text in the input box isn't a valid search, empty if it is
+	searchError string
 }
 
 func NewPagerModeSearch(p *Pager, direction SearchDirection, initialScrollPosition scrollPosition) *PagerModeSearch {
 	m := &PagerModeSearch{
-		pager:                 p,
-		initialScrollPosition: initialScrollPosition,
-		direction:             direction,
+		pager:                  p,
+		initialScrollPosition:  initialScrollPosition,
+		direction:              direction,
+		historyIndex:           len(p.searchHistory.entries),
+		initialLeftColumn:      p.leftColumnZeroBased,
+		initialShowLineNumbers: p.showLineNumbers,
 	}
+	p.searchDirection = direction
 	m.inputBox = &InputBox{
 		accept: INPUTBOX_ACCEPT_ALL,
 		onTextChanged: func(text string) {
+			// Editing a history entry makes it the current text
+			m.historyIndex = len(m.pager.searchHistory.entries)
 			m.updateSearchPattern(text)
 		},
 	}
 	return m
 }
 
-func (m PagerModeSearch) drawFooter(_ string, _ string) {
-	prompt := "Search: "
+func (m *PagerModeSearch) drawFooter(_ string, _ string) {
+	prompt := "Search"
 	if m.direction == SearchDirectionBackward {
-		prompt = "Search backwards: "
+		prompt = "Search backwards"
+	}
+	if m.pager.SearchCommaSeparated && m.pager.SearchLiteral {
+		prompt += " (comma separated, literal)"
+	} else if m.pager.SearchCommaSeparated {
+		prompt += " (comma separated)"
+	} else if m.pager.SearchLiteral {
+		prompt += " (literal)"
 	}
+	if m.searchError != "" {
+		prompt += " [" + m.searchError + ", searching literally]"
+	}
+	prompt += ": "
 	m.inputBox.draw(m.pager.screen, prompt)
 }
 
 func (m *PagerModeSearch) updateSearchPattern(text string) {
+	// Invalid parts are searched for verbatim, just like with --pattern, but
+	// the user should know that's what's happening
+	m.searchError = m.pager.searchSyntax().searchStringError(text)
+
 	m.pager.searchString = text
-	m.pager.searchPattern = toPattern(text)
+	m.pager.searchPattern, m.pager.searchMatcher = m.pager.searchSyntax().toSearch(text)
 
 	switch m.direction {
 	case SearchDirectionBackward:
@@ -55,34 +91,46 @@ func (m *PagerModeSearch) updateSearchPattern(text string) {
 	case SearchDirectionForward:
 		m.pager.scrollToSearchHits()
 	}
+
+	if m.pager.searchPattern == nil || !m.pager.searchHitIsVisible() {
+		// Nothing found, go back to where we were before searching rather
+		// than staying wherever an earlier keystroke took us
+		m.restoreInitialView()
+	}
+}
+
+// Go back to where the pager was when the search started
+func (m *PagerModeSearch) restoreInitialView() {
+	m.pager.scrollPosition = m.initialScrollPosition
+	m.pager.leftColumnZeroBased = m.initialLeftColumn
+	m.pager.showLineNumbers = m.initialShowLineNumbers
 }
 
 // toPattern compiles a search string into a pattern.
 //
-// If the string contains only lower-case letter the pattern will be case insensitive.
+// With SEARCH_CASE_AUTO, if the string contains only lower-case letters the
+// pattern will be case insensitive.
 //
 // If the string is empty the pattern will be nil.
 //
 // If the string does not compile into a regexp the pattern will match the string verbatim
-func toPattern(compileMe string) *regexp.Regexp {
+func toPattern(compileMe string, caseMode SearchCaseOption) *regexp.Regexp {
 	if len(compileMe) == 0 {
 		return nil
 	}
 
-	hasUppercase := false
-	for _, char := range compileMe {
-		if unicode.IsUpper(char) {
-			hasUppercase = true
+	prefix := ""
+	switch caseMode {
+	case SEARCH_CASE_INSENSITIVE:
+		prefix = "(?i)"
+	case SEARCH_CASE_AUTO:
+		// Smart case; be case insensitive unless there are upper case chars
+		// in the search string
+		if !hasUppercase(compileMe) {
+			prefix = "(?i)"
 		}
 	}
 
-	// Smart case; be case insensitive unless there are upper case chars
-	// in the search string
-	prefix := "(?i)"
-	if hasUppercase {
-		prefix = ""
-	}
-
 	pattern, err := regexp.Compile(prefix + compileMe)
 	if err == nil {
 		// Search string is a regexp
@@ -99,20 +147,92 @@ func toPattern(compileMe string) *regexp.Regexp {
 	panic(err)
 }
 
-func (m PagerModeSearch) onKey(key twin.KeyCode) {
+// Does this search string contain any upper case characters, not counting
+// regexp escapes like "\S" or "\p{Greek}"?
+func hasUppercase(searchString string) bool {
+	runes := []rune(searchString)
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		if char != '\\' {
+			if unicode.IsUpper(char) {
+				return true
+			}
+			continue
+		}
+
+		// Skip the escaped character
+		i++
+		if i >= len(runes) {
+			break
+		}
+
+		if runes[i] != 'p' && runes[i] != 'P' {
+			continue
+		}
+
+		// Unicode character class, like "\pL" or "\p{Greek}"
+		if i+1 < len(runes) && runes[i+1] == '{' {
+			for i < len(runes) && runes[i] != '}' {
+				i++
+			}
+		} else {
+			i++
+		}
+	}
+
+	return false
+}
+
+// Show an older (-1) or newer (+1) search from the history in the input box.
+// Searching for it waits until the user edits or accepts it.
+func (m *PagerModeSearch) browseHistory(delta int) {
+	entries := m.pager.searchHistory.entries
+	newIndex := m.historyIndex + delta
+	if newIndex < 0 || newIndex > len(entries) {
+		// Nothing more to browse in that direction
+		return
+	}
+
+	if m.historyIndex == len(entries) {
+		m.typedText = m.inputBox.text
+	}
+	m.historyIndex = newIndex
+
+	if newIndex == len(entries) {
+		m.inputBox.replaceText(m.typedText)
+	} else {
+		m.inputBox.replaceText(entries[newIndex])
+	}
+}
+
+func (m *PagerModeSearch) onKey(key twin.KeyCode) {
 	if m.inputBox.handleKey(key) {
 		return
 	}
 
 	switch key {
 	case twin.KeyEnter:
+		if m.inputBox.text != m.pager.searchString {
+			// Accepting an entry from the history
+			m.updateSearchPattern(m.inputBox.text)
+		}
+		m.pager.searchHistory.add(m.inputBox.text)
 		m.pager.mode = PagerModeViewing{pager: m.pager}
 
 	case twin.KeyEscape:
 		m.pager.mode = PagerModeViewing{pager: m.pager}
-		m.pager.scrollPosition = m.initialScrollPosition
+		m.restoreInitialView()
+		if !m.pager.KeepSearchOnEscape {
+			m.pager.clearSearch()
+		}
 
-	case twin.KeyUp, twin.KeyDown, twin.KeyPgUp, twin.KeyPgDown:
+	case twin.KeyUp:
+		m.browseHistory(-1)
+
+	case twin.KeyDown:
+		m.browseHistory(1)
+
+	case twin.KeyPgUp, twin.KeyPgDown:
 		m.pager.mode = PagerModeViewing{pager: m.pager}
 		m.pager.mode.onKey(key)
 
@@ -121,6 +241,10 @@ func (m PagerModeSearch) onKey(key twin.KeyCode) {
 	}
 }
 
-func (m PagerModeSearch) onRune(char rune) {
+func (m *PagerModeSearch) onRune(char rune) {
 	m.inputBox.handleRune(char)
 }
+
+func (m *PagerModeSearch) onPaste(text string) {
+	m.inputBox.handlePaste(text)
+}
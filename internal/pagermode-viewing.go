@@ -2,6 +2,8 @@ package internal
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/walles/moor/v2/twin"
@@ -22,11 +24,27 @@ func (m PagerModeViewing) drawFooter(statusText string, spinner string) {
 	m.pager.readerLock.Unlock()
 	helpText := "Press 'ESC' / 'q' to exit, " + colonHelp + "'/' to search, '&' to filter, 'h' for help"
 
+	if m.pager.fileChangedOnDisk.Load() {
+		helpText = "File changed on disk, press 'R' to reload"
+	}
+
 	if m.pager.isShowingHelp {
 		helpText = "Press 'ESC' / 'q' to exit help, '/' to search"
 		prefix = ""
 	}
 
+	if m.pager.ShowStatusBar && m.pager.hoveredHyperlink != nil {
+		// Like the status bar of a web browser
+		m.pager.setFooter(*m.pager.hoveredHyperlink, "")
+		return
+	}
+
+	if m.pager.ShowStatusBar && m.pager.SegmentedStatusBar {
+		width, _ := m.pager.screen.Size()
+		m.pager.setFooter(m.pager.footerSegments.layout(width), "")
+		return
+	}
+
 	if m.pager.ShowStatusBar {
 		if len(spinner) > 0 {
 			spinner = "  " + spinner
@@ -42,21 +60,19 @@ func (m PagerModeViewing) onKey(keyCode twin.KeyCode) {
 	case twin.KeyEscape:
 		p.Quit()
 
-	case twin.KeyUp:
-		// Clipping is done in _Redraw()
-		p.scrollPosition = p.scrollPosition.PreviousLine(1)
-		p.handleScrolledUp()
+	case twin.KeyUp, twin.KeyDown, twin.KeyPgUp, twin.KeyPgDown:
+		m.onRepeatedKey(keyCode, 1)
 
-	case twin.KeyDown, twin.KeyEnter:
+	case twin.KeyEnter:
 		// Clipping is done in _Redraw()
 		p.scrollPosition = p.scrollPosition.NextLine(1)
 		p.handleScrolledDown()
 
 	case twin.KeyRight:
-		p.moveRight(p.SideScrollAmount)
+		p.moveRight(p.sideScrollDelta(true))
 
 	case twin.KeyLeft:
-		p.moveRight(-p.SideScrollAmount)
+		p.moveRight(p.sideScrollDelta(false))
 
 	case twin.KeyAltRight:
 		p.moveRight(1)
@@ -71,22 +87,67 @@ func (m PagerModeViewing) onKey(keyCode twin.KeyCode) {
 	case twin.KeyEnd:
 		p.scrollToEnd()
 
+	default:
+		log.Debugf("Unhandled key event %v", keyCode)
+	}
+}
+
+// Handle count presses of one of the up / down / page up / page down keys in
+// one go.
+func (m PagerModeViewing) onRepeatedKey(keyCode twin.KeyCode, count int) {
+	p := m.pager
+
+	// Clipping is done in _Redraw()
+	switch keyCode {
+	case twin.KeyUp:
+		p.scrollPosition = p.scrollPosition.PreviousLine(count * p.scrollStep(keyCode, time.Now()))
+		p.handleScrolledUp()
+
+	case twin.KeyDown:
+		p.scrollPosition = p.scrollPosition.NextLine(count * p.scrollStep(keyCode, time.Now()))
+		p.handleScrolledDown()
+
 	case twin.KeyPgUp:
-		p.scrollPosition = p.scrollPosition.PreviousLine(p.visibleHeight())
+		p.scrollPosition = p.scrollPosition.PreviousLine(count * p.visibleHeight())
 		p.handleScrolledUp()
 
 	case twin.KeyPgDown:
-		p.scrollPosition = p.scrollPosition.NextLine(p.visibleHeight())
+		p.scrollPosition = p.scrollPosition.NextLine(count * p.visibleHeight())
 		p.handleScrolledDown()
 
 	default:
-		log.Debugf("Unhandled key event %v", keyCode)
+		log.Debugf("Unhandled repeated key event %v", keyCode)
 	}
 }
 
 func (m PagerModeViewing) onRune(char rune) {
 	p := m.pager
 
+	if p.InvertColorsKey != 0 && char == p.InvertColorsKey {
+		p.invertColors = !p.invertColors
+		return
+	}
+
+	if !m.handleRune(char) {
+		log.Debugf("Unhandled rune keypress '%s'/0x%08x", string(char), int32(char))
+	}
+}
+
+// Every rune handled by handleRune(), used for rejecting custom keys that would
+// hide one of these
+const viewingModeRunes = "qvRh\x1a\x0c=ky\x10je\x0e<>GFf bu\x15d\x04/?&g0123456789PxcCYST\tLl:nNpI,Hzm'[]|Mow"
+
+// IsViewingModeRune returns true if the rune already does something when
+// viewing
+func IsViewingModeRune(char rune) bool {
+	return strings.ContainsRune(viewingModeRunes, char)
+}
+
+// Returns false if the rune isn't bound to anything. Runes handled here must be
+// listed in viewingModeRunes.
+func (m PagerModeViewing) handleRune(char rune) bool {
+	p := m.pager
+
 	switch char {
 	case 'q':
 		p.Quit()
@@ -94,6 +155,12 @@ func (m PagerModeViewing) onRune(char rune) {
 	case 'v':
 		handleEditingRequest(p)
 
+	case 'R':
+		if !p.isShowingHelp {
+			p.reloadFile()
+			p.setTargetLine(p.TargetLine)
+		}
+
 	case 'h':
 		if p.isShowingHelp {
 			break
@@ -109,6 +176,16 @@ func (m PagerModeViewing) onRune(char rune) {
 		p.setTargetLine(nil)
 		p.isShowingHelp = true
 
+	// '\x1a' = CTRL-z, suspends us so that "fg" brings us back
+	case '\x1a':
+		p.suspend()
+
+	// '\x0c' = CTRL-l, redraws everything like in less
+	case '\x0c':
+		if redrawer, ok := p.screen.(twin.FullRedrawer); ok {
+			redrawer.RedrawAll()
+		}
+
 	case '=':
 		p.ShowStatusBar = !p.ShowStatusBar
 
@@ -133,6 +210,9 @@ func (m PagerModeViewing) onRune(char rune) {
 	case '>', 'G':
 		p.scrollToEnd()
 
+	case 'F':
+		p.toggleFollow()
+
 	case 'f', ' ':
 		p.scrollPosition = p.scrollPosition.NextLine(p.visibleHeight())
 		p.handleScrolledDown()
@@ -158,12 +238,14 @@ func (m PagerModeViewing) onRune(char rune) {
 		p.setTargetLine(nil)
 		p.searchString = ""
 		p.searchPattern = nil
+		p.searchMatcher = nil
 
 	case '?':
 		p.mode = NewPagerModeSearch(p, SearchDirectionBackward, p.scrollPosition)
 		p.setTargetLine(nil)
 		p.searchString = ""
 		p.searchPattern = nil
+		p.searchMatcher = nil
 
 	case '&':
 		if !p.isShowingHelp {
@@ -172,6 +254,7 @@ func (m PagerModeViewing) onRune(char rune) {
 			p.mode = NewPagerModeFilter(p)
 			p.searchString = ""
 			p.searchPattern = nil
+			p.searchMatcher = nil
 			p.filterPattern = nil
 		}
 
@@ -179,18 +262,78 @@ func (m PagerModeViewing) onRune(char rune) {
 		p.mode = NewPagerModeGotoLine(p)
 		p.setTargetLine(nil)
 
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		// Like 'g', but with the first digit already typed. Makes "50%" work
+		// like in less.
+		gotoLine := NewPagerModeGotoLine(p)
+		gotoLine.inputBox.handleRune(char)
+		p.mode = gotoLine
+		p.setTargetLine(nil)
+
+	case 'P':
+		if !p.isShowingHelp {
+			p.mode = NewPagerModeGotoOffset(p)
+		}
+		p.setTargetLine(nil)
+
+	case 'x':
+		p.mode = NewPagerModeRawBytes(p)
+
+	case 'c':
+		p.clearSearch()
+
+	case 'C':
+		p.copySourceCommand()
+
+	case 'Y':
+		p.copyAllLines(false)
+
+	case 'S':
+		p.copyAllLines(true)
+
+	case 'T':
+		p.copyCurrentLine()
+
+	case '\t':
+		if p.isSideBySide() {
+			p.switchSideBySideFocus()
+		}
+
+	case 'L':
+		if p.isSideBySide() {
+			p.toggleScrollLock()
+		}
+
+	case 'l':
+		p.mode = NewPagerModeJumpToLabel(p, p.scrollPosition)
+		p.setTargetLine(nil)
+
 	case ':':
 		p.mode = &PagerModeColonCommand{pager: p}
 		p.setTargetLine(nil)
 
-	// Should match the pagermode-not-found.go previous-search-hit bindings
+	// Should match the pagermode-not-found.go search-hit bindings
 	case 'n':
-		p.scrollToNextSearchHit()
+		p.repeatSearch()
+
+	case 'N':
+		p.repeatSearchReversed()
 
-	// Should match the pagermode-not-found.go next-search-hit bindings
-	case 'p', 'N':
+	case 'p':
 		p.scrollToPreviousSearchHit()
 
+	case 'I':
+		p.cycleSearchCaseMode()
+
+	case ',':
+		p.toggleSearchCommaSeparated()
+
+	case 'H':
+		p.toggleSearchHighlighting()
+
+	case 'z':
+		p.mode = PagerModeScrollCurrentLine{pager: p}
+
 	case 'm':
 		p.mode = PagerModeMark{pager: p}
 		p.setTargetLine(nil)
@@ -199,10 +342,27 @@ func (m PagerModeViewing) onRune(char rune) {
 		p.mode = PagerModeJumpToMark{pager: p}
 		p.setTargetLine(nil)
 
+	case '[':
+		p.setSearchRegionStart()
+
+	case ']':
+		p.setSearchRegionEnd()
+
+	case '|':
+		p.clearSearchRegion()
+
+	case 'M':
+		p.toggleMouseTracking()
+
+	case 'o':
+		p.showSearchHits()
+
 	case 'w':
-		p.WrapLongLines = !p.WrapLongLines
+		p.toggleWrapping()
 
 	default:
-		log.Debugf("Unhandled rune keypress '%s'/0x%08x", string(char), int32(char))
+		return false
 	}
+
+	return true
 }
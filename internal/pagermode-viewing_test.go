@@ -3,6 +3,10 @@ package internal
 import (
 	"os"
 	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
 )
 
 func TestErrUnlessExecutable_yes(t *testing.T) {
@@ -30,3 +34,17 @@ func TestErrUnlessExecutable_no(t *testing.T) {
 		t.Fatal("Expected error, got nil")
 	}
 }
+
+// Runes missing from viewingModeRunes could be hidden by a custom invert key
+func TestViewingModeRunes(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "hello"))
+	pager.screen = twin.NewFakeScreen(20, 5)
+	mode := PagerModeViewing{pager: pager}
+
+	for char := rune(0); char < 0x80; char++ {
+		if IsViewingModeRune(char) {
+			continue
+		}
+		assert.Assert(t, !mode.handleRune(char), "Rune %q is bound but not in viewingModeRunes", char)
+	}
+}
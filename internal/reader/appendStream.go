@@ -0,0 +1,98 @@
+package reader
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reads all of first, then all of second. If first doesn't end with a newline,
+// one is added so that the two don't share a line.
+type appendingReader struct {
+	first  io.Reader // Nil when done
+	second io.Reader
+
+	firstIsEmpty         bool
+	firstEndsWithNewline bool
+}
+
+func (r *appendingReader) Read(p []byte) (int, error) {
+	if r.first != nil {
+		n, err := r.first.Read(p)
+		if n > 0 {
+			r.firstIsEmpty = false
+			r.firstEndsWithNewline = p[n-1] == '\n'
+		}
+		if err != io.EOF {
+			return n, err
+		}
+
+		if closer, ok := r.first.(io.Closer); ok {
+			closeErr := closer.Close()
+			if closeErr != nil {
+				log.Debug("Closing appended-to file failed: ", closeErr)
+			}
+		}
+		r.first = nil
+		if n > 0 {
+			return n, nil
+		}
+	}
+
+	if !r.firstIsEmpty && !r.firstEndsWithNewline && len(p) > 0 {
+		r.firstEndsWithNewline = true
+		p[0] = '\n'
+		return 1, nil
+	}
+
+	return r.second.Read(p)
+}
+
+// NewFromFilenameAndStream creates a reader for a file, followed by whatever
+// arrives on the stream. Line numbers continue from the file into the stream.
+//
+// Highlighting is based on the file name, and is done when the stream ends.
+//
+// Note that you must call reader.SetStyleForHighlighting() after this to get
+// highlighting.
+func NewFromFilenameAndStream(filename string, stream io.Reader, formatter chroma.Formatter, options ReaderOptions) (*ReaderImpl, error) {
+	fileError := TryOpen(filename)
+	if fileError != nil {
+		return nil, fileError
+	}
+
+	fileStream, highlightingFilename, err := ZOpen(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Lexer == nil {
+		options.Lexer = lexers.Match(highlightingFilename)
+	}
+
+	// No file name here, the file should not be tailed since the stream comes
+	// after it
+	returnMe := newReaderFromStream(&appendingReader{
+		first:        fileStream,
+		second:       stream,
+		firstIsEmpty: true,
+	}, nil, formatter, options)
+
+	name := filepath.Base(highlightingFilename)
+	returnMe.Lock()
+	returnMe.Name = &name
+	returnMe.Unlock()
+
+	if options.Lexer == nil {
+		returnMe.HighlightingDone.Store(true)
+	}
+
+	if options.Style != nil {
+		returnMe.SetStyleForHighlighting(*options.Style)
+	}
+
+	return returnMe, nil
+}
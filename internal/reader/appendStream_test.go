@@ -0,0 +1,59 @@
+package reader
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/chroma/v2"
+	"gotest.tools/v3/assert"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+)
+
+// Wait for the reader to have at least this many lines
+func awaitLineCount(t *testing.T, reader *ReaderImpl, lineCount int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for reader.GetLineCount() < lineCount {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d lines, got %d", lineCount, reader.GetLineCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewFromFilenameAndStream(t *testing.T) {
+	file, err := os.CreateTemp("", "moor-TestNewFromFilenameAndStream-*.txt")
+	assert.NilError(t, err)
+	defer os.Remove(file.Name()) //nolint:errcheck
+
+	// No trailing newline, the stream should still start on a line of its own
+	_, err = file.WriteString("file 1\nfile 2")
+	assert.NilError(t, err)
+	assert.NilError(t, file.Close())
+
+	pipeReader, pipeWriter := io.Pipe()
+	reader, err := NewFromFilenameAndStream(file.Name(), pipeReader, nil, ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+
+	// The file contents should be available before anything is piped
+	awaitLineCount(t, reader, 2)
+
+	_, err = pipeWriter.Write([]byte("stdin 1\n"))
+	assert.NilError(t, err)
+	awaitLineCount(t, reader, 3)
+	assert.Assert(t, !reader.Done.Load())
+
+	_, err = pipeWriter.Write([]byte("stdin 2\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, pipeWriter.Close())
+	assert.NilError(t, reader.Wait())
+
+	lines := reader.GetLines(linemetadata.Index{}, 10)
+	assert.Equal(t, len(lines.Lines), 4)
+	for i, expected := range []string{"file 1", "file 2", "stdin 1", "stdin 2"} {
+		assert.Equal(t, lines.Lines[i].Plain(), expected)
+		assert.Equal(t, lines.Lines[i].Number.AsZeroBased(), i)
+	}
+}
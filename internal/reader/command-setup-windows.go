@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package reader
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command)
+}
+
+// Interrupting processes is not supported on Windows
+func interruptCommand(_ *exec.Cmd) error {
+	return fmt.Errorf("Interrupting commands is not supported on Windows")
+}
+
+func killCommand(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package reader
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+func shellCommand(command string) *exec.Cmd {
+	cmd := exec.Command("sh", "-c", command)
+
+	// Put the command in its own process group, so that we can stop the shell
+	// and everything it started in one go
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	return cmd
+}
+
+// Ask the command and all its children to exit
+func interruptCommand(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGINT)
+}
+
+// Kill the command and all its children
+func killCommand(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
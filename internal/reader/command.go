@@ -0,0 +1,197 @@
+package reader
+
+import (
+	"io"
+	"os/exec"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/chroma/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// How long to wait between a command exiting and starting it again
+const commandRestartDelay = time.Second
+
+// How long Close() waits for the command to exit after asking it to, before
+// killing it
+const commandTerminationTimeout = 2 * time.Second
+
+// Runs a command for NewFromCommand(), feeding its output into a pipe
+type commandRunner struct {
+	command string
+	restart bool
+
+	lock sync.Mutex
+
+	// The currently running process, if any
+	cmd *exec.Cmd
+
+	// "exit status 1", set when the command exits
+	exitStatus string
+
+	closed bool
+}
+
+// NewFromCommand creates a reader for the output of a shell command, stdout
+// and stderr combined. If restart is true, the command will be started again
+// every time it exits.
+//
+// Call Close() on the returned reader to stop the command.
+func NewFromCommand(command string, restart bool, formatter chroma.Formatter, options ReaderOptions) (*ReaderImpl, error) {
+	pipeReader, pipeWriter := io.Pipe()
+	runner := &commandRunner{
+		command: command,
+		restart: restart,
+	}
+
+	// Start the first run here, so that we can report failures to start
+	err := runner.start(pipeWriter)
+	if err != nil {
+		return nil, err
+	}
+
+	mReader := newReaderFromStream(pipeReader, nil, formatter, options)
+	mReader.Lock()
+	mReader.Name = &command
+	mReader.command = runner
+	mReader.Unlock()
+
+	if options.Lexer == nil {
+		mReader.HighlightingDone.Store(true)
+	}
+
+	if options.Style != nil {
+		mReader.SetStyleForHighlighting(*options.Style)
+	}
+
+	go func() {
+		defer func() {
+			PanicHandler("NewFromCommand()/run()", recover(), debug.Stack())
+		}()
+
+		runner.run(pipeWriter, mReader.MoreLinesAdded)
+	}()
+
+	return mReader, nil
+}
+
+func (runner *commandRunner) start(output io.Writer) error {
+	cmd := shellCommand(runner.command)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	// Don't wait forever for any leftover children to close our pipe
+	cmd.WaitDelay = commandTerminationTimeout
+
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	runner.cmd = cmd
+	runner.exitStatus = ""
+	return nil
+}
+
+// Wait for the command to exit, and restart it if we should. Closes the pipe
+// when done, which ends the reader's input.
+func (runner *commandRunner) run(pipeWriter *io.PipeWriter, moreLinesAdded chan bool) {
+	defer func() {
+		err := pipeWriter.Close()
+		if err != nil {
+			log.Debug("Closing command output pipe failed: ", err)
+		}
+	}()
+
+	for {
+		runner.lock.Lock()
+		cmd := runner.cmd
+		runner.lock.Unlock()
+
+		// We only get errors for exit codes here, and those we report in the
+		// status line
+		_ = cmd.Wait()
+
+		runner.lock.Lock()
+		runner.exitStatus = cmd.ProcessState.String()
+		closed := runner.closed
+		runner.lock.Unlock()
+		log.Debugf("Command <%s> ended: %s", runner.command, cmd.ProcessState.String())
+
+		// Get the new status on screen
+		select {
+		case moreLinesAdded <- true:
+		default:
+		}
+
+		if closed || !runner.restart {
+			return
+		}
+
+		time.Sleep(commandRestartDelay)
+
+		runner.lock.Lock()
+		closed = runner.closed
+		runner.lock.Unlock()
+		if closed {
+			return
+		}
+
+		err := runner.start(pipeWriter)
+		if err != nil {
+			log.Info("Restarting command failed: ", err)
+			return
+		}
+	}
+}
+
+// Returns the exit status of the most recent run, or an empty string if the
+// command is still running
+func (runner *commandRunner) status() string {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+	return runner.exitStatus
+}
+
+// Ask the command to exit, and kill it if it doesn't
+func (runner *commandRunner) close() {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+
+	runner.closed = true
+	if runner.cmd == nil || runner.cmd.Process == nil || runner.exitStatus != "" {
+		// Not running
+		return
+	}
+
+	cmd := runner.cmd
+	err := interruptCommand(cmd)
+	if err != nil {
+		log.Debug("Interrupting command failed, killing it: ", err)
+		killCommand(cmd)
+		return
+	}
+
+	time.AfterFunc(commandTerminationTimeout, func() {
+		// Does nothing if the command has already exited, which is what we
+		// want
+		killCommand(cmd)
+	})
+}
+
+// Close stops any command started by NewFromCommand(). For other readers this
+// does nothing.
+func (reader *ReaderImpl) Close() {
+	reader.Lock()
+	command := reader.command
+	reader.Unlock()
+
+	if command != nil {
+		command.close()
+	}
+}
@@ -0,0 +1,58 @@
+package reader
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/chroma/v2"
+	"gotest.tools/v3/assert"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+)
+
+func TestNewFromCommand(t *testing.T) {
+	reader, err := NewFromCommand("echo one; echo two >&2; echo three; exit 3", false, nil, ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+	assert.NilError(t, reader.Wait())
+
+	lines := reader.GetLines(linemetadata.Index{}, 10)
+	assert.Equal(t, len(lines.Lines), 3)
+	assert.Equal(t, lines.Lines[0].Plain(), "one")
+	assert.Equal(t, lines.Lines[1].Plain(), "two")
+	assert.Equal(t, lines.Lines[2].Plain(), "three")
+
+	// The exit status should be shown when the command is done
+	assert.Assert(t, strings.HasSuffix(lines.StatusText, "[exit status 3]"), lines.StatusText)
+}
+
+func TestNewFromCommandRestart(t *testing.T) {
+	reader, err := NewFromCommand("echo hello", true, nil, ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+
+	// Wait for the first restart
+	deadline := time.Now().Add(5 * time.Second)
+	for reader.GetLineCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, reader.GetLineCount(), 2)
+
+	// Restarting should stop when closed
+	reader.Close()
+	assert.NilError(t, reader.Wait())
+}
+
+func TestNewFromCommandClose(t *testing.T) {
+	reader, err := NewFromCommand("echo started; sleep 10", false, nil, ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+
+	for reader.GetLineCount() < 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t0 := time.Now()
+	reader.Close()
+	assert.NilError(t, reader.Wait())
+	assert.Assert(t, time.Since(t0) < 5*time.Second)
+	assert.Equal(t, reader.GetLineCount(), 1)
+}
@@ -0,0 +1,103 @@
+package reader
+
+import (
+	"regexp"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+)
+
+// Chunks smaller than this aren't worth starting a goroutine for
+const searchChunkMinSize = 1000
+
+// FindMatches returns the indices of up to limit lines, at or after from,
+// whose plain text matches the pattern. See Reader.FindMatches().
+func (reader *ReaderImpl) FindMatches(pattern *regexp.Regexp, from linemetadata.Index, limit int) []linemetadata.Index {
+	return FindMatchesIn(reader, pattern, from, limit)
+}
+
+// FindMatchesIn implements Reader.FindMatches() using only the other Reader
+// methods, for use by Reader implementations.
+//
+// The lines are searched in parallel chunks, but the results are always
+// returned in file order.
+func FindMatchesIn(r Reader, pattern *regexp.Regexp, from linemetadata.Index, limit int) []linemetadata.Index {
+	// Lines arriving while we search are not included
+	lineCount := r.GetLineCount()
+	if pattern == nil || !from.IsWithinLength(lineCount) {
+		return []linemetadata.Index{}
+	}
+
+	first := from.Index()
+	results := SearchInChunks(lineCount-first, func(offset int, count int) []linemetadata.Index {
+		return findChunkMatches(r, pattern, first+offset, first+offset+count, limit)
+	})
+
+	matches := []linemetadata.Index{}
+	for _, result := range results {
+		matches = append(matches, <-result...)
+		if limit > 0 && len(matches) >= limit {
+			// Later chunks finish on their own, their channels are buffered
+			return matches[:limit]
+		}
+	}
+
+	return matches
+}
+
+// SearchInChunks splits linesCount lines into chunks, at most one per core, and
+// calls search() on all chunks in parallel. Each chunk is given as an offset
+// from the first line to search, and a line count.
+//
+// The results are returned in chunk order. Each channel gets exactly one
+// result. The channels are buffered, so callers can stop reading as soon as
+// they have what they need.
+func SearchInChunks[T any](linesCount int, search func(offset int, count int) T) []chan T {
+	chunkCount := max(min(runtime.NumCPU(), linesCount/searchChunkMinSize), 1)
+	chunkSize := linesCount / chunkCount
+
+	results := make([]chan T, chunkCount)
+	for i := range results {
+		results[i] = make(chan T, 1)
+
+		offset := i * chunkSize
+		count := chunkSize
+		if i == chunkCount-1 {
+			// The last chunk gets whatever the others didn't
+			count = linesCount - offset
+		}
+		go func() {
+			defer func() {
+				PanicHandler("SearchInChunks()/chunk", recover(), debug.Stack())
+			}()
+
+			results[i] <- search(offset, count)
+		}()
+	}
+
+	return results
+}
+
+// Returns the indices of the matching lines from first up to but not including
+// end, at most limit of them unless limit is zero or less
+func findChunkMatches(r Reader, pattern *regexp.Regexp, first int, end int, limit int) []linemetadata.Index {
+	matches := []linemetadata.Index{}
+	for index := first; index < end; index++ {
+		line := r.GetLine(linemetadata.IndexFromZeroBased(index))
+		if line == nil {
+			break
+		}
+
+		if !pattern.MatchString(line.Plain()) {
+			continue
+		}
+
+		matches = append(matches, line.Index)
+		if limit > 0 && len(matches) >= limit {
+			break
+		}
+	}
+
+	return matches
+}
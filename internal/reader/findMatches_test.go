@@ -0,0 +1,69 @@
+package reader
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"gotest.tools/v3/assert"
+)
+
+func indicesToInts(indices []linemetadata.Index) []int {
+	ints := make([]int, 0, len(indices))
+	for _, index := range indices {
+		ints = append(ints, index.Index())
+	}
+	return ints
+}
+
+func TestFindMatches(t *testing.T) {
+	reader := NewFromTextForTesting("TestFindMatches", "a\nhit\nb\nhit\nc\nhit")
+	assert.NilError(t, reader.Wait())
+	pattern := regexp.MustCompile("hit")
+
+	assert.DeepEqual(t, indicesToInts(reader.FindMatches(pattern, linemetadata.Index{}, 0)), []int{1, 3, 5})
+	assert.DeepEqual(t, indicesToInts(reader.FindMatches(pattern, linemetadata.IndexFromZeroBased(2), 0)), []int{3, 5})
+	assert.DeepEqual(t, indicesToInts(reader.FindMatches(pattern, linemetadata.Index{}, 2)), []int{1, 3})
+
+	// Past the end
+	assert.DeepEqual(t, indicesToInts(reader.FindMatches(pattern, linemetadata.IndexFromZeroBased(6), 0)), []int{})
+}
+
+// Many lines are searched in multiple chunks, results should still be in order
+func TestFindMatchesChunked(t *testing.T) {
+	lines := []string{}
+	for i := range 10 * searchChunkMinSize {
+		lines = append(lines, fmt.Sprint("line ", i))
+	}
+	reader := NewFromTextForTesting("TestFindMatchesChunked", strings.Join(lines, "\n"))
+	assert.NilError(t, reader.Wait())
+
+	// Every line ending in 999
+	matches := indicesToInts(reader.FindMatches(regexp.MustCompile("999$"), linemetadata.Index{}, 0))
+	assert.Equal(t, len(matches), 10)
+	for i, match := range matches {
+		assert.Equal(t, match, i*1000+999)
+	}
+
+	matches = indicesToInts(reader.FindMatches(regexp.MustCompile("999$"), linemetadata.Index{}, 3))
+	assert.DeepEqual(t, matches, []int{999, 1999, 2999})
+}
+
+// The chunks should cover all lines exactly once, in order
+func TestSearchInChunks(t *testing.T) {
+	for _, linesCount := range []int{0, 1, searchChunkMinSize - 1, 10*searchChunkMinSize + 7} {
+		results := SearchInChunks(linesCount, func(offset int, count int) [2]int {
+			return [2]int{offset, count}
+		})
+
+		next := 0
+		for _, result := range results {
+			chunk := <-result
+			assert.Equal(t, chunk[0], next, "Lines count: %d", linesCount)
+			next += chunk[1]
+		}
+		assert.Equal(t, next, linesCount)
+	}
+}
@@ -0,0 +1,84 @@
+package reader
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// NewFromString creates a reader from a string, split into lines the same way
+// as when reading a file with the same contents.
+//
+// The returned reader is done from the start, so Wait() returns immediately.
+// No highlighting is done.
+func NewFromString(content string) *ReaderImpl {
+	lines := []*Line{}
+	lineOffsets := []int64{}
+	offset := 0
+	for offset < len(content) {
+		lineString, rest, terminated := strings.Cut(content[offset:], "\n")
+		if terminated {
+			// Like bufio.Reader.ReadLine(), accept both "\n" and "\r\n" line
+			// endings
+			lineString = strings.TrimSuffix(lineString, "\r")
+		}
+
+		line := NewLine(lineString)
+		lines = append(lines, &line)
+		lineOffsets = append(lineOffsets, int64(offset))
+
+		offset = len(content) - len(rest)
+	}
+
+	return newDoneReader(lines, lineOffsets)
+}
+
+// NewFromLines creates a reader showing the given lines. The lines should not
+// contain any newlines.
+//
+// The returned reader is done from the start, so Wait() returns immediately.
+// No highlighting is done.
+func NewFromLines(lineStrings []string) *ReaderImpl {
+	lines := make([]*Line, 0, len(lineStrings))
+	lineOffsets := make([]int64, 0, len(lineStrings))
+	offset := int64(0)
+	for _, lineString := range lineStrings {
+		line := NewLine(lineString)
+		lines = append(lines, &line)
+		lineOffsets = append(lineOffsets, offset)
+		offset += int64(len(lineString)) + 1
+	}
+
+	return newDoneReader(lines, lineOffsets)
+}
+
+// Creates a reader that already has all its lines
+func newDoneReader(lines []*Line, lineOffsets []int64) *ReaderImpl {
+	done := atomic.Bool{}
+	done.Store(true)
+	highlightingDone := atomic.Bool{}
+	highlightingDone.Store(true) // No highlighting to do = nothing left = Done!
+	pauseStatus := atomic.Bool{}
+
+	returnMe := &ReaderImpl{
+		lines:       lines,
+		lineOffsets: lineOffsets,
+
+		pauseAfterLinesUpdated: make(chan bool, 1),
+		PauseStatus:            &pauseStatus,
+
+		MoreLinesAdded:          make(chan bool, 1),
+		MaybeDone:               make(chan bool, 1),
+		highlightingStyle:       make(chan chroma.Style, 1),
+		doneWaitingForFirstByte: make(chan bool, 1),
+		HighlightingDone:        &highlightingDone,
+		Done:                    &done,
+	}
+
+	// We already have everything
+	returnMe.doneWaitingForFirstByte <- true
+	returnMe.MaybeDone <- true
+
+	return returnMe
+}
@@ -0,0 +1,73 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"gotest.tools/v3/assert"
+)
+
+// Verify that NewFromString() splits content the same way as reading a file
+// with the same contents does
+func TestNewFromStringLikeFile(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"\n",
+		"a",
+		"a\n",
+		"a\nb",
+		"a\nb\n",
+		"\n\na\n\n",
+		"a\r\nb\r\n",
+		"a\r\nb\r\nc",
+		"\x1b[1mbold\x1b[m\nplain",
+	} {
+		fileName := filepath.Join(t.TempDir(), "content.txt")
+		assert.NilError(t, os.WriteFile(fileName, []byte(content), 0o600))
+		fileReader, err := NewFromFilename(fileName, formatters.TTY16m, ReaderOptions{Style: styles.Get("native")})
+		assert.NilError(t, err)
+		assert.NilError(t, fileReader.Wait())
+
+		stringReader := NewFromString(content)
+		assert.NilError(t, stringReader.Wait())
+
+		lineCount := fileReader.GetLineCount()
+		assert.Equal(t, stringReader.GetLineCount(), lineCount, "Content: %q", content)
+		for i := range lineCount {
+			index := linemetadata.IndexFromZeroBased(i)
+			fileLine := fileReader.GetLine(index)
+			stringLine := stringReader.GetLine(index)
+			assert.Equal(t, stringLine.Plain(), fileLine.Plain(), "Content: %q, line %d", content, i)
+			assert.Equal(t, stringLine.Index, fileLine.Index)
+			assert.Equal(t, stringLine.Number, fileLine.Number)
+		}
+		assert.Assert(t, stringReader.GetLine(linemetadata.IndexFromZeroBased(lineCount)) == nil)
+
+		// Byte offsets are used for mapping search hits to lines
+		assert.DeepEqual(t, stringReader.lineOffsets, fileReader.lineOffsets, cmpopts.EquateEmpty())
+
+		fileLines := fileReader.GetLines(linemetadata.Index{}, 3).Lines
+		stringLines := stringReader.GetLines(linemetadata.Index{}, 3).Lines
+		assert.Equal(t, len(stringLines), len(fileLines), "Content: %q", content)
+	}
+}
+
+func TestNewFromLines(t *testing.T) {
+	reader := NewFromLines([]string{"first", "", "third"})
+	assert.NilError(t, reader.Wait())
+	reader.AwaitFirstByte()
+
+	assert.Equal(t, reader.GetLineCount(), 3)
+	assert.Equal(t, reader.GetLine(linemetadata.IndexFromZeroBased(0)).Plain(), "first")
+	assert.Equal(t, reader.GetLine(linemetadata.IndexFromZeroBased(1)).Plain(), "")
+	assert.Equal(t, reader.GetLine(linemetadata.IndexFromZeroBased(2)).Plain(), "third")
+
+	lines := reader.GetLines(linemetadata.IndexFromZeroBased(1), 2)
+	assert.Equal(t, len(lines.Lines), 2)
+	assert.Equal(t, lines.Lines[0].Number, linemetadata.NumberFromOneBased(2))
+}
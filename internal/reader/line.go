@@ -53,6 +53,7 @@ func (line *Line) HighlightedTokens(
 
 		returnRunes = append(returnRunes, textstyles.CellWithMetadata{
 			Rune:            token.Rune,
+			Combining:       token.Combining,
 			Style:           style,
 			StartsSearchHit: searchHit && !lastWasSearchHit,
 		})
@@ -82,3 +83,19 @@ func (line *Line) Plain(lineIndex *linemetadata.Index) string {
 	}
 	return *line.plain
 }
+
+// Raw returns the line exactly as it was read from the input
+func (line *Line) Raw() string {
+	return line.raw
+}
+
+// SearchHits returns the cell positions of all regexp matches in the plain text
+// representation of the line, the same ones HighlightedTokens() highlights.
+func (line *Line) SearchHits(search *regexp.Regexp, lineIndex *linemetadata.Index) [][2]int {
+	plain := line.Plain(lineIndex)
+	matchRanges := getMatchRanges(&plain, search)
+	if matchRanges == nil {
+		return nil
+	}
+	return matchRanges.Matches
+}
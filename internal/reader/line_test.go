@@ -1,6 +1,7 @@
 package reader
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/walles/moor/v2/internal/textstyles"
@@ -28,3 +29,70 @@ func TestHighlightedTokensWithManPageHeading(t *testing.T) {
 		assert.Equal(t, cell.Style, textstyles.ManPageHeading)
 	}
 }
+
+func TestHighlightedTokensOnlyHighlightsMatch(t *testing.T) {
+	hitStyle := twin.StyleDefault.WithAttr(twin.AttrReverse)
+	red := twin.StyleDefault.WithForeground(twin.NewColor16(1))
+
+	// Multibyte runes before the match, and the match inside a styled region
+	line := NewLine("åäö \x1b[31mxyzzy\x1b[m ü")
+	highlighted := line.HighlightedTokens(twin.StyleDefault, hitStyle, nil, regexp.MustCompile("yz"), nil)
+
+	assert.Equal(t, len(highlighted.StyledRunes), 11)
+	for i, cell := range highlighted.StyledRunes {
+		switch {
+		case i == 5 || i == 6:
+			assert.Equal(t, cell.Style, hitStyle, "index %d", i)
+		case i >= 4 && i <= 8:
+			assert.Equal(t, cell.Style, red, "index %d", i)
+		default:
+			assert.Equal(t, cell.Style, twin.StyleDefault, "index %d", i)
+		}
+		assert.Equal(t, cell.StartsSearchHit, i == 5, "index %d", i)
+	}
+	assert.Equal(t, highlighted.Trailer, twin.StyleDefault)
+}
+
+func TestHighlightedTokensWithLineBackground(t *testing.T) {
+	hitStyle := twin.StyleDefault.WithAttr(twin.AttrReverse)
+	background := twin.NewColor16(4)
+
+	line := NewLine("abcd")
+	highlighted := line.HighlightedTokens(twin.StyleDefault, hitStyle, &background, regexp.MustCompile("b"), nil)
+
+	assert.Equal(t, highlighted.StyledRunes[0].Style, twin.StyleDefault.WithBackground(background))
+	assert.Equal(t, highlighted.StyledRunes[1].Style, hitStyle)
+	assert.Equal(t, highlighted.StyledRunes[2].Style, twin.StyleDefault.WithBackground(background))
+	assert.Equal(t, highlighted.Trailer, twin.StyleDefault.WithBackground(background))
+}
+
+func TestHighlightedTokensKeepsCombiningMarks(t *testing.T) {
+	hitStyle := twin.StyleDefault.WithAttr(twin.AttrReverse)
+
+	// "é" here is an "e" followed by U+0301 COMBINING ACUTE ACCENT
+	line := NewLine("cafe\u0301!")
+	highlighted := line.HighlightedTokens(twin.StyleDefault, hitStyle, nil, regexp.MustCompile("e\u0301"), nil)
+
+	assert.Equal(t, len(highlighted.StyledRunes), 5)
+	assert.Equal(t, highlighted.StyledRunes[3].Rune, 'e')
+	assert.Equal(t, highlighted.StyledRunes[3].Combining, "\u0301")
+	assert.Equal(t, highlighted.StyledRunes[3].Style, hitStyle)
+	assert.Equal(t, highlighted.StyledRunes[4].Style, twin.StyleDefault)
+}
+
+// Styles spanning multiple columns should be kept in all of them
+func TestHighlightedColumnsCarriesStyle(t *testing.T) {
+	red := twin.StyleDefault.WithForeground(twin.NewColor16(1))
+
+	raw := NewLine("\x1b[31ma\tb\x1b[m\tc")
+	line := NumberedLine{Line: &raw}
+	columns := line.HighlightedColumns(twin.StyleDefault, twin.StyleDefault, nil, nil)
+
+	assert.Equal(t, len(columns), 3)
+	assert.Equal(t, columns[0][0].Rune, 'a')
+	assert.Equal(t, columns[0][0].Style, red)
+	assert.Equal(t, columns[1][0].Rune, 'b')
+	assert.Equal(t, columns[1][0].Style, red)
+	assert.Equal(t, columns[2][0].Rune, 'c')
+	assert.Equal(t, columns[2][0].Style, twin.StyleDefault)
+}
@@ -1,6 +1,10 @@
 package reader
 
-import "regexp"
+import (
+	"regexp"
+
+	"github.com/walles/moor/v2/twin"
+)
 
 // MatchRanges collects match indices
 type MatchRanges struct {
@@ -14,35 +18,54 @@ func getMatchRanges(String *string, Pattern *regexp.Regexp) *MatchRanges {
 	}
 
 	return &MatchRanges{
-		Matches: toRunePositions(Pattern.FindAllStringIndex(*String, -1), String),
+		Matches: toCellPositions(Pattern.FindAllStringIndex(*String, -1), String),
 	}
 }
 
-// Convert byte indices to rune indices
-func toRunePositions(byteIndices [][]int, matchedString *string) [][2]int {
+// Convert byte indices to cell indices. Combining marks share a cell with the
+// character before them, see textstyles.PlainCells().
+func toCellPositions(byteIndices [][]int, matchedString *string) [][2]int {
 	var returnMe [][2]int
 	if len(byteIndices) == 0 {
 		// Nothing to see here, move along
 		return returnMe
 	}
 
-	runeIndex := 0
-	byteIndicesToRuneIndices := make(map[int]int, 0)
-	for byteIndex := range *matchedString {
-		byteIndicesToRuneIndices[byteIndex] = runeIndex
+	cellIndex := 0
+	byteIndicesToCellIndices := make(map[int]int, 0)
+	combiningByteIndices := make(map[int]bool, 0)
+	for byteIndex, char := range *matchedString {
+		if byteIndex > 0 && twin.IsCombining(char) {
+			// Part of the previous cell
+			byteIndicesToCellIndices[byteIndex] = cellIndex - 1
+			combiningByteIndices[byteIndex] = true
+			continue
+		}
+
+		byteIndicesToCellIndices[byteIndex] = cellIndex
 
-		runeIndex++
+		cellIndex++
 	}
 
 	// If a match touches the end of the string, that will be encoded as one
 	// byte past the end of the string. Therefore we must add a mapping for
 	// first-index-after-the-end.
-	byteIndicesToRuneIndices[len(*matchedString)] = runeIndex
+	byteIndicesToCellIndices[len(*matchedString)] = cellIndex
 
 	for _, bytePair := range byteIndices {
-		fromRuneIndex := byteIndicesToRuneIndices[bytePair[0]]
-		toRuneIndex := byteIndicesToRuneIndices[bytePair[1]]
-		returnMe = append(returnMe, [2]int{fromRuneIndex, toRuneIndex})
+		if bytePair[0] == bytePair[1] {
+			// Zero width matches, like from "x*", have nothing to highlight
+			continue
+		}
+
+		fromCellIndex := byteIndicesToCellIndices[bytePair[0]]
+		toCellIndex := byteIndicesToCellIndices[bytePair[1]]
+		if combiningByteIndices[bytePair[1]] {
+			// Match ends before the combining mark, but we can't highlight
+			// half a cell, so include it
+			toCellIndex++
+		}
+		returnMe = append(returnMe, [2]int{fromCellIndex, toCellIndex})
 	}
 
 	return returnMe
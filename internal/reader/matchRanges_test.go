@@ -87,3 +87,28 @@ func TestRealWorldBug(t *testing.T) {
 	assert.DeepEqual(t, matchRanges.Matches[1][0], 2) // Second match starts at 2
 	assert.DeepEqual(t, matchRanges.Matches[1][1], 3) // And ends on 3 exclusive
 }
+
+func TestZeroWidthMatches(t *testing.T) {
+	// "a*" matches the empty string between every pair of non-a characters
+	matchRanges := getMatchRanges(&_TestString, regexp.MustCompile("a*"))
+	assert.DeepEqual(t, matchRanges.Matches, [][2]int{{1, 2}, {4, 5}})
+
+	onlyEmpty := "xyz"
+	matchRanges = getMatchRanges(&onlyEmpty, regexp.MustCompile("a*"))
+	assert.Assert(t, matchRanges.Empty())
+}
+
+func TestGetMatchRangesCombiningDiacritics(t *testing.T) {
+	// "e" + U+0301 COMBINING ACUTE ACCENT is one cell
+	text := "ae\u0301b"
+
+	matchRanges := getMatchRanges(&text, regexp.MustCompile("e\u0301"))
+	assert.DeepEqual(t, matchRanges.Matches, [][2]int{{1, 2}})
+
+	// Matching only the base character highlights the whole cell
+	matchRanges = getMatchRanges(&text, regexp.MustCompile("e"))
+	assert.DeepEqual(t, matchRanges.Matches, [][2]int{{1, 2}})
+
+	matchRanges = getMatchRanges(&text, regexp.MustCompile("b"))
+	assert.DeepEqual(t, matchRanges.Matches, [][2]int{{2, 3}})
+}
@@ -23,6 +23,19 @@ func (nl *NumberedLine) HighlightedTokens(plainTextStyle twin.Style, searchHitSt
 	return nl.Line.HighlightedTokens(plainTextStyle, searchHitStyle, searchHitLineBackground, search, &nl.Index)
 }
 
+// Like HighlightedTokens(), but with the line split into its tab separated
+// columns
+func (nl *NumberedLine) HighlightedColumns(plainTextStyle twin.Style, searchHitStyle twin.Style, searchHitLineBackground *twin.Color, search *regexp.Regexp) []textstyles.CellWithMetadataSlice {
+	rawColumns := textstyles.SplitColumns(nl.Line.raw)
+	columns := make([]textstyles.CellWithMetadataSlice, 0, len(rawColumns))
+	for _, rawColumn := range rawColumns {
+		column := NewLine(rawColumn)
+		highlighted := column.HighlightedTokens(plainTextStyle, searchHitStyle, searchHitLineBackground, search, &nl.Index)
+		columns = append(columns, highlighted.StyledRunes)
+	}
+	return columns
+}
+
 func (nl *NumberedLine) DisplayWidth() int {
 	width := 0
 	for _, r := range nl.Plain() {
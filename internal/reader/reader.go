@@ -10,8 +10,10 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime/debug"
 	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -65,6 +67,15 @@ type Reader interface {
 	// When we're not paused, the number will be constantly changing, indicating
 	// that the counting is not done yet.
 	ShouldShowLineCount() bool
+
+	// Returns the indices of up to limit lines, at or after from, whose plain
+	// text matches the pattern. Zero or negative limit means no limit.
+	//
+	// The indices are returned in file order. Only the lines available when
+	// the call starts are searched, so it is safe to call from any goroutine,
+	// also while more lines are being read. The search is done in parallel
+	// chunks on multiple cores.
+	FindMatches(pattern *regexp.Regexp, from linemetadata.Index, limit int) []linemetadata.Index
 }
 
 // ReaderImpl reads a file into an array of strings.
@@ -78,6 +89,10 @@ type ReaderImpl struct {
 
 	lines []*Line
 
+	// Byte offsets into the input where each line starts. Nil if unknown,
+	// like after reformatting the input.
+	lineOffsets []int64
+
 	// Display name for the buffer. If not set, no buffer name will be shown.
 	//
 	// For files, this will be the file name. For our help text, this will be
@@ -91,6 +106,21 @@ type ReaderImpl struct {
 	// How many bytes have we read so far?
 	bytesCount int64
 
+	// The file we opened, before any decompression. Nil for streams.
+	diskFileName *string
+
+	// Size and modification time of diskFileName when we opened it, or when
+	// we last read more lines from it while tailing. Used by ChangedOnDisk().
+	diskFileSize    int64
+	diskFileModTime time.Time
+
+	// Set for command output readers, see NewFromCommand()
+	command *commandRunner
+
+	// For Reopen()
+	formatter chroma.Formatter
+	options   ReaderOptions
+
 	endsWithNewline bool
 
 	Err error
@@ -126,6 +156,11 @@ type InputLines struct {
 
 	// "monkey.txt: 1-23/45 51%"
 	StatusText string
+
+	// The parts of StatusText, "monkey.txt" and "1-23/45 51%". For laying out
+	// the status bar in segments.
+	StatusName     string
+	StatusPosition string
 }
 
 // Count lines in the original file and preallocate space for them.  Good
@@ -218,10 +253,19 @@ func (reader *ReaderImpl) consumeLinesFromStream(stream io.Reader) {
 	bufioReader := bufio.NewReader(&inspectionReader)
 	completeLine := make([]byte, 0)
 
+	// When tailing, we continue where we left off last time
+	reader.Lock()
+	baseOffset := reader.bytesCount
+	reader.Unlock()
+
 	t0 := time.Now()
 	for {
 		reader.maybePause()
 
+		// Counting what bufio has consumed rather than what it has buffered
+		// makes this exact, line terminators included
+		lineOffset := baseOffset + inspectionReader.bytesCount - int64(bufioReader.Buffered())
+
 		keepReadingLine := true
 		eof := false
 
@@ -276,6 +320,7 @@ func (reader *ReaderImpl) consumeLinesFromStream(stream io.Reader) {
 			reader.lines[len(reader.lines)-1] = &newLine
 		} else {
 			reader.lines = append(reader.lines, &newLine)
+			reader.lineOffsets = append(reader.lineOffsets, lineOffset)
 		}
 		reader.endsWithNewline = true
 
@@ -380,6 +425,11 @@ func (reader *ReaderImpl) tailFile() error {
 		log.Tracef("File %s up from %d bytes to %d bytes, reading more lines...", *fileName, bytesCount, fileStats.Size())
 
 		reader.consumeLinesFromStream(seekable)
+
+		reader.Lock()
+		reader.diskFileSize = fileStats.Size()
+		reader.diskFileModTime = fileStats.ModTime()
+		reader.Unlock()
 		err = seekable.Close()
 		if err != nil {
 			// This can lead to file handle leaks
@@ -489,22 +539,17 @@ func newReaderFromStream(reader io.Reader, originalFileName *string, formatter c
 func NewFromTextForTesting(name string, text string) *ReaderImpl {
 	noExternalNewlines := strings.Trim(text, "\n")
 	lines := []*Line{}
+	lineOffsets := []int64{}
 	if len(noExternalNewlines) > 0 {
+		offset := int64(len(text) - len(strings.TrimLeft(text, "\n")))
 		for _, lineString := range strings.Split(noExternalNewlines, "\n") {
 			line := NewLine(lineString)
 			lines = append(lines, &line)
+			lineOffsets = append(lineOffsets, offset)
+			offset += int64(len(lineString)) + 1
 		}
 	}
-	done := atomic.Bool{}
-	done.Store(true)
-	highlightingDone := atomic.Bool{}
-	highlightingDone.Store(true) // No highlighting to do = nothing left = Done!
-	returnMe := &ReaderImpl{
-		lines:                   lines,
-		Done:                    &done,
-		HighlightingDone:        &highlightingDone,
-		doneWaitingForFirstByte: make(chan bool, 1),
-	}
+	returnMe := newDoneReader(lines, lineOffsets)
 	if name != "" {
 		returnMe.Name = &name
 	}
@@ -604,6 +649,13 @@ func NewFromFilename(filename string, formatter chroma.Formatter, options Reader
 		return nil, fileError
 	}
 
+	// Stat before opening, so that any changes made while we read will be
+	// noticed by ChangedOnDisk()
+	fileStats, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
 	stream, highlightingFilename, err := ZOpen(filename)
 	if err != nil {
 		return nil, err
@@ -615,6 +667,14 @@ func NewFromFilename(filename string, formatter chroma.Formatter, options Reader
 
 	returnMe := newReaderFromStream(stream, &highlightingFilename, formatter, options)
 
+	returnMe.Lock()
+	returnMe.diskFileName = &filename
+	returnMe.diskFileSize = fileStats.Size()
+	returnMe.diskFileModTime = fileStats.ModTime()
+	returnMe.formatter = formatter
+	returnMe.options = options
+	returnMe.Unlock()
+
 	if options.Lexer == nil {
 		returnMe.HighlightingDone.Store(true)
 	}
@@ -752,19 +812,44 @@ func highlightFromMemory(reader *ReaderImpl, formatter chroma.Formatter, options
 	reader.setText(*highlighted)
 }
 
-// createStatusUnlocked() assumes that its caller is holding the lock
-func (reader *ReaderImpl) createStatusUnlocked(lastLine linemetadata.Index) string {
+// Create InputLines without any lines, with a status text for lastLine.
+//
+// Assumes that its caller is holding the lock.
+func (reader *ReaderImpl) createStatusUnlocked(lastLine linemetadata.Index) *InputLines {
+	name, position, statusText := reader.createStatusTextsUnlocked(lastLine)
+	return &InputLines{
+		StatusText:     statusText,
+		StatusName:     name,
+		StatusPosition: position,
+	}
+}
+
+// Returns the file name part of the status, the position part, and both of
+// them combined.
+//
+// createStatusTextsUnlocked() assumes that its caller is holding the lock
+func (reader *ReaderImpl) createStatusTextsUnlocked(lastLine linemetadata.Index) (string, string, string) {
 	filename := ""
 	if reader.Name != nil {
 		filename = filepath.Base(*reader.Name)
 	}
 
+	commandStatus := ""
+	if reader.command != nil {
+		// Commands can contain slashes, they are not file names
+		filename = reader.command.command
+		commandStatus = reader.command.status()
+	}
+	if len(commandStatus) > 0 {
+		commandStatus = "  [" + commandStatus + "]"
+	}
+
 	if len(reader.lines) == 0 {
 		empty := "<empty>"
 		if len(filename) > 0 {
-			return filename + ": " + empty
+			return filename, empty + commandStatus, filename + ": " + empty + commandStatus
 		}
-		return empty
+		return "", empty + commandStatus, empty + commandStatus
 	}
 
 	linesCount := ""
@@ -782,6 +867,12 @@ func (reader *ReaderImpl) createStatusUnlocked(lastLine linemetadata.Index) stri
 		linesCount = ""
 	}
 
+	if !reader.Done.Load() && !reader.PauseStatus.Load() {
+		// The total may still grow, so the percentage is of what we have so
+		// far. With --follow this can go on forever, so still show it.
+		percent += "  loading…"
+	}
+
 	return_me := ""
 	if len(filename) > 0 {
 		return_me = filename
@@ -801,7 +892,15 @@ func (reader *ReaderImpl) createStatusUnlocked(lastLine linemetadata.Index) stri
 		return_me += percent
 	}
 
-	return return_me
+	position := linesCount
+	if len(percent) > 0 {
+		if len(position) > 0 {
+			position += "  "
+		}
+		position += percent
+	}
+
+	return filename, position + commandStatus, return_me + commandStatus
 }
 
 // Wait for the first line to be read.
@@ -874,9 +973,7 @@ func (reader *ReaderImpl) GetLines(firstLine linemetadata.Index, wantedLineCount
 
 func (reader *ReaderImpl) getLinesUnlocked(firstLine linemetadata.Index, wantedLineCount int) *InputLines {
 	if len(reader.lines) == 0 || wantedLineCount == 0 {
-		return &InputLines{
-			StatusText: reader.createStatusUnlocked(firstLine),
-		}
+		return reader.createStatusUnlocked(firstLine)
 	}
 
 	lastLine := firstLine.NonWrappingAdd(wantedLineCount - 1)
@@ -904,10 +1001,9 @@ func (reader *ReaderImpl) getLinesUnlocked(firstLine linemetadata.Index, wantedL
 		})
 	}
 
-	return &InputLines{
-		Lines:      returnLines,
-		StatusText: reader.createStatusUnlocked(lastLine),
-	}
+	returnMe := reader.createStatusUnlocked(lastLine)
+	returnMe.Lines = returnLines
+	return returnMe
 }
 
 func (reader *ReaderImpl) PumpToStdout() {
@@ -974,6 +1070,10 @@ func (reader *ReaderImpl) setText(text string) {
 	}
 
 	reader.Lock()
+	if len(lines) != len(reader.lines) {
+		// Reformatted, the byte offsets don't match the lines any more
+		reader.lineOffsets = nil
+	}
 	reader.lines = lines
 	reader.Unlock()
 
@@ -1021,5 +1121,107 @@ func (reader *ReaderImpl) SetPauseAfterLines(lines int) {
 }
 
 func (reader *ReaderImpl) SetStyleForHighlighting(style chroma.Style) {
+	reader.Lock()
+	reader.options.Style = &style
+	reader.Unlock()
+
 	reader.highlightingStyle <- style
 }
+
+// ChangedOnDisk returns true if the file we're reading has changed since we
+// read it. Files growing at the end are tailed and don't count as changed,
+// unless they are compressed.
+//
+// Always false for streams.
+func (reader *ReaderImpl) ChangedOnDisk() bool {
+	if !reader.Done.Load() {
+		// Still reading, too early to tell
+		return false
+	}
+
+	reader.Lock()
+	diskFileName := reader.diskFileName
+	fileName := reader.FileName
+	size := reader.diskFileSize
+	modTime := reader.diskFileModTime
+	reader.Unlock()
+
+	if diskFileName == nil {
+		// Not a file
+		return false
+	}
+
+	fileStats, err := os.Stat(*diskFileName)
+	if err != nil {
+		log.Debugf("Failed to stat %s for changes: %s", *diskFileName, err.Error())
+		return false
+	}
+
+	if fileStats.Size() > size {
+		compressed := fileName == nil || *fileName != *diskFileName
+		return compressed
+	}
+
+	return fileStats.Size() < size || !fileStats.ModTime().Equal(modTime)
+}
+
+// InitialLoad is true while a file is being read for the first time, as
+// opposed to being tailed or read from a stream.
+func (reader *ReaderImpl) InitialLoad() bool {
+	reader.Lock()
+	defer reader.Unlock()
+	return reader.diskFileName != nil && !reader.Done.Load()
+}
+
+// Reopen creates a new reader, reading the same file from the start.
+func (reader *ReaderImpl) Reopen() (*ReaderImpl, error) {
+	reader.Lock()
+	diskFileName := reader.diskFileName
+	name := reader.Name
+	formatter := reader.formatter
+	options := reader.options
+	reader.Unlock()
+
+	if diskFileName == nil {
+		return nil, fmt.Errorf("Not reading from a file, can't reopen")
+	}
+
+	reopened, err := NewFromFilename(*diskFileName, formatter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	reopened.Lock()
+	reopened.Name = name
+	reopened.Unlock()
+
+	return reopened, nil
+}
+
+// IndexFromByteOffset returns the index of the line containing the given byte
+// offset into the input. Offsets before the start or past the end are clamped
+// to the first or last line.
+//
+// For compressed files, offsets are into the decompressed contents.
+//
+// Returns nil if there are no lines, or if the offsets are unknown.
+func (reader *ReaderImpl) IndexFromByteOffset(offset int64) *linemetadata.Index {
+	reader.Lock()
+	defer reader.Unlock()
+
+	if len(reader.lines) == 0 || len(reader.lineOffsets) != len(reader.lines) {
+		return nil
+	}
+
+	// Find the first line starting after the offset, we want the one before
+	// that
+	after := sort.Search(len(reader.lineOffsets), func(i int) bool {
+		return reader.lineOffsets[i] > offset
+	})
+	if after == 0 {
+		after = 1
+	}
+
+	index := linemetadata.IndexFromZeroBased(after - 1)
+	return &index
+}
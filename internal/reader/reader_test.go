@@ -656,3 +656,71 @@ func BenchmarkCountLines(b *testing.B) {
 		assert.NilError(b, err)
 	}
 }
+
+func TestChangedOnDisk(t *testing.T) {
+	file, err := os.CreateTemp("", "moor-TestChangedOnDisk-*.txt")
+	assert.NilError(t, err)
+	defer os.Remove(file.Name()) //nolint:errcheck
+
+	_, err = file.WriteString("First line\n")
+	assert.NilError(t, err)
+
+	testMe, err := NewFromFilename(file.Name(), formatters.TTY16m, ReaderOptions{Style: styles.Get("native")})
+	assert.NilError(t, err)
+	assert.NilError(t, testMe.Wait())
+	assert.Assert(t, !testMe.ChangedOnDisk())
+
+	// Pretend the file was rewritten without changing its size
+	later := time.Now().Add(time.Hour)
+	assert.NilError(t, os.Chtimes(file.Name(), later, later))
+	assert.Assert(t, testMe.ChangedOnDisk())
+
+	// A reopened reader should be up to date
+	reopened, err := testMe.Reopen()
+	assert.NilError(t, err)
+	assert.NilError(t, reopened.Wait())
+	assert.Assert(t, !reopened.ChangedOnDisk())
+	assert.Equal(t, *reopened.Name, *testMe.Name)
+}
+
+func TestChangedOnDiskStream(t *testing.T) {
+	testMe, err := NewFromStream("", strings.NewReader("First line\n"), nil, ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+	assert.NilError(t, testMe.Wait())
+
+	// Streams never change on disk
+	assert.Assert(t, !testMe.ChangedOnDisk())
+
+	_, err = testMe.Reopen()
+	assert.ErrorContains(t, err, "Not reading from a file")
+}
+
+func TestIndexFromByteOffset(t *testing.T) {
+	// Line starts: 0, 4 (after "ab\r\n"), 5 (after "\n"), 9 (after "cde\n")
+	testMe, err := NewFromStream("", strings.NewReader("ab\r\n\ncde\nf"), nil, ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+	assert.NilError(t, testMe.Wait())
+
+	for offset, expected := range map[int64]int{
+		-5:  0, // Clamped
+		0:   0,
+		3:   0, // The '\n' of the CRLF
+		4:   1,
+		5:   2,
+		8:   2,
+		9:   3,
+		999: 3, // Clamped
+	} {
+		index := testMe.IndexFromByteOffset(offset)
+		assert.Assert(t, index != nil)
+		assert.Equal(t, *index, linemetadata.IndexFromZeroBased(expected), "offset=%d", offset)
+	}
+}
+
+func TestIndexFromByteOffsetEmpty(t *testing.T) {
+	testMe, err := NewFromStream("", strings.NewReader(""), nil, ReaderOptions{Style: &chroma.Style{}})
+	assert.NilError(t, err)
+	assert.NilError(t, testMe.Wait())
+
+	assert.Assert(t, testMe.IndexFromByteOffset(0) == nil)
+}
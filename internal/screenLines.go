@@ -2,6 +2,7 @@ package internal
 
 import (
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/walles/moor/v2/internal/linemetadata"
@@ -33,17 +34,40 @@ type renderedScreen struct {
 	inputLines        []*reader.NumberedLine
 	numberPrefixWidth int // Including padding. 0 means no line numbers.
 	statusText        string
+
+	// The parts of statusText, see reader.InputLines
+	statusName     string
+	statusPosition string
+
+	// How many of the lines are pinned to the top and bottom of the screen by
+	// the sticky header and footer. The footer rows include any empty rows
+	// above the footer.
+	headerRowCount int
+	footerRowCount int
+}
+
+// The lines scrolling between the sticky header and footer
+func (r renderedScreen) scrollingLines() []renderedLine {
+	return r.lines[r.headerRowCount : len(r.lines)-r.footerRowCount]
 }
 
 // Refresh the whole pager display, both contents lines and the status line at
 // the bottom
 func (p *Pager) redraw(spinner string) {
 	log.Trace("redraw called")
+	p.updateTitle()
 	p.screen.Clear()
 	p.longestLineLength = 0
 
 	lastUpdatedScreenLineNumber := -1
-	renderedScreen := p.renderLines()
+	var renderedScreen renderedScreen
+	if p.isSideBySide() {
+		renderedScreen = p.renderSideBySide()
+	} else {
+		renderedScreen = p.renderLines()
+	}
+	p.highlightSelection(renderedScreen)
+	p.renderedLines = renderedScreen.lines
 	for screenLineNumber, row := range renderedScreen.lines {
 		lastUpdatedScreenLineNumber = screenLineNumber
 		column := 0
@@ -52,6 +76,11 @@ func (p *Pager) redraw(spinner string) {
 		}
 	}
 
+	if searchHits, ok := p.mode.(*PagerModeSearchHits); ok {
+		searchHits.drawPane()
+	}
+	p.drawSearchMinimap()
+
 	// Status line code follows
 
 	eofSpinner := spinner
@@ -65,7 +94,27 @@ func (p *Pager) redraw(spinner string) {
 		column += p.screen.SetCell(column, lastUpdatedScreenLineNumber+1, cell.ToStyledRune())
 	}
 
-	p.mode.drawFooter(renderedScreen.statusText, spinner)
+	if hitCount := p.searchHitCountStatus(renderedScreen.inputLines); hitCount != "" {
+		renderedScreen.statusText += "  " + hitCount
+		renderedScreen.statusPosition += "  " + hitCount
+	}
+
+	statusText := renderedScreen.statusText
+	statusPosition := renderedScreen.statusPosition
+	if p.ShowSearchContext {
+		width, _ := p.screen.Size()
+		searchContext := p.searchContextStatus(renderedScreen.inputLines, width/3)
+		if searchContext != "" {
+			statusText += "  " + searchContext
+			statusPosition += "  " + searchContext
+		}
+	}
+
+	if p.SegmentedStatusBar {
+		p.footerSegments = p.createFooterSegments(renderedScreen.statusName, statusPosition, spinner)
+	}
+
+	p.mode.drawFooter(statusText, spinner)
 
 	p.screen.Show()
 }
@@ -85,15 +134,35 @@ func (p *Pager) renderLines() renderedScreen {
 	if p.lineIndex() != nil {
 		lineIndex = *p.lineIndex()
 	}
-	inputLines := p.Reader().GetLines(lineIndex, p.visibleHeight())
+
+	// Don't let the footer lines scroll into view, they are already pinned
+	// to the bottom of the screen
+	wantedLineCount := min(p.visibleHeight(), p.scrollableLineCount()-lineIndex.Index())
+	inputLines := p.Reader().GetLines(lineIndex, max(wantedLineCount, 0))
 	if len(inputLines.Lines) == 0 {
 		// Empty input, empty output
-		return renderedScreen{statusText: inputLines.StatusText}
+		return renderedScreen{
+			statusText:     inputLines.StatusText,
+			statusName:     inputLines.StatusName,
+			statusPosition: inputLines.StatusPosition,
+		}
+	}
+
+	header, footer := p.stickyLines()
+
+	if p.isShowingTable() {
+		tableLines := append(append(append([]*reader.NumberedLine{}, header...), inputLines.Lines...), footer...)
+		p.updateTableColumnWidths(tableLines)
 	}
 
 	lastVisibleLineNumber := inputLines.Lines[len(inputLines.Lines)-1].Number
 	numberPrefixLength := p.getLineNumberPrefixLength(lastVisibleLineNumber)
 
+	p.dedentWidth = 0
+	if p.Dedent && !p.isShowingTable() {
+		p.dedentWidth = p.commonIndent(inputLines.Lines)
+	}
+
 	allLines := make([]renderedLine, 0)
 	for _, line := range inputLines.Lines {
 		rendering := p.renderLine(line, numberPrefixLength)
@@ -143,13 +212,26 @@ func (p *Pager) renderLines() renderedScreen {
 	allLines = allLines[firstVisibleIndex:]
 
 	// Drop the lines that would have gone below the screen
-	wantedLineCount := p.visibleHeight()
-	if len(allLines) > wantedLineCount {
-		allLines = allLines[0:wantedLineCount]
+	visibleHeight := p.visibleHeight()
+	if len(allLines) > visibleHeight {
+		allLines = allLines[0:visibleHeight]
+	}
+
+	// Pin the sticky lines above and below the scrolling ones, with empty rows
+	// in between if the scrolling lines don't fill the screen
+	headerRows := p.renderStickyLines(header, numberPrefixLength)
+	underlineStickyHeader(headerRows)
+	footerRows := p.renderStickyLines(footer, numberPrefixLength)
+	scrollingRowCount := len(allLines)
+	if len(footerRows) > 0 {
+		for len(allLines) < visibleHeight {
+			allLines = append(allLines, renderedLine{})
+		}
 	}
+	allLines = append(append(headerRows, allLines...), footerRows...)
 
 	// Fill in the line trailers
-	screenWidth, _ := p.screen.Size()
+	screenWidth := p.contentWidth()
 	for i := range allLines {
 		line := &allLines[i]
 		if line.trailer == twin.StyleDefault {
@@ -161,14 +243,97 @@ func (p *Pager) renderLines() renderedScreen {
 		}
 	}
 
+	if p.invertColors {
+		p.invertLines(allLines)
+	}
+
 	return renderedScreen{
 		lines:             allLines,
 		statusText:        inputLines.StatusText,
+		statusName:        inputLines.StatusName,
+		statusPosition:    inputLines.StatusPosition,
 		inputLines:        inputLines.Lines,
 		numberPrefixWidth: numberPrefixLength,
+		headerRowCount:    len(headerRows),
+		footerRowCount:    len(allLines) - len(headerRows) - scrollingRowCount,
 	}
 }
 
+// Swap foreground and background colors of all cells, all the way to the right
+// edge of the screen. The input lines are not affected.
+func (p *Pager) invertLines(lines []renderedLine) {
+	defaultFg, defaultBg := p.defaultColors()
+
+	screenWidth := p.contentWidth()
+	for i := range lines {
+		line := &lines[i]
+		for len(line.cells) < screenWidth {
+			line.cells = append(line.cells, textstyles.CellWithMetadata{Rune: ' ', Style: line.trailer})
+		}
+
+		for j := range line.cells {
+			line.cells[j].Style = line.cells[j].Style.Inverted(defaultFg, defaultBg)
+		}
+	}
+}
+
+// The colors to invert to for cells without colors of their own
+func (p *Pager) defaultColors() (foreground twin.Color, background twin.Color) {
+	foreground = plainTextStyle.Foreground()
+	background = plainTextStyle.Background()
+	if background == twin.ColorDefault {
+		terminalBackground := p.screen.TerminalBackground()
+		if terminalBackground != nil {
+			background = *terminalBackground
+		}
+	}
+
+	return foreground, background
+}
+
+// Background color for lines with search hits, or nil if those lines should
+// keep their normal background.
+func (p *Pager) lineBackgroundForSearchHits() *twin.Color {
+	if !p.SearchHitLineBackground {
+		return nil
+	}
+	return searchHitLineBackground
+}
+
+// Returns the highlighted cells, but styled like the plain ones. Search hit
+// markers are kept so that search hit navigation still works.
+func withoutSearchHitStyles(highlighted []textstyles.CellWithMetadata, plain []textstyles.CellWithMetadata) []textstyles.CellWithMetadata {
+	if len(highlighted) != len(plain) {
+		// Should never happen, but better safe than sorry
+		return highlighted
+	}
+
+	for i := range highlighted {
+		highlighted[i].Style = plain[i].Style
+	}
+	return highlighted
+}
+
+// Shown after the last row of a line cut off by MaxWrapRows
+const lineContinuesMarker = "… line continues"
+
+// How many rows to show of the given line, zero means no limit. The line with
+// the current search hit is shown in full, otherwise hits in the rows cut off by
+// MaxWrapRows could never be scrolled into view.
+func (p *Pager) maxWrapRows(lineIndex linemetadata.Index) int {
+	if p.currentSearchHit != nil && *p.currentSearchHit == lineIndex {
+		return 0
+	}
+	return p.MaxWrapRows
+}
+
+// True if the given screen line of an input line is the "line continues"
+// marker, shown instead of the rows cut off by MaxWrapRows
+func (p *Pager) isLineContinuesMarker(lineIndex linemetadata.Index, wrapIndex int) bool {
+	maxWrapRows := p.maxWrapRows(lineIndex)
+	return maxWrapRows > 0 && wrapIndex >= maxWrapRows
+}
+
 // Render one input line into one or more screen lines.
 //
 // The returned line is display ready, meaning that it comes with horizontal
@@ -177,16 +342,36 @@ func (p *Pager) renderLines() renderedScreen {
 // lineNumber and numberPrefixLength are required for knowing how much to
 // indent, and to (optionally) render the line number.
 func (p *Pager) renderLine(line *reader.NumberedLine, numberPrefixLength int) []renderedLine {
-	highlighted := line.HighlightedTokens(plainTextStyle, searchHitStyle, searchHitLineBackground, p.searchPattern)
+	return p.renderLineMaxRows(line, numberPrefixLength, p.maxWrapRows(line.Index))
+}
+
+// Like renderLine(), but showing at most maxWrapRows rows of the line. Zero
+// means no limit.
+func (p *Pager) renderLineMaxRows(line *reader.NumberedLine, numberPrefixLength int, maxWrapRows int) []renderedLine {
+	highlighted := p.highlightLine(line)
+
 	var wrapped []textstyles.CellWithMetadataSlice
-	if p.WrapLongLines {
-		width, _ := p.screen.Size()
-		wrapped = wrapLine(width-numberPrefixLength, highlighted.StyledRunes)
+	if p.isWrappingAsNeeded() && textstyles.CellWithMetadataSlice(highlighted.StyledRunes).Width() <= p.contentWidth()-numberPrefixLength {
+		// Fits on screen, no wrap margin needed
+		wrapped = []textstyles.CellWithMetadataSlice{highlighted.StyledRunes}
+	} else if p.WrapLongLines {
+		// Always leave room for at least one character per line
+		wrapWidth := max(p.contentWidth()-numberPrefixLength-p.WrapMargin, 1)
+		wrapped = wrapLine(wrapWidth, highlighted.StyledRunes)
 	} else {
 		// All on one line
 		wrapped = []textstyles.CellWithMetadataSlice{highlighted.StyledRunes}
 	}
 
+	cappedWrapping := maxWrapRows > 0 && len(wrapped) > maxWrapRows
+	if cappedWrapping {
+		// Don't let one line take over the whole screen
+		wrapped = wrapped[:maxWrapRows]
+	}
+
+	isNew := p.isNewLine(line, time.Now())
+	hasSearchHit := p.SearchHitGutterMarker.Rune != 0 && hasSearchHit(highlighted.StyledRunes)
+
 	rendered := make([]renderedLine, 0)
 	for wrapIndex, inputLinePart := range wrapped {
 		lineNumber := line.Number
@@ -195,7 +380,11 @@ func (p *Pager) renderLine(line *reader.NumberedLine, numberPrefixLength int) []
 			visibleLineNumber = nil
 		}
 
-		decorated := p.decorateLine(visibleLineNumber, numberPrefixLength, inputLinePart)
+		decorated := p.decorateLine(visibleLineNumber, numberPrefixLength, isNew, hasSearchHit, inputLinePart)
+		if wrapIndex > 0 && p.WrapContinuationMarker.Rune != 0 && numberPrefixLength >= 2 {
+			// Right aligned with the line numbers, before the separator column
+			decorated[numberPrefixLength-2] = p.WrapContinuationMarker
+		}
 
 		rendered = append(rendered, renderedLine{
 			inputLineIndex: line.Index,
@@ -210,17 +399,169 @@ func (p *Pager) renderLine(line *reader.NumberedLine, numberPrefixLength int) []
 		rendered[len(rendered)-1].trailer = highlighted.Trailer
 	}
 
+	if cappedWrapping {
+		marker := textstyles.CellWithMetadataSlice{}
+		for _, char := range lineContinuesMarker {
+			marker = append(marker, textstyles.CellWithMetadata{Rune: char, Style: lineNumbersStyle})
+		}
+
+		rendered = append(rendered, renderedLine{
+			inputLineIndex: line.Index,
+			wrapIndex:      len(wrapped),
+			cells:          p.decorateLine(nil, numberPrefixLength, false, false, marker),
+		})
+	}
+
 	return rendered
 }
 
+// The cells of one input line, styled and with search hits highlighted, before
+// wrapping and horizontal scrolling
+func (p *Pager) highlightLine(line *reader.NumberedLine) textstyles.StyledRunesWithTrailer {
+	var highlighted textstyles.StyledRunesWithTrailer
+	if p.isShowingTable() {
+		columns := line.HighlightedColumns(plainTextStyle, searchHitStyle, p.lineBackgroundForSearchHits(), p.searchPattern)
+		if p.NoSearchHighlight && p.searchPattern != nil {
+			plainColumns := line.HighlightedColumns(plainTextStyle, searchHitStyle, nil, nil)
+			for i := range columns {
+				columns[i] = withoutSearchHitStyles(columns[i], plainColumns[i])
+			}
+		}
+		highlighted = textstyles.StyledRunesWithTrailer{StyledRunes: p.alignTableRow(columns)}
+		p.restyle(highlighted.StyledRunes)
+	} else {
+		highlighted = line.HighlightedTokens(plainTextStyle, searchHitStyle, p.lineBackgroundForSearchHits(), p.searchPattern)
+		if p.NoSearchHighlight && p.searchPattern != nil {
+			plain := line.HighlightedTokens(plainTextStyle, searchHitStyle, nil, nil)
+			highlighted.StyledRunes = withoutSearchHitStyles(highlighted.StyledRunes, plain.StyledRunes)
+			highlighted.Trailer = plain.Trailer
+		}
+		p.restyle(highlighted.StyledRunes)
+		highlighted.StyledRunes = p.stripPrefix(line, highlighted.StyledRunes)
+		highlighted.StyledRunes = p.dedent(highlighted.StyledRunes)
+	}
+
+	return highlighted
+}
+
+// Apply ASCIILines and DetectURLs to the cells of a line
+func (p *Pager) restyle(cells []textstyles.CellWithMetadata) {
+	if p.ASCIILines {
+		textstyles.ToASCIILines(cells)
+	}
+	if p.DetectURLs {
+		textstyles.LinkifyURLs(cells)
+	}
+}
+
+// Hide the part of the line matching StripPrefix. The cells must come from the
+// same line, and map one-to-one to textstyles.PlainCells() of its plain text.
+func (p *Pager) stripPrefix(line *reader.NumberedLine, cells []textstyles.CellWithMetadata) []textstyles.CellWithMetadata {
+	if p.StripPrefix == nil {
+		return cells
+	}
+
+	plain := line.Plain()
+	match := p.StripPrefix.FindStringIndex(plain)
+	if match == nil || match[0] != 0 || match[1] == 0 {
+		return cells
+	}
+
+	strippedCount := len(textstyles.PlainCells(plain[:match[1]]))
+	if strippedCount > len(cells) {
+		// Should never happen, but better safe than sorry
+		strippedCount = len(cells)
+	}
+
+	hiddenSearchHit := false
+	for _, cell := range cells[:strippedCount] {
+		if cell.StartsSearchHit {
+			hiddenSearchHit = true
+			break
+		}
+	}
+
+	stripped := cells[strippedCount:]
+	if hiddenSearchHit {
+		// Don't hide search hits completely
+		marker := textstyles.CellWithMetadata{Rune: '…', Style: searchHitStyle, StartsSearchHit: true}
+		if p.NoSearchHighlight {
+			marker.Style = lineNumbersStyle
+		}
+		return append([]textstyles.CellWithMetadata{marker}, stripped...)
+	}
+	if p.ShowStrippedPrefixMarker {
+		marker := textstyles.CellWithMetadata{Rune: '…', Style: lineNumbersStyle}
+		return append([]textstyles.CellWithMetadata{marker}, stripped...)
+	}
+
+	return stripped
+}
+
+// True if any of the cells starts a search hit
+func hasSearchHit(cells []textstyles.CellWithMetadata) bool {
+	for _, cell := range cells {
+		if cell.StartsSearchHit {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the number of leading spaces shared by all the lines, not counting
+// whitespace-only lines. Tabs count as the spaces they expand into.
+func (p *Pager) commonIndent(lines []*reader.NumberedLine) int {
+	common := -1
+	for _, line := range lines {
+		cells := line.HighlightedTokens(plainTextStyle, searchHitStyle, nil, nil).StyledRunes
+		cells = p.stripPrefix(line, cells)
+
+		indent := leadingSpaceCount(cells)
+		if indent == len(cells) {
+			// Blank lines don't count
+			continue
+		}
+
+		if common == -1 || indent < common {
+			common = indent
+		}
+	}
+
+	return max(common, 0)
+}
+
+func leadingSpaceCount(cells []textstyles.CellWithMetadata) int {
+	for i, cell := range cells {
+		if cell.Rune != ' ' || cell.StartsSearchHit {
+			return i
+		}
+	}
+	return len(cells)
+}
+
+// With Dedent, hide the indentation shared by all visible lines. Only the
+// display is affected, searching and copying use the real text.
+func (p *Pager) dedent(cells []textstyles.CellWithMetadata) []textstyles.CellWithMetadata {
+	if p.dedentWidth == 0 {
+		return cells
+	}
+
+	return cells[min(p.dedentWidth, leadingSpaceCount(cells)):]
+}
+
 // Take a rendered line and decorate as needed:
 //   - Line number, or leading whitespace for wrapped lines
+//   - Search hit gutter marker, see SearchHitGutterMarker
 //   - Scroll left indicator
 //   - Scroll right indicator
-func (p *Pager) decorateLine(lineNumberToShow *linemetadata.Number, numberPrefixLength int, contents []textstyles.CellWithMetadata) []textstyles.CellWithMetadata {
-	width, _ := p.screen.Size()
+func (p *Pager) decorateLine(lineNumberToShow *linemetadata.Number, numberPrefixLength int, isNew bool, hasSearchHit bool, contents []textstyles.CellWithMetadata) []textstyles.CellWithMetadata {
+	width := p.contentWidth()
 	newLine := make([]textstyles.CellWithMetadata, 0, width)
-	newLine = append(newLine, createLinePrefix(lineNumberToShow, numberPrefixLength)...)
+	newLine = append(newLine, p.createLinePrefix(lineNumberToShow, numberPrefixLength, isNew)...)
+	if hasSearchHit && lineNumberToShow != nil && len(newLine) > 0 {
+		// Replace the space after the line number
+		newLine[len(newLine)-1] = p.SearchHitGutterMarker
+	}
 
 	// Find the first and last fully visible runes.
 	var firstVisibleRuneIndex *int
@@ -279,9 +620,9 @@ func (p *Pager) decorateLine(lineNumberToShow *linemetadata.Number, numberPrefix
 		newLine = append(newLine, contents[*firstVisibleRuneIndex:lastVisibleRuneIndex+1]...)
 	}
 
-	// Append a space if we had to cut a rune in half at the end
+	// Replace the rune we had to cut in half at the end
 	if cutOffRuneToTheRight {
-		newLine = append(newLine, textstyles.CellWithMetadata{Rune: ' ', Style: p.ScrollRightHint.Style})
+		newLine = append(newLine, p.wideRuneAtEdgeReplacement())
 	}
 
 	// Add scroll left indicator
@@ -310,11 +651,11 @@ func (p *Pager) decorateLine(lineNumberToShow *linemetadata.Number, numberPrefix
 	// Add scroll right indicator
 	if canScrollRight {
 		if newLine[len(newLine)-1].Width() > 1 {
-			// Replace the last rune with two spaces so we can replace the
+			// Replace the last rune with two cells so we can replace the
 			// rightmost cell with a scroll right indicator. First, convert to one
-			// space...
-			newLine[len(newLine)-1] = textstyles.CellWithMetadata{Rune: ' ', Style: p.ScrollRightHint.Style}
-			// ...then append another space:
+			// cell...
+			newLine[len(newLine)-1] = p.wideRuneAtEdgeReplacement()
+			// ...then append another one:
 			newLine = append(newLine, textstyles.CellWithMetadata{Rune: ' ', Style: p.ScrollRightHint.Style})
 		}
 
@@ -324,10 +665,19 @@ func (p *Pager) decorateLine(lineNumberToShow *linemetadata.Number, numberPrefix
 	return newLine
 }
 
+// What to show instead of a wide rune cut off by the right edge, the same way
+// twin.Screen.SetCell() does it.
+func (p *Pager) wideRuneAtEdgeReplacement() textstyles.CellWithMetadata {
+	replacement := p.WideRuneAtEdge.Replacement(twin.NewStyledRune(' ', p.ScrollRightHint.Style))
+	return textstyles.CellWithMetadata{Rune: replacement.Rune, Style: replacement.Style}
+}
+
 // Generate a line number prefix of the given length.
 //
 // Can be empty or all-whitespace depending on parameters.
-func createLinePrefix(lineNumber *linemetadata.Number, numberPrefixLength int) []textstyles.CellWithMetadata {
+// If isNew is true, the line number is followed by a newLineMarker rather than
+// a space.
+func (p *Pager) createLinePrefix(lineNumber *linemetadata.Number, numberPrefixLength int, isNew bool) []textstyles.CellWithMetadata {
 	if numberPrefixLength == 0 {
 		return []textstyles.CellWithMetadata{}
 	}
@@ -340,7 +690,11 @@ func createLinePrefix(lineNumber *linemetadata.Number, numberPrefixLength int) [
 		return lineNumberPrefix
 	}
 
-	lineNumberString := fmt.Sprintf("%*s ", numberPrefixLength-1, lineNumber.Format())
+	separator := ' '
+	if isNew {
+		separator = newLineMarker
+	}
+	lineNumberString := fmt.Sprintf("%*s%c", numberPrefixLength-1, p.formatLineNumber(*lineNumber), separator)
 	if len(lineNumberString) > numberPrefixLength {
 		panic(fmt.Errorf(
 			"lineNumberString <%s> longer than numberPrefixLength %d",
@@ -20,7 +20,7 @@ import (
 func renderedToString(row []textstyles.CellWithMetadata) string {
 	rowString := ""
 	for _, cell := range row {
-		rowString += string(cell.Rune)
+		rowString += string(cell.Rune) + cell.Combining
 	}
 
 	return strings.TrimRight(rowString, " ")
@@ -87,6 +87,36 @@ func TestCreateScreenLineChopWideCharRight(t *testing.T) {
 	testHorizontalCropping(t, "上午下", 0, 1, " >")
 }
 
+func TestCreateScreenLineWideRuneAtEdge(t *testing.T) {
+	pager := NewPager(nil)
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.scrollPosition = newScrollPosition("TestCreateScreenLineWideRuneAtEdge")
+
+	lineContents := reader.NewLine("上午下")
+	numberedLine := reader.NumberedLine{
+		Line: &lineContents,
+	}
+
+	for option, expected := range map[twin.WideRuneAtEdgeOption]textstyles.CellWithMetadata{
+		twin.WideRuneAtEdgeSpace: {Rune: ' ', Style: pager.ScrollRightHint.Style},
+		twin.WideRuneAtEdgeBlank: {Rune: ' ', Style: twin.StyleDefault},
+		twin.WideRuneAtEdgeHint:  {Rune: '…', Style: pager.ScrollRightHint.Style},
+	} {
+		pager.WideRuneAtEdge = option
+
+		// The last wide rune has to make room for the scroll right indicator
+		for _, width := range []int{2, 4} {
+			pager.screen = twin.NewFakeScreen(width, 99)
+
+			screenLine := pager.renderLine(&numberedLine, pager.getLineNumberPrefixLength(numberedLine.Number))
+			cells := screenLine[0].cells
+			assert.Equal(t, cells[len(cells)-2], expected, "Option %d, width %d", option, width)
+			assert.Equal(t, cells[len(cells)-1].Rune, '>')
+		}
+	}
+}
+
 func TestEmpty(t *testing.T) {
 	pager := Pager{
 		screen: twin.NewFakeScreen(99, 10),
@@ -142,6 +172,123 @@ func TestSearchHighlight(t *testing.T) {
 	)
 }
 
+// All hits on a line should be highlighted, not just the first one
+func TestSearchHighlightAllHits(t *testing.T) {
+	line := reader.NewLine("axbxxc")
+	pager := Pager{
+		screen:        twin.NewFakeScreen(100, 10),
+		searchPattern: regexp.MustCompile("x+"),
+	}
+
+	numberedLine := reader.NumberedLine{
+		Line: &line,
+	}
+	rendered := pager.renderLine(&numberedLine, pager.getLineNumberPrefixLength(numberedLine.Number))
+
+	hitStyle := twin.StyleDefault.WithAttr(twin.AttrReverse)
+	cells := rendered[0].cells
+	assert.Equal(t, cells[0].Style, twin.StyleDefault)
+	assert.Equal(t, cells[1].Style, hitStyle)
+	assert.Assert(t, cells[1].StartsSearchHit)
+	assert.Equal(t, cells[2].Style, twin.StyleDefault)
+	assert.Equal(t, cells[3].Style, hitStyle)
+	assert.Assert(t, cells[3].StartsSearchHit)
+	assert.Equal(t, cells[4].Style, hitStyle)
+	assert.Assert(t, !cells[4].StartsSearchHit)
+	assert.Equal(t, cells[5].Style, twin.StyleDefault)
+}
+
+func TestNoSearchHighlight(t *testing.T) {
+	line := reader.NewLine("axbxxc")
+	pager := Pager{
+		screen:            twin.NewFakeScreen(100, 10),
+		searchPattern:     regexp.MustCompile("x+"),
+		NoSearchHighlight: true,
+	}
+
+	numberedLine := reader.NumberedLine{
+		Line: &line,
+	}
+	rendered := pager.renderLine(&numberedLine, pager.getLineNumberPrefixLength(numberedLine.Number))
+
+	hitStarts := 0
+	for _, cell := range rendered[0].cells {
+		assert.Equal(t, cell.Style, twin.StyleDefault)
+		if cell.StartsSearchHit {
+			hitStarts++
+		}
+	}
+
+	// Needed for search hit navigation
+	assert.Equal(t, hitStarts, 2)
+}
+
+func TestSearchHitLineBackground(t *testing.T) {
+	background := twin.NewColor16(4)
+	searchHitLineBackground = &background
+	defer func() { searchHitLineBackground = nil }()
+
+	line := reader.NewLine("axb")
+	numberedLine := reader.NumberedLine{
+		Line: &line,
+	}
+	pager := Pager{
+		screen:        twin.NewFakeScreen(100, 10),
+		searchPattern: regexp.MustCompile("x"),
+	}
+
+	// Only the hit itself should be highlighted by default
+	rendered := pager.renderLine(&numberedLine, 0)
+	assert.Equal(t, rendered[0].cells[0].Style, twin.StyleDefault)
+	assert.Equal(t, rendered[0].cells[1].Style, searchHitStyle)
+	assert.Equal(t, rendered[0].cells[2].Style, twin.StyleDefault)
+
+	pager.SearchHitLineBackground = true
+	rendered = pager.renderLine(&numberedLine, 0)
+	assert.Equal(t, rendered[0].cells[0].Style, twin.StyleDefault.WithBackground(background))
+	assert.Equal(t, rendered[0].cells[1].Style, searchHitStyle)
+	assert.Equal(t, rendered[0].cells[2].Style, twin.StyleDefault.WithBackground(background))
+}
+
+func TestSearchHitGutterMarker(t *testing.T) {
+	reader := reader.NewFromTextForTesting("TestSearchHitGutterMarker", "miss\nhit\nmiss\nhit")
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 10)
+	pager.SearchHitGutterMarker = textstyles.CellWithMetadata{Rune: '*', Style: twin.StyleDefault.WithAttr(twin.AttrBold)}
+	pager.searchPattern = regexp.MustCompile("hit")
+	assert.NilError(t, reader.Wait())
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "  1 miss")
+	assert.Equal(t, renderedToString(rendered[1].cells), "  2*hit")
+	assert.Equal(t, renderedToString(rendered[2].cells), "  3 miss")
+	assert.Equal(t, renderedToString(rendered[3].cells), "  4*hit")
+	assert.Equal(t, rendered[1].cells[3], pager.SearchHitGutterMarker)
+
+	// Still there when scrolled sideways away from the hit
+	pager.leftColumnZeroBased = 10
+	rendered = pager.renderLines().lines
+	assert.Equal(t, rendered[0].cells[3].Rune, ' ')
+	assert.Equal(t, rendered[1].cells[3].Rune, '*')
+}
+
+func TestWrapContinuationMarker(t *testing.T) {
+	reader := reader.NewFromTextForTesting("TestWrapContinuationMarker", "short\nthis line wraps into three rows\nshort")
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(16, 10)
+	pager.WrapLongLines = true
+	pager.WrapContinuationMarker = textstyles.CellWithMetadata{Rune: '↪', Style: twin.StyleDefault.WithAttr(twin.AttrDim)}
+	assert.NilError(t, reader.Wait())
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "  1 short")
+	assert.Equal(t, renderedToString(rendered[1].cells), "  2 this line")
+	assert.Equal(t, renderedToString(rendered[2].cells), "  ↪ wraps into")
+	assert.Equal(t, renderedToString(rendered[3].cells), "  ↪ three rows")
+	assert.Equal(t, renderedToString(rendered[4].cells), "  3 short")
+	assert.Equal(t, rendered[2].cells[2], pager.WrapContinuationMarker)
+}
+
 func TestOverflowDown(t *testing.T) {
 	pager := Pager{
 		screen: twin.NewFakeScreen(
@@ -356,3 +503,186 @@ func BenchmarkRenderLines(b *testing.B) {
 		pager.renderLines()
 	}
 }
+
+func TestInvertColors(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "a\x1b[7mb"))
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(5, 3)
+
+	pager.invertColors = true
+	rendered := pager.renderLines().lines
+	assert.Equal(t, len(rendered), 1)
+
+	// Inverted all the way to the right edge of the screen
+	cells := rendered[0].cells
+	assert.Equal(t, len(cells), 5)
+	assert.Equal(t, cells[0].Style, twin.StyleDefault.WithAttr(twin.AttrReverse))
+	assert.Equal(t, cells[1].Style, twin.StyleDefault, "Already reversed text should become un-reversed")
+	assert.Equal(t, cells[4].Style, twin.StyleDefault.WithAttr(twin.AttrReverse))
+
+	pager.invertColors = false
+	rendered = pager.renderLines().lines
+	assert.Equal(t, rendered[0].cells[0].Style, twin.StyleDefault)
+	assert.Equal(t, rendered[0].cells[1].Style, twin.StyleDefault.WithAttr(twin.AttrReverse))
+}
+
+func TestStripPrefix(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("",
+		"2025-01-01 12:00:00 INFO hello\n2025-01-01 12:00:01 WARN world\nno timestamp"))
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(40, 10)
+	pager.StripPrefix = regexp.MustCompile(`^[0-9-]+ [0-9:]+ `)
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "INFO hello")
+	assert.Equal(t, renderedToString(rendered[1].cells), "WARN world")
+	assert.Equal(t, renderedToString(rendered[2].cells), "no timestamp")
+
+	// Searching should still see the hidden prefix, and mark where it is
+	pager.searchPattern = regexp.MustCompile("12:00:01")
+	rendered = pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "INFO hello")
+	assert.Equal(t, renderedToString(rendered[1].cells), "…WARN world")
+	assert.Assert(t, rendered[1].cells[0].StartsSearchHit)
+	assert.Assert(t, pager.searchHitIsVisible())
+
+	// Search hits after the hidden prefix should still be highlighted
+	pager.searchPattern = regexp.MustCompile("world")
+	rendered = pager.renderLines().lines
+	assert.Assert(t, rendered[1].cells[len("WARN ")].StartsSearchHit)
+}
+
+// Combining marks share a cell with the character before them, so the prefix
+// covers fewer cells than it has runes
+func TestStripPrefixCombining(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "cafe\u0301: hello"))
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(40, 10)
+	pager.StripPrefix = regexp.MustCompile(`^[^:]+: `)
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "hello")
+}
+
+func TestDedent(t *testing.T) {
+	reader := reader.NewFromTextForTesting("",
+		"        if x {\n\n            return\n\t}\n")
+	pager := NewPager(reader)
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(40, 10)
+	pager.Dedent = true
+	assert.NilError(t, reader.Wait())
+
+	lines := pager.Reader().GetLines(linemetadata.Index{}, 4).Lines
+	assert.Equal(t, pager.commonIndent(lines), 8, "The tab should count as eight spaces")
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "if x {")
+	assert.Equal(t, renderedToString(rendered[1].cells), "")
+	assert.Equal(t, renderedToString(rendered[2].cells), "    return")
+	assert.Equal(t, renderedToString(rendered[3].cells), "}")
+
+	// The real text should still be there for searching and copying
+	assert.Equal(t, lines[0].Plain(), "        if x {")
+	assert.Equal(t, lines[3].Line.Raw(), "\t}")
+}
+
+func TestStripPrefixMarker(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "com.example.Logger: hello\nno prefix"))
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(40, 10)
+	pager.StripPrefix = regexp.MustCompile(`^[a-z.]+\.[A-Za-z]+: `)
+	pager.ShowStrippedPrefixMarker = true
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "…hello")
+	assert.Equal(t, renderedToString(rendered[1].cells), "no prefix")
+}
+
+func TestWrapMargin(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "abcdefghijklmno"))
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(10, 10)
+	pager.WrapLongLines = true
+	pager.WrapMargin = 3
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, len(rendered), 3)
+	assert.Equal(t, renderedToString(rendered[0].cells), "abcdefg")
+	assert.Equal(t, renderedToString(rendered[1].cells), "hijklmn")
+	assert.Equal(t, renderedToString(rendered[2].cells), "o")
+
+	// The line number column should come out of the wrap width as well
+	pager.ShowLineNumbers = true
+	pager.showLineNumbers = true
+	rendered = pager.renderLines().lines
+	assert.Equal(t, len(rendered), 5)
+	assert.Equal(t, renderedToString(rendered[0].cells), "  1 abc")
+	assert.Equal(t, renderedToString(rendered[1].cells), "    def")
+
+	// A margin wider than the screen should still show something
+	pager.WrapMargin = 100
+	rendered = pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "  1 a")
+}
+
+func TestMaxWrapRows(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", strings.Repeat("01234567890123456789", 10)+"\nnext"))
+	pager.screen = twin.NewFakeScreen(24, 12)
+	pager.WrapLongLines = true
+	pager.MaxWrapRows = 3
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, len(rendered), 5)
+	assert.Equal(t, renderedToString(rendered[0].cells), "  1 01234567890123456789")
+	assert.Equal(t, renderedToString(rendered[1].cells), "    01234567890123456789")
+	assert.Equal(t, renderedToString(rendered[2].cells), "    01234567890123456789")
+	assert.Equal(t, renderedToString(rendered[3].cells), "    … line continues")
+	assert.Equal(t, renderedToString(rendered[4].cells), "  2 next")
+
+	// Lines not exceeding the limit should not get any marker
+	pager.MaxWrapRows = 10
+	rendered = pager.renderLines().lines
+	assert.Equal(t, len(rendered), 11)
+	assert.Equal(t, renderedToString(rendered[9].cells), "    01234567890123456789")
+	assert.Equal(t, renderedToString(rendered[10].cells), "  2 next")
+}
+
+func TestWrapAsNeeded(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "short\nabcdefghij\nabcdefghijklmno"))
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.screen = twin.NewFakeScreen(10, 10)
+	pager.WrapLongLines = true
+	pager.WrapAsNeeded = true
+	pager.WrapMargin = 3
+
+	// Lines that fit should not be affected by the margin, long lines should
+	// be wrapped with it
+	rendered := pager.renderLines().lines
+	assert.Equal(t, len(rendered), 5)
+	assert.Equal(t, renderedToString(rendered[0].cells), "short")
+	assert.Equal(t, renderedToString(rendered[1].cells), "abcdefghij")
+	assert.Equal(t, renderedToString(rendered[2].cells), "abcdefg")
+	assert.Equal(t, renderedToString(rendered[3].cells), "hijklmn")
+	assert.Equal(t, renderedToString(rendered[4].cells), "o")
+
+	// No sideways scrolling while wrapping as needed
+	pager.moveRight(5)
+	assert.Equal(t, pager.leftColumnZeroBased, 0)
+
+	// Until wrapping is turned off
+	pager.mode.onRune('w')
+	assert.Assert(t, !pager.WrapLongLines)
+	pager.moveRight(5)
+	assert.Equal(t, pager.leftColumnZeroBased, 5)
+	rendered = pager.renderLines().lines
+	assert.Equal(t, len(rendered), 3)
+	assert.Equal(t, renderedToString(rendered[2].cells), "<ghijklmno")
+}
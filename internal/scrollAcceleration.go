@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/walles/moor/v2/twin"
+)
+
+// ScrollAcceleration makes the up / down arrow keys scroll faster the longer
+// they are held down.
+type ScrollAcceleration struct {
+	// Presses of the same key closer together than this are considered to be
+	// the key being held down. Slower presses always scroll one line.
+	RepeatInterval time.Duration
+
+	// While a key is held down, the scroll step grows by one line for every
+	// this many repeats.
+	RepeatsPerLine int
+
+	// The scroll step will never be larger than this. One or less disables
+	// acceleration.
+	MaxStep int
+}
+
+// Tracks for how long a scroll key has been held down
+type scrollAccelerationState struct {
+	lastKey  twin.KeyCode
+	lastTime time.Time
+	repeats  int
+}
+
+// How many lines a press of keyCode at the given time should scroll.
+func (p *Pager) scrollStep(keyCode twin.KeyCode, now time.Time) int {
+	acceleration := p.ScrollAcceleration
+	state := &p.scrollAccelerationState
+
+	isHeld := keyCode == state.lastKey && now.Sub(state.lastTime) <= acceleration.RepeatInterval
+	if isHeld {
+		state.repeats++
+	} else {
+		// Single step taps should stay precise
+		state.repeats = 0
+	}
+	state.lastKey = keyCode
+	state.lastTime = now
+
+	if acceleration.MaxStep <= 1 {
+		return 1
+	}
+
+	repeatsPerLine := max(acceleration.RepeatsPerLine, 1)
+	return min(1+state.repeats/repeatsPerLine, acceleration.MaxStep)
+}
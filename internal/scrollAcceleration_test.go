@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestScrollAcceleration(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "x"))
+	pager.ScrollAcceleration = ScrollAcceleration{
+		RepeatInterval: 50 * time.Millisecond,
+		RepeatsPerLine: 2,
+		MaxStep:        3,
+	}
+
+	// A burst of down arrow presses 30ms apart
+	now := time.Now()
+	steps := []int{}
+	for range 8 {
+		steps = append(steps, pager.scrollStep(twin.KeyDown, now))
+		now = now.Add(30 * time.Millisecond)
+	}
+	assert.DeepEqual(t, steps, []int{1, 1, 2, 2, 3, 3, 3, 3})
+
+	// Changing direction starts over
+	assert.Equal(t, pager.scrollStep(twin.KeyUp, now), 1)
+
+	// So does pausing
+	now = now.Add(30 * time.Millisecond)
+	assert.Equal(t, pager.scrollStep(twin.KeyUp, now), 1)
+	now = now.Add(30 * time.Millisecond)
+	assert.Equal(t, pager.scrollStep(twin.KeyUp, now), 2)
+	now = now.Add(time.Second)
+	assert.Equal(t, pager.scrollStep(twin.KeyUp, now), 1)
+}
+
+func TestScrollAccelerationDisabledByDefault(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "x"))
+
+	now := time.Now()
+	for range 20 {
+		assert.Equal(t, pager.scrollStep(twin.KeyDown, now), 1)
+		now = now.Add(time.Millisecond)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
 )
 
 // Please create using newScrollPosition(name)
@@ -37,11 +38,18 @@ type scrollPositionInternal struct {
 
 // If any of these change, we have to recompute the scrollPositionInternal values
 type scrollPositionCanonical struct {
-	width           int  // From pager
-	height          int  // From pager
-	showLineNumbers bool // From pager
-	showStatusBar   bool // From pager
-	wrapLongLines   bool // From pager
+	width           int                 // From pager
+	height          int                 // From pager
+	showLineNumbers bool                // From pager
+	showStatusBar   bool                // From pager
+	wrapLongLines   bool                // From pager
+	wrapMargin      int                 // From pager
+	wrapAsNeeded    bool                // From pager
+	maxWrapRows     int                 // From pager
+	uncappedLine    *linemetadata.Index // From pager.currentSearchHit
+	scrollPastEnd   bool                // From pager
+	firstLineIndex  linemetadata.Index  // From pager.firstScrollableLineIndex()
+	scrollableCount int                 // From pager.scrollableLineCount()
 
 	pagerLineCount int // From pager.Reader().GetLineCount()
 
@@ -50,7 +58,7 @@ type scrollPositionCanonical struct {
 }
 
 func canonicalFromPager(pager *Pager) scrollPositionCanonical {
-	width, _ := pager.screen.Size()
+	width := pager.contentWidth()
 	height := pager.visibleHeight()
 	return scrollPositionCanonical{
 		width:           width,
@@ -58,6 +66,13 @@ func canonicalFromPager(pager *Pager) scrollPositionCanonical {
 		showLineNumbers: pager.showLineNumbers,
 		showStatusBar:   pager.ShowStatusBar,
 		wrapLongLines:   pager.WrapLongLines,
+		wrapMargin:      pager.WrapMargin,
+		wrapAsNeeded:    pager.WrapAsNeeded,
+		maxWrapRows:     pager.MaxWrapRows,
+		uncappedLine:    pager.currentSearchHit,
+		scrollPastEnd:   pager.ScrollPastEnd,
+		firstLineIndex:  pager.firstScrollableLineIndex(),
+		scrollableCount: pager.scrollableLineCount(),
 
 		pagerLineCount: pager.Reader().GetLineCount(),
 
@@ -126,7 +141,8 @@ func NewScrollPositionFromIndex(index linemetadata.Index, name string) scrollPos
 
 // Move towards the top until deltaScreenLines is not negative any more
 func (si *scrollPositionInternal) handleNegativeDeltaScreenLines(pager *Pager) {
-	for !si.lineIndex.IsZero() && si.deltaScreenLines < 0 {
+	firstLineIndex := pager.firstScrollableLineIndex()
+	for si.lineIndex.IsAfter(firstLineIndex) && si.deltaScreenLines < 0 {
 		// Render the previous line
 		previousLineIndex := si.lineIndex.NonWrappingAdd(-1)
 		previousLine := pager.Reader().GetLine(previousLineIndex)
@@ -142,7 +158,7 @@ func (si *scrollPositionInternal) handleNegativeDeltaScreenLines(pager *Pager) {
 		si.deltaScreenLines += previousSubLinesCount
 	}
 
-	if si.lineIndex.IsZero() && si.deltaScreenLines <= 0 {
+	if !si.lineIndex.IsAfter(firstLineIndex) && si.deltaScreenLines <= 0 {
 		// Can't go any higher
 		si.deltaScreenLines = 0
 		return
@@ -156,12 +172,16 @@ func (si *scrollPositionInternal) handleNegativeDeltaScreenLines(pager *Pager) {
 // the position is too far down to display after this returns.
 func (si *scrollPositionInternal) handlePositiveDeltaScreenLines(pager *Pager) {
 	maxPrefixLength := si.getMaxNumberPrefixLength(pager)
+	scrollableLineCount := pager.scrollableLineCount()
 
 	for {
-		line := pager.Reader().GetLine(*si.lineIndex)
+		var line *reader.NumberedLine
+		if si.lineIndex.IsWithinLength(scrollableLineCount) {
+			line = pager.Reader().GetLine(*si.lineIndex)
+		}
 		if line == nil {
 			// Out of bounds downwards, get the last line...
-			si.lineIndex = linemetadata.IndexFromLength(pager.Reader().GetLineCount())
+			si.lineIndex = linemetadata.IndexFromLength(scrollableLineCount)
 			line = pager.Reader().GetLine(*si.lineIndex)
 			if line == nil {
 				panic(fmt.Errorf("Last line is nil"))
@@ -204,8 +224,9 @@ func (si *scrollPositionInternal) emptyBottomLinesCount(pager *Pager) int {
 	lineIndex := *si.lineIndex
 
 	lastLineNumberWidth := si.getMaxNumberPrefixLength(pager)
+	scrollableLineCount := pager.scrollableLineCount()
 
-	for {
+	for lineIndex.IsWithinLength(scrollableLineCount) {
 		line := pager.Reader().GetLine(lineIndex)
 		if line == nil {
 			// No more lines!
@@ -264,13 +285,21 @@ func (si *scrollPositionInternal) canonicalize(pager *Pager) {
 		return
 	}
 
-	if si.lineIndex == nil {
-		// We have lines, but no line number, start at the top
-		si.lineIndex = &linemetadata.Index{}
+	firstLineIndex := pager.firstScrollableLineIndex()
+	if si.lineIndex == nil || si.lineIndex.IsBefore(firstLineIndex) {
+		// We have lines, but no line number or one hidden by the sticky
+		// header, start at the top
+		si.lineIndex = &firstLineIndex
 	}
 
 	si.handleNegativeDeltaScreenLines(pager)
 	si.handlePositiveDeltaScreenLines(pager)
+	if pager.ScrollPastEnd {
+		// Empty lines below the last one are fine, and we have already made
+		// sure the last line is on screen
+		return
+	}
+
 	emptyBottomLinesCount := si.emptyBottomLinesCount(pager)
 	if emptyBottomLinesCount > 0 {
 		// First, adjust deltaScreenLines to get us to the top
@@ -290,6 +319,14 @@ func scrollPositionFromIndex(name string, index linemetadata.Index) *scrollPosit
 	}
 }
 
+// Like scrollPositionFromIndex(), but starting at some wrapped screen line of
+// the input line
+func scrollPositionFromWrapIndex(name string, index linemetadata.Index, wrapIndex int) *scrollPosition {
+	position := scrollPositionFromIndex(name, index)
+	position.internalDontTouch.deltaScreenLines = wrapIndex
+	return position
+}
+
 // Line index in the input stream, or nil if nothing has been read
 func (p *Pager) lineIndex() *linemetadata.Index {
 	p.scrollPosition.internalDontTouch.canonicalize(p)
@@ -310,8 +347,16 @@ func (p *Pager) deltaScreenLines() int {
 	return p.scrollPosition.internalDontTouch.deltaScreenLines
 }
 
+// Scroll this many screen lines into the input line before rendering
+//
+// Always >= 0.
+func (sp *scrollPosition) deltaScreenLines(pager *Pager) int {
+	sp.internalDontTouch.canonicalize(pager)
+	return sp.internalDontTouch.deltaScreenLines
+}
+
 func (p *Pager) scrollToEnd() {
-	inputLineCount := p.Reader().GetLineCount()
+	inputLineCount := p.scrollableLineCount()
 	if inputLineCount == 0 {
 		return
 	}
@@ -325,6 +370,13 @@ func (p *Pager) scrollToEnd() {
 	// lines than the number of characters it contains.
 	p.scrollPosition.internalDontTouch.deltaScreenLines = len(lastInputLine.Line.Plain(&lastInputLine.Index))
 
+	if p.ScrollPastEnd {
+		// That put the last line at the top of the screen, but we want it at
+		// the bottom
+		p.scrollPosition.lineIndex(p)
+		p.scrollPosition = p.scrollPosition.PreviousLine(p.visibleHeight() - 1)
+	}
+
 	if p.TargetLine == nil {
 		// Start following the end of the file
 		//
@@ -337,15 +389,18 @@ func (p *Pager) scrollToEnd() {
 
 // Can be either because Pager.scrollToEnd() was just called or because the user
 // has pressed the down arrow enough times.
+//
+// With ScrollPastEnd, we are at the end as soon as all of the last line is
+// visible. Scrolling further down only adds empty space below it.
 func (p *Pager) isScrolledToEnd() bool {
-	inputLineCount := p.Reader().GetLineCount()
+	inputLineCount := p.scrollableLineCount()
 	if inputLineCount == 0 {
 		// No lines available, which means we can't scroll any further down
 		return true
 	}
 	lastInputLineIndex := *linemetadata.IndexFromLength(inputLineCount)
 
-	visibleLines := p.renderLines().lines
+	visibleLines := p.renderLines().scrollingLines()
 	lastVisibleLine := visibleLines[len(visibleLines)-1]
 	if lastVisibleLine.inputLineIndex != lastInputLineIndex {
 		// Last input line is not on the screen
@@ -365,12 +420,12 @@ func (p *Pager) isScrolledToEnd() bool {
 
 // Returns nil if there are no lines
 func (p *Pager) getLastVisiblePosition() *scrollPosition {
-	rendered := p.renderLines()
-	if len(rendered.lines) == 0 {
+	rendered := p.renderLines().scrollingLines()
+	if len(rendered) == 0 {
 		return nil
 	}
 
-	lastRenderedLine := rendered.lines[len(rendered.lines)-1]
+	lastRenderedLine := rendered[len(rendered)-1]
 	return &scrollPosition{
 		internalDontTouch: scrollPositionInternal{
 			name:             "Last Visible Position",
@@ -98,3 +98,42 @@ func TestFastScrollAcross1000DoesNotPanic(t *testing.T) {
 	assert.Assert(t, rendered.lines != nil) // sanity
 	_ = rendered.statusText                 // not asserted here; we only care about not panicking
 }
+
+func testScrollPastEnd(t *testing.T, scrollPastEnd bool, expectedTopIndex int) {
+	lines := []string{}
+	for i := range 20 {
+		lines = append(lines, fmt.Sprint("line ", i))
+	}
+	reader := reader.NewFromTextForTesting("testScrollPastEnd", strings.Join(lines, "\n"))
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 5) // Four lines and the status bar
+	pager.ScrollPastEnd = scrollPastEnd
+	assert.NilError(t, reader.Wait())
+
+	// Scrolling to the end puts the last line at the bottom either way
+	pager.scrollToEnd()
+	assert.Equal(t, pager.lineIndex().Index(), 16)
+	assert.Assert(t, pager.isScrolledToEnd())
+	assert.Equal(t, pager.getLastVisiblePosition().internalDontTouch.lineIndex.Index(), 19)
+
+	// Try scrolling way further down
+	pager.scrollPosition = pager.scrollPosition.NextLine(100)
+	assert.Equal(t, pager.lineIndex().Index(), expectedTopIndex)
+	assert.Assert(t, pager.isScrolledToEnd())
+	assert.Equal(t, pager.getLastVisiblePosition().internalDontTouch.lineIndex.Index(), 19)
+
+	// One line up from there, the last line should still be visible
+	pager.scrollPosition = pager.scrollPosition.PreviousLine(1)
+	assert.Equal(t, pager.lineIndex().Index(), expectedTopIndex-1)
+	assert.Equal(t, pager.isScrolledToEnd(), scrollPastEnd)
+}
+
+func TestScrollPastEnd(t *testing.T) {
+	// The last line ends up at the top of the screen
+	testScrollPastEnd(t, true, 19)
+}
+
+func TestNoScrollPastEnd(t *testing.T) {
+	// The last line stays at the bottom of the screen
+	testScrollPastEnd(t, false, 16)
+}
@@ -2,9 +2,10 @@ package internal
 
 import (
 	"fmt"
-	"regexp"
+	"math"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -12,6 +13,12 @@ import (
 	"github.com/walles/moor/v2/internal/reader"
 )
 
+// Lines shorter than this are searched without any timeout. Starting a
+// goroutine for every line would slow down searching too much.
+//
+//revive:disable-next-line:var-naming
+const SEARCH_LINE_TIMEOUT_MIN_LENGTH = 100_000
+
 // Scroll to the next search hit, while the user is typing the search string.
 func (p *Pager) scrollToSearchHits() {
 	if p.searchPattern == nil {
@@ -24,6 +31,11 @@ func (p *Pager) scrollToSearchHits() {
 		return
 	}
 
+	if p.MinimalSearchScroll && p.scrollMinimallyToSearchHits() {
+		// Found it on screen, a bit to the side
+		return
+	}
+
 	if p.scrollRightToSearchHits() {
 		// Found it to the right, done!
 		return
@@ -35,16 +47,16 @@ func (p *Pager) scrollToSearchHits() {
 		return
 	}
 
-	firstHitIndex := p.findFirstHit(*lineIndex, nil, false)
+	firstHitIndex, _ := p.findFirstHit(*lineIndex, nil, false)
 	if firstHitIndex == nil {
 		alreadyAtTheTop := (*lineIndex == linemetadata.Index{})
-		if alreadyAtTheTop {
+		if alreadyAtTheTop || !p.WrapSearch {
 			// No match, can't wrap, give up
 			return
 		}
 
 		// Try again from the top
-		firstHitIndex = p.findFirstHit(linemetadata.Index{}, lineIndex, false)
+		firstHitIndex, _ = p.findFirstHit(linemetadata.Index{}, lineIndex, false)
 	}
 	if firstHitIndex == nil {
 		// No match, give up
@@ -52,12 +64,15 @@ func (p *Pager) scrollToSearchHits() {
 	}
 
 	// Found a match on some line
-	p.scrollPosition = NewScrollPositionFromIndex(*firstHitIndex, "scrollToSearchHits")
+	p.scrollPosition = *scrollPositionFromWrapIndex("scrollToSearchHits", *firstHitIndex, p.searchHitWrapIndex(*firstHitIndex, false))
+	p.currentSearchHit = firstHitIndex
 
-	p.leftColumnZeroBased = 0
-	p.showLineNumbers = p.ShowLineNumbers
-	if !p.searchHitIsVisible() {
-		p.scrollRightToSearchHits()
+	if !p.MinimalSearchScroll || !p.scrollMinimallyToSearchHits() {
+		p.leftColumnZeroBased = 0
+		p.showLineNumbers = p.ShowLineNumbers
+		if !p.searchHitIsVisible() {
+			p.scrollRightToSearchHits()
+		}
 	}
 	p.centerSearchHitsVertically()
 }
@@ -80,33 +95,64 @@ func (p *Pager) scrollToNextSearchHit() {
 	}
 
 	if p.isViewing() && p.isScrolledToEnd() {
-		p.mode = PagerModeNotFound{pager: p}
+		p.enterNotFoundMode(0)
 		return
 	}
 
 	var firstSearchIndex linemetadata.Index
+	firstSearchWrapIndex := 0
 
 	switch {
 	case p.isViewing():
-		// Start searching on the first line below the bottom of the screen
-		position := p.getLastVisiblePosition().NextLine(1)
-		firstSearchIndex = *position.lineIndex(p)
+		// Start searching on the first line below the bottom of the screen.
+		// With wrapping, that can be in the middle of a long input line.
+		// If the bottom screen line is a "line continues" marker, start with
+		// the rows hidden behind it.
+		lastVisible := p.getLastVisiblePosition()
+		firstSearchIndex = *lastVisible.lineIndex(p)
+		firstSearchWrapIndex = lastVisible.deltaScreenLines(p)
+		if !p.isLineContinuesMarker(firstSearchIndex, firstSearchWrapIndex) {
+			position := lastVisible.NextLine(1)
+			firstSearchIndex = *position.lineIndex(p)
+			firstSearchWrapIndex = position.deltaScreenLines(p)
+		}
+
+		if p.searchRegionStart != nil && firstSearchIndex.IsBefore(*p.searchRegionStart) {
+			firstSearchIndex = *p.searchRegionStart
+			firstSearchWrapIndex = 0
+		}
 
 	case p.isNotFound():
+		if !p.WrapSearch {
+			// Already at the end, stay there
+			return
+		}
+
 		// Restart searching from the top
 		p.mode = PagerModeViewing{pager: p}
 		firstSearchIndex = linemetadata.Index{}
+		if p.searchRegionStart != nil {
+			firstSearchIndex = *p.searchRegionStart
+		}
 
 	default:
 		panic(fmt.Sprint("Unknown search mode when finding next: ", p.mode))
 	}
 
-	firstHitIndex := p.findFirstHit(firstSearchIndex, nil, false)
+	beforeIndex := p.searchRegionForwardLimit()
+	if beforeIndex != nil && !firstSearchIndex.IsBefore(*beforeIndex) {
+		// Below the search region
+		p.enterNotFoundMode(0)
+		return
+	}
+
+	firstHitIndex, firstHitWrapIndex, skippedLines := p.findFirstWrappedHit(firstSearchIndex, firstSearchWrapIndex, beforeIndex, false)
 	if firstHitIndex == nil {
-		p.mode = PagerModeNotFound{pager: p}
+		p.enterNotFoundMode(skippedLines)
 		return
 	}
-	p.scrollPosition = NewScrollPositionFromIndex(*firstHitIndex, "scrollToNextSearchHit")
+	p.scrollPosition = *scrollPositionFromWrapIndex("scrollToNextSearchHit", *firstHitIndex, firstHitWrapIndex)
+	p.currentSearchHit = firstHitIndex
 
 	// Don't let any search hit scroll out of sight
 	p.setTargetLine(nil)
@@ -132,6 +178,11 @@ func (p *Pager) scrollToSearchHitsBackwards() {
 		return
 	}
 
+	if p.MinimalSearchScroll && p.scrollMinimallyToSearchHits() {
+		// Found it on screen, a bit to the side
+		return
+	}
+
 	if p.scrollLeftToSearchHits() {
 		// Found it to the left, done!
 		return
@@ -140,7 +191,7 @@ func (p *Pager) scrollToSearchHitsBackwards() {
 	// Start at the top visible line
 	lineIndex := p.scrollPosition.lineIndex(p)
 
-	firstHitIndex := p.findFirstHit(*lineIndex, nil, true)
+	firstHitIndex, _ := p.findFirstHit(*lineIndex, nil, true)
 	if firstHitIndex == nil {
 		lastReaderLineIndex := linemetadata.IndexFromLength(p.Reader().GetLineCount())
 		if lastReaderLineIndex == nil {
@@ -151,29 +202,32 @@ func (p *Pager) scrollToSearchHitsBackwards() {
 		}
 
 		lastVisibleLineIndex := p.getLastVisiblePosition().lineIndex(p)
-		canWrap := (*lineIndex != *lastVisibleLineIndex)
+		canWrap := (*lineIndex != *lastVisibleLineIndex) && p.WrapSearch
 		if !canWrap {
 			// No match, can't wrap, give up
 			return
 		}
 
 		// Try again from the bottom
-		firstHitIndex = p.findFirstHit(*lastReaderLineIndex, lineIndex, true)
+		firstHitIndex, _ = p.findFirstHit(*lastReaderLineIndex, lineIndex, true)
 	}
 	if firstHitIndex == nil {
 		// No match, give up
 		return
 	}
 
-	hitPosition := NewScrollPositionFromIndex(*firstHitIndex, "scrollToSearchHitsBackwards")
+	hitPosition := *scrollPositionFromWrapIndex("scrollToSearchHitsBackwards", *firstHitIndex, p.searchHitWrapIndex(*firstHitIndex, true))
+	p.currentSearchHit = firstHitIndex
 
 	// Scroll so that the first hit is at the bottom of the screen. If the
 	// visible height is 1, we should scroll 0 steps.
 	p.scrollPosition = hitPosition.PreviousLine(p.visibleHeight() - 1)
 
-	p.scrollMaxRight()
-	if !p.searchHitIsVisible() {
-		p.scrollLeftToSearchHits()
+	if !p.MinimalSearchScroll || !p.scrollMinimallyToSearchHits() {
+		p.scrollMaxRight()
+		if !p.searchHitIsVisible() {
+			p.scrollLeftToSearchHits()
+		}
 	}
 	p.centerSearchHitsVertically()
 }
@@ -196,34 +250,62 @@ func (p *Pager) scrollToPreviousSearchHit() {
 	}
 
 	var firstSearchIndex linemetadata.Index
+	firstSearchWrapIndex := math.MaxInt // All of the first line
 
 	switch {
 	case p.isViewing():
-		if p.scrollPosition.lineIndex(p).Index() == 0 {
+		if p.scrollPosition.lineIndex(p).Index() == 0 && p.deltaScreenLines() == 0 {
 			// Already at the top, can't go further up
-			p.mode = PagerModeNotFound{pager: p}
+			p.enterNotFoundMode(0)
 			return
 		}
 
-		// Start searching on the first line above the top of the screen
+		// Start searching on the first line above the top of the screen.
+		// With wrapping, that can be in the middle of a long input line.
 		position := p.scrollPosition.PreviousLine(1)
 		firstSearchIndex = *position.lineIndex(p)
+		firstSearchWrapIndex = position.deltaScreenLines(p)
+		if p.isLineContinuesMarker(firstSearchIndex, firstSearchWrapIndex) {
+			// Search the rows hidden behind the marker as well
+			firstSearchWrapIndex = math.MaxInt
+		}
+
+		if p.searchRegionEnd != nil && firstSearchIndex.IsAfter(*p.searchRegionEnd) {
+			firstSearchIndex = *p.searchRegionEnd
+			firstSearchWrapIndex = math.MaxInt
+		}
 
 	case p.isNotFound():
+		if !p.WrapSearch {
+			// Already at the start, stay there
+			return
+		}
+
 		// Restart searching from the bottom
 		p.mode = PagerModeViewing{pager: p}
 		firstSearchIndex = *linemetadata.IndexFromLength(p.Reader().GetLineCount())
+		if p.searchRegionEnd != nil && firstSearchIndex.IsAfter(*p.searchRegionEnd) {
+			firstSearchIndex = *p.searchRegionEnd
+		}
 
 	default:
 		panic(fmt.Sprint("Unknown search mode when finding previous: ", p.mode))
 	}
 
-	hitIndex := p.findFirstHit(firstSearchIndex, nil, true)
+	beforeIndex := p.searchRegionBackwardLimit()
+	if beforeIndex != nil && !firstSearchIndex.IsAfter(*beforeIndex) {
+		// Above the search region
+		p.enterNotFoundMode(0)
+		return
+	}
+
+	hitIndex, hitWrapIndex, skippedLines := p.findFirstWrappedHit(firstSearchIndex, firstSearchWrapIndex, beforeIndex, true)
 	if hitIndex == nil {
-		p.mode = PagerModeNotFound{pager: p}
+		p.enterNotFoundMode(skippedLines)
 		return
 	}
-	p.scrollPosition = *scrollPositionFromIndex("scrollToPreviousSearchHit", *hitIndex)
+	p.scrollPosition = *scrollPositionFromWrapIndex("scrollToPreviousSearchHit", *hitIndex, hitWrapIndex)
+	p.currentSearchHit = hitIndex
 
 	// Don't let any search hit scroll out of sight
 	p.setTargetLine(nil)
@@ -236,6 +318,132 @@ func (p *Pager) scrollToPreviousSearchHit() {
 	p.centerSearchHitsVertically()
 }
 
+// Scroll to the next search hit in the direction of the last search, when the
+// user presses 'n'.
+func (p *Pager) repeatSearch() {
+	if p.searchDirection == SearchDirectionBackward {
+		p.scrollToPreviousSearchHit()
+		return
+	}
+
+	p.scrollToNextSearchHit()
+}
+
+// Scroll to the next search hit in the opposite direction of the last search,
+// when the user presses 'N'.
+func (p *Pager) repeatSearchReversed() {
+	if p.searchDirection == SearchDirectionBackward {
+		p.scrollToNextSearchHit()
+		return
+	}
+
+	p.scrollToPreviousSearchHit()
+}
+
+// Like findFirstHit(), but starts at the given wrapped screen line of the
+// startPosition input line, and only finds hits from there on. Also returns the
+// wrap index of the first screen line with a hit, or of the last one when
+// searching backwards, and how many lines were skipped like in findFirstHit().
+//
+// Without wrapping, all wrap indices are zero.
+func (p *Pager) findFirstWrappedHit(startPosition linemetadata.Index, startWrapIndex int, beforePosition *linemetadata.Index, backwards bool) (*linemetadata.Index, int, int) {
+	if p.WrapLongLines {
+		hitWrapIndices, wrapCount := p.searchHitWrapIndices(startPosition)
+
+		startsMidLine := startWrapIndex > 0
+		if backwards {
+			startsMidLine = startWrapIndex < wrapCount-1
+		}
+
+		if startsMidLine {
+			if backwards {
+				for i := len(hitWrapIndices) - 1; i >= 0; i-- {
+					if hitWrapIndices[i] <= startWrapIndex {
+						return &startPosition, hitWrapIndices[i], 0
+					}
+				}
+			} else {
+				for _, hitWrapIndex := range hitWrapIndices {
+					if hitWrapIndex >= startWrapIndex {
+						return &startPosition, hitWrapIndex, 0
+					}
+				}
+			}
+
+			// No hits in the rest of this line, go on with the next one
+			if backwards {
+				if startPosition.IsZero() {
+					return nil, 0, 0
+				}
+				startPosition = startPosition.NonWrappingAdd(-1)
+			} else {
+				startPosition = startPosition.NonWrappingAdd(1)
+				if !startPosition.IsWithinLength(p.Reader().GetLineCount()) {
+					return nil, 0, 0
+				}
+			}
+
+			if beforePosition != nil && startPosition == *beforePosition {
+				// That was the last line to search
+				return nil, 0, 0
+			}
+		}
+	}
+
+	hitIndex, skippedLines := p.findFirstHit(startPosition, beforePosition, backwards)
+	if hitIndex == nil {
+		return nil, 0, skippedLines
+	}
+
+	return hitIndex, p.searchHitWrapIndex(*hitIndex, backwards), skippedLines
+}
+
+// With wrapping, returns the wrap index of the first screen line of the input
+// line with a search hit on it, or of the last one if backwards is true. Zero
+// if there is no visible hit, or if we aren't wrapping.
+func (p *Pager) searchHitWrapIndex(lineIndex linemetadata.Index, backwards bool) int {
+	if !p.WrapLongLines {
+		return 0
+	}
+
+	hitWrapIndices, _ := p.searchHitWrapIndices(lineIndex)
+	if len(hitWrapIndices) == 0 {
+		return 0
+	}
+
+	if backwards {
+		return hitWrapIndices[len(hitWrapIndices)-1]
+	}
+	return hitWrapIndices[0]
+}
+
+// Returns the wrap indices of the screen lines where search hits start in the
+// given input line, using the same wrapping as when rendering. Also returns how
+// many screen lines the input line wraps into.
+//
+// Rows cut off by MaxWrapRows are included, they become visible once the line
+// holds the current search hit.
+func (p *Pager) searchHitWrapIndices(lineIndex linemetadata.Index) ([]int, int) {
+	line := p.Reader().GetLine(lineIndex)
+	if line == nil {
+		return nil, 0
+	}
+
+	rendered := p.renderLineMaxRows(line, p.getLineNumberPrefixLength(line.Number), 0)
+
+	hitWrapIndices := []int{}
+	for _, row := range rendered {
+		for _, cell := range row.cells {
+			if cell.StartsSearchHit {
+				hitWrapIndices = append(hitWrapIndices, row.wrapIndex)
+				break
+			}
+		}
+	}
+
+	return hitWrapIndices, len(rendered)
+}
+
 // Search input lines. Not screen lines!
 //
 // The `beforePosition` parameter is exclusive, meaning that line will not be
@@ -243,10 +451,10 @@ func (p *Pager) scrollToPreviousSearchHit() {
 //
 // For the actual searching, this method will call _findFirstHit() in parallel
 // on multiple cores, to help large file search performance.
-func (p *Pager) findFirstHit(startPosition linemetadata.Index, beforePosition *linemetadata.Index, backwards bool) *linemetadata.Index {
-	// If the number of lines to search matches the number of cores (or more),
-	// divide the search into chunks. Otherwise use one chunk.
-	chunkCount := runtime.NumCPU()
+//
+// Also returns how many lines before any hit were skipped because they took
+// longer than SearchLineTimeout to search.
+func (p *Pager) findFirstHit(startPosition linemetadata.Index, beforePosition *linemetadata.Index, backwards bool) (*linemetadata.Index, int) {
 	var linesCount int
 	if backwards {
 		// If the startPosition is zero, that should make the count one
@@ -263,12 +471,6 @@ func (p *Pager) findFirstHit(startPosition linemetadata.Index, beforePosition *l
 		}
 	}
 
-	if linesCount < chunkCount {
-		chunkCount = 1
-	}
-	chunkSize := linesCount / chunkCount
-
-	log.Debugf("Searching %d lines across %d cores with %d lines per core...", linesCount, chunkCount, chunkSize)
 	t0 := time.Now()
 	defer func() {
 		linesPerSecond := float64(linesCount) / time.Since(t0).Seconds()
@@ -290,51 +492,46 @@ func (p *Pager) findFirstHit(startPosition linemetadata.Index, beforePosition *l
 		}
 	}()
 
-	// Each parallel search will start at one of these positions
-	searchStarts := make([]linemetadata.Index, chunkCount)
 	direction := 1
 	if backwards {
 		direction = -1
 	}
-	for i := 0; i < chunkCount; i++ {
-		searchStarts[i] = startPosition.NonWrappingAdd(i * direction * chunkSize)
-	}
 
-	// Make a results array, with one result per chunk
-	findings := make([]chan *linemetadata.Index, chunkCount)
-
-	// Search all chunks in parallel
-	for i, searchStart := range searchStarts {
-		findings[i] = make(chan *linemetadata.Index)
-
-		searchEndIndex := i + 1
-		var chunkBefore *linemetadata.Index
-		if searchEndIndex < len(searchStarts) {
-			chunkBefore = &searchStarts[searchEndIndex]
-		} else if beforePosition != nil {
-			chunkBefore = beforePosition
+	inputReader := p.Reader()
+	matches := p.searchLineMatcher()
+	windowLines := p.searchWindowLines()
+	lineTimeout := p.SearchLineTimeout
+	type finding struct {
+		hit          *linemetadata.Index
+		skippedLines int
+	}
+	findings := reader.SearchInChunks(linesCount, func(offset int, count int) finding {
+		searchStart := startPosition.NonWrappingAdd(direction * offset)
+
+		// The last chunk ends where the whole search ends, the others where the
+		// next chunk starts
+		chunkBefore := beforePosition
+		if offset+count < linesCount {
+			nextStart := startPosition.NonWrappingAdd(direction * (offset + count))
+			chunkBefore = &nextStart
 		}
 
-		reader := p.Reader()
-		pattern := *p.searchPattern
-		go func(i int, searchStart linemetadata.Index, chunkBefore *linemetadata.Index) {
-			defer func() {
-				PanicHandler("findFirstHit()/chunkSearch", recover(), debug.Stack())
-			}()
-
-			findings[i] <- _findFirstHit(reader, searchStart, pattern, chunkBefore, backwards)
-		}(i, searchStart, chunkBefore)
-	}
+		hit, skippedLines := _findFirstHit(inputReader, searchStart, matches, windowLines, lineTimeout, chunkBefore, backwards)
+		return finding{hit: hit, skippedLines: skippedLines}
+	})
+	log.Debugf("Searching %d lines in %d chunks...", linesCount, len(findings))
 
 	// Return the first non-nil result
-	for _, finding := range findings {
-		result := <-finding
-		if result != nil {
-			return result
+	skippedLines := 0
+	for _, chunkFinding := range findings {
+		result := <-chunkFinding
+		skippedLines += result.skippedLines
+		if result.hit != nil {
+			return result.hit, skippedLines
 		}
 	}
 
-	return nil
+	return nil, skippedLines
 }
 
 // NOTE: When we search, we do that by looping over the *input lines*, not the
@@ -344,26 +541,40 @@ func (p *Pager) findFirstHit(startPosition linemetadata.Index, beforePosition *l
 // The `beforePosition` parameter is exclusive, meaning that line will not be
 // searched.
 //
+// Lines taking longer than lineTimeout to search are skipped. Zero means no
+// timeout. The second return value is the number of skipped lines.
+//
+// With windowLines above one, each line is matched together with the lines
+// after it, see searchText(). The returned index is the line where the match
+// starts.
+//
 // This method will run over multiple chunks of the input file in parallel to
 // help large file search performance.
-func _findFirstHit(reader reader.Reader, startPosition linemetadata.Index, pattern regexp.Regexp, beforePosition *linemetadata.Index, backwards bool) *linemetadata.Index {
+func _findFirstHit(reader reader.Reader, startPosition linemetadata.Index, matches lineMatcher, windowLines int, lineTimeout time.Duration, beforePosition *linemetadata.Index, backwards bool) (*linemetadata.Index, int) {
+	skippedLines := 0
 	searchPosition := startPosition
 	for {
 		line := reader.GetLine(searchPosition)
 		if line == nil {
 			// No match, give up
-			return nil
+			return nil, skippedLines
 		}
 
-		lineText := line.Plain()
-		if pattern.MatchString(lineText) {
-			return &searchPosition
+		lineText := searchText(reader, line, windowLines)
+		isMatch, timedOut := matchWithTimeout(matches, lineText, lineTimeout)
+		if timedOut {
+			log.Warnf("Skipped searching line %s, %d bytes long, because it took more than %s",
+				searchPosition.Format(), len(lineText), lineTimeout)
+			skippedLines++
+		}
+		if isMatch {
+			return &searchPosition, skippedLines
 		}
 
 		if backwards {
 			if (searchPosition == linemetadata.Index{}) {
 				// Reached the top without any match, give up
-				return nil
+				return nil, skippedLines
 			}
 
 			searchPosition = searchPosition.NonWrappingAdd(-1)
@@ -373,11 +584,53 @@ func _findFirstHit(reader reader.Reader, startPosition linemetadata.Index, patte
 
 		if beforePosition != nil && searchPosition == *beforePosition {
 			// No match, give up
-			return nil
+			return nil, skippedLines
 		}
 	}
 }
 
+// Matches that time out can't be interrupted, they keep running in the
+// background until they are done. To not pile those up, at most this many
+// timeout-guarded matches can run at the same time. Further ones wait for a
+// slot before starting.
+var timeoutMatchSlots = make(chan struct{}, runtime.NumCPU())
+
+// Run the matcher on the line, but give up after the timeout. Zero timeout means
+// no timeout.
+//
+// Waiting for a free timeoutMatchSlots slot counts against the timeout as well.
+func matchWithTimeout(matches lineMatcher, line string, timeout time.Duration) (isMatch bool, timedOut bool) {
+	if timeout <= 0 || len(line) < SEARCH_LINE_TIMEOUT_MIN_LENGTH {
+		return matches(line), false
+	}
+
+	deadline := time.After(timeout)
+	select {
+	case timeoutMatchSlots <- struct{}{}:
+	case <-deadline:
+		// All slots are taken by earlier matches that timed out
+		return false, true
+	}
+
+	// Buffered so that the goroutine can finish after we have given up
+	result := make(chan bool, 1)
+	go func() {
+		defer func() {
+			<-timeoutMatchSlots
+			PanicHandler("matchWithTimeout()", recover(), debug.Stack())
+		}()
+
+		result <- matches(line)
+	}()
+
+	select {
+	case isMatch := <-result:
+		return isMatch, false
+	case <-deadline:
+		return false, true
+	}
+}
+
 // Return true if any search hit is currently visible on screen.
 //
 // A search hit is considered visible if the first character of the hit is
@@ -385,7 +638,19 @@ func _findFirstHit(reader reader.Reader, startPosition linemetadata.Index, patte
 // it may be off-screen to the right. If that happens, the user can scroll right
 // manually to see the rest of the hit.
 func (p *Pager) searchHitIsVisible() bool {
-	for _, row := range p.renderLines().lines {
+	rendered := p.renderLines()
+	if windowLines := p.searchWindowLines(); windowLines > 1 {
+		// Multiline hits aren't highlighted, look for them in the text instead
+		matches := p.searchLineMatcher()
+		for _, line := range rendered.inputLines {
+			if matches(searchText(p.Reader(), line, windowLines)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, row := range rendered.lines {
 		for _, cell := range row.cells {
 			if cell.StartsSearchHit {
 				// Found a search hit on screen!
@@ -404,12 +669,14 @@ func (p *Pager) centerSearchHitsVertically() {
 		return
 	}
 
+	matches := p.searchLineMatcher()
+	windowLines := p.searchWindowLines()
 	for {
 		rendered := p.renderLines()
 		firstHitRow := -1
 		lastHitRow := -1
 		for rowIndex, row := range rendered.inputLines {
-			if !p.searchPattern.MatchString(row.Plain()) {
+			if !matches(searchText(p.Reader(), row, windowLines)) {
 				continue
 			}
 
@@ -469,7 +736,7 @@ func (p *Pager) scrollMaxRight() {
 		}
 	}
 
-	screenWidth, _ := p.screen.Size()
+	screenWidth := p.contentWidth()
 
 	availableWidth := screenWidth - rendered.numberPrefixWidth
 	if widestLineWidth <= availableWidth {
@@ -490,7 +757,158 @@ func (p *Pager) scrollMaxRight() {
 	p.leftColumnZeroBased = widestLineWidth - availableWidth
 }
 
-// Scroll right looking for search hits. Return true if we found any.
+// Switch to the next SearchCaseMode when the user presses 'I', and redo the
+// current search using it
+func (p *Pager) cycleSearchCaseMode() {
+	message := ""
+	switch p.SearchCaseMode {
+	case SEARCH_CASE_AUTO:
+		p.SearchCaseMode = SEARCH_CASE_SENSITIVE
+		message = "Search is case sensitive"
+	case SEARCH_CASE_SENSITIVE:
+		p.SearchCaseMode = SEARCH_CASE_INSENSITIVE
+		message = "Search is case insensitive"
+	default:
+		p.SearchCaseMode = SEARCH_CASE_AUTO
+		message = "Search is case sensitive if it contains upper case characters"
+	}
+	p.mode = PagerModeMessage{pager: p, message: message}
+	p.redoSearch()
+}
+
+// Switch between searching for comma separated terms and for the search as a
+// whole when the user presses ',', and redo the current search
+func (p *Pager) toggleSearchCommaSeparated() {
+	p.SearchCommaSeparated = !p.SearchCommaSeparated
+
+	message := "Searching for the whole search, commas included"
+	if p.SearchCommaSeparated {
+		message = "Searching for any of the comma separated terms, like \"foo, bar\""
+	}
+	p.mode = PagerModeMessage{pager: p, message: message}
+	p.redoSearch()
+}
+
+// Recompile the current search after the search settings changed, and scroll
+// to its hits
+func (p *Pager) redoSearch() {
+	if p.searchString == "" {
+		return
+	}
+
+	p.searchPattern, p.searchMatcher = p.searchSyntax().toSearch(p.searchString)
+	if p.searchDirection == SearchDirectionBackward {
+		p.scrollToSearchHitsBackwards()
+	} else {
+		p.scrollToSearchHits()
+	}
+}
+
+// Turn highlighting of search hits on or off when the user presses 'H'
+func (p *Pager) toggleSearchHighlighting() {
+	p.NoSearchHighlight = !p.NoSearchHighlight
+
+	message := "Search hits are highlighted"
+	if p.NoSearchHighlight {
+		message = "Search hits are not highlighted"
+	}
+	p.mode = PagerModeMessage{pager: p, message: message}
+}
+
+// Stop highlighting search hits
+func (p *Pager) clearSearch() {
+	p.searchString = ""
+	p.searchPattern = nil
+	p.searchMatcher = nil
+	p.currentSearchHit = nil
+}
+
+// Returns the columns where search hits start in the given lines, counting
+// screen cells from the start of each line. Sorted, lowest first.
+func (p *Pager) searchHitColumns(lines []*reader.NumberedLine) []int {
+	columns := []int{}
+	for _, line := range lines {
+		column := 0
+		for _, cell := range p.highlightLine(line).StyledRunes {
+			if cell.StartsSearchHit {
+				columns = append(columns, column)
+			}
+			column += cell.Width()
+		}
+	}
+
+	slices.Sort(columns)
+	return columns
+}
+
+// Scroll sideways as little as possible to show the search hit closest to the
+// current view, see MinimalSearchScroll. Return true if a hit is visible
+// afterwards.
+func (p *Pager) scrollMinimallyToSearchHits() bool {
+	if p.WrapLongLines {
+		// No horizontal scrolling when wrapping
+		return p.searchHitIsVisible()
+	}
+
+	rendered := p.renderLines()
+	screenWidth := p.contentWidth()
+
+	// The columns not covered by scroll markers
+	firstVisibleColumn := 0
+	if p.leftColumnZeroBased > 0 {
+		firstVisibleColumn = p.leftColumnZeroBased + 1
+	}
+	lastVisibleColumn := p.leftColumnZeroBased + screenWidth - rendered.numberPrefixWidth - 2
+
+	closestColumn := -1
+	closestDistance := math.MaxInt
+	for _, column := range p.searchHitColumns(rendered.inputLines) {
+		distance := 0
+		if column < firstVisibleColumn {
+			distance = firstVisibleColumn - column
+		} else if column > lastVisibleColumn {
+			distance = column - lastVisibleColumn
+		}
+
+		if distance < closestDistance {
+			closestColumn = column
+			closestDistance = distance
+		}
+	}
+	if closestColumn < 0 {
+		// No hits on screen
+		return false
+	}
+	if closestDistance == 0 {
+		// Already visible
+		return true
+	}
+
+	restoreShowLineNumbers := p.showLineNumbers
+	restoreLeftColumn := p.leftColumnZeroBased
+
+	if closestColumn < firstVisibleColumn {
+		// Put the hit right after the scroll-left marker
+		p.leftColumnZeroBased = max(closestColumn-1, 0)
+	} else {
+		// Put the hit right before the scroll-right marker
+		p.showLineNumbers = false
+		p.leftColumnZeroBased = max(closestColumn-screenWidth+2, 0)
+	}
+
+	if p.searchHitIsVisible() {
+		return true
+	}
+
+	// Should never happen, but pretend nothing happened
+	log.Info("Search hit in column ", closestColumn, " not visible after scrolling minimally")
+	p.showLineNumbers = restoreShowLineNumbers
+	p.leftColumnZeroBased = restoreLeftColumn
+	return false
+}
+
+// Scroll right to the first search hit off screen to the right. Return true if
+// we found any.
 func (p *Pager) scrollRightToSearchHits() bool {
 	if p.WrapLongLines {
 		// No horizontal scrolling when wrapping
@@ -503,7 +921,7 @@ func (p *Pager) scrollRightToSearchHits() bool {
 	// Check how far right we can scroll at most. Factors involved:
 	// - Screen width
 	// - Length of longest visible line
-	screenWidth, _ := p.screen.Size()
+	screenWidth := p.contentWidth()
 
 	widestLineWidth := 0 // In screen cells, some runes are double-width
 	rendered := p.renderLines()
@@ -521,83 +939,69 @@ func (p *Pager) scrollRightToSearchHits() bool {
 	// Line column:   5678901234
 	maxLeftmostColumn := widestLineWidth - screenWidth
 
-	// If we have line numbers and disable them, do any new hits appear?
-	if rendered.numberPrefixWidth > 0 {
-		// If the number prefix width is 4, and the screen width is 10, then 6 should be
-		// the first newly revealed column index (10-4):
-		//
-		// Screen column: 0123456789
-		// New cells:     ______1234
-		//
-		// But since the rightmost column can be covered by scroll-right we need to subtract
-		// one more and get to 5.
-		firstJustRevealedColumn := screenWidth - rendered.numberPrefixWidth - 1
-		if firstJustRevealedColumn <= 0 {
-			log.Info("Screen too narrow ({}) to disable line numbers for search hits, skipping", screenWidth)
-			return false
-		}
-
-		p.showLineNumbers = false
-		for _, row := range p.renderLines().lines {
-			for column := firstJustRevealedColumn; column < len(row.cells); column++ {
-				if row.cells[column].StartsSearchHit {
-					// Found a search hit on screen!
-					return true
-				}
-			}
-		}
-		p.showLineNumbers = restoreShowLineNumbers
+	// If the screen width is 1, and we have no line numbers, the answer could
+	// be 1. But since the last column could be covered by scroll-right
+	// markers, we'll say 0.
+	firstNotVisibleColumn := p.leftColumnZeroBased + screenWidth - rendered.numberPrefixWidth - 1
+	if firstNotVisibleColumn < 1 {
+		log.Info("Screen is narrower than number prefix length, not scrolling right for search hits")
+		return false
 	}
 
-	for p.leftColumnZeroBased < maxLeftmostColumn {
-		// FIXME: Rather than scrolling right one screen at a time, we should
-		// consider scanning all lines for search hits and scrolling directly to the
-		// first one that is off-screen to the right.
-
-		// If the screen width is 1, and we have no line numbers, the answer
-		// could be 1. But since the last column could be covered by scroll-right
-		// markers, we'll say 0.
-		firstNotVisibleColumn := p.leftColumnZeroBased + screenWidth - rendered.numberPrefixWidth - 1
-		if firstNotVisibleColumn < 1 {
-			log.Info("Screen is narrower than number prefix length, not scrolling right for search hits")
-			p.showLineNumbers = restoreShowLineNumbers
-			p.leftColumnZeroBased = restoreLeftColumn
-			return false
+	hitColumn := -1
+	for _, column := range p.searchHitColumns(rendered.inputLines) {
+		if column >= firstNotVisibleColumn {
+			hitColumn = column
+			break
 		}
+	}
+	if hitColumn < 0 {
+		// Nothing to the right
+		return false
+	}
 
-		// Minus one to account for the scroll-left marker that will cover the
-		// first column after scrolling.
-		scrollToColumn := firstNotVisibleColumn - 1
-		if scrollToColumn > maxLeftmostColumn {
-			scrollToColumn = maxLeftmostColumn
-		}
+	p.showLineNumbers = false
+	if p.leftColumnZeroBased > 0 || hitColumn > screenWidth-2 {
+		// Hiding the line numbers isn't enough, the hit goes right after the
+		// scroll-left marker that will cover the first column
+		p.leftColumnZeroBased = max(min(hitColumn-1, maxLeftmostColumn), 0)
+	}
 
-		p.showLineNumbers = false
-		p.leftColumnZeroBased = scrollToColumn
+	if p.leftColumnZeroBased == restoreLeftColumn && p.showLineNumbers == restoreShowLineNumbers {
+		// Already max scrolled right, the hit is in the last column and
+		// visible since there's no scroll-right marker covering it
+		return false
+	}
 
-		if p.searchHitIsVisible() {
-			// Found it!
-			return true
-		}
+	if p.searchHitIsVisible() {
+		// Found it!
+		return true
 	}
 
-	// Can't scroll right, pretend nothing happened
+	// Should never happen, but pretend nothing happened
+	log.Info("Search hit in column ", hitColumn, " not visible after scrolling right")
 	p.showLineNumbers = restoreShowLineNumbers
 	p.leftColumnZeroBased = restoreLeftColumn
 	return false
 }
 
-// Scroll left looking for search hits. Return true if we found any.
+// Scroll left to the first search hit off screen to the left. Return true if
+// we found any.
 func (p *Pager) scrollLeftToSearchHits() bool {
 	if p.WrapLongLines {
 		// No horizontal scrolling when wrapping
 		return false
 	}
 
+	if p.leftColumnZeroBased == 0 {
+		// Nothing is cut off to the left
+		return false
+	}
+
 	restoreLeftColumn := p.leftColumnZeroBased
 	restoreShowLineNumbers := p.showLineNumbers
 
-	screenWidth, _ := p.screen.Size()
+	screenWidth := p.contentWidth()
 
 	// If we go max left, which column will be the rightmost visible one?
 	var fullLeftRightmostVisibleColumn int
@@ -619,51 +1023,45 @@ func (p *Pager) scrollLeftToSearchHits() bool {
 		return false
 	}
 
-	// Keep scrolling left until we either find a search hit, or reach the
-	// leftmost column with line numbers shown or not based on the user's
-	// preference.
-	for p.leftColumnZeroBased > 0 || (p.showLineNumbers != p.ShowLineNumbers) {
-		// FIXME: Rather than scrolling left one screen at a time, we should
-		// consider scanning all lines for search hits and scrolling directly to the
-		// first one that is off-screen to the left.
-
-		// Pretend the current leftmost column is not visible, since it could be
-		// covered by scroll-left markers.
-		lastNotVisibleColumn := p.leftColumnZeroBased
-
-		// Go left
-		if lastNotVisibleColumn <= fullLeftRightmostVisibleColumn {
-			// Going max left will show the column we want
-			p.showLineNumbers = p.ShowLineNumbers
-			p.leftColumnZeroBased = 0
-		} else {
-			// Scroll left one screen.
-			//
-			// If the screen width is 3, and we want column 5 to be visible, and
-			// there can be both scroll-left and scroll-right markers, we should
-			// start at colum 4 (covered by a scroll-left marker), so that
-			// column 5 is visible next to it.
-			//
-			// Set the leftmost column to 4, which is "5 - 3 + 2".
-			scrollToColumn := lastNotVisibleColumn - screenWidth + 2
-			if scrollToColumn < 0 {
-				scrollToColumn = 0
-			}
-
-			p.leftColumnZeroBased = scrollToColumn
+	// The current leftmost column is covered by the scroll-left marker
+	lastNotVisibleColumn := p.leftColumnZeroBased
 
-			// If showing line numbers was possible we should have ended up in
-			// the other if branch ^
-			p.showLineNumbers = false
+	hitColumn := -1
+	for _, column := range p.searchHitColumns(p.renderLines().inputLines) {
+		if column > lastNotVisibleColumn {
+			break
 		}
+		hitColumn = column
+	}
+	if hitColumn < 0 {
+		// Nothing to the left
+		return false
+	}
 
-		if p.searchHitIsVisible() {
-			// Found it!
-			return true
-		}
+	if hitColumn <= fullLeftRightmostVisibleColumn {
+		// Going max left will show the hit
+		p.showLineNumbers = p.ShowLineNumbers
+		p.leftColumnZeroBased = 0
+	} else {
+		// Put the hit right before the scroll-right marker.
+		//
+		// If the screen width is 3, and we want column 5 to be visible, and
+		// there can be both scroll-left and scroll-right markers, we should
+		// start at colum 4 (covered by a scroll-left marker), so that column 5
+		// is visible next to it.
+		//
+		// Set the leftmost column to 4, which is "5 - 3 + 2".
+		p.showLineNumbers = false
+		p.leftColumnZeroBased = max(hitColumn-screenWidth+2, 0)
+	}
+
+	if p.searchHitIsVisible() {
+		// Found it!
+		return true
 	}
 
-	// Scrolling left didn't find anything, pretend nothing happened
+	// Should never happen, but pretend nothing happened
+	log.Info("Search hit in column ", hitColumn, " not visible after scrolling left")
 	p.showLineNumbers = restoreShowLineNumbers
 	p.leftColumnZeroBased = restoreLeftColumn
 	return false
@@ -678,3 +1076,29 @@ func (p *Pager) isNotFound() bool {
 	_, isNotFound := p.mode.(PagerModeNotFound)
 	return isNotFound
 }
+
+// Returns the input line and screen row of the search hit on screen, preferring
+// the current search hit. Nil if there are no search hits on screen.
+func (p *Pager) searchHitOnScreen() (*linemetadata.Index, int) {
+	var firstHit *linemetadata.Index
+	firstHitRow := 0
+	for row, line := range p.renderLines().lines {
+		for _, cell := range line.cells {
+			if !cell.StartsSearchHit {
+				continue
+			}
+
+			if p.currentSearchHit != nil && line.inputLineIndex == *p.currentSearchHit {
+				return p.currentSearchHit, row
+			}
+			if firstHit == nil {
+				hitIndex := line.inputLineIndex
+				firstHit = &hitIndex
+				firstHitRow = row
+			}
+			break
+		}
+	}
+
+	return firstHit, firstHitRow
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/textstyles"
+)
+
+// How many cells to show on each side of a search hit in the status bar
+//
+//revive:disable-next-line:var-naming
+const SEARCH_CONTEXT_LENGTH = 10
+
+// For the status bar, describe the first search hit among the lines on screen,
+// like "/pattern: …text around the hit…". Returns an empty string if there are
+// no hits.
+func (p *Pager) searchContextStatus(inputLines []*reader.NumberedLine, maxLength int) string {
+	if p.searchPattern == nil {
+		return ""
+	}
+
+	prefix := "/" + p.searchString + ": "
+	for _, line := range inputLines {
+		hits := line.Line.SearchHits(p.searchPattern, &line.Index)
+		if len(hits) == 0 {
+			continue
+		}
+
+		return prefix + searchHitContext(line.Plain(), hits[0], maxLength-len(textstyles.PlainCells(prefix)))
+	}
+
+	return ""
+}
+
+// Extract a search hit with up to SEARCH_CONTEXT_LENGTH cells of context on
+// each side. Cut-off text is marked with "…". The context is shrunk as needed
+// for the result to be at most maxLength cells.
+//
+// The hit is a pair of cell positions, as returned by Line.SearchHits().
+func searchHitContext(plain string, hit [2]int, maxLength int) string {
+	if maxLength <= 0 {
+		return ""
+	}
+
+	// Tabs and other control characters would mess up the status bar
+	cells := textstyles.PlainCells(strings.Map(func(char rune) rune {
+		if char < ' ' {
+			return ' '
+		}
+		return char
+	}, plain))
+
+	for contextLength := SEARCH_CONTEXT_LENGTH; contextLength >= 0; contextLength-- {
+		start := max(0, hit[0]-contextLength)
+		end := min(len(cells), hit[1]+contextLength)
+
+		contextLength := end - start
+		context := strings.Join(cells[start:end], "")
+		if start > 0 {
+			context = "…" + context
+			contextLength++
+		}
+		if end < len(cells) {
+			context += "…"
+			contextLength++
+		}
+
+		if contextLength <= maxLength {
+			return context
+		}
+	}
+
+	// Not even the hit itself fits, show as much of it as we can
+	hitCells := cells[hit[0]:hit[1]]
+	if len(hitCells) <= maxLength {
+		return strings.Join(hitCells, "")
+	}
+	return strings.Join(hitCells[:maxLength-1], "") + "…"
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchHitContext(t *testing.T) {
+	line := "The quick brown fox jumps over the lazy dog"
+	fox := [2]int{16, 19}
+
+	assert.Equal(t, searchHitContext(line, fox, 100), "…ick brown fox jumps ove…")
+
+	// Context shrinks to fit
+	assert.Equal(t, searchHitContext(line, fox, 11), "…wn fox ju…")
+
+	// Hit at the start of the line, nothing cut off there
+	assert.Equal(t, searchHitContext(line, [2]int{0, 3}, 100), "The quick bro…")
+
+	// Not even the hit fits
+	assert.Equal(t, searchHitContext(line, [2]int{4, 15}, 5), "quic…")
+
+	// Tabs would mess up the status bar
+	assert.Equal(t, searchHitContext("a\tb", [2]int{2, 3}, 100), "a b")
+
+	// Combining marks share a cell with the character before them. Here "é" is
+	// an "e" followed by U+0301 COMBINING ACUTE ACCENT.
+	assert.Equal(t, searchHitContext("cafe\u0301 au lait", [2]int{5, 7}, 100), "cafe\u0301 au lait")
+	assert.Equal(t, searchHitContext("cafe\u0301 au lait", [2]int{5, 7}, 8), "…e\u0301 au l…")
+}
+
+func TestSearchContextStatus(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "first line\nsecond line with a hit\nthird hit"))
+	pager.screen = twin.NewFakeScreen(80, 10)
+	pager.searchString = "hit"
+	pager.searchPattern = regexp.MustCompile("hit")
+
+	rendered := pager.renderLines()
+	assert.Equal(t, pager.searchContextStatus(rendered.inputLines, 100), "/hit: …ne with a hit")
+
+	pager.searchPattern = nil
+	assert.Equal(t, pager.searchContextStatus(rendered.inputLines, 100), "")
+}
@@ -0,0 +1,216 @@
+package internal
+
+import (
+	"errors"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// Decides whether a line is a search hit
+type lineMatcher func(line string) bool
+
+// Decides which lines are hits for the current search. Only call this when
+// there is a search pattern.
+func (p *Pager) searchLineMatcher() lineMatcher {
+	if p.searchMatcher != nil {
+		return p.searchMatcher
+	}
+	if p.MultilineSearch {
+		return multilineMatcher(p.searchPattern)
+	}
+	return p.searchPattern.MatchString
+}
+
+// How search strings are turned into patterns, see toSearch()
+type searchSyntax struct {
+	caseMode SearchCaseOption
+
+	// "foo, bar" searches for either "foo" or "bar"
+	commaSeparated bool
+
+	// Search for the text as is, rather than as a regexp
+	literal bool
+
+	// " && " and " || " combine patterns, see toSearch(). Ignored when
+	// searching literally.
+	andOr bool
+}
+
+// The pager's current search settings
+func (p *Pager) searchSyntax() searchSyntax {
+	return searchSyntax{
+		caseMode:       p.SearchCaseMode,
+		commaSeparated: p.SearchCommaSeparated,
+		literal:        p.SearchLiteral,
+		andOr:          p.SearchAndOr,
+	}
+}
+
+// With andOr set, search strings can combine multiple patterns using " && " and
+// " || ", like "error && disk || panic". "&&" binds harder than "||", so that
+// example finds lines with both "error" and "disk" in them, and lines with
+// "panic" in them.
+//
+// Returns a pattern for highlighting all the parts, and a matcher deciding
+// which lines are hits. The matcher is nil if there is only one part, use the
+// pattern for matching in that case.
+//
+// Each part is compiled using toPattern(), so with SEARCH_CASE_AUTO each part
+// decides for itself whether it is case sensitive.
+//
+// If the string is empty the pattern will be nil.
+func toSearch(searchString string, caseMode SearchCaseOption) (*regexp.Regexp, lineMatcher) {
+	return searchSyntax{caseMode: caseMode}.toSearch(searchString)
+}
+
+// Like the toSearch() function, but with commas, literal searching and " && " /
+// " || " as configured.
+func (s searchSyntax) toSearch(searchString string) (*regexp.Regexp, lineMatcher) {
+	orGroups := [][]*regexp.Regexp{}
+	allPatterns := []*regexp.Regexp{}
+	for _, orPart := range s.split(searchString, " || ") {
+		andGroup := []*regexp.Regexp{}
+		for _, andPart := range s.split(orPart, " && ") {
+			pattern := s.toPattern(andPart)
+			if pattern == nil {
+				// Empty, probably still being typed
+				continue
+			}
+			andGroup = append(andGroup, pattern)
+			allPatterns = append(allPatterns, pattern)
+		}
+
+		if len(andGroup) > 0 {
+			orGroups = append(orGroups, andGroup)
+		}
+	}
+
+	if len(allPatterns) == 0 {
+		return nil, nil
+	}
+	if len(allPatterns) == 1 {
+		// Nothing to combine
+		return allPatterns[0], nil
+	}
+
+	matcher := func(line string) bool {
+		for _, andGroup := range orGroups {
+			allMatch := true
+			for _, pattern := range andGroup {
+				if !pattern.MatchString(line) {
+					allMatch = false
+					break
+				}
+			}
+
+			if allMatch {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// Highlight all parts. Flags like (?i) apply only within their own group.
+	alternatives := make([]string, 0, len(allPatterns))
+	for _, pattern := range allPatterns {
+		alternatives = append(alternatives, "(?:"+pattern.String()+")")
+	}
+	return regexp.MustCompile(strings.Join(alternatives, "|")), matcher
+}
+
+// Split the search string on the operator, if " && " and " || " are enabled
+func (s searchSyntax) split(searchString string, operator string) []string {
+	if !s.andOr || s.literal {
+		return []string{searchString}
+	}
+	return strings.Split(searchString, operator)
+}
+
+// Compile one part of a search string. With commaSeparated, that's an
+// alternation of the comma separated terms, each compiled using toPattern().
+//
+// Returns nil if there is nothing to search for.
+func (s searchSyntax) toPattern(part string) *regexp.Regexp {
+	terms := s.terms(part)
+	if len(terms) == 1 {
+		return toPattern(terms[0], s.caseMode)
+	}
+
+	// Flags like (?i) apply only within their own group, so each term gets to
+	// decide for itself whether it is case sensitive.
+	patterns := make([]*regexp.Regexp, 0, len(terms))
+	for _, term := range terms {
+		pattern := toPattern(term, s.caseMode)
+		if pattern == nil {
+			// Empty, like after a trailing comma
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	if len(patterns) == 1 {
+		return patterns[0]
+	}
+
+	alternatives := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		alternatives = append(alternatives, "(?:"+pattern.String()+")")
+	}
+	return regexp.MustCompile(strings.Join(alternatives, "|"))
+}
+
+// Split a part of a search string into the terms to search for, quoted if
+// searching literally
+func (s searchSyntax) terms(part string) []string {
+	terms := []string{part}
+	if s.commaSeparated {
+		terms = strings.Split(part, ",")
+		for i := range terms {
+			terms[i] = strings.TrimSpace(terms[i])
+		}
+	}
+
+	if s.literal {
+		for i := range terms {
+			terms[i] = regexp.QuoteMeta(terms[i])
+		}
+	}
+
+	return terms
+}
+
+// Returns a description of what's wrong with the search string, like "invalid
+// regex: missing closing )", or an empty string if it's fine.
+//
+// toSearch() searches verbatim for parts that aren't valid regexps, but while
+// the user is typing we want to tell them that's what's happening.
+func searchStringError(searchString string) string {
+	return searchSyntax{}.searchStringError(searchString)
+}
+
+// Like the searchStringError() function, but with commas, literal searching and
+// " && " / " || " as configured.
+func (s searchSyntax) searchStringError(searchString string) string {
+	for _, orPart := range s.split(searchString, " || ") {
+		for _, andPart := range s.split(orPart, " && ") {
+			for _, term := range s.terms(andPart) {
+				_, err := regexp.Compile(term)
+				if err == nil {
+					continue
+				}
+
+				var syntaxError *syntax.Error
+				if errors.As(err, &syntaxError) {
+					return "invalid regex: " + string(syntaxError.Code)
+				}
+				return "invalid regex: " + err.Error()
+			}
+		}
+	}
+
+	return ""
+}
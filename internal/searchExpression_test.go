@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchAnd(t *testing.T) {
+	syntax := searchSyntax{caseMode: SEARCH_CASE_AUTO, andOr: true}
+	pattern, matcher := syntax.toSearch("disk && error")
+	assert.Assert(t, matcher != nil)
+	assert.Assert(t, matcher("disk error"))
+	assert.Assert(t, matcher("Error: DISK full"), "Lower case parts should be case insensitive")
+	assert.Assert(t, !matcher("disk full"))
+	assert.Assert(t, !matcher("network error"))
+
+	// Both parts should be highlighted
+	assert.DeepEqual(t, pattern.FindAllString("disk error", -1), []string{"disk", "error"})
+}
+
+func TestSearchOr(t *testing.T) {
+	syntax := searchSyntax{caseMode: SEARCH_CASE_AUTO, andOr: true}
+	_, matcher := syntax.toSearch("disk || Error")
+	assert.Assert(t, matcher != nil)
+	assert.Assert(t, matcher("disk full"))
+	assert.Assert(t, matcher("Error"))
+	assert.Assert(t, !matcher("error"), "Upper case parts should be case sensitive")
+	assert.Assert(t, !matcher("network down"))
+}
+
+func TestSearchAndBindsHarderThanOr(t *testing.T) {
+	syntax := searchSyntax{caseMode: SEARCH_CASE_AUTO, andOr: true}
+	_, matcher := syntax.toSearch("error && disk || panic")
+	assert.Assert(t, matcher("panic"))
+	assert.Assert(t, matcher("disk error"))
+	assert.Assert(t, !matcher("error"))
+}
+
+func TestSearchSinglePattern(t *testing.T) {
+	syntax := searchSyntax{caseMode: SEARCH_CASE_AUTO, andOr: true}
+	pattern, matcher := syntax.toSearch("a&&b")
+	assert.Assert(t, matcher == nil)
+	assert.Equal(t, pattern.String(), toPattern("a&&b", SEARCH_CASE_AUTO).String())
+
+	// Empty parts are ignored, since they are probably still being typed
+	pattern, matcher = syntax.toSearch("disk && ")
+	assert.Assert(t, matcher == nil)
+	assert.Equal(t, pattern.String(), toPattern("disk", SEARCH_CASE_AUTO).String())
+
+	pattern, matcher = syntax.toSearch("")
+	assert.Assert(t, pattern == nil)
+	assert.Assert(t, matcher == nil)
+}
+
+// " && " and " || " are just text unless asked for, and always when searching
+// literally
+func TestSearchAndOrOptIn(t *testing.T) {
+	pattern, matcher := toSearch("disk && error", SEARCH_CASE_AUTO)
+	assert.Assert(t, matcher == nil)
+	assert.Assert(t, pattern.MatchString("disk && error"))
+	assert.Assert(t, !pattern.MatchString("disk error"))
+
+	syntax := searchSyntax{caseMode: SEARCH_CASE_AUTO, literal: true, andOr: true}
+	pattern, matcher = syntax.toSearch("a.b || c")
+	assert.Assert(t, matcher == nil)
+	assert.Assert(t, pattern.MatchString("a.b || c"))
+	assert.Assert(t, !pattern.MatchString("c"))
+	assert.Equal(t, syntax.searchStringError("a( || b"), "")
+}
+
+func TestSearchCommaSeparated(t *testing.T) {
+	syntax := searchSyntax{caseMode: SEARCH_CASE_AUTO, commaSeparated: true}
+
+	pattern, matcher := syntax.toSearch("foo, Bar,baz.*")
+	assert.Assert(t, matcher == nil, "One pattern matching any of the terms")
+	assert.Assert(t, pattern.MatchString("FOO"), "Lower case terms should be case insensitive")
+	assert.Assert(t, pattern.MatchString("Bar"))
+	assert.Assert(t, !pattern.MatchString("bar"), "Upper case terms should be case sensitive")
+	assert.Assert(t, pattern.MatchString("bazooka"), "Terms are regexps")
+	assert.DeepEqual(t, pattern.FindAllString("foo Bar bazooka", -1), []string{"foo", "Bar", "bazooka"})
+
+	// Empty terms are ignored, since they are probably still being typed
+	pattern, _ = syntax.toSearch("foo, ")
+	assert.Equal(t, pattern.String(), toPattern("foo", SEARCH_CASE_AUTO).String())
+
+	pattern, _ = syntax.toSearch(" , ")
+	assert.Assert(t, pattern == nil)
+
+	// Commas combine with &&
+	syntax.andOr = true
+	_, matcher = syntax.toSearch("disk, network && error")
+	assert.Assert(t, matcher("disk error"))
+	assert.Assert(t, matcher("network error"))
+	assert.Assert(t, !matcher("disk full"))
+}
+
+func TestSearchLiteral(t *testing.T) {
+	syntax := searchSyntax{caseMode: SEARCH_CASE_AUTO, literal: true}
+	pattern, _ := syntax.toSearch("a.b, (c")
+	assert.Assert(t, pattern.MatchString("a.b, (c"))
+	assert.Assert(t, !pattern.MatchString("axb, (c"), "Dots should be just dots")
+	assert.Equal(t, syntax.searchStringError("a("), "", "Nothing is an invalid regexp")
+
+	// Quoting is done per term, not for the comma separated list as a whole
+	syntax.commaSeparated = true
+	pattern, _ = syntax.toSearch("a.b, (c")
+	assert.DeepEqual(t, pattern.FindAllString("axb a.b (c", -1), []string{"a.b", "(c"})
+}
+
+func TestSearchCommaSeparatedError(t *testing.T) {
+	syntax := searchSyntax{commaSeparated: true}
+	assert.Equal(t, syntax.searchStringError("foo, ba(r"), "invalid regex: missing closing )")
+	assert.Equal(t, syntax.searchStringError("foo, (a,b)"), "invalid regex: missing closing )",
+		"Commas split terms, even inside parentheses")
+}
+
+func TestToggleSearchCommaSeparated(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "foo\nbar, baz\nbaz\n"))
+	pager.screen = twin.NewFakeScreen(20, 5)
+	pager.searchString = "bar, baz"
+	pager.searchPattern, pager.searchMatcher = pager.searchSyntax().toSearch(pager.searchString)
+	assert.Assert(t, !pager.searchPattern.MatchString("baz"))
+
+	pager.mode.onRune(',')
+	assert.Assert(t, pager.SearchCommaSeparated)
+	assert.Assert(t, pager.searchPattern.MatchString("baz"))
+
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.mode.onRune(',')
+	assert.Assert(t, !pager.SearchCommaSeparated)
+	assert.Assert(t, !pager.searchPattern.MatchString("baz"))
+}
+
+func TestFindFirstHitAnd(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("", "disk\nerror\nnetwork error\ndisk error\n"))
+	pager.SearchAndOr = true
+	pager.searchPattern, pager.searchMatcher = pager.searchSyntax().toSearch("disk && error")
+
+	hit, _ := pager.findFirstHit(linemetadata.Index{}, nil, false)
+	assert.Assert(t, hit != nil)
+	assert.Equal(t, hit.Index(), 3)
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Max number of remembered searches, older ones are dropped
+const searchHistoryMaxLength = 100
+
+// Previous searches, oldest first. Browse them using the up and down arrow
+// keys in the search prompt.
+type searchHistory struct {
+	entries []string
+
+	// If set, entries are loaded from and saved to this file
+	file string
+}
+
+// Start using the given history file. Empty means no file, remember searches
+// only until we exit.
+func (h *searchHistory) load(file string) {
+	h.file = file
+	if file == "" {
+		return
+	}
+
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Info("Failed to read search history from ", file, ": ", err)
+		}
+		return
+	}
+
+	h.entries = nil
+	for _, entry := range strings.Split(string(contents), "\n") {
+		h.appendEntry(entry)
+	}
+}
+
+// Remember a search, and save the history file if we have one
+func (h *searchHistory) add(entry string) {
+	if !h.appendEntry(entry) {
+		return
+	}
+
+	h.save()
+}
+
+// Returns false if the entry wasn't added
+func (h *searchHistory) appendEntry(entry string) bool {
+	if entry == "" || strings.Contains(entry, "\n") {
+		return false
+	}
+
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		// Same as last time
+		return false
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > searchHistoryMaxLength {
+		h.entries = h.entries[len(h.entries)-searchHistoryMaxLength:]
+	}
+
+	return true
+}
+
+func (h *searchHistory) save() {
+	if h.file == "" {
+		return
+	}
+
+	err := os.MkdirAll(filepath.Dir(h.file), 0o700)
+	if err != nil {
+		log.Info("Failed to create search history directory for ", h.file, ": ", err)
+		return
+	}
+
+	err = os.WriteFile(h.file, []byte(strings.Join(h.entries, "\n")+"\n"), 0o600)
+	if err != nil {
+		log.Info("Failed to save search history to ", h.file, ": ", err)
+	}
+}
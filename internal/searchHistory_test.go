@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchHistoryAdd(t *testing.T) {
+	history := searchHistory{}
+	history.add("a")
+	history.add("a")
+	history.add("")
+	history.add("b")
+	history.add("a")
+	assert.DeepEqual(t, history.entries, []string{"a", "b", "a"})
+
+	for i := range searchHistoryMaxLength + 5 {
+		history.add(strings.Repeat("x", i+1))
+	}
+	assert.Equal(t, len(history.entries), searchHistoryMaxLength)
+	assert.Equal(t, history.entries[len(history.entries)-1], strings.Repeat("x", searchHistoryMaxLength+5))
+}
+
+func TestSearchHistoryFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "moor", "search-history")
+
+	history := searchHistory{}
+	history.load(file)
+	assert.Equal(t, len(history.entries), 0)
+	history.add("first")
+	history.add("second")
+
+	loaded := searchHistory{}
+	loaded.load(file)
+	assert.DeepEqual(t, loaded.entries, []string{"first", "second"})
+}
+
+func TestSearchHistoryBrowsing(t *testing.T) {
+	reader := reader.NewFromTextForTesting("TestSearchHistoryBrowsing", "apa\nbepa\ncepa\ndepa\nepa\nfepa\n")
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 3)
+	assert.NilError(t, reader.Wait())
+
+	pager.searchHistory.add("depa")
+	pager.searchHistory.add("bepa")
+
+	pager.mode.onRune('/')
+	pager.mode.onRune('x')
+	assert.Equal(t, pager.searchString, "x")
+
+	// Browsing should not search
+	pager.mode.onKey(twin.KeyUp)
+	searchMode := pager.mode.(*PagerModeSearch)
+	assert.Equal(t, searchMode.inputBox.text, "bepa")
+	pager.mode.onKey(twin.KeyUp)
+	assert.Equal(t, searchMode.inputBox.text, "depa")
+	pager.mode.onKey(twin.KeyUp)
+	assert.Equal(t, searchMode.inputBox.text, "depa", "Should stay at the oldest entry")
+	assert.Equal(t, pager.searchString, "x")
+	assert.Equal(t, pager.lineIndex().Index(), 0)
+
+	// Back to what we had typed
+	pager.mode.onKey(twin.KeyDown)
+	pager.mode.onKey(twin.KeyDown)
+	assert.Equal(t, searchMode.inputBox.text, "x")
+
+	// Accepting an entry searches for it
+	pager.mode.onKey(twin.KeyUp)
+	pager.mode.onKey(twin.KeyUp)
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, modeName(pager), "Viewing")
+	assert.Equal(t, pager.searchString, "depa")
+	assert.Assert(t, pager.searchHitIsVisible())
+	assert.DeepEqual(t, pager.searchHistory.entries, []string{"depa", "bepa", "depa"})
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+// Lines to count per goroutine, fewer than this and we don't bother going
+// parallel
+const searchHitCountChunkMinSize = 10_000
+
+// Posted by the background search hit counter when it's done
+type eventSearchHitsCounted struct {
+	count searchHitCount
+}
+
+// What a searchHitCount was counted for. If any of these change, the count
+// needs to be redone.
+type searchHitCountKey struct {
+	reader        reader.Reader
+	currentReader int
+	filterPattern *regexp.Regexp
+	searchPattern *regexp.Regexp
+	lineCount     int
+}
+
+type searchHitCount struct {
+	key searchHitCountKey
+
+	// Zero based indices of all matching lines, in order
+	hits []int
+}
+
+func (p *Pager) searchHitCountKey() searchHitCountKey {
+	return searchHitCountKey{
+		reader:        p.Reader(),
+		currentReader: p.currentReader,
+		filterPattern: p.filterPattern,
+		searchPattern: p.searchPattern,
+		lineCount:     p.Reader().GetLineCount(),
+	}
+}
+
+// Start counting search hits in the background if we're showing a search hit
+// or the search minimap, and don't have an up to date count. Called by the
+// main loop before each redraw.
+func (p *Pager) updateSearchHitCount() {
+	if p.searchPattern == nil {
+		return
+	}
+	if p.currentSearchHit == nil && p.searchMinimapWidth() == 0 {
+		return
+	}
+
+	key := p.searchHitCountKey()
+	if p.searchHitCount != nil && p.searchHitCount.key == key {
+		// Already counted
+		return
+	}
+	if p.searchHitCountStarted != nil && *p.searchHitCountStarted == key {
+		// Already counting
+		return
+	}
+
+	p.searchHitCountStarted = &key
+	matches := p.searchLineMatcher()
+	windowLines := p.searchWindowLines()
+	lineTimeout := p.SearchLineTimeout
+	screen := p.screen
+	go func() {
+		defer func() {
+			PanicHandler("updateSearchHitCount()", recover(), debug.Stack())
+		}()
+
+		screen.Events() <- eventSearchHitsCounted{
+			count: countSearchHits(key, matches, windowLines, lineTimeout),
+		}
+	}()
+}
+
+// Called by the main loop when the background counter is done
+func (p *Pager) noteSearchHitsCounted(event eventSearchHitsCounted) {
+	p.searchHitCount = &event.count
+	if p.searchHitCountStarted != nil && *p.searchHitCountStarted == event.count.key {
+		p.searchHitCountStarted = nil
+	}
+}
+
+// Count the lines matching the search
+func countSearchHits(key searchHitCountKey, matches lineMatcher, windowLines int, lineTimeout time.Duration) searchHitCount {
+	t0 := time.Now()
+	hits := findSearchHits(key.reader, 0, key.lineCount, matches, windowLines, lineTimeout)
+	log.Debugf("Counted %d search hits in %d lines in %s", len(hits), key.lineCount, time.Since(t0))
+	return searchHitCount{key: key, hits: hits}
+}
+
+// Returns the zero based indices of the matching lines from first up to but
+// not including end, in order. Large ranges are split into chunks and searched
+// in parallel, like in findFirstHit().
+func findSearchHits(reader reader.Reader, first int, end int, matches lineMatcher, windowLines int, lineTimeout time.Duration) []int {
+	lineCount := end - first
+	chunkCount := min(runtime.NumCPU(), lineCount/searchHitCountChunkMinSize)
+	chunkCount = max(chunkCount, 1)
+	chunkSize := (lineCount + chunkCount - 1) / chunkCount
+
+	// One result per chunk
+	results := make([]chan []int, chunkCount)
+	for i := range results {
+		results[i] = make(chan []int, 1)
+
+		chunkFirst := first + i*chunkSize
+		chunkEnd := min(chunkFirst+chunkSize, end)
+		go func(i int) {
+			defer func() {
+				PanicHandler("findSearchHits()/chunk", recover(), debug.Stack())
+			}()
+
+			results[i] <- countChunkSearchHits(reader, chunkFirst, chunkEnd, matches, windowLines, lineTimeout)
+		}(i)
+	}
+
+	hits := make([]int, 0)
+	for _, result := range results {
+		hits = append(hits, <-result...)
+	}
+
+	return hits
+}
+
+// Returns the indices of the matching lines from first up to but not including
+// end
+func countChunkSearchHits(reader reader.Reader, first int, end int, matches lineMatcher, windowLines int, lineTimeout time.Duration) []int {
+	hits := make([]int, 0)
+	for index := first; index < end; index++ {
+		line := reader.GetLine(linemetadata.IndexFromZeroBased(index))
+		if line == nil {
+			break
+		}
+
+		isMatch, _ := matchWithTimeout(matches, searchText(reader, line, windowLines), lineTimeout)
+		if isMatch {
+			hits = append(hits, index)
+		}
+	}
+	return hits
+}
+
+// Returns a text like "match 12 of 340" if the current search hit is on
+// screen, or an empty string otherwise. Shows "?" while counting.
+func (p *Pager) searchHitCountStatus(inputLines []*reader.NumberedLine) string {
+	if p.searchPattern == nil || p.currentSearchHit == nil {
+		return ""
+	}
+
+	isVisible := false
+	for _, line := range inputLines {
+		if line.Index == *p.currentSearchHit {
+			isVisible = true
+			break
+		}
+	}
+	if !isVisible {
+		// The user has scrolled away from the hit
+		return ""
+	}
+
+	if p.searchHitCount == nil || p.searchHitCount.key != p.searchHitCountKey() {
+		return "match ? of ?"
+	}
+
+	hits := p.searchHitCount.hits
+	ordinal, found := slices.BinarySearch(hits, p.currentSearchHit.Index())
+	if !found {
+		// Not a hit for the current search
+		return ""
+	}
+
+	return fmt.Sprintf("match %d of %d", ordinal+1, len(hits))
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+// Wait for the background counter and hand its result to the pager, like the
+// main loop would
+func awaitSearchHitCount(t *testing.T, pager *Pager, screen *twin.FakeScreen) {
+	select {
+	case event := <-screen.Events():
+		counted, isCounted := event.(eventSearchHitsCounted)
+		assert.Assert(t, isCounted, "Expected a search hit count, got %v", event)
+		pager.noteSearchHitsCounted(counted)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for search hits to be counted")
+	}
+}
+
+func TestSearchHitCountStatus(t *testing.T) {
+	lines := []string{}
+	for i := range 100 {
+		if i%10 == 5 {
+			lines = append(lines, fmt.Sprint("hit ", i))
+		} else {
+			lines = append(lines, fmt.Sprint("line ", i))
+		}
+	}
+
+	reader := reader.NewFromTextForTesting("TestSearchHitCountStatus", strings.Join(lines, "\n"))
+	screen := twin.NewFakeScreen(80, 6)
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	assert.NilError(t, reader.Wait())
+
+	pager.searchString = "hit"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, pager.currentSearchHit.Index(), 5)
+
+	// Not counted yet
+	pager.redraw("")
+	assert.Assert(t, strings.Contains(rowToString(screen.GetRow(5)), "match ? of ?"), rowToString(screen.GetRow(5)))
+
+	pager.updateSearchHitCount()
+	awaitSearchHitCount(t, pager, screen)
+	pager.redraw("")
+	assert.Assert(t, strings.Contains(rowToString(screen.GetRow(5)), "match 1 of 10"), rowToString(screen.GetRow(5)))
+
+	pager.scrollToNextSearchHit()
+	pager.redraw("")
+	assert.Assert(t, strings.Contains(rowToString(screen.GetRow(5)), "match 2 of 10"), rowToString(screen.GetRow(5)))
+
+	// Already counted, so this should not start another count
+	pager.updateSearchHitCount()
+	assert.Assert(t, pager.searchHitCountStarted == nil)
+
+	// A new pattern needs a new count
+	pager.searchString = "hit 9"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+	pager.scrollToNextSearchHit()
+	pager.redraw("")
+	assert.Assert(t, strings.Contains(rowToString(screen.GetRow(5)), "match ? of ?"), rowToString(screen.GetRow(5)))
+	pager.updateSearchHitCount()
+	awaitSearchHitCount(t, pager, screen)
+	pager.redraw("")
+	assert.Assert(t, strings.Contains(rowToString(screen.GetRow(5)), "match 1 of 1"), rowToString(screen.GetRow(5)))
+
+	// Scrolling away from the hit hides the count
+	pager.scrollPosition = newScrollPosition("TestSearchHitCountStatus")
+	pager.redraw("")
+	assert.Assert(t, !strings.Contains(rowToString(screen.GetRow(5)), "match"), rowToString(screen.GetRow(5)))
+}
+
+func TestCountSearchHitsParallel(t *testing.T) {
+	lines := []string{}
+	expected := []int{}
+	for i := range 3*searchHitCountChunkMinSize + 17 {
+		if i%7 == 0 {
+			lines = append(lines, "hit")
+			expected = append(expected, i)
+		} else {
+			lines = append(lines, "miss")
+		}
+	}
+
+	reader := reader.NewFromTextForTesting("TestCountSearchHitsParallel", strings.Join(lines, "\n"))
+	assert.NilError(t, reader.Wait())
+
+	pattern := regexp.MustCompile("hit")
+	count := countSearchHits(searchHitCountKey{
+		reader:        reader,
+		searchPattern: pattern,
+		lineCount:     reader.GetLineCount(),
+	}, pattern.MatchString, 1, 0)
+
+	assert.DeepEqual(t, count.hits, expected)
+}
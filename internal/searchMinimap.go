@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"github.com/walles/moor/v2/twin"
+)
+
+// How many screen columns the search hits minimap uses, see SearchMinimap.
+// Zero if it isn't showing.
+func (p *Pager) searchMinimapWidth() int {
+	if !p.SearchMinimap {
+		return 0
+	}
+	if p.searchHitsPaneWidth() > 0 {
+		// The pane lists the hits already
+		return 0
+	}
+
+	return 1
+}
+
+// Draw the minimap in the rightmost screen column. Each row stands for a part
+// of the input, and is highlighted if there are search hits in that part.
+//
+// Until the search hits have been counted, and when there is no search, the
+// minimap is empty.
+func (p *Pager) drawSearchMinimap() {
+	if p.searchMinimapWidth() == 0 {
+		return
+	}
+
+	width, _ := p.screen.Size()
+	for row, hasHits := range p.searchMinimapRows(p.visibleHeight()) {
+		cell := twin.NewStyledRune('│', lineNumbersStyle)
+		if hasHits {
+			cell = twin.NewStyledRune(' ', searchHitStyle)
+		}
+		p.screen.SetCell(width-1, row, cell)
+	}
+}
+
+// Which rows of a minimap this high have search hits in the corresponding
+// parts of the input
+func (p *Pager) searchMinimapRows(height int) []bool {
+	if height <= 0 {
+		// No room for a minimap, the status bar uses the whole screen
+		return nil
+	}
+
+	rows := make([]bool, height)
+	if p.searchPattern == nil || p.searchHitCount == nil || p.searchHitCount.key != p.searchHitCountKey() {
+		return rows
+	}
+
+	lineCount := p.searchHitCount.key.lineCount
+	for _, hit := range p.searchHitCount.hits {
+		rows[hit*height/lineCount] = true
+	}
+
+	return rows
+}
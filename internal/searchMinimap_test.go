@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchMinimap(t *testing.T) {
+	lines := []string{}
+	for i := range 100 {
+		if i == 10 || i == 12 || i == 95 {
+			lines = append(lines, fmt.Sprint("hit ", i))
+		} else {
+			lines = append(lines, fmt.Sprint("line ", i))
+		}
+	}
+
+	reader := reader.NewFromTextForTesting("TestSearchMinimap", strings.Join(lines, "\n"))
+	assert.NilError(t, reader.Wait())
+	screen := twin.NewFakeScreen(20, 6)
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.SearchMinimap = true
+
+	minimap := func() string {
+		column := ""
+		for row := range 5 {
+			column += string(screen.GetRow(row)[19].Rune)
+		}
+		return column
+	}
+
+	// No search, empty minimap
+	pager.redraw("")
+	assert.Equal(t, minimap(), "│││││")
+	assert.Equal(t, pager.contentWidth(), 19)
+
+	pager.searchString = "hit"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+	pager.updateSearchHitCount()
+	awaitSearchHitCount(t, pager, screen)
+	pager.redraw("")
+
+	// Five rows for 100 lines is 20 lines per row
+	assert.Equal(t, minimap(), " │││ ")
+	assert.Equal(t, screen.GetRow(0)[19].Style, searchHitStyle)
+
+	// A new pattern means a new count
+	pager.searchString = "hit 9"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+	pager.redraw("")
+	assert.Equal(t, minimap(), "│││││", "Not counted yet")
+	pager.updateSearchHitCount()
+	awaitSearchHitCount(t, pager, screen)
+	pager.redraw("")
+	assert.Equal(t, minimap(), "││││ ")
+}
+
+// With only room for the status bar, there's no room for the minimap
+func TestSearchMinimapOneRow(t *testing.T) {
+	reader := reader.NewFromTextForTesting("TestSearchMinimapOneRow", "hit\nmiss\nhit")
+	assert.NilError(t, reader.Wait())
+	screen := twin.NewFakeScreen(20, 1)
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.SearchMinimap = true
+
+	pager.searchString = "hit"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+	pager.updateSearchHitCount()
+	awaitSearchHitCount(t, pager, screen)
+	pager.redraw("")
+}
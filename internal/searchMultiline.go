@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+// How many lines a multiline search hit can span
+const multilineSearchWindow = 10
+
+// How many lines to match against at a time. One unless we're doing a
+// multiline search.
+//
+// Combined searches like "error && disk" are always matched line by line.
+func (p *Pager) searchWindowLines() int {
+	if p.MultilineSearch && p.searchMatcher == nil {
+		return multilineSearchWindow
+	}
+	return 1
+}
+
+// Returns the text to match the search against for the given line. For
+// multiline searches that's the line followed by up to windowLines-1 lines
+// after it, separated by newlines.
+func searchText(r reader.Reader, line *reader.NumberedLine, windowLines int) string {
+	if windowLines <= 1 {
+		return line.Plain()
+	}
+
+	text := strings.Builder{}
+	text.WriteString(line.Plain())
+	for i := 1; i < windowLines; i++ {
+		next := r.GetLine(line.Index.NonWrappingAdd(i))
+		if next == nil {
+			break
+		}
+
+		text.WriteByte('\n')
+		text.WriteString(next.Plain())
+	}
+
+	return text.String()
+}
+
+// Returns a matcher for texts made by searchText(). A line is a hit if a
+// match starts on it, the match may continue on the lines after it.
+//
+// "." matches newlines, and "^" and "$" match at the start and end of each
+// line.
+func multilineMatcher(pattern *regexp.Regexp) lineMatcher {
+	multilinePattern := regexp.MustCompile("(?ms)" + pattern.String())
+
+	return func(text string) bool {
+		match := multilinePattern.FindStringIndex(text)
+		if match == nil {
+			return false
+		}
+
+		firstLineLength := strings.IndexByte(text, '\n')
+		if firstLineLength < 0 {
+			firstLineLength = len(text)
+		}
+
+		// A match starting on the newline after the first line, like for
+		// "\nfoo", counts as starting on the first line
+		return match[0] <= firstLineLength
+	}
+}
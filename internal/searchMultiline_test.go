@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+const multilineTestText = "Exception\nnot a frame\nException\n  at main()\nException"
+
+func TestMultilineSearchAcrossTwoLines(t *testing.T) {
+	testMe := reader.NewFromTextForTesting("TestMultilineSearchAcrossTwoLines", multilineTestText)
+	assert.NilError(t, testMe.Wait())
+	matches := multilineMatcher(regexp.MustCompile(`Exception\n\s+at`))
+
+	// The hit is reported on the line where the match starts
+	hit, _ := _findFirstHit(testMe, linemetadata.Index{}, matches, multilineSearchWindow, 0, nil, false)
+	assert.Equal(t, 2, hit.Index())
+
+	// Starting on the second line of the match should not find it
+	hit, _ = _findFirstHit(testMe, linemetadata.IndexFromZeroBased(3), matches, multilineSearchWindow, 0, nil, false)
+	assert.Assert(t, hit == nil)
+
+	hit, _ = _findFirstHit(testMe, linemetadata.IndexFromZeroBased(4), matches, multilineSearchWindow, 0, nil, true)
+	assert.Equal(t, 2, hit.Index())
+
+	// Line by line, there is no hit
+	hit, _ = _findFirstHit(testMe, linemetadata.Index{}, matches, 1, 0, nil, false)
+	assert.Assert(t, hit == nil)
+}
+
+func TestMultilineSearchDotMatchesNewline(t *testing.T) {
+	testMe := reader.NewFromTextForTesting("TestMultilineSearchDotMatchesNewline", multilineTestText)
+	assert.NilError(t, testMe.Wait())
+	matches := multilineMatcher(regexp.MustCompile(`frame.Exception$`))
+
+	hit, _ := _findFirstHit(testMe, linemetadata.Index{}, matches, multilineSearchWindow, 0, nil, false)
+	assert.Equal(t, 1, hit.Index())
+}
+
+func TestMultilineSearchPager(t *testing.T) {
+	testMe := reader.NewFromTextForTesting("TestMultilineSearchPager", multilineTestText)
+	assert.NilError(t, testMe.Wait())
+
+	pager := NewPager(testMe)
+	pager.MultilineSearch = true
+	pager.searchPattern, pager.searchMatcher = toSearch(`exception\n\s+at`, SEARCH_CASE_AUTO)
+
+	hit, _ := pager.findFirstHit(linemetadata.Index{}, nil, false)
+	assert.Equal(t, 2, hit.Index())
+}
+
+// Typing a multiline search in the prompt should scroll to the hit
+func TestMultilineSearchTyped(t *testing.T) {
+	lines := ""
+	for range 20 {
+		lines += "filler\n"
+	}
+	testMe := reader.NewFromTextForTesting("TestMultilineSearchTyped", lines+multilineTestText)
+	assert.NilError(t, testMe.Wait())
+
+	screen := twin.NewFakeScreen(20, 5)
+	pager := NewPager(testMe)
+	pager.screen = screen
+	pager.MultilineSearch = true
+	pager.mode = PagerModeViewing{pager: pager}
+
+	pager.mode.onRune('/')
+	for _, char := range `Exception\n  at` {
+		pager.mode.onRune(char)
+	}
+
+	// Line 22 is where the hit starts
+	firstLine := pager.lineIndex().Index()
+	assert.Assert(t, firstLine <= 22 && 22 < firstLine+pager.visibleHeight(), "firstLine=%d", firstLine)
+}
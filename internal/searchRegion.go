@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"github.com/walles/moor/v2/internal/linemetadata"
+)
+
+// Make 'n' / 'N' only find hits from the line at the top of the screen and on.
+func (p *Pager) setSearchRegionStart() {
+	lineIndex := p.lineIndex()
+	if lineIndex == nil {
+		// No lines to mark
+		return
+	}
+
+	start := *lineIndex
+	p.searchRegionStart = &start
+	if p.searchRegionEnd != nil && p.searchRegionEnd.IsBefore(start) {
+		// The old end is useless now
+		p.searchRegionEnd = nil
+	}
+
+	p.mode = PagerModeMessage{pager: p, message: "Search region starts at line " + start.Format()}
+}
+
+// Make 'n' / 'N' only find hits up to the line at the bottom of the screen.
+func (p *Pager) setSearchRegionEnd() {
+	lastVisiblePosition := p.getLastVisiblePosition()
+	if lastVisiblePosition == nil {
+		// No lines to mark
+		return
+	}
+
+	end := *lastVisiblePosition.lineIndex(p)
+	p.searchRegionEnd = &end
+	if p.searchRegionStart != nil && p.searchRegionStart.IsAfter(end) {
+		// The old start is useless now
+		p.searchRegionStart = nil
+	}
+
+	p.mode = PagerModeMessage{pager: p, message: "Search region ends at line " + end.Format()}
+}
+
+func (p *Pager) clearSearchRegion() {
+	if p.searchRegionStart == nil && p.searchRegionEnd == nil {
+		p.mode = PagerModeMessage{pager: p, message: "No search region set, press '[' and ']' to set one"}
+		return
+	}
+
+	p.searchRegionStart = nil
+	p.searchRegionEnd = nil
+	p.mode = PagerModeMessage{pager: p, message: "Search region cleared, searching everything"}
+}
+
+// Where to stop searching forwards, exclusive. Nil means at the end of the
+// input.
+func (p *Pager) searchRegionForwardLimit() *linemetadata.Index {
+	if p.searchRegionEnd == nil {
+		return nil
+	}
+
+	limit := p.searchRegionEnd.NonWrappingAdd(1)
+	return &limit
+}
+
+// Where to stop searching backwards, exclusive. Nil means at the start of the
+// input.
+func (p *Pager) searchRegionBackwardLimit() *linemetadata.Index {
+	if p.searchRegionStart == nil || p.searchRegionStart.IsZero() {
+		return nil
+	}
+
+	limit := p.searchRegionStart.NonWrappingAdd(-1)
+	return &limit
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchRegion(t *testing.T) {
+	lines := []string{}
+	for i := range 30 {
+		line := fmt.Sprint("line ", i)
+		if i == 2 || i == 10 || i == 15 || i == 25 {
+			line += " hit"
+		}
+		lines = append(lines, line)
+	}
+
+	reader := reader.NewFromTextForTesting("TestSearchRegion", strings.Join(lines, "\n"))
+	screen := twin.NewFakeScreen(60, 5) // Four lines and the status bar
+	pager := NewPager(reader)
+	pager.screen = screen
+	assert.NilError(t, reader.Wait())
+
+	pager.searchString = "hit"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+
+	// Mark lines 8-16 as the search region
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(8), "TestSearchRegion")
+	pager.mode.onRune('[')
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "Search region starts at line 9")
+
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(13), "TestSearchRegion")
+	pager.mode.onRune(']')
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "Search region ends at line 17")
+
+	// Searching from above the region should skip the hit before it
+	pager.scrollPosition = newScrollPosition("TestSearchRegion")
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.mode.onRune('n')
+	assert.Equal(t, pager.currentSearchHit.Index(), 10)
+
+	pager.mode.onRune('n')
+	assert.Equal(t, pager.currentSearchHit.Index(), 15)
+
+	// The hit after the region should not be found
+	pager.mode.onRune('n')
+	assert.Equal(t, "NotFound", modeName(pager))
+	assert.Equal(t, pager.currentSearchHit.Index(), 15)
+
+	// Wrapping should stay within the region
+	pager.mode.onRune('n')
+	assert.Equal(t, pager.currentSearchHit.Index(), 10)
+
+	pager.mode.onRune('N')
+	assert.Equal(t, "NotFound", modeName(pager))
+	assert.Equal(t, pager.currentSearchHit.Index(), 10)
+
+	// Without the region, searching backwards should find the hit above it
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.mode.onRune('|')
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "Search region cleared, searching everything")
+
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.mode.onRune('N')
+	assert.Equal(t, pager.currentSearchHit.Index(), 2)
+}
@@ -1,9 +1,12 @@
 package internal
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/walles/moor/v2/internal/linemetadata"
 	"github.com/walles/moor/v2/internal/reader"
 	"github.com/walles/moor/v2/twin"
 	"gotest.tools/v3/assert"
@@ -87,6 +90,18 @@ func modeName(pager *Pager) string {
 		return "Search"
 	case *PagerModeGotoLine:
 		return "GotoLine"
+	case *PagerModeGotoOffset:
+		return "GotoOffset"
+	case *PagerModeRawBytes:
+		return "RawBytes"
+	case *PagerModeJumpToLabel:
+		return "JumpToLabel"
+	case *PagerModeSearchHits:
+		return "SearchHits"
+	case PagerModeMessage:
+		return "Message"
+	case PagerModeScrollCurrentLine:
+		return "ScrollCurrentLine"
 	default:
 		panic("Unknown pager mode")
 	}
@@ -113,7 +128,7 @@ func TestScrollToNextSearchHit_StartAtBottom(t *testing.T) {
 
 	// Set the search to something that doesn't exist in this pager
 	pager.searchString = "xxx"
-	pager.searchPattern = toPattern(pager.searchString)
+	pager.searchPattern = toPattern(pager.searchString, SEARCH_CASE_AUTO)
 
 	// Scroll to the next search hit
 	pager.scrollToNextSearchHit()
@@ -127,7 +142,7 @@ func TestScrollToNextSearchHit_StartAtTop(t *testing.T) {
 
 	// Set the search to something that doesn't exist in this pager
 	pager.searchString = "xxx"
-	pager.searchPattern = toPattern(pager.searchString)
+	pager.searchPattern = toPattern(pager.searchString, SEARCH_CASE_AUTO)
 
 	// Scroll to the next search hit
 	pager.scrollToNextSearchHit()
@@ -142,7 +157,7 @@ func TestScrollToNextSearchHit_WrapAfterNotFound(t *testing.T) {
 
 	// Search for "a", it's on the first line (ref createThreeLinesPager())
 	pager.searchString = "a"
-	pager.searchPattern = toPattern(pager.searchString)
+	pager.searchPattern = toPattern(pager.searchString, SEARCH_CASE_AUTO)
 
 	// Scroll to the next search hit, this should take us into _NotFound
 	pager.scrollToNextSearchHit()
@@ -162,7 +177,7 @@ func TestScrollToNextSearchHit_WrapAfterFound(t *testing.T) {
 
 	// Search for "f", it's on the last line (ref createThreeLinesPager())
 	pager.searchString = "f"
-	pager.searchPattern = toPattern(pager.searchString)
+	pager.searchPattern = toPattern(pager.searchString, SEARCH_CASE_AUTO)
 
 	// Scroll to the next search hit, this should take us into _NotFound
 	pager.scrollToNextSearchHit()
@@ -175,6 +190,83 @@ func TestScrollToNextSearchHit_WrapAfterFound(t *testing.T) {
 	assert.Equal(t, 4, pager.lineIndex().Index())
 }
 
+func TestScrollToNextSearchHit_NoWrapAfterNotFound(t *testing.T) {
+	// Create a pager scrolled to the last line
+	pager := createThreeLinesPager(t)
+	pager.WrapSearch = false
+	pager.scrollToEnd()
+	lastLineIndex := pager.lineIndex().Index()
+
+	// Search for "a", it's on the first line (ref createThreeLinesPager())
+	pager.searchString = "a"
+	pager.searchPattern = toPattern(pager.searchString, SEARCH_CASE_AUTO)
+
+	// Scroll to the next search hit, this should take us into _NotFound
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, "NotFound", modeName(pager))
+
+	// Scroll to the next search hit again, we should stay at the bottom
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, "NotFound", modeName(pager))
+	assert.Equal(t, lastLineIndex, pager.lineIndex().Index())
+}
+
+func TestScrollToPreviousSearchHit_WrapAfterNotFound(t *testing.T) {
+	// Create a pager scrolled to the first line
+	pager := createThreeLinesPager(t)
+
+	// Search for "f", it's on the last line (ref createThreeLinesPager())
+	pager.searchString = "f"
+	pager.searchPattern = toPattern(pager.searchString, SEARCH_CASE_AUTO)
+
+	// Scroll to the previous search hit, this should take us into _NotFound
+	pager.scrollToPreviousSearchHit()
+	assert.Equal(t, "NotFound", modeName(pager))
+
+	// Scroll to the previous search hit, this should wrap the search and take
+	// us to the bottom
+	pager.scrollToPreviousSearchHit()
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, 4, pager.lineIndex().Index())
+}
+
+func TestScrollToPreviousSearchHit_NoWrapAfterNotFound(t *testing.T) {
+	// Create a pager scrolled to the first line
+	pager := createThreeLinesPager(t)
+	pager.WrapSearch = false
+
+	// Search for "f", it's on the last line (ref createThreeLinesPager())
+	pager.searchString = "f"
+	pager.searchPattern = toPattern(pager.searchString, SEARCH_CASE_AUTO)
+
+	// Scroll to the previous search hit, this should take us into _NotFound
+	pager.scrollToPreviousSearchHit()
+	assert.Equal(t, "NotFound", modeName(pager))
+
+	// Scroll to the previous search hit again, we should stay at the top
+	pager.scrollToPreviousSearchHit()
+	assert.Equal(t, "NotFound", modeName(pager))
+	assert.Assert(t, pager.lineIndex().IsZero())
+}
+
+func TestScrollToSearchHits_NoWrap(t *testing.T) {
+	pager := createThreeLinesPager(t)
+	pager.WrapSearch = false
+	pager.scrollToEnd()
+	lastLineIndex := pager.lineIndex().Index()
+
+	// "a" is on the first line, we shouldn't go there when typing it
+	pager.searchString = "a"
+	pager.searchPattern = toPattern(pager.searchString, SEARCH_CASE_AUTO)
+	pager.scrollToSearchHits()
+	assert.Equal(t, lastLineIndex, pager.lineIndex().Index())
+
+	// With wrapping, we should
+	pager.WrapSearch = true
+	pager.scrollToSearchHits()
+	assert.Assert(t, pager.lineIndex().IsZero())
+}
+
 // setText sets the text of the inputBox and triggers the onTextChanged callback.
 func (b *InputBox) setText(text string) {
 	b.text = text
@@ -210,7 +302,7 @@ func TestScrollLeftToSearchHits_NoLineNumbers(t *testing.T) {
 	pager.ShowLineNumbers = false
 	pager.showLineNumbers = false
 	pager.searchString = "a"
-	pager.searchPattern = toPattern("a")
+	pager.searchPattern = toPattern("a", SEARCH_CASE_AUTO)
 	pager.leftColumnZeroBased = 1
 
 	assert.Equal(t, true, pager.scrollLeftToSearchHits())
@@ -226,7 +318,7 @@ func TestScrollLeftToSearchHits_WithLineNumbers(t *testing.T) {
 	pager.ShowLineNumbers = true
 	pager.showLineNumbers = false
 	pager.searchString = "a"
-	pager.searchPattern = toPattern("a")
+	pager.searchPattern = toPattern("a", SEARCH_CASE_AUTO)
 	pager.leftColumnZeroBased = 1
 
 	assert.Equal(t, true, pager.scrollLeftToSearchHits())
@@ -234,7 +326,7 @@ func TestScrollLeftToSearchHits_WithLineNumbers(t *testing.T) {
 	assert.Equal(t, true, pager.showLineNumbers)
 }
 
-func TestScrollLeftToSearchHits_ScrollOneScreen(t *testing.T) {
+func TestScrollLeftToSearchHits_ScrollDirectlyToHit(t *testing.T) {
 	reader := reader.NewFromTextForTesting("", "01234567890a234567890123456789")
 	screen := twin.NewFakeScreen(10, 5)
 	pager := NewPager(reader)
@@ -242,15 +334,43 @@ func TestScrollLeftToSearchHits_ScrollOneScreen(t *testing.T) {
 	pager.ShowLineNumbers = true
 	pager.showLineNumbers = false
 	pager.searchString = "a"
-	pager.searchPattern = toPattern("a")
+	pager.searchPattern = toPattern("a", SEARCH_CASE_AUTO)
 	pager.leftColumnZeroBased = 20
 
 	assert.Equal(t, true, pager.scrollLeftToSearchHits())
-	assert.Equal(t, 4, pager.leftColumnZeroBased,
-		"We started at 20, screen is 10 wide, each scroll moves 8 to compensate for scroll markers, and 20-8-8=4")
+	assert.Equal(t, 3, pager.leftColumnZeroBased,
+		"The hit is in column 11, screen is 10 wide, and the hit should go right before the scroll-right marker: 11-10+2=3")
 	assert.Equal(t, false, pager.showLineNumbers)
 }
 
+// Hits many screens away should be scrolled to directly, not one screen at a
+// time
+func TestScrollToSearchHitsFarAway(t *testing.T) {
+	line := strings.Repeat("x", 50) + "a" + strings.Repeat("x", 50) + "a"
+	reader := reader.NewFromTextForTesting("", line)
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(10, 5)
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.searchString = "a"
+	pager.searchPattern = toPattern("a", SEARCH_CASE_AUTO)
+
+	assert.Equal(t, true, pager.scrollRightToSearchHits())
+	assert.Equal(t, 49, pager.leftColumnZeroBased, "First hit right after the scroll-left marker")
+
+	assert.Equal(t, true, pager.scrollRightToSearchHits())
+	assert.Equal(t, 92, pager.leftColumnZeroBased, "Second hit at the end of the line, can't scroll further than that")
+
+	assert.Equal(t, false, pager.scrollRightToSearchHits(), "No more hits to the right")
+	assert.Equal(t, 92, pager.leftColumnZeroBased)
+
+	assert.Equal(t, true, pager.scrollLeftToSearchHits())
+	assert.Equal(t, 42, pager.leftColumnZeroBased, "First hit right before the scroll-right marker")
+
+	assert.Equal(t, false, pager.scrollLeftToSearchHits(), "No more hits to the left")
+	assert.Equal(t, 42, pager.leftColumnZeroBased)
+}
+
 // If the screen is too narrow for line numbers, there's no point in scrolling.
 // This test has provoked some panics.
 func TestScrollRightToSearchHits_NarrowScreen(t *testing.T) {
@@ -274,7 +394,7 @@ func TestScrollRightToSearchHits_DisableLineNumbersToSeeHit0(t *testing.T) {
 	pager.ShowLineNumbers = true
 	pager.showLineNumbers = true
 	pager.searchString = "a"
-	pager.searchPattern = toPattern("a")
+	pager.searchPattern = toPattern("a", SEARCH_CASE_AUTO)
 	pager.leftColumnZeroBased = 0
 
 	assert.Equal(t, true, pager.scrollRightToSearchHits())
@@ -290,7 +410,7 @@ func TestScrollRightToSearchHits_DisableLineNumbersToSeeHit(t *testing.T) {
 	pager.ShowLineNumbers = true
 	pager.showLineNumbers = true
 	pager.searchString = "a"
-	pager.searchPattern = toPattern("a")
+	pager.searchPattern = toPattern("a", SEARCH_CASE_AUTO)
 	pager.leftColumnZeroBased = 0
 
 	assert.Equal(t, true, pager.scrollRightToSearchHits())
@@ -306,7 +426,7 @@ func TestScrollRightToSearchHits_HiddenByScrollMarker(t *testing.T) {
 	pager.ShowLineNumbers = false
 	pager.showLineNumbers = false
 	pager.searchString = "a"
-	pager.searchPattern = toPattern("a")
+	pager.searchPattern = toPattern("a", SEARCH_CASE_AUTO)
 	pager.leftColumnZeroBased = 0
 
 	assert.Equal(t, true, pager.scrollRightToSearchHits())
@@ -322,7 +442,7 @@ func TestScrollRightToSearchHits_LastCharHit(t *testing.T) {
 	pager.ShowLineNumbers = false
 	pager.showLineNumbers = false
 	pager.searchString = "a"
-	pager.searchPattern = toPattern("a")
+	pager.searchPattern = toPattern("a", SEARCH_CASE_AUTO)
 	pager.leftColumnZeroBased = 0
 
 	assert.Equal(t, true, pager.scrollRightToSearchHits())
@@ -330,3 +450,461 @@ func TestScrollRightToSearchHits_LastCharHit(t *testing.T) {
 	lastCol := pager.leftColumnZeroBased + width - 1
 	assert.Equal(t, strings.Index(line, "a"), lastCol, "Search hit should be in the last screen column")
 }
+
+func createBackwardsSearchPager(t *testing.T) *Pager {
+	reader := reader.NewFromTextForTesting("", "x0 hit\nx1\nx2 hit\nx3\nx4 hit\nx5\nx6\nx7")
+	assert.NilError(t, reader.Wait())
+
+	// Two lines of contents plus the status bar
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 3)
+
+	// Show the last two lines
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(6), "test")
+
+	return pager
+}
+
+func TestBackwardsIncrementalSearch(t *testing.T) {
+	pager := createBackwardsSearchPager(t)
+
+	pager.mode.onRune('?')
+	assert.Equal(t, "Search", modeName(pager))
+	for _, char := range "hit" {
+		pager.mode.onRune(char)
+	}
+
+	// The nearest preceding hit is on line 4, which should now be at the
+	// bottom of the screen
+	assert.Equal(t, 3, pager.lineIndex().Index())
+
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, 3, pager.lineIndex().Index())
+}
+
+func TestBackwardsIncrementalSearchWraps(t *testing.T) {
+	pager := createBackwardsSearchPager(t)
+	pager.scrollPosition = newScrollPosition("test")
+
+	// Nothing above the top line, so this should wrap around to the bottom
+	pager.mode.onRune('?')
+	for _, char := range "x6" {
+		pager.mode.onRune(char)
+	}
+	assert.Equal(t, 5, pager.lineIndex().Index())
+	assert.Equal(t, 6, pager.currentSearchHit.Index())
+}
+
+func TestBackwardsSearchNextPrevious(t *testing.T) {
+	pager := createBackwardsSearchPager(t)
+
+	pager.mode.onRune('?')
+	for _, char := range "hit" {
+		pager.mode.onRune(char)
+	}
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, 3, pager.lineIndex().Index())
+
+	// After a backwards search, 'n' should keep going backwards...
+	pager.mode.onRune('n')
+	assert.Equal(t, 2, pager.lineIndex().Index())
+
+	// ... and 'N' should go forwards
+	pager.mode.onRune('N')
+	assert.Equal(t, 4, pager.lineIndex().Index())
+}
+
+func TestForwardsSearchNextPrevious(t *testing.T) {
+	pager := createBackwardsSearchPager(t)
+	pager.scrollPosition = newScrollPosition("test")
+
+	pager.mode.onRune('/')
+	for _, char := range "hit" {
+		pager.mode.onRune(char)
+	}
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, 0, pager.lineIndex().Index())
+
+	// After a forwards search, 'n' should go forwards
+	pager.mode.onRune('n')
+	assert.Equal(t, 2, pager.lineIndex().Index())
+
+	// ... and 'N' should go backwards
+	pager.mode.onRune('N')
+	assert.Equal(t, 0, pager.lineIndex().Index())
+}
+
+func TestSearchKeptOnEscape(t *testing.T) {
+	pager := createBackwardsSearchPager(t)
+
+	pager.mode.onRune('/')
+	for _, char := range "hit" {
+		pager.mode.onRune(char)
+	}
+	pager.mode.onKey(twin.KeyEscape)
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, pager.searchPattern.String(), "(?i)hit")
+
+	// Until explicitly cleared
+	pager.mode.onRune('c')
+	assert.Assert(t, pager.searchPattern == nil)
+	assert.Equal(t, pager.searchString, "")
+}
+
+func TestSearchClearedOnEscape(t *testing.T) {
+	pager := createBackwardsSearchPager(t)
+	pager.KeepSearchOnEscape = false
+
+	pager.mode.onRune('/')
+	for _, char := range "hit" {
+		pager.mode.onRune(char)
+	}
+	pager.mode.onKey(twin.KeyEscape)
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Assert(t, pager.searchPattern == nil)
+	assert.Equal(t, pager.searchString, "")
+
+	// RETURN keeps the search though
+	pager.mode.onRune('/')
+	for _, char := range "hit" {
+		pager.mode.onRune(char)
+	}
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, pager.searchPattern.String(), "(?i)hit")
+}
+
+func TestSearchLineTimeout(t *testing.T) {
+	longLine := strings.Repeat("x", 1_000_000) + "needle"
+	testMe := reader.NewFromTextForTesting("TestSearchLineTimeout", longLine+"\nhaystack\nneedle")
+	matches := toPattern("needle", SEARCH_CASE_AUTO).MatchString
+
+	// The long line takes way more than a nanosecond to search, so we should
+	// skip it and find the short one
+	hit, skippedLines := _findFirstHit(testMe, linemetadata.Index{}, matches, 1, time.Nanosecond, nil, false)
+	assert.Equal(t, 2, hit.Index())
+	assert.Equal(t, skippedLines, 1)
+
+	// Without a timeout, the long line should be found
+	hit, skippedLines = _findFirstHit(testMe, linemetadata.Index{}, matches, 1, 0, nil, false)
+	assert.Equal(t, 0, hit.Index())
+	assert.Equal(t, skippedLines, 0)
+}
+
+// When earlier timed out matches are still running in all slots, new long lines
+// should be skipped rather than wait for a slot
+func TestSearchLineTimeoutNoFreeSlot(t *testing.T) {
+	for range cap(timeoutMatchSlots) {
+		timeoutMatchSlots <- struct{}{}
+	}
+	defer func() {
+		for range cap(timeoutMatchSlots) {
+			<-timeoutMatchSlots
+		}
+	}()
+
+	longLine := strings.Repeat("x", SEARCH_LINE_TIMEOUT_MIN_LENGTH) + "needle"
+	matches := toPattern("needle", SEARCH_CASE_AUTO).MatchString
+	isMatch, timedOut := matchWithTimeout(matches, longLine, time.Millisecond)
+	assert.Assert(t, !isMatch)
+	assert.Assert(t, timedOut)
+}
+
+// If the only hit is on a line that took too long to search, the user should be
+// told that lines were skipped
+func TestSearchLineTimeoutNotFound(t *testing.T) {
+	longLine := strings.Repeat("x", 1_000_000) + "needle"
+	reader := reader.NewFromTextForTesting("TestSearchLineTimeoutNotFound", "haystack\n"+longLine)
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(80, 2)
+	pager.SearchLineTimeout = time.Nanosecond
+	assert.NilError(t, reader.Wait())
+
+	pager.searchString = "needle"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+	pager.scrollToNextSearchHit()
+	assert.Assert(t, pager.isNotFound())
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(pager.screen.(*twin.FakeScreen).GetRow(1)),
+		"Not found: needle, skipped 1 line taking more than 1ns to search")
+}
+
+func TestCycleSearchCaseMode(t *testing.T) {
+	lines := []string{"ERROR one"}
+	for range 20 {
+		lines = append(lines, "filler")
+	}
+	lines = append(lines, "error two")
+
+	reader := reader.NewFromTextForTesting("TestCycleSearchCaseMode", strings.Join(lines, "\n"))
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 5)
+	assert.NilError(t, reader.Wait())
+
+	pager.searchString = "error"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+	pager.scrollToSearchHits()
+	assert.Equal(t, pager.lineIndex().Index(), 0, "Smart case should find ERROR")
+
+	// Case sensitive, "ERROR" doesn't match any more
+	pager.mode.onRune('I')
+	assert.Equal(t, pager.SearchCaseMode, SEARCH_CASE_SENSITIVE)
+	assert.Assert(t, !pager.searchPattern.MatchString("ERROR"))
+	assert.Assert(t, pager.lineIndex().Index() > 0, "Should have moved to the lower case hit")
+	assert.Assert(t, pager.searchHitIsVisible())
+
+	// The mode message goes away on the next key press, which is then handled
+	pager.mode.onRune('I')
+	assert.Equal(t, pager.SearchCaseMode, SEARCH_CASE_INSENSITIVE)
+	pager.mode.onRune('I')
+	assert.Equal(t, pager.SearchCaseMode, SEARCH_CASE_AUTO)
+}
+
+// Hits in a long wrapped line should be visited one screen line at a time
+func TestScrollToNextSearchHit_Wrapped(t *testing.T) {
+	// Each word wraps into a screen line of its own
+	words := []string{
+		"word0xx", "word1xx", "word2xx", "word3xx", "word4xx", "hit5xxx",
+		"word6xx", "word7xx", "word8xx", "hit9xxx", "word10x", "word11x",
+	}
+	lines := []string{"first", strings.Join(words, " ")}
+	for range 20 {
+		lines = append(lines, "filler")
+	}
+	lines = append(lines, "hit last")
+
+	reader := reader.NewFromTextForTesting("TestScrollToNextSearchHit_Wrapped", strings.Join(lines, "\n"))
+	screen := twin.NewFakeScreen(8, 4)
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.WrapLongLines = true
+	assert.NilError(t, reader.Wait())
+
+	pager.searchString = "hit"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+
+	// The first hit should end up at the top of the screen, not the first
+	// screen line of its input line
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, pager.lineIndex().Index(), 1)
+	assert.Equal(t, pager.deltaScreenLines(), 5)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "hit5xxx")
+
+	// The second hit is in the same input line
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, pager.lineIndex().Index(), 1)
+	assert.Equal(t, pager.deltaScreenLines(), 9)
+
+	// After that we should move on to the next input line. It's the last one,
+	// so it ends up at the bottom of the screen.
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, pager.lineIndex().Index(), 20)
+	assert.Assert(t, pager.isScrolledToEnd())
+
+	// And backwards we should visit the same screen lines in reverse
+	pager.scrollToPreviousSearchHit()
+	assert.Equal(t, pager.lineIndex().Index(), 1)
+	assert.Equal(t, pager.deltaScreenLines(), 9)
+
+	pager.scrollToPreviousSearchHit()
+	assert.Equal(t, pager.lineIndex().Index(), 1)
+	assert.Equal(t, pager.deltaScreenLines(), 5)
+
+	pager.scrollToPreviousSearchHit()
+	assert.Equal(t, "NotFound", modeName(pager))
+}
+
+// Hits in rows cut off by MaxWrapRows should still be reachable
+func TestScrollToNextSearchHit_MaxWrapRows(t *testing.T) {
+	// Each word wraps into a screen line of its own
+	words := []string{"word0xx", "word1xx", "word2xx", "word3xx", "hit4xxx", "word5xx"}
+	lines := []string{"first", strings.Join(words, " ")}
+	for range 20 {
+		lines = append(lines, "filler")
+	}
+
+	reader := reader.NewFromTextForTesting("TestScrollToNextSearchHit_MaxWrapRows", strings.Join(lines, "\n"))
+	screen := twin.NewFakeScreen(8, 5)
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.WrapLongLines = true
+	pager.MaxWrapRows = 2
+	assert.NilError(t, reader.Wait())
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "word1xx")
+	assert.Equal(t, rowToString(screen.GetRow(3)), "… line >")
+
+	pager.searchString = "hit"
+	pager.searchPattern, pager.searchMatcher = toSearch(pager.searchString, pager.SearchCaseMode)
+
+	// The line with the hit should be shown in full, with the hit on screen
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, pager.lineIndex().Index(), 1)
+	assert.Equal(t, pager.deltaScreenLines(), 4)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "hit4xxx")
+	assert.Assert(t, pager.searchHitIsVisible())
+
+	// Same thing backwards, with the marker just above the screen
+	pager.currentSearchHit = nil
+	pager.scrollPosition = *scrollPositionFromIndex("test", linemetadata.IndexFromZeroBased(2))
+	pager.scrollToPreviousSearchHit()
+	assert.Equal(t, pager.lineIndex().Index(), 1)
+	pager.redraw("")
+	assert.Assert(t, pager.searchHitIsVisible())
+}
+
+func TestSearchStringError(t *testing.T) {
+	assert.Equal(t, searchStringError(""), "")
+	assert.Equal(t, searchStringError("a.*b"), "")
+	assert.Equal(t, searchStringError("a("), "invalid regex: missing closing )")
+	assert.Equal(t, searchStringError("a && b["), "invalid regex: missing closing ]")
+}
+
+func TestInvalidSearchIsLiteral(t *testing.T) {
+	pager := createBackwardsSearchPager(t)
+	pager.screen = twin.NewFakeScreen(80, 3)
+
+	pager.mode.onRune('/')
+	for _, char := range "hit(" {
+		pager.mode.onRune(char)
+	}
+	assert.Equal(t, pager.searchPattern.String(), `(?i)hit\(`)
+
+	pager.redraw("")
+	footer := rowToString(pager.screen.(*twin.FakeScreen).GetRow(2))
+	assert.Equal(t, footer, "Search [invalid regex: missing closing ), searching literally]: hit(")
+
+	// Fixing it should make it a regexp again
+	pager.mode.onRune(')')
+	assert.Equal(t, pager.searchPattern.String(), "(?i)hit()")
+
+	// Accepting an invalid search should search for it literally
+	pager.mode.onRune('(')
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, modeName(pager), "Viewing")
+	assert.Equal(t, pager.searchPattern.String(), `(?i)hit\(\)\(`)
+}
+
+func TestToggleWrappingKeepsSearchHit(t *testing.T) {
+	reader := reader.NewFromTextForTesting("", "a\nb\n0123456789abcdefHIT\nc\nd\ne")
+	assert.NilError(t, reader.Wait())
+
+	// Four lines of contents plus the status bar
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(10, 5)
+	pager.showLineNumbers = false
+	pager.searchPattern = toPattern("HIT", SEARCH_CASE_AUTO)
+	hitIndex := linemetadata.IndexFromZeroBased(2)
+	pager.currentSearchHit = &hitIndex
+	assert.Assert(t, pager.scrollRightToSearchHits())
+
+	pager.mode.onRune('w')
+	assert.Assert(t, pager.WrapLongLines)
+	assert.Assert(t, pager.searchHitIsVisible())
+	lines := pager.renderLines().lines
+	assert.Equal(t, renderedToString(lines[1].cells), "0123456789")
+	assert.Equal(t, renderedToString(lines[2].cells), "abcdefHIT", "Hit should stay on the same screen row")
+
+	pager.mode.onRune('w')
+	assert.Assert(t, !pager.WrapLongLines)
+	assert.Assert(t, pager.searchHitIsVisible())
+	assert.Equal(t, pager.lineIndex().Index(), 0)
+}
+
+// Typing a search that moves the hit sideways should scroll only as far as
+// needed to keep it visible
+func TestMinimalSearchScroll(t *testing.T) {
+	line := ".....bar" + strings.Repeat(".", 12) + "foo" + strings.Repeat(".", 5) + "food" + strings.Repeat(".", 20)
+	reader := reader.NewFromTextForTesting("", line)
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(10, 3)
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.MinimalSearchScroll = true
+	pager.mode = NewPagerModeSearch(pager, SearchDirectionForward, pager.scrollPosition)
+
+	pager.mode.onRune('f')
+	assert.Assert(t, pager.searchHitIsVisible())
+	assert.Equal(t, 12, pager.leftColumnZeroBased, "Hit in column 20 right before the scroll-right marker")
+
+	pager.mode.onRune('o')
+	pager.mode.onRune('o')
+	assert.Assert(t, pager.searchHitIsVisible())
+	assert.Equal(t, 12, pager.leftColumnZeroBased, "Same hit, no need to scroll")
+
+	pager.mode.onRune('d')
+	assert.Assert(t, pager.searchHitIsVisible())
+	assert.Equal(t, 20, pager.leftColumnZeroBased, "Hit in column 28 right before the scroll-right marker")
+
+	// Removing the "d" brings back the first hit, to the left
+	pager.mode.onKey(twin.KeyBackspace)
+	assert.Assert(t, pager.searchHitIsVisible())
+	assert.Equal(t, 20, pager.leftColumnZeroBased, "The second hit is still visible")
+
+	pager.mode.onKey(twin.KeyEnter)
+	pager.mode = NewPagerModeSearch(pager, SearchDirectionForward, pager.scrollPosition)
+	pager.mode.onRune('b')
+	assert.Assert(t, pager.searchHitIsVisible())
+	assert.Equal(t, 4, pager.leftColumnZeroBased, "Hit in column 5 right after the scroll-left marker")
+}
+
+// Typing something that isn't found, or giving up using ESC, should take us
+// back to where the search started
+func TestSearchWithoutHitsRestoresView(t *testing.T) {
+	lines := []string{}
+	for i := range 30 {
+		lines = append(lines, fmt.Sprint("line ", i, strings.Repeat(" ", 20), "wide"))
+	}
+	lines[20] = "line 20 has bar in it"
+	reader := reader.NewFromTextForTesting("", strings.Join(lines, "\n"))
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 5)
+	pager.ShowLineNumbers = true
+	pager.showLineNumbers = false
+	pager.leftColumnZeroBased = 10
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(3), "test")
+
+	pager.mode = NewPagerModeSearch(pager, SearchDirectionForward, pager.scrollPosition)
+	pager.mode.onRune('b')
+	pager.mode.onRune('a')
+	assert.Assert(t, pager.searchHitIsVisible())
+	assert.Assert(t, pager.lineIndex().Index() > 3, "Should have moved down to the hit")
+	assert.Equal(t, 0, pager.leftColumnZeroBased, "Should have scrolled left to the hit")
+
+	pager.mode.onRune('x')
+	assert.Equal(t, 3, pager.lineIndex().Index(), "Nothing found, back where we started")
+	assert.Equal(t, 10, pager.leftColumnZeroBased)
+	assert.Equal(t, false, pager.showLineNumbers)
+
+	pager.mode.onKey(twin.KeyBackspace)
+	assert.Assert(t, pager.searchHitIsVisible())
+
+	pager.mode.onKey(twin.KeyEscape)
+	assert.Equal(t, 3, pager.lineIndex().Index(), "Gave up, back where we started")
+	assert.Equal(t, 10, pager.leftColumnZeroBased)
+	assert.Equal(t, false, pager.showLineNumbers)
+}
+
+func TestPasteSearch(t *testing.T) {
+	reader := reader.NewFromTextForTesting("", "a\nfoo bar\nb")
+	pager := NewPager(reader)
+	pager.screen = twin.NewFakeScreen(20, 5)
+
+	// Viewing mode doesn't take pastes, so that pasted text can't run commands
+	var viewing PagerMode = PagerModeViewing{pager: pager}
+	_, isPasteHandler := viewing.(pasteHandler)
+	assert.Assert(t, !isPasteHandler)
+
+	pager.mode = NewPagerModeSearch(pager, SearchDirectionForward, pager.scrollPosition)
+	pager.mode.(pasteHandler).onPaste("foo\nbar")
+	assert.Equal(t, "foo bar", pager.searchString)
+	assert.Assert(t, pager.searchHitIsVisible())
+}
@@ -0,0 +1,256 @@
+package internal
+
+import (
+	"math"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/util"
+	"github.com/walles/moor/v2/twin"
+)
+
+// A position in the scrolling part of the screen. Kept in input line terms so
+// that it stays put when scrolling.
+type selectionPoint struct {
+	lineIndex linemetadata.Index
+	wrapIndex int // Which screen line of a wrapped input line
+	column    int // Screen column, including any line number prefix
+}
+
+// Text being selected by dragging with the left mouse button
+type textSelection struct {
+	anchor selectionPoint // Where the drag started
+	end    selectionPoint // Where the mouse pointer is now
+}
+
+func (point selectionPoint) isBefore(other selectionPoint) bool {
+	if point.lineIndex != other.lineIndex {
+		return point.lineIndex.IsBefore(other.lineIndex)
+	}
+	if point.wrapIndex != other.wrapIndex {
+		return point.wrapIndex < other.wrapIndex
+	}
+	return point.column < other.column
+}
+
+// Returns the selection end points in reading order
+func (selection textSelection) ordered() (first selectionPoint, last selectionPoint) {
+	if selection.end.isBefore(selection.anchor) {
+		return selection.end, selection.anchor
+	}
+	return selection.anchor, selection.end
+}
+
+// Which of the given row's screen columns are selected. Both ends are
+// inclusive. ok is false if nothing on the row is selected.
+func (selection textSelection) columns(lineIndex linemetadata.Index, wrapIndex int) (from int, to int, ok bool) {
+	first, last := selection.ordered()
+	row := selectionPoint{lineIndex: lineIndex, wrapIndex: wrapIndex}
+	firstRow := selectionPoint{lineIndex: first.lineIndex, wrapIndex: first.wrapIndex}
+	lastRow := selectionPoint{lineIndex: last.lineIndex, wrapIndex: last.wrapIndex}
+	if row.isBefore(firstRow) || lastRow.isBefore(row) {
+		return 0, 0, false
+	}
+
+	from = 0
+	if row == firstRow {
+		from = first.column
+	}
+	to = math.MaxInt
+	if row == lastRow {
+		to = last.column
+	}
+	return from, to, true
+}
+
+// Returns the point under the mouse pointer on the rendered screen. Positions
+// above or below the scrolling lines are moved to the nearest scrolling line if
+// clamp is true, otherwise nil is returned for those.
+func selectionPointAt(rendered renderedScreen, column int, row int, clamp bool) *selectionPoint {
+	firstRow := rendered.headerRowCount
+	lastRow := len(rendered.lines) - rendered.footerRowCount - 1
+	if lastRow < firstRow {
+		// Nothing to select
+		return nil
+	}
+
+	if row < firstRow || row > lastRow {
+		if !clamp {
+			return nil
+		}
+		row = max(firstRow, min(row, lastRow))
+	}
+
+	line := rendered.lines[row]
+	return &selectionPoint{
+		lineIndex: line.inputLineIndex,
+		wrapIndex: line.wrapIndex,
+		column:    max(column, 0),
+	}
+}
+
+// Start selecting text when the left mouse button is pressed
+func (p *Pager) startSelection(column int, row int) {
+	p.selection = nil
+
+	switch p.mode.(type) {
+	case PagerModeViewing, PagerModeMessage:
+		// Selecting is fine
+	default:
+		return
+	}
+	if p.isSideBySide() || column >= p.contentWidth() {
+		return
+	}
+
+	point := selectionPointAt(p.renderLines(), column, row, false)
+	if point == nil {
+		return
+	}
+
+	p.selection = &textSelection{anchor: *point, end: *point}
+}
+
+// Extend the selection to where the mouse pointer was dragged. Dragging to the
+// top or bottom edge of the screen scrolls.
+//
+// The selection is extended on the screen as it was before scrolling, so the
+// line scrolled into view gets selected by the next drag event, or when the
+// button is released. This way we only render once per event.
+func (p *Pager) extendSelection(column int, row int) {
+	if p.selection == nil {
+		return
+	}
+
+	rendered := p.renderLines()
+	if point := selectionPointAt(rendered, column, row, true); point != nil {
+		p.selection.end = *point
+	}
+
+	firstRow := rendered.headerRowCount
+	lastRow := len(rendered.lines) - rendered.footerRowCount - 1
+	_, height := p.screen.Size()
+	if row < firstRow || row <= 0 {
+		p.scrollPosition = p.scrollPosition.PreviousLine(1)
+	} else if row > lastRow || row >= height-1 {
+		p.scrollPosition = p.scrollPosition.NextLine(1)
+	}
+}
+
+// Copy the selection to the clipboard when the left mouse button is released
+func (p *Pager) finishSelection(column int, row int) {
+	if p.selection == nil {
+		return
+	}
+
+	rendered := p.renderLines()
+	if point := selectionPointAt(rendered, column, row, true); point != nil {
+		p.selection.end = *point
+	}
+	if p.selection.anchor == p.selection.end {
+		// Just a click
+		p.selection = nil
+		return
+	}
+
+	rows := p.selectedRows(rendered.numberPrefixWidth)
+	plain := twin.ClipboardText(rows, false, twin.ColorCount24bit)
+	if plain == "" {
+		p.selection = nil
+		return
+	}
+
+	text := plain
+	if p.CopyWithANSI {
+		// We don't know where this will be pasted, so don't downsample the
+		// colors
+		text = twin.ClipboardText(rows, true, twin.ColorCount24bit)
+	}
+	if p.isTooLargeForClipboard(len(text)) {
+		return
+	}
+
+	log.Debug("Copying ", len(text), " selected bytes to clipboard")
+	if !p.copyToClipboard(text) {
+		return
+	}
+
+	charCount := len([]rune(plain))
+	message := "Copied " + util.FormatInt(charCount) + " characters to clipboard"
+	if charCount == 1 {
+		message = "Copied one character to clipboard"
+	}
+	if p.CopyWithANSI {
+		message += " with colors"
+	}
+	p.mode = PagerModeMessage{pager: p, message: message}
+}
+
+// The selected cells as shown on screen, without line numbers, one row per
+// input line. Wrapped lines are joined back together, and trailing spaces are
+// dropped.
+//
+// Lines are rendered like on screen so that the selected columns match up, so
+// numberPrefixLength must be the one from the current screen.
+func (p *Pager) selectedRows(numberPrefixLength int) [][]twin.StyledRune {
+	if p.selection == nil {
+		return nil
+	}
+
+	first, last := p.selection.ordered()
+
+	rows := [][]twin.StyledRune{}
+	for lineIndex := first.lineIndex; !last.lineIndex.IsBefore(lineIndex); lineIndex = lineIndex.NonWrappingAdd(1) {
+		line := p.Reader().GetLine(lineIndex)
+		if line == nil {
+			break
+		}
+
+		selected := []twin.StyledRune{}
+		for _, row := range p.renderLine(line, numberPrefixLength) {
+			from, to, ok := p.selection.columns(row.inputLineIndex, row.wrapIndex)
+			if !ok {
+				continue
+			}
+
+			column := 0
+			for _, cell := range row.cells {
+				if column >= numberPrefixLength && column >= from && column <= to {
+					selected = appendClipboardCell(selected, cell)
+				}
+				column += cell.Width()
+			}
+		}
+
+		for len(selected) > 0 && selected[len(selected)-1].Rune == ' ' && selected[len(selected)-1].Combining == "" {
+			selected = selected[:len(selected)-1]
+		}
+		rows = append(rows, selected)
+	}
+
+	return rows
+}
+
+// Invert the colors of the selected cells
+func (p *Pager) highlightSelection(rendered renderedScreen) {
+	if p.selection == nil {
+		return
+	}
+
+	defaultFg, defaultBg := p.defaultColors()
+	for row := rendered.headerRowCount; row < len(rendered.lines)-rendered.footerRowCount; row++ {
+		line := &rendered.lines[row]
+		from, to, ok := p.selection.columns(line.inputLineIndex, line.wrapIndex)
+		if !ok {
+			continue
+		}
+
+		column := 0
+		for i := range line.cells {
+			if column >= rendered.numberPrefixWidth && column >= from && column <= to {
+				line.cells[i].Style = line.cells[i].Style.Inverted(defaultFg, defaultBg)
+			}
+			column += line.cells[i].Width()
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func newSelectionTestPager(text string, width int, height int) (*Pager, *twin.FakeScreen) {
+	screen := twin.NewFakeScreen(width, height)
+	pager := NewPager(reader.NewFromTextForTesting("", text))
+	pager.screen = screen
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.mode = PagerModeViewing{pager: pager}
+
+	return pager, screen
+}
+
+func TestSelectAndCopy(t *testing.T) {
+	pager, screen := newSelectionTestPager("first line\nsecond line\nthird line", 40, 5)
+
+	pager.startSelection(6, 0)
+	pager.extendSelection(5, 1)
+	pager.finishSelection(5, 1)
+	assert.Equal(t, screen.ClipboardContents(), "line\nsecond")
+	assert.Equal(t, "Message", modeName(pager))
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "Copied 11 characters to clipboard")
+
+	// The selection is highlighted
+	row := screen.GetRow(0)
+	assert.Equal(t, row[5].Style, twin.StyleDefault)
+	assert.Equal(t, row[6].Style, twin.StyleDefault.WithAttr(twin.AttrReverse))
+	assert.Equal(t, screen.GetRow(1)[6].Style, twin.StyleDefault)
+}
+
+func TestSelectBackwards(t *testing.T) {
+	pager, screen := newSelectionTestPager("first line\nsecond line", 20, 5)
+
+	pager.startSelection(2, 1)
+	pager.extendSelection(6, 0)
+	pager.finishSelection(6, 0)
+	assert.Equal(t, screen.ClipboardContents(), "line\nsec")
+}
+
+func TestSelectWithLineNumbers(t *testing.T) {
+	pager, screen := newSelectionTestPager("first\nsecond", 20, 5)
+	pager.ShowLineNumbers = true
+	pager.showLineNumbers = true
+
+	// Start in the line numbers, they shouldn't be copied
+	pager.startSelection(0, 0)
+	pager.finishSelection(10, 1)
+	assert.Equal(t, screen.ClipboardContents(), "first\nsecond")
+}
+
+func TestClickDoesNotCopy(t *testing.T) {
+	pager, screen := newSelectionTestPager("first line", 20, 5)
+
+	pager.startSelection(3, 0)
+	pager.finishSelection(3, 0)
+	assert.Equal(t, screen.ClipboardContents(), "")
+	assert.Assert(t, pager.selection == nil)
+	assert.Equal(t, "Viewing", modeName(pager))
+}
+
+func TestDragScrollsAtBottomEdge(t *testing.T) {
+	pager, screen := newSelectionTestPager("0\n1\n2\n3\n4\n5\n6\n7\n8\n9", 20, 5)
+
+	pager.startSelection(0, 0)
+
+	// Drag onto the status bar
+	pager.extendSelection(0, 4)
+	pager.extendSelection(0, 4)
+	assert.Equal(t, 2, pager.lineIndex().Index())
+
+	pager.finishSelection(0, 4)
+	assert.Equal(t, screen.ClipboardContents(), "0\n1\n2\n3\n4\n5")
+}
+
+func TestSelectCombining(t *testing.T) {
+	// "é" here is an "e" followed by U+0301 COMBINING ACUTE ACCENT
+	pager, screen := newSelectionTestPager("cafe\u0301 au lait", 20, 5)
+
+	pager.startSelection(0, 0)
+	pager.finishSelection(3, 0)
+	assert.Equal(t, screen.ClipboardContents(), "cafe\u0301")
+}
+
+func TestSelectAndCopyWithColors(t *testing.T) {
+	pager, screen := newSelectionTestPager("plain \x1b[31mred\x1b[m 日本 end", 50, 5)
+
+	pager.startSelection(4, 0)
+	pager.finishSelection(15, 0)
+	assert.Equal(t, screen.ClipboardContents(), "n red 日本 e")
+
+	pager.CopyWithANSI = true
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.startSelection(4, 0)
+	pager.finishSelection(15, 0)
+	assert.Equal(t, screen.ClipboardContents(), "\x1b[mn \x1b[31mred\x1b[m 日本 e")
+
+	// ANSI codes aren't characters
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "Copied 10 characters to clipboard with colors")
+}
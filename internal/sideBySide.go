@@ -0,0 +1,209 @@
+package internal
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/textstyles"
+	"github.com/walles/moor/v2/twin"
+)
+
+// In side by side mode, the current reader is shown in one pane and this one
+// in the other
+type sideBySidePane struct {
+	readerIndex         int
+	scrollPosition      scrollPosition
+	leftColumnZeroBased int
+}
+
+// Should the first two readers be shown next to each other?
+func (p *Pager) isSideBySide() bool {
+	return p.SideBySide && len(p.readers) >= 2 && !p.isShowingHelp
+}
+
+// The reader being rendered. In side by side mode this can be the other pane's
+// reader.
+func (p *Pager) renderedReader() *reader.ReaderImpl {
+	p.readerLock.Lock()
+	defer p.readerLock.Unlock()
+
+	if len(p.readers) == 0 {
+		return nil
+	}
+
+	if p.renderingOtherPane {
+		return p.readers[p.otherPane.readerIndex]
+	}
+
+	return p.readers[p.currentReader]
+}
+
+// Is the pane currently being rendered the left one?
+func (p *Pager) renderingLeftPane() bool {
+	p.readerLock.Lock()
+	defer p.readerLock.Unlock()
+
+	if p.renderingOtherPane {
+		return p.otherPane.readerIndex < p.currentReader
+	}
+	return p.currentReader <= p.otherPane.readerIndex
+}
+
+// How many screen columns the contents can use. This is the screen width,
+// except in side by side mode where it is the width of one pane, and when
+// showing the search hits pane.
+func (p *Pager) contentWidth() int {
+	width, _ := p.screen.Size()
+	width -= p.searchHitsPaneWidth() + p.searchMinimapWidth()
+	if !p.isSideBySide() {
+		return width
+	}
+
+	// One column is for the divider
+	leftWidth := (width - 1) / 2
+	if p.renderingLeftPane() {
+		return leftWidth
+	}
+	return width - 1 - leftWidth
+}
+
+// Move keyboard focus to the other pane. Searching and scrolling apply to the
+// focused pane.
+func (p *Pager) switchSideBySideFocus() {
+	p.readerLock.Lock()
+	p.switchToFileLocked(p.otherPane.readerIndex)
+
+	// Don't wait for the reader goroutine, we're about to redraw
+	p.filteringReader.SetBackingReader(p.readers[p.currentReader])
+	p.readerLock.Unlock()
+	log.Tracef("Switched side by side focus to file index %d", p.currentReader)
+
+	p.scrollLockOffset = -p.scrollLockOffset
+
+	// Searches don't carry over between files
+	p.searchString = ""
+	p.searchPattern = nil
+	p.searchMatcher = nil
+	p.setTargetLine(nil)
+
+	select {
+	case p.readerSwitched <- struct{}{}:
+	default:
+	}
+}
+
+// Make both panes scroll together, or stop doing that
+func (p *Pager) toggleScrollLock() {
+	p.scrollLocked = !p.scrollLocked
+	if !p.scrollLocked {
+		return
+	}
+
+	// Keep the current distance between the panes
+	focusedIndex := p.lineIndex()
+	var otherIndex *linemetadata.Index
+	p.withOtherPane(func() {
+		otherIndex = p.lineIndex()
+	})
+	p.scrollLockOffset = 0
+	if focusedIndex != nil && otherIndex != nil {
+		p.scrollLockOffset = otherIndex.Index() - focusedIndex.Index()
+	}
+}
+
+// Render the focused pane and the other pane next to each other, with a
+// divider in between. The lines of the other pane are not searched.
+func (p *Pager) renderSideBySide() renderedScreen {
+	focused := p.renderLines()
+	focusedWidth := p.contentWidth()
+	focusedIsLeft := p.renderingLeftPane()
+
+	if p.scrollLocked && p.lineIndex() != nil {
+		otherIndex := linemetadata.IndexFromZeroBased(max(0, p.lineIndex().Index()+p.scrollLockOffset))
+		p.otherPane.scrollPosition = NewScrollPositionFromIndex(otherIndex, "Other pane")
+	}
+
+	var other renderedScreen
+	var otherWidth int
+	p.withOtherPane(func() {
+		other = p.renderLines()
+		otherWidth = p.contentWidth()
+	})
+
+	left, right := focused.lines, other.lines
+	leftWidth := focusedWidth
+	if !focusedIsLeft {
+		left, right = right, left
+		leftWidth = otherWidth
+	}
+
+	divider := textstyles.CellWithMetadata{Rune: '│', Style: lineNumbersStyle}
+	combined := make([]renderedLine, 0, max(len(left), len(right)))
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var line renderedLine
+		if i < len(focused.lines) {
+			line = focused.lines[i]
+		}
+
+		cells := textstyles.CellWithMetadataSlice{}
+		if i < len(left) {
+			cells = append(cells, left[i].cells...)
+		}
+		cells = padToWidth(cells, leftWidth)
+		cells = append(cells, divider)
+		if i < len(right) {
+			cells = append(cells, right[i].cells...)
+		}
+
+		line.cells = cells
+		line.trailer = twin.StyleDefault
+		combined = append(combined, line)
+	}
+
+	focused.lines = combined
+	return focused
+}
+
+// Run the function with the pager state swapped for the other pane's. Anything
+// the function changes in the other pane's state is kept.
+func (p *Pager) withOtherPane(f func()) {
+	savedLongestLineLength := p.longestLineLength
+	savedTableColumnWidths := p.tableColumnWidths
+	savedSearchPattern := p.searchPattern
+	p.searchPattern = nil
+
+	p.otherPane.scrollPosition, p.scrollPosition = p.scrollPosition, p.otherPane.scrollPosition
+	p.otherPane.leftColumnZeroBased, p.leftColumnZeroBased = p.leftColumnZeroBased, p.otherPane.leftColumnZeroBased
+	p.renderingOtherPane = true
+
+	defer func() {
+		p.renderingOtherPane = false
+		p.otherPane.scrollPosition, p.scrollPosition = p.scrollPosition, p.otherPane.scrollPosition
+		p.otherPane.leftColumnZeroBased, p.leftColumnZeroBased = p.leftColumnZeroBased, p.otherPane.leftColumnZeroBased
+
+		p.searchPattern = savedSearchPattern
+		p.tableColumnWidths = savedTableColumnWidths
+		p.longestLineLength = savedLongestLineLength
+	}()
+
+	f()
+}
+
+// Pad or cut the cells so that they cover exactly width screen columns
+func padToWidth(cells textstyles.CellWithMetadataSlice, width int) textstyles.CellWithMetadataSlice {
+	result := make(textstyles.CellWithMetadataSlice, 0, width)
+	column := 0
+	for _, cell := range cells {
+		if column+cell.Width() > width {
+			break
+		}
+		result = append(result, cell)
+		column += cell.Width()
+	}
+
+	for ; column < width; column++ {
+		result = append(result, textstyles.CellWithMetadata{Rune: ' ', Style: plainTextStyle})
+	}
+
+	return result
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func createSideBySidePager(t *testing.T, leftLines int, rightLines int) (*Pager, *twin.FakeScreen) {
+	left := []string{}
+	for i := range leftLines {
+		left = append(left, "left "+string(rune('a'+i)))
+	}
+	right := []string{}
+	for i := range rightLines {
+		right = append(right, "right "+string(rune('a'+i)))
+	}
+
+	leftReader := reader.NewFromTextForTesting("left", strings.Join(left, "\n"))
+	rightReader := reader.NewFromTextForTesting("right", strings.Join(right, "\n"))
+	assert.NilError(t, leftReader.Wait())
+	assert.NilError(t, rightReader.Wait())
+
+	pager := NewPager(leftReader, rightReader)
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.SideBySide = true
+
+	// Panes are 10 wide, plus one column for the divider
+	screen := twin.NewFakeScreen(21, 4)
+	pager.screen = screen
+
+	return pager, screen
+}
+
+func TestSideBySideRendering(t *testing.T) {
+	pager, screen := createSideBySidePager(t, 2, 3)
+	pager.redraw("")
+
+	assert.Equal(t, rowToString(screen.GetRow(0)), "left a    │right a")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "left b    │right b")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "          │right c")
+}
+
+func TestSideBySideLongLinesAreCut(t *testing.T) {
+	pager, screen := createSideBySidePager(t, 1, 1)
+	pager.readers[0] = reader.NewFromTextForTesting("left", "0123456789abcdef")
+	pager.filteringReader.SetBackingReader(pager.readers[0])
+	pager.redraw("")
+
+	assert.Equal(t, rowToString(screen.GetRow(0)), "012345678>│right a")
+}
+
+func TestSideBySideFocusSwitch(t *testing.T) {
+	pager, screen := createSideBySidePager(t, 5, 5)
+	pager.redraw("")
+
+	// Scrolling only moves the focused pane
+	pager.mode.onKey(twin.KeyDown)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "left b    │right a")
+
+	// After switching, the right pane scrolls, and stays on the right
+	pager.mode.onRune('\t')
+	pager.mode.onKey(twin.KeyDown)
+	pager.mode.onKey(twin.KeyDown)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "left b    │right c")
+}
+
+func TestSideBySideScrollLock(t *testing.T) {
+	pager, screen := createSideBySidePager(t, 8, 8)
+
+	pager.mode.onKey(twin.KeyDown)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "left b    │right a")
+
+	// Scroll locking keeps the panes one line apart
+	pager.mode.onRune('L')
+	pager.mode.onKey(twin.KeyDown)
+	pager.mode.onKey(twin.KeyDown)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "left d    │right c")
+
+	// Both directions
+	pager.mode.onKey(twin.KeyUp)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "left c    │right b")
+
+	// Unlocked, only the focused pane moves
+	pager.mode.onRune('L')
+	pager.mode.onKey(twin.KeyDown)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "left d    │right b")
+}
+
+// Switching to the file in the other pane should not show it in both panes
+func TestSideBySideSwitchToOtherPaneFile(t *testing.T) {
+	pager, screen := createSideBySidePager(t, 5, 5)
+	pager.redraw("")
+	pager.mode.onKey(twin.KeyDown)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "left b    │right a")
+
+	pager.nextFile()
+	assert.Equal(t, pager.currentReader, 1)
+	assert.Equal(t, pager.otherPane.readerIndex, 0)
+
+	// Done by the StartPaging() goroutine on the readerSwitched signal
+	pager.filteringReader.SetBackingReader(pager.readers[pager.currentReader])
+
+	// Both files keep their scroll positions
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "left b    │right a")
+}
+
+// Switching focus should switch per file view settings just like switching
+// files does
+func TestSideBySideFocusSwitchViewSettings(t *testing.T) {
+	pager, _ := createSideBySidePager(t, 5, 5)
+	pager.RememberViewPerFile = true
+	pager.WrapLongLines = true
+
+	pager.mode.onRune('\t')
+	assert.Equal(t, pager.currentReader, 1)
+	assert.Equal(t, pager.WrapLongLines, false)
+
+	pager.mode.onRune('\t')
+	assert.Equal(t, pager.currentReader, 0)
+	assert.Equal(t, pager.WrapLongLines, true)
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"regexp"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+)
+
+// With SmoothScroll, jumps longer than this many lines are animated
+const smoothScrollMinLines = 3
+
+// Number of frames in a smooth scroll animation, the last one showing the
+// target position
+const smoothScrollFrames = 8
+
+// Time between smooth scroll frames, caps the frame rate at about 60 frames
+// per second
+const smoothScrollFrameInterval = 16 * time.Millisecond
+
+// Time to show the next frame of a smooth scroll animation
+type eventSmoothScrollFrame struct {
+	animation int
+}
+
+// What the user was looking at before pressing a key, see startSmoothScroll()
+type smoothScrollOrigin struct {
+	position      scrollPosition
+	reader        int
+	isShowingHelp bool
+	filterPattern *regexp.Regexp
+}
+
+type smoothScrollState struct {
+	// Incremented for each new animation, so that frames from interrupted
+	// animations can be told apart from the current one
+	animation int
+
+	active bool
+	frame  int
+
+	from   linemetadata.Index
+	to     linemetadata.Index
+	target scrollPosition
+}
+
+func (p *Pager) smoothScrollOrigin() smoothScrollOrigin {
+	return smoothScrollOrigin{
+		position:      p.scrollPosition,
+		reader:        p.currentReader,
+		isShowingHelp: p.isShowingHelp,
+		filterPattern: p.filterPattern,
+	}
+}
+
+// If the user just jumped far from the given origin, go back to it and animate
+// the rest of the way. Does nothing unless SmoothScroll is set.
+func (p *Pager) startSmoothScroll(origin smoothScrollOrigin) {
+	if !p.SmoothScroll || p.screen == nil {
+		return
+	}
+
+	if origin.reader != p.currentReader || origin.isShowingHelp != p.isShowingHelp || origin.filterPattern != p.filterPattern {
+		// Not the same lines as before, animating would make no sense
+		return
+	}
+
+	fromIndex := origin.position.lineIndex(p)
+	target := p.scrollPosition
+	toIndex := target.lineIndex(p)
+	if fromIndex == nil || toIndex == nil {
+		return
+	}
+
+	distance := toIndex.Index() - fromIndex.Index()
+	if distance < 0 {
+		distance = -distance
+	}
+	if distance <= smoothScrollMinLines {
+		return
+	}
+
+	state := &p.smoothScroll
+	state.animation++
+	state.active = true
+	state.frame = 0
+	state.from = *fromIndex
+	state.to = *toIndex
+	state.target = target
+
+	p.showNextSmoothScrollFrame(eventSmoothScrollFrame{animation: state.animation})
+}
+
+// Move one frame closer to the target, and ask for another frame unless we're
+// there
+func (p *Pager) showNextSmoothScrollFrame(event eventSmoothScrollFrame) {
+	state := &p.smoothScroll
+	if !state.active || event.animation != state.animation {
+		// Interrupted, never mind
+		return
+	}
+
+	state.frame++
+	if state.frame >= smoothScrollFrames {
+		p.finishSmoothScroll()
+		return
+	}
+
+	// Ease out, start fast and slow down towards the target
+	remaining := 1.0 - float64(state.frame)/smoothScrollFrames
+	progress := 1.0 - remaining*remaining
+
+	distance := state.to.Index() - state.from.Index()
+	index := state.from.NonWrappingAdd(int(float64(distance) * progress))
+	p.scrollPosition = NewScrollPositionFromIndex(index, "smoothScroll")
+
+	screen := p.screen
+	time.AfterFunc(smoothScrollFrameInterval, func() {
+		select {
+		case screen.Events() <- event:
+		default:
+			// Busy, or nobody is listening any more. The animation stops
+			// where it is, and the next key press finishes it.
+			log.Debug("Event queue full, dropping smooth scroll frame")
+		}
+	})
+}
+
+// Jump straight to the end of any ongoing smooth scroll animation
+func (p *Pager) finishSmoothScroll() {
+	state := &p.smoothScroll
+	if !state.active {
+		return
+	}
+
+	state.active = false
+	p.scrollPosition = state.target
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func createSmoothScrollPager(t *testing.T) (*Pager, *twin.FakeScreen) {
+	lines := []string{}
+	for i := range 100 {
+		lines = append(lines, fmt.Sprint("line ", i))
+	}
+
+	screen := twin.NewFakeScreen(20, 11) // 10 lines plus the footer
+	pager := NewPager(reader.NewFromTextForTesting("TestSmoothScroll", strings.Join(lines, "\n")))
+	pager.screen = screen
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.SmoothScroll = true
+	assert.NilError(t, pager.readers[0].Wait())
+
+	return pager, screen
+}
+
+func TestSmoothScrollLongJump(t *testing.T) {
+	pager, screen := createSmoothScrollPager(t)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "line 0")
+
+	origin := pager.smoothScrollOrigin()
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(50), "TestSmoothScrollLongJump")
+	pager.startSmoothScroll(origin)
+
+	// Handle frame events like the main loop would, and collect what ends up
+	// on screen
+	topLines := []string{}
+	for {
+		pager.redraw("")
+		topLines = append(topLines, rowToString(screen.GetRow(0)))
+		if !pager.smoothScroll.active {
+			break
+		}
+
+		select {
+		case event := <-screen.Events():
+			frame, isFrame := event.(eventSmoothScrollFrame)
+			assert.Assert(t, isFrame, "Expected a smooth scroll frame, got %v", event)
+			pager.showNextSmoothScrollFrame(frame)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the next smooth scroll frame")
+		}
+	}
+
+	assert.Equal(t, len(topLines), smoothScrollFrames)
+	assert.Equal(t, topLines[len(topLines)-1], "line 50")
+
+	// Every frame should take us closer to the target
+	previous := 0
+	for _, topLine := range topLines {
+		var lineNumber int
+		_, err := fmt.Sscanf(topLine, "line %d", &lineNumber)
+		assert.NilError(t, err)
+		assert.Assert(t, lineNumber > previous, "Not moving forward: %v", topLines)
+		previous = lineNumber
+	}
+}
+
+func TestSmoothScrollShortJump(t *testing.T) {
+	pager, _ := createSmoothScrollPager(t)
+
+	origin := pager.smoothScrollOrigin()
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(2), "TestSmoothScrollShortJump")
+	pager.startSmoothScroll(origin)
+
+	assert.Assert(t, !pager.smoothScroll.active)
+	assert.Equal(t, pager.lineIndex().Index(), 2)
+}
+
+func TestSmoothScrollInterrupted(t *testing.T) {
+	pager, screen := createSmoothScrollPager(t)
+
+	origin := pager.smoothScrollOrigin()
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(50), "TestSmoothScrollInterrupted")
+	pager.startSmoothScroll(origin)
+	assert.Assert(t, pager.lineIndex().Index() < 50)
+
+	// Like the main loop does on a key press
+	pager.finishSmoothScroll()
+	assert.Equal(t, pager.lineIndex().Index(), 50)
+
+	// Frames from the interrupted animation should have no effect
+	frame := (<-screen.Events()).(eventSmoothScrollFrame)
+	pager.showNextSmoothScrollFrame(frame)
+	assert.Equal(t, pager.lineIndex().Index(), 50)
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/textstyles"
+	"github.com/walles/moor/v2/twin"
+)
+
+// With StickyHeaderLines or StickyFooterLines, returns the lines to pin to the
+// top and the bottom of the screen. Nil means nothing pinned there.
+func (p *Pager) stickyLines() (header []*reader.NumberedLine, footer []*reader.NumberedLine) {
+	headerCount := max(p.StickyHeaderLines, 0)
+	footerCount := max(p.StickyFooterLines, 0)
+	if headerCount+footerCount == 0 || p.isShowingHelp || p.isSideBySide() {
+		return nil, nil
+	}
+
+	if headerCount+footerCount >= p.heightWithoutStatusBar() {
+		// No room for scrolling anything between the pinned lines
+		return nil, nil
+	}
+
+	p.readerLock.Lock()
+	r := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	lineCount := r.GetLineCount()
+	if lineCount <= headerCount+footerCount {
+		// Nothing to scroll between the pinned lines
+		return nil, nil
+	}
+
+	if headerCount > 0 {
+		header = r.GetLines(linemetadata.Index{}, headerCount).Lines
+	}
+	if footerCount > 0 {
+		footer = r.GetLines(linemetadata.IndexFromZeroBased(lineCount-footerCount), footerCount).Lines
+	}
+
+	return header, footer
+}
+
+// Filtered lines are scrolled from the first to the last one, since the pinned
+// lines generally won't be among them.
+func (p *Pager) isFilteringStickyLines() bool {
+	return p.filterPattern != nil && p.filterPattern.String() != ""
+}
+
+// The topmost line that can be scrolled to. With a sticky header, that's the
+// line after the header, since the header is always visible anyway.
+func (p *Pager) firstScrollableLineIndex() linemetadata.Index {
+	header, _ := p.stickyLines()
+	if len(header) == 0 || p.isFilteringStickyLines() {
+		return linemetadata.Index{}
+	}
+
+	return linemetadata.IndexFromZeroBased(len(header))
+}
+
+// The number of lines that can be scrolled through, not counting the ones
+// pinned to the bottom of the screen by a sticky footer.
+func (p *Pager) scrollableLineCount() int {
+	lineCount := p.Reader().GetLineCount()
+
+	_, footer := p.stickyLines()
+	if len(footer) == 0 || p.isFilteringStickyLines() {
+		return lineCount
+	}
+
+	return lineCount - len(footer)
+}
+
+// Render each pinned line into one screen row. Search hits are highlighted, but
+// don't count when navigating between hits.
+func (p *Pager) renderStickyLines(lines []*reader.NumberedLine, numberPrefixLength int) []renderedLine {
+	renderedLines := make([]renderedLine, 0, len(lines))
+	for _, line := range lines {
+		rendered := p.renderLine(line, numberPrefixLength)[0]
+
+		cells := make([]textstyles.CellWithMetadata, 0, len(rendered.cells))
+		for _, cell := range rendered.cells {
+			cell.StartsSearchHit = false
+			cells = append(cells, cell)
+		}
+		rendered.cells = cells
+
+		renderedLines = append(renderedLines, rendered)
+	}
+
+	return renderedLines
+}
+
+// Underline the last header row to set it apart from the lines scrolling below
+// it, like a frozen table header.
+func underlineStickyHeader(header []renderedLine) {
+	if len(header) == 0 {
+		return
+	}
+
+	last := &header[len(header)-1]
+	for i := range last.cells {
+		last.cells[i].Style = last.cells[i].Style.WithAttr(twin.AttrUnderline)
+	}
+}
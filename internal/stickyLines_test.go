@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestStickyHeader(t *testing.T) {
+	lines := []string{"name value"}
+	for i := range 50 {
+		lines = append(lines, fmt.Sprint("row", i, " ", i*10))
+	}
+
+	reader := reader.NewFromTextForTesting("TestStickyHeader", strings.Join(lines, "\n"))
+	screen := twin.NewFakeScreen(20, 6) // Header, four lines and the status bar
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.StickyHeaderLines = 1
+	assert.NilError(t, reader.Wait())
+
+	// At the top, the header should not be repeated below itself
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "name value")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "row0 0")
+	assert.Equal(t, screen.GetRow(0)[0].Style, twin.StyleDefault.WithAttr(twin.AttrUnderline))
+
+	// Scrolling down should keep the header at the top
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(20), "TestStickyHeader")
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "name value")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "row19 190")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "row22 220")
+
+	// Scrolling back up should stop below the header
+	pager.scrollPosition = pager.scrollPosition.PreviousLine(100)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "name value")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "row0 0")
+
+	// The header should follow sideways scrolling to stay aligned with the
+	// columns
+	pager.leftColumnZeroBased = 4
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "<value")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "<0")
+
+	// Scrolling to the end should show the last line above the status bar
+	pager.leftColumnZeroBased = 0
+	pager.scrollToEnd()
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "name value")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "row49 490")
+}
+
+func TestStickyHeaderAndFooter(t *testing.T) {
+	lines := []string{"banner one", "banner two"}
+	for i := range 50 {
+		lines = append(lines, fmt.Sprint("row", i, " ", i*10))
+	}
+	lines = append(lines, "total 12250")
+
+	reader := reader.NewFromTextForTesting("TestStickyHeaderAndFooter", strings.Join(lines, "\n"))
+	screen := twin.NewFakeScreen(20, 8) // Two header lines, four lines, one footer line and the status bar
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.StickyHeaderLines = 2
+	pager.StickyFooterLines = 1
+	assert.NilError(t, reader.Wait())
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "banner one")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "banner two")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "row0 0")
+	assert.Equal(t, rowToString(screen.GetRow(5)), "row3 30")
+	assert.Equal(t, rowToString(screen.GetRow(6)), "total 12250")
+
+	// Only the last header line is underlined
+	assert.Equal(t, screen.GetRow(0)[0].Style, twin.StyleDefault)
+	assert.Equal(t, screen.GetRow(1)[0].Style, twin.StyleDefault.WithAttr(twin.AttrUnderline))
+
+	// The middle region scrolls on its own
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(22), "TestStickyHeaderAndFooter")
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "banner two")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "row20 200")
+	assert.Equal(t, rowToString(screen.GetRow(6)), "total 12250")
+
+	// Pinned lines should follow sideways scrolling
+	pager.leftColumnZeroBased = 4
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "<r two")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "< 200")
+	assert.Equal(t, rowToString(screen.GetRow(6)), "< 12250")
+	pager.leftColumnZeroBased = 0
+
+	// At the end, the footer line should not be repeated above itself
+	pager.scrollToEnd()
+	pager.redraw("")
+	assert.Assert(t, pager.isScrolledToEnd())
+	assert.Equal(t, rowToString(screen.GetRow(5)), "row49 490")
+	assert.Equal(t, rowToString(screen.GetRow(6)), "total 12250")
+
+	// Scrolling past the end should stop above the footer
+	pager.scrollPosition = pager.scrollPosition.NextLine(100)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(5)), "row49 490")
+	assert.Equal(t, rowToString(screen.GetRow(6)), "total 12250")
+}
+
+func TestStickyFooterShortInput(t *testing.T) {
+	reader := reader.NewFromTextForTesting("TestStickyFooterShortInput", "first\nsecond\nlast")
+	screen := twin.NewFakeScreen(20, 6)
+	pager := NewPager(reader)
+	pager.screen = screen
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.StickyFooterLines = 1
+	assert.NilError(t, reader.Wait())
+
+	// The footer should go at the bottom of the screen, not right below the
+	// other lines
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "first")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "second")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "last")
+}
@@ -204,7 +204,38 @@ func configureHighlighting(terminalBackground *twin.Color) {
 		log.Trace("Search hit style set to default: ", searchHitStyle)
 	}
 
-	// Figure out a line background that lies between plainTextStyle and searchHit
+	configureSearchHitLineBackground(terminalBackground)
+}
+
+// Use the user's search hit colors rather than the ones from the theme. Nil
+// colors are picked automatically.
+//
+// Expects to be called after styleUI().
+func overrideSearchHitStyle(terminalBackground *twin.Color, foreground *twin.Color, background *twin.Color) {
+	if foreground == nil && background == nil {
+		return
+	}
+
+	// Whichever color is missing becomes black or white, whatever contrasts
+	// best with the one we got
+	if foreground == nil {
+		opposite := getOppositeColor(*background)
+		foreground = &opposite
+	}
+	if background == nil {
+		opposite := getOppositeColor(*foreground)
+		background = &opposite
+	}
+
+	searchHitStyle = twin.StyleDefault.WithForeground(*foreground).WithBackground(*background)
+	log.Trace("Search hit style set from options: ", searchHitStyle)
+
+	configureSearchHitLineBackground(terminalBackground)
+}
+
+// Figure out a line background that lies between plainTextStyle and
+// searchHitStyle
+func configureSearchHitLineBackground(terminalBackground *twin.Color) {
 	var plainBg twin.Color
 	if terminalBackground != nil {
 		plainBg = *terminalBackground
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/alecthomas/chroma/v2"
@@ -35,3 +36,27 @@ func TestSetStyle(t *testing.T) {
 
 	assert.Equal(t, style, twin.StyleDefault.WithAttr(twin.AttrBold).WithForeground(twin.NewColor16(1)))
 }
+
+func TestOverrideSearchHitStyle(t *testing.T) {
+	defer func(original twin.Style) { searchHitStyle = original }(searchHitStyle)
+
+	orange := twin.NewColorHex(0xff8000)
+	overrideSearchHitStyle(nil, nil, &orange)
+	assert.Equal(t, searchHitStyle,
+		twin.StyleDefault.WithForeground(twin.NewColor24Bit(0, 0, 0)).WithBackground(orange))
+
+	// 24 bit colors must be downsampled for terminals that can't show them
+	rendered := searchHitStyle.RenderUpdateFrom(twin.StyleDefault, twin.ColorCount256)
+	assert.Assert(t, strings.Contains(rendered, "48;5;"), rendered)
+	rendered = searchHitStyle.RenderUpdateFrom(twin.StyleDefault, twin.ColorCount16)
+	assert.Assert(t, !strings.Contains(rendered, "48;5;"), rendered)
+	assert.Assert(t, !strings.Contains(rendered, "48;2;"), rendered)
+}
+
+func TestOverrideSearchHitStyleNothing(t *testing.T) {
+	defer func(original twin.Style) { searchHitStyle = original }(searchHitStyle)
+
+	searchHitStyle = twin.StyleDefault.WithAttr(twin.AttrReverse)
+	overrideSearchHitStyle(nil, nil, nil)
+	assert.Equal(t, searchHitStyle, twin.StyleDefault.WithAttr(twin.AttrReverse))
+}
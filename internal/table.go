@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/textstyles"
+)
+
+// Number of screen cells between two table columns, including any separator
+//
+//revive:disable-next-line:var-naming
+const TABLE_COLUMN_GAP = 3
+
+// Should the current file be rendered as a table?
+func (p *Pager) isShowingTable() bool {
+	if p.TsvTable == TSV_TABLE_OFF || p.isShowingHelp {
+		return false
+	}
+
+	reader := p.renderedReader()
+	if reader == nil {
+		return false
+	}
+
+	fileName := reader.FileName
+	if fileName == nil {
+		return false
+	}
+
+	return strings.HasSuffix(strings.ToLower(*fileName), ".tsv")
+}
+
+// Compute the width of each column, based on the widest cell in that column
+func computeTableColumnWidths(rows [][]textstyles.CellWithMetadataSlice) []int {
+	widths := []int{}
+	for _, row := range rows {
+		for columnIndex, cell := range row {
+			width := 0
+			for i := range cell {
+				width += cell[i].Width()
+			}
+
+			if columnIndex >= len(widths) {
+				widths = append(widths, width)
+			} else if width > widths[columnIndex] {
+				widths[columnIndex] = width
+			}
+		}
+	}
+
+	return widths
+}
+
+// Update the column widths from the lines that are about to be rendered
+func (p *Pager) updateTableColumnWidths(lines []*reader.NumberedLine) {
+	rows := make([][]textstyles.CellWithMetadataSlice, 0, len(lines))
+	for _, line := range lines {
+		rows = append(rows, line.HighlightedColumns(plainTextStyle, searchHitStyle, p.lineBackgroundForSearchHits(), p.searchPattern))
+	}
+
+	p.tableColumnWidths = computeTableColumnWidths(rows)
+}
+
+// Pad all cells to their column widths and join them together. The last cell
+// is not padded, to avoid trailing whitespace.
+func (p *Pager) alignTableRow(row []textstyles.CellWithMetadataSlice) textstyles.CellWithMetadataSlice {
+	aligned := textstyles.CellWithMetadataSlice{}
+	for columnIndex, cell := range row {
+		if columnIndex > 0 {
+			separator := ' '
+			if p.TsvTable == TSV_TABLE_SEPARATED {
+				separator = '│'
+			}
+			aligned = append(aligned,
+				textstyles.CellWithMetadata{Rune: ' ', Style: plainTextStyle},
+				textstyles.CellWithMetadata{Rune: separator, Style: lineNumbersStyle},
+				textstyles.CellWithMetadata{Rune: ' ', Style: plainTextStyle},
+			)
+		}
+
+		aligned = append(aligned, cell...)
+
+		isLastColumn := columnIndex == len(row)-1
+		if isLastColumn || columnIndex >= len(p.tableColumnWidths) {
+			continue
+		}
+
+		width := 0
+		for i := range cell {
+			width += cell[i].Width()
+		}
+		for ; width < p.tableColumnWidths[columnIndex]; width++ {
+			aligned = append(aligned, textstyles.CellWithMetadata{Rune: ' ', Style: plainTextStyle})
+		}
+	}
+
+	return aligned
+}
+
+// How far to scroll right (or left, for negative return values) to get to the
+// next (or previous) table column. Returns 0 if there is no such column.
+func (p *Pager) tableColumnScrollDelta(toTheRight bool) int {
+	columnStart := 0
+	previousColumnStart := 0
+	for _, width := range p.tableColumnWidths {
+		if toTheRight && columnStart > p.leftColumnZeroBased {
+			return columnStart - p.leftColumnZeroBased
+		}
+		if !toTheRight && columnStart >= p.leftColumnZeroBased {
+			break
+		}
+
+		previousColumnStart = columnStart
+		columnStart += width + TABLE_COLUMN_GAP
+	}
+
+	if toTheRight {
+		return 0
+	}
+
+	return previousColumnStart - p.leftColumnZeroBased
+}
+
+// How far the left / right arrow keys should scroll. Negative values scroll
+// left.
+func (p *Pager) sideScrollDelta(toTheRight bool) int {
+	if p.isShowingTable() {
+		delta := p.tableColumnScrollDelta(toTheRight)
+		if delta != 0 {
+			return delta
+		}
+	}
+
+	if toTheRight {
+		return p.SideScrollAmount
+	}
+	return -p.SideScrollAmount
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/textstyles"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+const testTsv = "name\tcolor\tweight\n" +
+	"apple\tred\t150\n" +
+	"watermelon\tgreen\t3000\n" +
+	"fig\tpurple"
+
+func createTablePager(t *testing.T, tableOption TsvTableOption) *Pager {
+	r := reader.NewFromTextForTesting("test.tsv", testTsv)
+	fileName := "test.tsv"
+	r.FileName = &fileName
+
+	pager := NewPager(r)
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.TsvTable = tableOption
+	pager.screen = twin.NewFakeScreen(40, 10)
+
+	assert.NilError(t, r.Wait())
+
+	return pager
+}
+
+func TestComputeTableColumnWidths(t *testing.T) {
+	rows := [][]textstyles.CellWithMetadataSlice{}
+	for _, line := range reader.NewFromTextForTesting("test", testTsv).GetLines(linemetadata.Index{}, 10).Lines {
+		rows = append(rows, line.HighlightedColumns(twin.StyleDefault, twin.StyleDefault, nil, nil))
+	}
+
+	assert.DeepEqual(t, computeTableColumnWidths(rows), []int{len("watermelon"), len("purple"), len("weight")})
+}
+
+func TestComputeTableColumnWidthsWide(t *testing.T) {
+	line := reader.NewLine("日本\tx")
+	numberedLine := reader.NumberedLine{Line: &line}
+	rows := [][]textstyles.CellWithMetadataSlice{
+		numberedLine.HighlightedColumns(twin.StyleDefault, twin.StyleDefault, nil, nil),
+	}
+
+	// Two double width characters
+	assert.DeepEqual(t, computeTableColumnWidths(rows), []int{4, 1})
+}
+
+func TestRenderTableAligned(t *testing.T) {
+	pager := createTablePager(t, TSV_TABLE_ALIGNED)
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, len(rendered), 4)
+	assert.Equal(t, renderedToString(rendered[0].cells), "name         color    weight")
+	assert.Equal(t, renderedToString(rendered[1].cells), "apple        red      150")
+	assert.Equal(t, renderedToString(rendered[2].cells), "watermelon   green    3000")
+	assert.Equal(t, renderedToString(rendered[3].cells), "fig          purple")
+}
+
+func TestRenderTableSeparated(t *testing.T) {
+	pager := createTablePager(t, TSV_TABLE_SEPARATED)
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "name       │ color  │ weight")
+	assert.Equal(t, renderedToString(rendered[3].cells), "fig        │ purple")
+}
+
+func TestRenderTableOnlyForTsvFiles(t *testing.T) {
+	pager := createTablePager(t, TSV_TABLE_ALIGNED)
+	fileName := "test.txt"
+	pager.readers[0].FileName = &fileName
+
+	rendered := pager.renderLines().lines
+	assert.Equal(t, renderedToString(rendered[0].cells), "name    color   weight")
+}
+
+func TestTableScrollsByColumn(t *testing.T) {
+	pager := createTablePager(t, TSV_TABLE_ALIGNED)
+	pager.renderLines()
+
+	pager.moveRight(pager.sideScrollDelta(true))
+	assert.Equal(t, pager.leftColumnZeroBased, len("watermelon   "))
+
+	pager.moveRight(pager.sideScrollDelta(true))
+	assert.Equal(t, pager.leftColumnZeroBased, len("watermelon   purple   "))
+
+	pager.moveRight(pager.sideScrollDelta(false))
+	assert.Equal(t, pager.leftColumnZeroBased, len("watermelon   "))
+
+	pager.moveRight(pager.sideScrollDelta(false))
+	assert.Equal(t, pager.leftColumnZeroBased, 0)
+}
@@ -95,6 +95,12 @@ func WithoutFormatting(s string, lineIndex *linemetadata.Index) string {
 				runeCount++
 
 			default:
+				if twin.IsCombining(runeValue) && stripped.Len() > 0 {
+					// Shares a cell with the preceding character, keep it for
+					// searching but don't count it
+					stripped.WriteRune(runeValue)
+					continue
+				}
 				if !twin.Printable(runeValue) {
 					stripped.WriteRune('?')
 					runeCount++
@@ -109,6 +115,25 @@ func WithoutFormatting(s string, lineIndex *linemetadata.Index) string {
 	return stripped.String()
 }
 
+// StripANSI returns the text of s as it would be shown on screen, without any
+// ANSI escape codes, man page overstrike formatting or hyperlinks. Tabs are
+// expanded into spaces.
+func StripANSI(s string) string {
+	return WithoutFormatting(s, nil)
+}
+
+// StyledRunesFromANSI turns a (formatted) string into one styled rune per
+// character. Formatting is parsed the same way as when paging.
+func StyledRunesFromANSI(s string) []twin.StyledRune {
+	cells := StyledRunesFromString(twin.StyleDefault, s, nil).StyledRunes
+
+	styledRunes := make([]twin.StyledRune, 0, len(cells))
+	for _, cell := range cells {
+		styledRunes = append(styledRunes, cell.ToStyledRune())
+	}
+	return styledRunes
+}
+
 // Turn a (formatted) string into a series of screen cells
 //
 // The prefix will be prepended to the string before parsing. The lineIndex is
@@ -164,6 +189,12 @@ func StyledRunesFromString(plainTextStyle twin.Style, s string, lineIndex *linem
 				})
 
 			default:
+				if twin.IsCombining(token.Rune) && len(cells) > 0 {
+					// Draw it on top of the preceding cell rather than in a
+					// cell of its own
+					cells[len(cells)-1].Combining += string(token.Rune)
+					continue
+				}
 				if !twin.Printable(token.Rune) {
 					switch UnprintableStyle {
 					case UnprintableStyleHighlight:
@@ -418,6 +449,11 @@ type _StyledString struct {
 func splitIntoNumbers(s string, numbersBuffer []uint) ([]uint, error) {
 	numbers := numbersBuffer[:0]
 
+	// Parameters are separated by ';', and can have sub parameters separated
+	// by ':'
+	parameterStart := 0
+	parameterHasSubParameters := false
+
 	afterLastSeparator := 0
 	for i, char := range s {
 		if char >= '0' && char <= '9' {
@@ -426,17 +462,28 @@ func splitIntoNumbers(s string, numbersBuffer []uint) ([]uint, error) {
 
 		if char == ';' || char == ':' {
 			numberString := s[afterLastSeparator:i]
-			if numberString == "" {
-				numbers = append(numbers, 0)
+			afterLastSeparator = i + 1
+
+			number := uint64(0)
+			if numberString != "" {
+				var err error
+				number, err = strconv.ParseUint(numberString, 10, 64)
+				if err != nil {
+					return numbers, err
+				}
+			}
+			numbers = append(numbers, uint(number))
+
+			if char == ':' {
+				parameterHasSubParameters = true
 				continue
 			}
 
-			number, err := strconv.ParseUint(numberString, 10, 64)
-			if err != nil {
-				return numbers, err
+			if parameterHasSubParameters {
+				numbers = withoutColorSpaceID(numbers, parameterStart)
 			}
-			numbers = append(numbers, uint(number))
-			afterLastSeparator = i + 1
+			parameterStart = len(numbers)
+			parameterHasSubParameters = false
 			continue
 		}
 
@@ -445,19 +492,42 @@ func splitIntoNumbers(s string, numbersBuffer []uint) ([]uint, error) {
 
 	// Now we have to handle the last number
 	numberString := s[afterLastSeparator:]
-	if numberString == "" {
-		numbers = append(numbers, 0)
-		return numbers, nil
-	}
-	number, err := strconv.ParseUint(numberString, 10, 64)
-	if err != nil {
-		return numbers, err
+	number := uint64(0)
+	if numberString != "" {
+		var err error
+		number, err = strconv.ParseUint(numberString, 10, 64)
+		if err != nil {
+			return numbers, err
+		}
 	}
 	numbers = append(numbers, uint(number))
 
+	if parameterHasSubParameters {
+		numbers = withoutColorSpaceID(numbers, parameterStart)
+	}
+
 	return numbers, nil
 }
 
+// In the ITU-T T.416 colon separated form, 24 bit colors have a color space ID
+// before the RGB values: "38:2:<color space>:R:G:B". The color space ID is
+// usually empty. Drop it so that the result looks like the semicolon separated
+// "38;2;R;G;B".
+//
+// parameterStart is the index in numbers where the color parameter starts.
+func withoutColorSpaceID(numbers []uint, parameterStart int) []uint {
+	parameter := numbers[parameterStart:]
+	if len(parameter) != 6 || parameter[1] != 2 {
+		return numbers
+	}
+	if parameter[0] != 38 && parameter[0] != 48 && parameter[0] != 58 {
+		return numbers
+	}
+
+	copy(parameter[2:], parameter[3:])
+	return numbers[:len(numbers)-1]
+}
+
 // rawUpdateStyle parses a string of the form "33m" into changes to style. This
 // is what comes after ESC[ in an ANSI SGR sequence.
 func rawUpdateStyle(style twin.Style, escapeSequenceWithoutHeader string, numbersBuffer []uint) (twin.Style, []uint, error) {
@@ -7,7 +7,6 @@ import (
 	"path"
 	"strings"
 	"testing"
-	"unicode/utf8"
 
 	"github.com/google/go-cmp/cmp"
 	log "github.com/sirupsen/logrus"
@@ -23,7 +22,21 @@ const samplesDir = "../../sample-files"
 func cellsToPlainString(cells []CellWithMetadata) string {
 	returnMe := ""
 	for _, cell := range cells {
-		returnMe += string(cell.Rune)
+		returnMe += string(cell.Rune) + cell.Combining
+	}
+
+	return returnMe
+}
+
+// Returns the runes of a plain string that get cells of their own. Combining
+// marks are skipped, they share a cell with the rune before them.
+func cellRunes(plain string) []rune {
+	returnMe := []rune{}
+	for _, char := range plain {
+		if len(returnMe) > 0 && twin.IsCombining(char) {
+			continue
+		}
+		returnMe = append(returnMe, char)
 	}
 
 	return returnMe
@@ -81,15 +94,15 @@ func TestTokenize(t *testing.T) {
 
 				tokens := StyledRunesFromString(twin.StyleDefault, line, lineIndex).StyledRunes
 				plainString := WithoutFormatting(line, lineIndex)
-				if len(tokens) != utf8.RuneCountInString(plainString) {
+				plainStringChars := cellRunes(plainString)
+				if len(tokens) != len(plainStringChars) {
 					t.Errorf("%s:%s: len(tokens)=%d, len(plainString)=%d for: <%s>",
 						fileName, lineIndex.Format(),
-						len(tokens), utf8.RuneCountInString(plainString), line)
+						len(tokens), len(plainStringChars), line)
 					continue
 				}
 
 				// Tokens and plain have the same lengths, compare contents
-				plainStringChars := []rune(plainString)
 				for index, plainChar := range plainStringChars {
 					cellChar := tokens[index]
 					if cellChar.Rune == plainChar {
@@ -256,6 +269,27 @@ func TestRawUpdateStyle(t *testing.T) {
 	assert.Equal(t, numberColored, twin.StyleDefault.WithForeground(twin.NewColor16(3)))
 }
 
+// ITU-T T.416 colon separated 24 bit colors, with and without the color space ID
+func TestRawUpdateStyleColonColors(t *testing.T) {
+	expected, _, err := rawUpdateStyle(twin.StyleDefault, "38;2;10;20;30m", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, expected, twin.StyleDefault.WithForeground(twin.NewColor24Bit(10, 20, 30)))
+
+	for _, sequence := range []string{"38:2::10:20:30m", "38:2:0:10:20:30m", "38:2:10:20:30m"} {
+		colored, _, err := rawUpdateStyle(twin.StyleDefault, sequence, nil)
+		assert.NilError(t, err, sequence)
+		assert.Equal(t, colored, expected, sequence)
+	}
+
+	// Mixed with other parameters
+	colored, _, err := rawUpdateStyle(twin.StyleDefault, "1;48:2::10:20:30;58:2::40:50:60m", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, colored, twin.StyleDefault.
+		WithAttr(twin.AttrBold).
+		WithBackground(twin.NewColor24Bit(10, 20, 30)).
+		WithUnderlineColor(twin.NewColor24Bit(40, 50, 60)))
+}
+
 // Test with the recommended terminator ESC-backslash.
 //
 // Ref: https://gist.github.com/egmontkob/eb114294efbcd5adb1944c9f3cb5feda#the-escape-sequence
@@ -337,3 +371,142 @@ func TestRawUpdateStyleResetDoesNotAffectHyperlink(t *testing.T) {
 	assert.Assert(t, updated.HyperlinkURL() != nil)
 	assert.Equal(t, *updated.HyperlinkURL(), url)
 }
+
+func TestStripANSI(t *testing.T) {
+	// Man page bold and underline
+	assert.Equal(t, StripANSI("ab\bbc"), "abc")
+	assert.Equal(t, StripANSI("a_\bbc"), "abc")
+
+	// Man page bullet points
+	assert.Equal(t, StripANSI("a+\b+\bo\bob"), "a•b")
+	assert.Equal(t, StripANSI("a+\bob"), "a•b")
+
+	// Hyperlinks, both terminators
+	assert.Equal(t, StripANSI("a\x1b]8;;http://example.com\x1b\\bc\x1b]8;;\x1b\\d"), "abcd")
+	assert.Equal(t, StripANSI("a\x1b]8;;http://example.com\x07bc\x1b]8;;\x07d"), "abcd")
+
+	// Composite colors
+	assert.Equal(t, StripANSI("a\x1b[38;5;74mb\x1b[48;2;10;20;30mc\x1b[md"), "abcd")
+
+	// Plain text should pass through untouched
+	assert.Equal(t, StripANSI("hello"), "hello")
+}
+
+func TestStyledRunesFromANSI(t *testing.T) {
+	url := "http://example.com"
+
+	runes := StyledRunesFromANSI("a\x1b[38;5;74mb\x1b[m\x1b]8;;" + url + "\x1b\\c\x1b]8;;\x1b\\_\bd")
+	assert.Equal(t, len(runes), 4)
+	assert.Equal(t, runes[0], twin.NewStyledRune('a', twin.StyleDefault))
+	assert.Equal(t, runes[1], twin.NewStyledRune('b', twin.StyleDefault.WithForeground(twin.NewColor256(74))))
+	assert.Equal(t, *runes[2].Style.HyperlinkURL(), url)
+	assert.Equal(t, runes[3], twin.NewStyledRune('d', twin.StyleDefault.WithAttr(twin.AttrUnderline)))
+}
+
+// Ref: https://vt100.net/docs/vt510-rm/REP.html
+func TestRepeatPreviousCharacter(t *testing.T) {
+	cells := StyledRunesFromString(twin.StyleDefault, "a\x1b[3b", nil).StyledRunes
+	assert.Equal(t, len(cells), 4)
+	for _, cell := range cells {
+		assert.Equal(t, cell.Rune, 'a')
+	}
+
+	// Repeats should get the current style
+	cells = StyledRunesFromString(twin.StyleDefault, "x\x1b[1m\x1b[b", nil).StyledRunes
+	assert.Equal(t, len(cells), 2)
+	assert.Equal(t, cells[1].Rune, 'x')
+	assert.Equal(t, cells[1].Style, twin.StyleDefault.WithAttr(twin.AttrBold))
+
+	assert.Equal(t, WithoutFormatting("a\x1b[3bc", nil), "aaaac")
+}
+
+// Huge repeat counts shouldn't make us run out of memory
+func TestRepeatPreviousCharacterCapped(t *testing.T) {
+	cells := StyledRunesFromString(twin.StyleDefault, "a\x1b[2000000000b", nil).StyledRunes
+	assert.Equal(t, len(cells), 1+maxRepeatCount)
+}
+
+func TestASCIILines(t *testing.T) {
+	toASCII := func(s string) []CellWithMetadata {
+		cells := StyledRunesFromString(twin.StyleDefault, s, nil).StyledRunes
+		ToASCIILines(cells)
+		return cells
+	}
+	runesOf := func(cells []CellWithMetadata) string {
+		runes := ""
+		for _, cell := range cells {
+			runes += string(cell.Rune)
+		}
+		return runes
+	}
+
+	cells := toASCII("┌─┬─┐\x1b[1m│\x1b[m╰═╯")
+	assert.Equal(t, runesOf(cells), "+-+-+|+-+")
+	assert.Equal(t, cells[5].Style, twin.StyleDefault.WithAttr(twin.AttrBold))
+
+	// Other non-ASCII characters are kept
+	assert.Equal(t, runesOf(toASCII("│ö│")), "|ö|")
+
+	// Only the cells are changed, searching sees the original characters
+	assert.Equal(t, WithoutFormatting("┌─┐", nil), "┌─┐")
+}
+
+func TestDetectURLs(t *testing.T) {
+	linkified := func(s string) []CellWithMetadata {
+		cells := StyledRunesFromString(twin.StyleDefault, s, nil).StyledRunes
+		LinkifyURLs(cells)
+		return cells
+	}
+
+	cells := linkified("See https://example.com/a?b=c.")
+	url := "https://example.com/a?b=c"
+	assert.Equal(t, cells[3].Style, twin.StyleDefault, "Text before the URL should not be linked")
+	assert.Equal(t, *cells[4].Style.HyperlinkURL(), url)
+	assert.Equal(t, *cells[4+len(url)-1].Style.HyperlinkURL(), url)
+	assert.Equal(t, cells[4+len(url)].Style, twin.StyleDefault, "The final period should not be linked")
+
+	// Balanced parentheses are part of the URL, the unbalanced closing one
+	// isn't
+	cells = linkified("(https://en.wikipedia.org/wiki/Less_(Unix))")
+	assert.Equal(t, *cells[1].Style.HyperlinkURL(), "https://en.wikipedia.org/wiki/Less_(Unix)")
+	assert.Assert(t, cells[len(cells)-1].Style.HyperlinkURL() == nil)
+
+	// Already linked text should keep its own link
+	osc8 := "\x1b]8;;https://other.example.com\x1b\\https://example.com\x1b]8;;\x1b\\"
+	cells = linkified(osc8)
+	assert.Equal(t, *cells[0].Style.HyperlinkURL(), "https://other.example.com")
+
+	// Not linkified, no links
+	cells = StyledRunesFromString(twin.StyleDefault, "https://example.com", nil).StyledRunes
+	assert.Assert(t, cells[0].Style.HyperlinkURL() == nil)
+}
+
+func TestCombiningDiacritics(t *testing.T) {
+	// "e" followed by U+0301 COMBINING ACUTE ACCENT, styled differently to
+	// verify that the mark goes with the base character regardless
+	line := "x\x1b[1me\x1b[m\u0301y"
+
+	cells := StyledRunesFromString(twin.StyleDefault, line, nil).StyledRunes
+	assert.Equal(t, len(cells), 3)
+	assert.Equal(t, cells[1].Rune, 'e')
+	assert.Equal(t, cells[1].Combining, "\u0301")
+	assert.Equal(t, cells[1].Style, twin.StyleDefault.WithAttr(twin.AttrBold))
+	assert.Equal(t, cells[1].Width(), 1)
+	assert.Equal(t, CellWithMetadataSlice(cells).Width(), 3)
+
+	assert.Equal(t, WithoutFormatting(line, nil), "xe\u0301y")
+	assert.Equal(t, len(cellRunes(WithoutFormatting(line, nil))), len(cells))
+
+	// A mark at the start of the line has nothing to combine with
+	cells = StyledRunesFromString(twin.StyleDefault, "\u0301x", nil).StyledRunes
+	assert.Equal(t, len(cells), 2)
+	assert.Equal(t, cells[0].Rune, '\u0301')
+}
+
+func TestCombiningDiacriticsTabStops(t *testing.T) {
+	line := "e\u0301\tx"
+
+	cells := StyledRunesFromString(twin.StyleDefault, line, nil).StyledRunes
+	assert.Equal(t, cellsToPlainString(cells), "e\u0301"+strings.Repeat(" ", TabSize-1)+"x")
+	assert.Equal(t, WithoutFormatting(line, nil), cellsToPlainString(cells))
+}
@@ -0,0 +1,35 @@
+package textstyles
+
+// ASCII approximations of common box drawing characters
+//
+// Ref: https://en.wikipedia.org/wiki/Box-drawing_characters
+var asciiLineFallbacks = map[rune]rune{
+	// Horizontal lines: light, heavy, double, dashed
+	'─': '-', '━': '-', '═': '-', '┄': '-', '┅': '-', '┈': '-', '┉': '-', '╌': '-', '╍': '-',
+
+	// Vertical lines: light, heavy, double, dashed
+	'│': '|', '┃': '|', '║': '|', '┆': '|', '┇': '|', '┊': '|', '┋': '|', '╎': '|', '╏': '|',
+
+	// Corners: light, heavy, double, rounded
+	'┌': '+', '┐': '+', '└': '+', '┘': '+',
+	'┏': '+', '┓': '+', '┗': '+', '┛': '+',
+	'╔': '+', '╗': '+', '╚': '+', '╝': '+',
+	'╭': '+', '╮': '+', '╰': '+', '╯': '+',
+
+	// Tees and crossings: light, heavy, double
+	'├': '+', '┤': '+', '┬': '+', '┴': '+', '┼': '+',
+	'┣': '+', '┫': '+', '┳': '+', '┻': '+', '╋': '+',
+	'╠': '+', '╣': '+', '╦': '+', '╩': '+', '╬': '+',
+}
+
+// Replace box drawing characters in the cells with their ASCII approximations.
+// For terminals or fonts lacking box drawing characters. Other characters are
+// left unchanged.
+func ToASCIILines(cells []CellWithMetadata) {
+	for i := range cells {
+		fallback, found := asciiLineFallbacks[cells[i].Rune]
+		if found {
+			cells[i].Rune = fallback
+		}
+	}
+}
@@ -11,6 +11,9 @@ type CellWithMetadata struct {
 	Rune  rune
 	Style twin.Style
 
+	// Combining marks to draw on top of Rune, see twin.StyledRune.Combining
+	Combining string
+
 	cachedWidth *int
 
 	StartsSearchHit bool // True if this cell is the first cell of a search hit
@@ -22,6 +25,10 @@ func (r CellWithMetadata) Equal(b CellWithMetadata) bool {
 		return false
 	}
 
+	if r.Combining != b.Combining {
+		return false
+	}
+
 	if !r.Style.Equal(b.Style) {
 		return false
 	}
@@ -34,7 +41,7 @@ func (r CellWithMetadata) Equal(b CellWithMetadata) bool {
 }
 
 func (r CellWithMetadata) ToStyledRune() twin.StyledRune {
-	return twin.NewStyledRune(r.Rune, r.Style)
+	return twin.StyledRune{Rune: r.Rune, Style: r.Style, Combining: r.Combining}
 }
 
 func (r *CellWithMetadata) Width() int {
@@ -48,6 +55,21 @@ func (r *CellWithMetadata) Width() int {
 	return w
 }
 
+// Split plain text, as returned by WithoutFormatting(), into the text of each
+// cell. Combining marks share a cell with the character before them, just like
+// in StyledRunesFromString().
+func PlainCells(plain string) []string {
+	cells := make([]string, 0, len(plain))
+	for byteIndex, char := range plain {
+		if byteIndex > 0 && twin.IsCombining(char) {
+			cells[len(cells)-1] += string(char)
+			continue
+		}
+		cells = append(cells, string(char))
+	}
+	return cells
+}
+
 type CellWithMetadataSlice []CellWithMetadata
 
 func (runes CellWithMetadataSlice) Equal(other CellWithMetadataSlice) bool {
@@ -93,3 +115,12 @@ func (runes CellWithMetadataSlice) WithoutSpaceRight() CellWithMetadataSlice {
 	// All whitespace, return empty
 	return CellWithMetadataSlice{}
 }
+
+// How many screen columns these cells cover
+func (runes CellWithMetadataSlice) Width() int {
+	width := 0
+	for i := range runes {
+		width += runes[i].Width()
+	}
+	return width
+}
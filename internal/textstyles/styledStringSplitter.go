@@ -2,6 +2,7 @@ package textstyles
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -24,6 +25,9 @@ type styledStringSplitter struct {
 	inProgressStyle  twin.Style
 	numbersBuffer    []uint
 
+	// The most recent plain rune, for the REP control sequence to repeat
+	lastRune rune
+
 	trailer twin.Style
 
 	callback func(str string, style twin.Style)
@@ -50,6 +54,36 @@ func styledStringsFromString(plainTextStyle twin.Style, s string, lineIndex *lin
 	return splitter.trailer
 }
 
+// SplitColumns splits a (formatted) line at its tabs. Each column starts with
+// the formatting in effect at the end of the previous one, so that styles
+// spanning multiple columns are kept.
+func SplitColumns(s string) []string {
+	rawColumns := strings.Split(s, "\t")
+	if !strings.ContainsAny(s, "\x1b") {
+		// No formatting to carry over
+		return rawColumns
+	}
+
+	columns := make([]string, 0, len(rawColumns))
+	style := twin.StyleDefault
+	for _, rawColumn := range rawColumns {
+		column := style.RenderUpdateFrom(twin.StyleDefault, twin.ColorCount24bit) + rawColumn
+		columns = append(columns, column)
+
+		splitter := styledStringSplitter{
+			input:           column,
+			plainTextStyle:  twin.StyleDefault,
+			inProgressStyle: twin.StyleDefault,
+			callback:        func(string, twin.Style) {},
+			trailer:         twin.StyleDefault,
+		}
+		splitter.run()
+		style = splitter.inProgressStyle
+	}
+
+	return columns
+}
+
 func (s *styledStringSplitter) nextChar() rune {
 	if s.nextByteIndex >= len(s.input) {
 		s.previousByteIndex = s.nextByteIndex
@@ -113,6 +147,7 @@ func (s *styledStringSplitter) run() {
 
 func (s *styledStringSplitter) handleRune(char rune) {
 	s.inProgressString.WriteRune(char)
+	s.lastRune = char
 }
 
 func (s *styledStringSplitter) handleEscape() error {
@@ -194,6 +229,10 @@ func (s *styledStringSplitter) handleCompleteControlSequence(sequence string) er
 		return nil
 	}
 
+	if lastChar == 'b' {
+		return s.handleRepeat(sequence)
+	}
+
 	if lastChar == 'n' {
 		// Device status report, expects us to respond, just ignore them.
 		//
@@ -204,6 +243,42 @@ func (s *styledStringSplitter) handleCompleteControlSequence(sequence string) er
 	return fmt.Errorf("Unhandled CSI type %q", lastChar)
 }
 
+// Repeat counts larger than this are capped. Wider than any reasonable screen,
+// but protects us from running out of memory on garbage input.
+const maxRepeatCount = 5000
+
+// Repeat the previous character. If the whole CSI sequence is ESC[3b, you
+// should call this function with just "3b".
+//
+// Ref: https://vt100.net/docs/vt510-rm/REP.html
+func (s *styledStringSplitter) handleRepeat(sequence string) error {
+	count := 1
+	if len(sequence) > 1 {
+		var err error
+		count, err = strconv.Atoi(sequence[:len(sequence)-1])
+		if err != nil {
+			return fmt.Errorf("Invalid repeat count %q", sequence[:len(sequence)-1])
+		}
+		if count == 0 {
+			// Zero means one, just like for other CSI sequences
+			count = 1
+		}
+		if count > maxRepeatCount {
+			count = maxRepeatCount
+		}
+	}
+
+	if s.lastRune == 0 {
+		return fmt.Errorf("Nothing to repeat")
+	}
+
+	for range count {
+		s.inProgressString.WriteRune(s.lastRune)
+	}
+
+	return nil
+}
+
 // Consume an OSC sequence up until it ends
 func (s *styledStringSplitter) consumeOsc() error {
 	// Points to right after "ESC]"
@@ -148,3 +148,10 @@ func TestUnsupportedG0CharSet(t *testing.T) {
 	assert.Equal(t, 1, len(styledStrings))
 	assert.Equal(t, "\x1b(Xhello", styledStrings[0].String)
 }
+
+func TestSplitColumns(t *testing.T) {
+	assert.DeepEqual(t, SplitColumns("a\tb"), []string{"a", "b"})
+
+	// The bold from the first column should carry over into the second one
+	assert.DeepEqual(t, SplitColumns("\x1b[1ma\tb\x1b[m\tc"), []string{"\x1b[1ma", "\x1b[1mb\x1b[m", "c"})
+}
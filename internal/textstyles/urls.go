@@ -0,0 +1,75 @@
+package textstyles
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Conservative on purpose, we'd rather miss a link than make one out of
+// something that isn't.
+var urlPattern = regexp.MustCompile(`\bhttps?://[A-Za-z0-9.-]+(:[0-9]+)?(/[^\s<>"'` + "`" + `]*)?`)
+
+// Make hyperlinks out of the URLs in cells, unless they are already linked.
+// This works just like if they had been marked up using OSC 8.
+func LinkifyURLs(cells []CellWithMetadata) {
+	runes := make([]rune, 0, len(cells))
+	for _, cell := range cells {
+		runes = append(runes, cell.Rune)
+	}
+	text := string(runes)
+	if !strings.Contains(text, "://") {
+		// Shortcut for the common case
+		return
+	}
+
+	for _, match := range urlPattern.FindAllStringIndex(text, -1) {
+		url := trimURLEnd(text[match[0]:match[1]])
+		if !urlPattern.MatchString(url) {
+			// Nothing left after trimming
+			continue
+		}
+
+		// The match is in bytes, our cells are in runes
+		firstCell := len([]rune(text[:match[0]]))
+		lastCell := firstCell + len([]rune(url)) // Exclusive
+
+		if isLinked(cells[firstCell:lastCell]) {
+			continue
+		}
+
+		for i := firstCell; i < lastCell; i++ {
+			cells[i].Style = cells[i].Style.WithHyperlink(&url)
+		}
+	}
+}
+
+// Drop trailing punctuation, which is more likely part of the surrounding text
+// than of the URL. Closing parentheses are kept if they close something in the
+// URL, like in Wikipedia links.
+func trimURLEnd(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		switch {
+		case strings.IndexByte(".,;:!?", last) >= 0:
+			url = url[:len(url)-1]
+		case last == ')' && strings.Count(url, "(") < strings.Count(url, ")"):
+			url = url[:len(url)-1]
+		case last == ']' && strings.Count(url, "[") < strings.Count(url, "]"):
+			url = url[:len(url)-1]
+		default:
+			return url
+		}
+	}
+
+	return url
+}
+
+func isLinked(cells []CellWithMetadata) bool {
+	for _, cell := range cells {
+		if cell.Style.HyperlinkURL() != nil {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"path/filepath"
+
+	"github.com/walles/moor/v2/twin"
+)
+
+// What to show in the terminal window title
+func (p *Pager) title() string {
+	p.readerLock.Lock()
+	r := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	r.Lock()
+	name := r.Name
+	fileName := r.FileName
+	r.Unlock()
+
+	if fileName != nil {
+		return "moor: " + filepath.Base(*fileName)
+	}
+	if name != nil && *name != "" {
+		// Could be a command, don't cut it up like a path
+		return "moor: " + *name
+	}
+	return "moor: stdin"
+}
+
+// Update the terminal window title if the current file has changed
+func (p *Pager) updateTitle() {
+	titleSetter, ok := p.screen.(twin.TitleSetter)
+	if !ok {
+		return
+	}
+
+	title := p.title()
+	if title == p.shownTitle {
+		return
+	}
+
+	titleSetter.SetTitle(title)
+	p.shownTitle = title
+}
@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestTitle(t *testing.T) {
+	screen := twin.NewFakeScreen(20, 5)
+	pager := NewPager(reader.NewFromTextForTesting("", "hello"))
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+
+	pager.redraw("")
+	assert.Equal(t, screen.Title(), "moor: stdin")
+
+	fileName := "/some/where/pager.go"
+	pager.readers[0].FileName = &fileName
+	pager.redraw("")
+	assert.Equal(t, screen.Title(), "moor: pager.go")
+}
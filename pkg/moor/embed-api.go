@@ -12,6 +12,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/walles/moor/v2/internal"
 	internalReader "github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/textstyles"
 	"github.com/walles/moor/v2/twin"
 	"golang.org/x/term"
 )
@@ -34,6 +35,10 @@ type Options struct {
 	// Long lines are truncated by default. Set this to true to wrap them.
 	// Users can toggle wrapping on / off using the 'w' key while paging.
 	WrapLongLines bool
+
+	// The command line that produced the paged text, if any. Users can copy
+	// it to the clipboard using the 'C' key while paging.
+	SourceCommand string
 }
 
 // If stdout is not a terminal, the stream contents will just be printed to
@@ -100,6 +105,20 @@ func PageFromString(text string, options Options) error {
 	return PageFromStream(strings.NewReader(text), options)
 }
 
+// StripANSI returns the text as it would be shown by the pager, without any
+// ANSI escape codes, man page overstrike formatting or hyperlinks.
+//
+// Useful for measuring or searching formatted text without paging it.
+func StripANSI(text string) string {
+	return textstyles.StripANSI(text)
+}
+
+// StyledRunesFromANSI parses formatted text the same way the pager does, and
+// returns one styled rune per character.
+func StyledRunesFromANSI(text string) []twin.StyledRune {
+	return textstyles.StyledRunesFromANSI(text)
+}
+
 func startLogCollection() *internal.LogWriter {
 	log.SetLevel(logLevel)
 
@@ -143,6 +162,7 @@ func getColorFormatter() chroma.Formatter {
 func pageFromReader(reader *internalReader.ReaderImpl, options Options) error {
 	pager := internal.NewPager(reader)
 	pager.WrapLongLines = options.WrapLongLines
+	pager.SourceCommand = options.SourceCommand
 
 	screen, e := twin.NewScreen()
 	if e != nil {
@@ -68,3 +68,15 @@ func TestEmbedApi(t *testing.T) {
 		demoPageFromString()
 	}
 }
+
+func TestStripANSI(t *testing.T) {
+	stripped := StripANSI("a\x1b[1mb\x1b[m\x1b]8;;http://example.com\x1b\\c\x1b]8;;\x1b\\_\bd")
+	if stripped != "abcd" {
+		t.Errorf("Expected <abcd>, got <%s>", stripped)
+	}
+
+	runes := StyledRunesFromANSI("a\x1b[1mb")
+	if len(runes) != 2 || runes[1].Rune != 'b' {
+		t.Errorf("Expected two runes ending with 'b', got %v", runes)
+	}
+}
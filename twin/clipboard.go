@@ -0,0 +1,39 @@
+package twin
+
+import "strings"
+
+// ClipboardText turns rows of screen cells into text suitable for the
+// clipboard, with rows separated by newlines.
+//
+// If withANSI is false the text is plain. Otherwise styling is kept as ANSI
+// escape codes. Each row then starts with a style reset and ends in the default
+// style, so that it looks the same no matter where it is pasted.
+func ClipboardText(rows [][]StyledRune, withANSI bool, terminalColorCount ColorCount) string {
+	var builder strings.Builder
+	for rowIndex, row := range rows {
+		if rowIndex > 0 {
+			builder.WriteRune('\n')
+		}
+
+		if withANSI {
+			builder.WriteString("\x1b[m")
+		}
+
+		lastStyle := StyleDefault
+		for _, cell := range withoutHiddenRunes(row) {
+			if withANSI && cell.Style != lastStyle {
+				builder.WriteString(cell.Style.RenderUpdateFrom(lastStyle, terminalColorCount))
+				lastStyle = cell.Style
+			}
+
+			builder.WriteRune(cell.Rune)
+			builder.WriteString(cell.Combining)
+		}
+
+		if withANSI && lastStyle != StyleDefault {
+			builder.WriteString(StyleDefault.RenderUpdateFrom(lastStyle, terminalColorCount))
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,35 @@
+package twin
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestClipboardText(t *testing.T) {
+	bold := StyleDefault.WithAttr(AttrBold)
+	red := StyleDefault.WithForeground(NewColor16(1))
+	rows := [][]StyledRune{
+		{NewStyledRune('a', StyleDefault), NewStyledRune('b', bold), NewStyledRune('c', StyleDefault)},
+		{NewStyledRune('x', red)},
+		{},
+	}
+
+	assert.Equal(t, ClipboardText(rows, false, ColorCount16), "abc\nx\n")
+
+	ansi := ClipboardText(rows, true, ColorCount16)
+	assert.Equal(t, strings.ReplaceAll(ansi, "\x1b", "ESC"),
+		"ESC[ma"+"ESC[1mb"+"ESC[mc\n"+
+			"ESC[m"+"ESC[31mx"+"ESC[m\n"+
+			"ESC[m")
+}
+
+func TestClipboardTextWideChars(t *testing.T) {
+	// The cell after a wide char is hidden by it, and shouldn't be copied
+	rows := [][]StyledRune{
+		{NewStyledRune('日', StyleDefault), NewStyledRune(' ', StyleDefault), NewStyledRune('x', StyleDefault)},
+	}
+
+	assert.Equal(t, ClipboardText(rows, false, ColorCount16), "日x")
+}
@@ -3,6 +3,7 @@ package twin
 import (
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/alecthomas/chroma/v2"
 )
@@ -44,6 +45,20 @@ const (
 // Reset to default foreground / background color
 var ColorDefault = newColor(ColorCountDefault, 0)
 
+// Finding the closest palette color is slow, and colorful input tends to reuse
+// the same colors over and over.
+//
+//revive:disable-next-line:var-naming
+const DOWNSAMPLE_CACHE_MAX_SIZE = 10_000
+
+type downsampleCacheKey struct {
+	color              Color
+	terminalColorCount ColorCount
+}
+
+var downsampleCache = map[downsampleCacheKey]Color{}
+var downsampleCacheLock sync.Mutex
+
 // From: https://en.wikipedia.org/wiki/ANSI_escape_code#3-bit_and_4-bit
 var colorNames16 = map[int]string{
 	0:  "0 black",
@@ -98,6 +113,17 @@ func (color Color) colorValue() uint32 {
 //
 // Ref: https://en.wikipedia.org/wiki/ANSI_escape_code#SGR_(Select_Graphic_Rendition)_parameters
 func (color Color) ansiString(cType colorType, terminalColorCount ColorCount) string {
+	params := color.sgrParams(cType, terminalColorCount)
+	if params == "" {
+		return ""
+	}
+
+	return "\x1b[" + params + "m"
+}
+
+// Render color into SGR parameters, like "38;5;123" for a 256 color
+// foreground. Empty if the terminal can't show this color type.
+func (color Color) sgrParams(cType colorType, terminalColorCount ColorCount) string {
 	var typeMarker string
 	if cType == colorTypeForeground {
 		typeMarker = "3"
@@ -110,7 +136,7 @@ func (color Color) ansiString(cType colorType, terminalColorCount ColorCount) st
 	}
 
 	if color.ColorCount() == ColorCountDefault {
-		return fmt.Sprint("\x1b[", typeMarker, "9m")
+		return fmt.Sprint(typeMarker, "9")
 	}
 
 	color = color.downsampleTo(terminalColorCount)
@@ -125,13 +151,13 @@ func (color Color) ansiString(cType colorType, terminalColorCount ColorCount) st
 
 		value := color.colorValue()
 		if value < 8 {
-			return fmt.Sprint("\x1b[", typeMarker, value, "m")
+			return fmt.Sprint(typeMarker, value)
 		} else if value <= 15 {
 			typeMarker := "9"
 			if cType == colorTypeBackground {
 				typeMarker = "10"
 			}
-			return fmt.Sprint("\x1b[", typeMarker, value-8, "m")
+			return fmt.Sprint(typeMarker, value-8)
 		}
 
 		panic(fmt.Errorf("unhandled color16 value %d", value))
@@ -140,7 +166,7 @@ func (color Color) ansiString(cType colorType, terminalColorCount ColorCount) st
 	if color.ColorCount() == ColorCount256 {
 		value := color.colorValue()
 		if value <= 255 {
-			return fmt.Sprint("\x1b[", typeMarker, "8;5;", value, "m")
+			return fmt.Sprint(typeMarker, "8;5;", value)
 		}
 	}
 
@@ -150,7 +176,7 @@ func (color Color) ansiString(cType colorType, terminalColorCount ColorCount) st
 		green := (value & 0xff00) >> 8
 		blue := value & 0xff
 
-		return fmt.Sprint("\x1b[", typeMarker, "8;2;", red, ";", green, ";", blue, "m")
+		return fmt.Sprint(typeMarker, "8;2;", red, ";", green, ";", blue)
 	}
 
 	panic(fmt.Errorf("unhandled color type=%d %s", color.ColorCount(), color.String()))
@@ -200,33 +226,41 @@ func (color Color) downsampleTo(terminalColorCount ColorCount) Color {
 		return color
 	}
 
+	key := downsampleCacheKey{color: color, terminalColorCount: terminalColorCount}
+	downsampleCacheLock.Lock()
+	cached, found := downsampleCache[key]
+	downsampleCacheLock.Unlock()
+	if found {
+		return cached
+	}
+
+	downsampled := color.downsampleUncached(terminalColorCount)
+
+	downsampleCacheLock.Lock()
+	if len(downsampleCache) >= DOWNSAMPLE_CACHE_MAX_SIZE {
+		// Start over rather than growing forever
+		downsampleCache = map[downsampleCacheKey]Color{}
+	}
+	downsampleCache[key] = downsampled
+	downsampleCacheLock.Unlock()
+
+	return downsampled
+}
+
+func (color Color) downsampleUncached(terminalColorCount ColorCount) Color {
 	target := color.to24Bit()
 
 	// Find the closest match in the terminal color palette
-	var scanFirst int
-	var scanLast int
-	switch terminalColorCount {
-	case ColorCount8:
-		scanFirst = 0
-		scanLast = 7
-	case ColorCount16:
-		scanFirst = 0
-		scanLast = 15
-	case ColorCount256:
-		// Colors 0-15 can be customized by the user, so we skip them and use
-		// only the well defined ones
-		scanFirst = 16
-		scanLast = 255
-	default:
-		panic(fmt.Errorf("unhandled terminal color count %#v", terminalColorCount))
-	}
+	scanFirst, scanLast := paletteScanRange(terminalColorCount)
+
+	terminalPaletteLock.Lock()
+	defer terminalPaletteLock.Unlock()
 
 	// Iterate over the scan range and find the best matching index
 	bestMatch := 0
 	bestDistance := math.MaxFloat64
 	for i := scanFirst; i <= scanLast; i++ {
-		r, g, b := color256ToRGB(uint8(i))
-		candidate := NewColor24Bit(r, g, b)
+		candidate := paletteColorLocked(uint8(i))
 
 		distance := target.Distance(candidate)
 		if distance < bestDistance {
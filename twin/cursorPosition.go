@@ -0,0 +1,109 @@
+package twin
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// How long CursorPosition() waits for the terminal to respond
+const cursorPositionTimeout = 100 * time.Millisecond
+
+// Cursor Position Report, "\x1b[row;columnR" with one based row and column
+//
+// Ref: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Functions-using-CSI-_-ordered-by-the-final-character_s_
+var cursorPositionResponseRegex = regexp.MustCompile("\x1b\\[([0-9]+);([0-9]+)R")
+
+// Matches the start of a Cursor Position Report at the end of the input
+var partialCursorPositionResponseRegex = regexp.MustCompile("\x1b\\[[0-9;]*$")
+
+type cursorPosition struct {
+	column int
+	row    int
+}
+
+// Returns the escape sequence for moving the cursor here
+//
+// Ref: https://en.wikipedia.org/wiki/ANSI_escape_code#CSI_(Control_Sequence_Introducer)_sequences
+func (position cursorPosition) moveCursorTo() string {
+	return "\x1b[" + strconv.Itoa(position.row+1) + ";" + strconv.Itoa(position.column+1) + "H"
+}
+
+// CursorPosition asks the terminal where the cursor is, and waits a short
+// while for the answer. Column and row are zero based, just like for SetCell().
+//
+// Returns ok=false if the terminal didn't respond in time.
+func (screen *UnixScreen) CursorPosition() (column int, row int, ok bool) {
+	// One query at a time, otherwise we can't tell the responses apart
+	screen.cursorPositionLock.Lock()
+	defer screen.cursorPositionLock.Unlock()
+
+	// Drop any late response to an earlier query
+	select {
+	case <-screen.cursorPositions:
+	default:
+	}
+
+	screen.cursorPositionRequested.Store(true)
+	defer screen.cursorPositionRequested.Store(false)
+
+	screen.write("\x1b[6n")
+
+	select {
+	case position := <-screen.cursorPositions:
+		return position.column, position.row, true
+	case <-time.After(cursorPositionTimeout):
+		log.Info("Terminal didn't report the cursor position within ", cursorPositionTimeout)
+		return 0, 0, false
+	}
+}
+
+// Pick a Cursor Position Report out of the input, and pass it on to
+// CursorPosition().
+//
+// Returns the input without the report, and whether we should wait for more
+// input to complete a partial report.
+func (screen *UnixScreen) consumeCursorPositionResponse(input string) (string, bool) {
+	position, rest, found := parseCursorPositionResponse(input)
+	if found {
+		select {
+		case screen.cursorPositions <- position:
+		default:
+			log.Debug("Nobody waiting for cursor position report, dropping it: ", position)
+		}
+		return rest, false
+	}
+
+	if partialCursorPositionResponseRegex.MatchString(input) && len(input) < 20 {
+		log.Trace("Cursor position report received so far: <", HumanizeLowASCII(input), ">")
+		return input, true
+	}
+
+	return input, false
+}
+
+// Find a Cursor Position Report in the input. Returns the zero based position,
+// and the input with the report removed.
+func parseCursorPositionResponse(input string) (cursorPosition, string, bool) {
+	match := cursorPositionResponseRegex.FindStringSubmatchIndex(input)
+	if match == nil {
+		return cursorPosition{}, input, false
+	}
+
+	row, err := strconv.Atoi(input[match[2]:match[3]])
+	if err != nil {
+		log.Info("Failed parsing row in cursor position report: <", HumanizeLowASCII(input), ">: ", err)
+		return cursorPosition{}, input, false
+	}
+
+	column, err := strconv.Atoi(input[match[4]:match[5]])
+	if err != nil {
+		log.Info("Failed parsing column in cursor position report: <", HumanizeLowASCII(input), ">: ", err)
+		return cursorPosition{}, input, false
+	}
+
+	position := cursorPosition{column: column - 1, row: row - 1}
+	return position, input[:match[0]] + input[match[1]:], true
+}
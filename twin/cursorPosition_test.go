@@ -0,0 +1,42 @@
+package twin
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseCursorPositionResponse(t *testing.T) {
+	position, rest, found := parseCursorPositionResponse("\x1b[12;34R")
+	assert.Assert(t, found)
+	assert.Equal(t, position, cursorPosition{column: 33, row: 11})
+	assert.Equal(t, rest, "")
+
+	// Key presses around the response should be kept
+	position, rest, found = parseCursorPositionResponse("j\x1b[1;1Rk")
+	assert.Assert(t, found)
+	assert.Equal(t, position, cursorPosition{column: 0, row: 0})
+	assert.Equal(t, rest, "jk")
+
+	_, rest, found = parseCursorPositionResponse("\x1b[A")
+	assert.Assert(t, !found)
+	assert.Equal(t, rest, "\x1b[A")
+}
+
+func TestConsumeCursorPositionResponse(t *testing.T) {
+	screen := UnixScreen{cursorPositions: make(chan cursorPosition, 1)}
+
+	// Partial response, wait for the rest
+	rest, waitForMore := screen.consumeCursorPositionResponse("\x1b[5;")
+	assert.Assert(t, waitForMore)
+
+	rest, waitForMore = screen.consumeCursorPositionResponse(rest + "7R")
+	assert.Assert(t, !waitForMore)
+	assert.Equal(t, rest, "")
+	assert.Equal(t, <-screen.cursorPositions, cursorPosition{column: 6, row: 4})
+
+	// The Escape key is not part of a response
+	rest, waitForMore = screen.consumeCursorPositionResponse("\x1b")
+	assert.Assert(t, !waitForMore)
+	assert.Equal(t, rest, "\x1b")
+}
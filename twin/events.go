@@ -6,10 +6,18 @@ type Event interface {
 
 type EventRune struct {
 	rune rune
+
+	// Only reported by terminals speaking the Kitty keyboard protocol, see
+	// ScreenOptions.KittyKeyboard
+	modifiers KeyModifiers
 }
 
 type EventKeyCode struct {
 	keyCode KeyCode
+
+	// Only reported by terminals speaking the Kitty keyboard protocol, see
+	// ScreenOptions.KittyKeyboard
+	modifiers KeyModifiers
 }
 
 type MouseButtonMask uint16
@@ -19,10 +27,25 @@ const (
 	MouseWheelDown
 	MouseWheelLeft
 	MouseWheelRight
+
+	// The mouse moved, see EventMouse.Position() for where to. Only reported
+	// in MouseModeHover.
+	MouseMotion
+
+	// The left mouse button was pressed, moved while held down or released.
+	// See EventMouse.Position() for where.
+	MouseLeftPress
+	MouseLeftDrag
+	MouseLeftRelease
 )
 
 type EventMouse struct {
 	buttons MouseButtonMask
+
+	// Zero based screen position, only set for MouseMotion and MouseLeft*
+	// events
+	column int
+	row    int
 }
 
 // After you get this, query Screen.Size() to get the new size
@@ -30,6 +53,18 @@ type EventResize struct {
 	// This interface intentionally left blank
 }
 
+// Text pasted into the terminal, delivered in one piece rather than as
+// individual key presses
+type EventPaste struct {
+	text string
+}
+
+// Sent when we're asked to stop by SIGTSTP. Respond by calling
+// SuspendProcess() on the screen, see ProcessSuspender.
+type EventSuspend struct {
+	// This interface intentionally left blank
+}
+
 // If we're unable to continue showing the screen, we'll send this event and
 // drop out.
 //
@@ -38,14 +73,49 @@ type EventExit struct {
 	// This interface intentionally left blank
 }
 
+func NewEventRune(char rune) EventRune {
+	return EventRune{rune: char}
+}
+
+// Like NewEventRune(), but as reported with the Kitty keyboard protocol
+func NewEventRuneWithModifiers(char rune, modifiers KeyModifiers) EventRune {
+	return EventRune{rune: char, modifiers: modifiers}
+}
+
+func NewEventKeyCode(keyCode KeyCode) EventKeyCode {
+	return EventKeyCode{keyCode: keyCode}
+}
+
 func (eventRune *EventRune) Rune() rune {
 	return eventRune.rune
 }
 
+func (eventRune *EventRune) Modifiers() KeyModifiers {
+	return eventRune.modifiers
+}
+
+func NewEventPaste(text string) EventPaste {
+	return EventPaste{text: text}
+}
+
 func (eventKeyCode *EventKeyCode) KeyCode() KeyCode {
 	return eventKeyCode.keyCode
 }
 
+func (eventKeyCode *EventKeyCode) Modifiers() KeyModifiers {
+	return eventKeyCode.modifiers
+}
+
 func (eventMouse *EventMouse) Buttons() MouseButtonMask {
 	return eventMouse.buttons
 }
+
+// Zero based screen column and row of the mouse pointer. Only set for
+// MouseMotion and MouseLeft* events.
+func (eventMouse *EventMouse) Position() (column int, row int) {
+	return eventMouse.column, eventMouse.row
+}
+
+func (eventPaste *EventPaste) Text() string {
+	return eventPaste.text
+}
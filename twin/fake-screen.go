@@ -7,6 +7,14 @@ type FakeScreen struct {
 	width  int
 	height int
 	cells  [][]StyledRune
+	events chan Event
+
+	clipboard     string
+	beeps         int
+	suspended     bool
+	title         string
+	mouseTracking bool
+	cursorAt      *cursorPosition
 }
 
 func NewFakeScreen(width int, height int) *FakeScreen {
@@ -19,6 +27,7 @@ func NewFakeScreen(width int, height int) *FakeScreen {
 		width:  width,
 		height: height,
 		cells:  rows,
+		events: make(chan Event, 80),
 	}
 }
 
@@ -29,6 +38,8 @@ func (screen *FakeScreen) Close() {
 func (screen *FakeScreen) Clear() {
 	// This method's contents has been copied from UnixScreen.Clear()
 
+	screen.cursorAt = nil
+
 	empty := NewStyledRune(' ', StyleDefault)
 
 	width, height := screen.Size()
@@ -42,6 +53,8 @@ func (screen *FakeScreen) Clear() {
 func (screen *FakeScreen) SetCell(column int, row int, styledRune StyledRune) int {
 	// This method's contents has been copied from UnixScreen.Clear()
 
+	screen.cursorAt = nil
+
 	if column < 0 {
 		return styledRune.Width()
 	}
@@ -58,8 +71,8 @@ func (screen *FakeScreen) SetCell(column int, row int, styledRune StyledRune) in
 	}
 
 	if column+styledRune.Width() > width {
-		// This cell is too wide for the screen, write a space instead
-		screen.cells[row][column] = NewStyledRune(' ', styledRune.Style)
+		// This cell is too wide for the screen, write a replacement instead
+		screen.cells[row][column] = WideRuneAtEdgeSpace.Replacement(styledRune)
 		return styledRune.Width()
 	}
 
@@ -76,6 +89,10 @@ func (screen *FakeScreen) ShowNLines(int) {
 	// This method intentionally left blank
 }
 
+func (screen *FakeScreen) ShowRows([][]StyledRune) {
+	// This method intentionally left blank
+}
+
 func (screen *FakeScreen) Size() (width int, height int) {
 	return screen.width, screen.height
 }
@@ -88,13 +105,85 @@ func (screen *FakeScreen) TerminalBackground() *Color {
 	return nil
 }
 
-func (screen *FakeScreen) ShowCursorAt(_ int, _ int) {
-	// This method intentionally left blank
+func (screen *FakeScreen) TerminalForeground() *Color {
+	return nil
+}
+
+func (screen *FakeScreen) SetTitle(title string) {
+	screen.title = title
+}
+
+// Whatever was last passed to SetTitle()
+func (screen *FakeScreen) Title() string {
+	return screen.title
+}
+
+func (screen *FakeScreen) CopyToClipboard(text string) {
+	screen.clipboard = text
+}
+
+// Whatever was last passed to CopyToClipboard()
+func (screen *FakeScreen) ClipboardContents() string {
+	return screen.clipboard
+}
+
+func (screen *FakeScreen) Beep() {
+	screen.beeps++
+}
+
+// How many times Beep() has been called
+func (screen *FakeScreen) BeepCount() int {
+	return screen.beeps
+}
+
+func (screen *FakeScreen) Suspend() {
+	screen.suspended = true
+}
+
+func (screen *FakeScreen) Resume() {
+	screen.suspended = false
+
+	// Like UnixScreen.Resume()
+	screen.events <- EventResize{}
+}
+
+func (screen *FakeScreen) MouseTrackingEnabled() bool {
+	return screen.mouseTracking
+}
+
+func (screen *FakeScreen) SetMouseTracking(enable bool) {
+	screen.mouseTracking = enable
+}
+
+// True between Suspend() and Resume()
+func (screen *FakeScreen) IsSuspended() bool {
+	return screen.suspended
+}
+
+func (screen *FakeScreen) ShowCursorAt(column int, row int) {
+	if column < 0 || row < 0 || column >= screen.width || row >= screen.height {
+		screen.cursorAt = nil
+		return
+	}
+
+	screen.cursorAt = &cursorPosition{column: column, row: row}
+}
+
+// Where ShowCursorAt() last put the cursor. ok is false if the cursor is
+// hidden.
+func (screen *FakeScreen) CursorAt() (column int, row int, ok bool) {
+	if screen.cursorAt == nil {
+		return 0, 0, false
+	}
+	return screen.cursorAt.column, screen.cursorAt.row, true
+}
+
+func (screen *FakeScreen) CursorPosition() (column int, row int, ok bool) {
+	return 0, 0, false
 }
 
 func (screen *FakeScreen) Events() chan Event {
-	// TODO: Do better here if or when this becomes a problem
-	return nil
+	return screen.events
 }
 
 func (screen *FakeScreen) GetRow(row int) []StyledRune {
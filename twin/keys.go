@@ -19,6 +19,10 @@ const (
 	KeyAltRight
 	KeyAltLeft
 
+	KeyCtrlRight
+	KeyCtrlLeft
+
+	KeyInsert
 	KeyHome
 	KeyEnd
 	KeyPgUp
@@ -68,6 +72,11 @@ var escapeSequenceToKeyCode = map[string]KeyCode{
 	"\x1b[1;3C": KeyAltRight,
 	"\x1b[1;3D": KeyAltLeft,
 
+	"\x1b[1;5C": KeyCtrlRight,
+	"\x1b[1;5D": KeyCtrlLeft,
+
+	"\x1b[2~": KeyInsert,
+
 	"\x1b[H":  KeyHome,
 	"\x1b[F":  KeyEnd,
 	"\x1b[1~": KeyHome,
@@ -0,0 +1,85 @@
+package twin
+
+import (
+	"regexp"
+	"strconv"
+)
+
+const kittyKeyboardQuery = "\x1b[?u"
+
+// Only the "disambiguate escape codes" flag (1). Text is still sent as text,
+// and most special keys as the usual escape sequences.
+const kittyKeyboardEnable = "\x1b[>1u"
+const kittyKeyboardDisable = "\x1b[<u"
+
+// The terminal's answer to kittyKeyboardQuery, with the currently enabled flags
+var kittyKeyboardResponseRegex = regexp.MustCompile(`^\x1b\[\?[0-9]*u`)
+
+// Example key report: "\x1b[105;5u"
+//
+// Where:
+//   - "105" is the Unicode code point of the key, "i" in this case. It can be
+//     followed by alternate code points after colons, which we ignore.
+//   - "5" is one plus the modifiers bit mask, Ctrl (4) in this case. Can be
+//     followed by an event type after a colon, which we ignore.
+//   - Anything after another semicolon is the text the key would produce, which
+//     we also ignore.
+var kittyKeyReportRegex = regexp.MustCompile(`^\x1b\[([0-9]+)(?::[0-9:]*)?(?:;([0-9]*)(?::[0-9]+)?)?(?:;[0-9:]*)?u`)
+
+type KeyModifiers uint8
+
+const (
+	ModShift KeyModifiers = 1 << iota
+	ModAlt
+	ModCtrl
+)
+
+// Turn a Kitty keyboard protocol key report into an event.
+//
+// Ctrl-letter combinations become control characters just like without the
+// Kitty protocol, but with ModCtrl set. That's how to tell Ctrl-I ('\t' with
+// ModCtrl) from Tab ('\t').
+//
+// Returns a nil event for sequences that aren't key reports, or for keys we
+// don't know about. The remainder is the sequence after the key report.
+func consumeKittyKeyReport(encodedEventSequences string) (*Event, string) {
+	match := kittyKeyReportRegex.FindStringSubmatch(encodedEventSequences)
+	if match == nil {
+		return nil, encodedEventSequences
+	}
+	remainder := encodedEventSequences[len(match[0]):]
+
+	codePoint, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil, remainder
+	}
+
+	modifiers := KeyModifiers(0)
+	if match[2] != "" {
+		encoded, err := strconv.Atoi(match[2])
+		if err != nil || encoded < 1 {
+			return nil, remainder
+		}
+		modifiers = KeyModifiers(encoded-1) & (ModShift | ModAlt | ModCtrl)
+	}
+
+	var event Event
+	switch {
+	case codePoint == 27:
+		event = EventKeyCode{keyCode: KeyEscape, modifiers: modifiers}
+	case codePoint == 13:
+		event = EventKeyCode{keyCode: KeyEnter, modifiers: modifiers}
+	case codePoint == 127:
+		event = EventKeyCode{keyCode: KeyBackspace, modifiers: modifiers}
+	case codePoint >= 0xe000 && codePoint <= 0xf8ff:
+		// Private use area, the Kitty protocol uses these for keys without
+		// code points, like keypad keys and media keys
+		return nil, remainder
+	case modifiers&ModCtrl != 0 && codePoint >= 'a' && codePoint <= 'z':
+		event = EventRune{rune: rune(codePoint - 'a' + 1), modifiers: modifiers}
+	default:
+		event = EventRune{rune: rune(codePoint), modifiers: modifiers}
+	}
+
+	return &event, remainder
+}
@@ -0,0 +1,38 @@
+package twin
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConsumeKittyKeyReport(t *testing.T) {
+	// Ctrl-I, told apart from Tab by the modifier
+	assertEncode(t, "\x1b[105;5u", EventRune{rune: '\t', modifiers: ModCtrl}, "")
+	assertEncode(t, "\t", EventRune{rune: '\t'}, "")
+
+	assertEncode(t, "\x1b[27ux", EventKeyCode{keyCode: KeyEscape}, "x")
+	assertEncode(t, "\x1b[13;2u", EventKeyCode{keyCode: KeyEnter, modifiers: ModShift}, "")
+	assertEncode(t, "\x1b[97;3u", EventRune{rune: 'a', modifiers: ModAlt}, "")
+
+	// Alternate key codes, event types and texts are ignored
+	assertEncode(t, "\x1b[97:65;3:1;65u", EventRune{rune: 'a', modifiers: ModAlt}, "")
+
+	// Private use keys are consumed but not reported
+	event, remainder := consumeEncodedEvent("\x1b[57399ux")
+	assert.Assert(t, event == nil)
+	assert.Equal(t, remainder, "x")
+}
+
+func TestConsumeKittyKeyboardResponse(t *testing.T) {
+	screen := UnixScreen{options: ScreenOptions{KittyKeyboard: true}}
+
+	rest, waitForMore := screen.consumeTerminalResponses("\x1b[?")
+	assert.Assert(t, waitForMore)
+	assert.Assert(t, !screen.kittyKeyboardDetected.Load())
+
+	rest, waitForMore = screen.consumeTerminalResponses(rest + "0uq")
+	assert.Assert(t, !waitForMore)
+	assert.Equal(t, rest, "q")
+	assert.Assert(t, screen.kittyKeyboardDetected.Load())
+}
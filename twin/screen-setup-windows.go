@@ -67,6 +67,19 @@ func newInterruptableReader(base *os.File) (interruptableReader, error) {
 	return &interruptableReaderImpl{base: base}, nil
 }
 
+// No SIGTSTP on Windows
+func (screen *UnixScreen) setupSuspendNotification() {
+}
+
+// No SIGTSTP on Windows
+func (screen *UnixScreen) stopSuspendNotification() {
+}
+
+// Suspending is not supported on Windows
+func (screen *UnixScreen) SuspendProcess() error {
+	return fmt.Errorf("Suspending is not supported on Windows")
+}
+
 // Poll for terminal size changes. No SIGWINCH on Windows, this is apparently
 // the way.
 func (screen *UnixScreen) setupSigwinchNotification() {
@@ -176,3 +189,26 @@ func (screen *UnixScreen) restoreTtyInTtyOut() error {
 
 	return fmt.Errorf("failed to restore terminal state: %v", errors)
 }
+
+// Go back to raw mode after restoreTtyInTtyOut(), keeping the original modes
+// for the next restore.
+func (screen *UnixScreen) reenterRawMode() error {
+	stdin := windows.Handle(screen.ttyIn.Fd())
+	err := windows.SetConsoleMode(stdin, screen.oldTtyInMode|windows.ENABLE_VIRTUAL_TERMINAL_INPUT)
+	if err != nil {
+		return fmt.Errorf("failed to set stdin console mode: %w", err)
+	}
+
+	_, err = term.MakeRaw(int(screen.ttyIn.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set raw mode: %w", err)
+	}
+
+	stdout := windows.Handle(screen.ttyOut.Fd())
+	err = windows.SetConsoleMode(stdout, screen.oldTtyOutMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	if err != nil {
+		return fmt.Errorf("failed to set stdout console mode: %w", err)
+	}
+
+	return nil
+}
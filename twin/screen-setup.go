@@ -134,6 +134,62 @@ func (screen *UnixScreen) setupSigwinchNotification() {
 	}()
 }
 
+// Ask the client app to suspend us when getting SIGTSTP. Without this, "fg"
+// would bring us back with the terminal in the wrong mode.
+//
+// The terminal is not touched from here, since the client app may be drawing
+// on it at the same time. See SuspendProcess().
+func (screen *UnixScreen) setupSuspendNotification() {
+	sigtstp := make(chan os.Signal, 1)
+	signal.Notify(sigtstp, syscall.SIGTSTP)
+	screen.sigtstp = sigtstp
+	go func() {
+		defer func() {
+			panicHandler("setupSuspendNotification()/SIGTSTP", recover(), debug.Stack())
+		}()
+
+		// Ends when stopSuspendNotification() closes the channel
+		for range sigtstp {
+			select {
+			case screen.events <- EventSuspend{}:
+				// Event delivered
+			default:
+				log.Warn("Unable to deliver EventSuspend, event queue full")
+			}
+		}
+	}()
+}
+
+// Let SIGTSTP stop the process the default way again. Without this, a host
+// process that is done with us could never be stopped using Ctrl-Z.
+func (screen *UnixScreen) stopSuspendNotification() {
+	if screen.sigtstp == nil {
+		return
+	}
+
+	signal.Stop(screen.sigtstp)
+	close(screen.sigtstp)
+	screen.sigtstp = nil
+}
+
+// Give the terminal back and stop, like the terminal does on Ctrl-Z when not
+// in raw mode. When continued by SIGCONT, from "fg" or "bg", take the terminal
+// back and trigger an EventResize to get the screen redrawn.
+//
+// Call this from the same goroutine that calls Show().
+func (screen *UnixScreen) SuspendProcess() error {
+	screen.Suspend()
+
+	// Stop our whole process group like the terminal does on Ctrl-Z, so that
+	// the shell notices. SIGSTOP can't be caught, so this really stops us.
+	err := syscall.Kill(0, syscall.SIGSTOP)
+
+	// We get here when continued, or if stopping failed
+	screen.Resume()
+
+	return err
+}
+
 func (screen *UnixScreen) setupTtyInTtyOut() error {
 	// Dup stdout so we can close stdin in Close() without closing stdout.
 	// Before this dupping, we crashed on using --quit-if-one-screen.
@@ -181,3 +237,10 @@ func (screen *UnixScreen) setupTtyInTtyOut() error {
 func (screen *UnixScreen) restoreTtyInTtyOut() error {
 	return term.Restore(int(screen.ttyIn.Fd()), screen.oldTerminalState)
 }
+
+// Go back to raw mode after restoreTtyInTtyOut(), keeping the original state
+// for the next restore.
+func (screen *UnixScreen) reenterRawMode() error {
+	_, err := term.MakeRaw(int(screen.ttyIn.Fd()))
+	return err
+}
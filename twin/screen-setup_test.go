@@ -4,9 +4,11 @@
 package twin
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"runtime/debug"
+	"strings"
 	"testing"
 	"time"
 
@@ -69,3 +71,308 @@ func TestInterruptableReader_blockedOnReadImmediate(t *testing.T) {
 	assert.Equal(t, err, io.EOF)
 	assert.Equal(t, n, 0)
 }
+
+// Suspend() should give the terminal back the way we found it, and Resume()
+// should take it back again with a working input reader.
+func TestSuspendResume(t *testing.T) {
+	screen, ttyInWriter, writtenSinceLastTime := newTestUnixScreen(t)
+	screen.mouseTracking = true
+
+	ttyInReader, err := newInterruptableReader(screen.ttyIn)
+	assert.NilError(t, err)
+	screen.takeTerminal()
+	screen.startMainLoop(ttyInReader)
+
+	taken := writtenSinceLastTime()
+	assert.Equal(t, taken, "ESC[?1049hESC[?1007hESC[?2004hESC[?1006;1002hESC[?25l")
+
+	screen.Suspend()
+	assert.Equal(t, writtenSinceLastTime(), "ESC[?25hESC[?1003lESC[?1006;1002lESC[?2004lESC[?1007lESC[?1049l")
+
+	// Suspending twice should be a no-op
+	screen.Suspend()
+	assert.Equal(t, writtenSinceLastTime(), "")
+
+	screen.Resume()
+	assert.Equal(t, writtenSinceLastTime(), taken)
+	assert.Equal(t, <-screen.events, Event(EventResize{}))
+
+	// Verify that input is still being read after resuming
+	_, err = ttyInWriter.Write([]byte("x"))
+	assert.NilError(t, err)
+	assert.Equal(t, <-screen.events, Event(EventRune{rune: 'x'}))
+
+	screen.Close()
+	assert.Equal(t, <-screen.events, Event(EventExit{}))
+}
+
+func TestSetMouseTracking(t *testing.T) {
+	screen, _, writtenSinceLastTime := newTestUnixScreen(t)
+	screen.mouseTracking = true
+	screen.mouseMotionTracking = true
+
+	ttyInReader, err := newInterruptableReader(screen.ttyIn)
+	assert.NilError(t, err)
+	screen.takeTerminal()
+	screen.startMainLoop(ttyInReader)
+	writtenSinceLastTime()
+	assert.Assert(t, screen.MouseTrackingEnabled())
+
+	// Motion tracking goes with mouse tracking, alternateScroll (1007) is
+	// left on so that the mouse wheel still scrolls
+	screen.SetMouseTracking(false)
+	assert.Assert(t, !screen.MouseTrackingEnabled())
+	assert.Equal(t, writtenSinceLastTime(), "ESC[?1003lESC[?1006;1002l")
+
+	// Setting the same value again should be a no-op
+	screen.SetMouseTracking(false)
+	assert.Equal(t, writtenSinceLastTime(), "")
+
+	screen.SetMouseTracking(true)
+	assert.Assert(t, screen.MouseTrackingEnabled())
+	assert.Equal(t, writtenSinceLastTime(), "ESC[?1006;1002hESC[?1003h")
+
+	// While suspended, the change should wait for Resume()
+	screen.Suspend()
+	writtenSinceLastTime()
+	screen.SetMouseTracking(false)
+	assert.Equal(t, writtenSinceLastTime(), "")
+	screen.Resume()
+	assert.Equal(t, writtenSinceLastTime(), "ESC[?1049hESC[?1007hESC[?2004hESC[?1006;1002lESC[?25l")
+	assert.Equal(t, <-screen.events, Event(EventResize{}))
+
+	screen.Close()
+	assert.Equal(t, <-screen.events, Event(EventExit{}))
+}
+
+// Pastes can arrive in several reads, and should be delivered in one piece
+func TestSplitPaste(t *testing.T) {
+	screen, ttyInWriter, _ := newTestUnixScreen(t)
+
+	ttyInReader, err := newInterruptableReader(screen.ttyIn)
+	assert.NilError(t, err)
+	screen.startMainLoop(ttyInReader)
+	defer func() {
+		screen.ttyInReaderLock.Lock()
+		screen.ttyInReader = nil
+		screen.ttyInReaderLock.Unlock()
+		ttyInReader.Interrupt()
+		<-screen.mainLoopDone
+	}()
+
+	// Stop the main loop from waiting for terminal query responses
+	_, err = ttyInWriter.Write([]byte("x"))
+	assert.NilError(t, err)
+	assert.Equal(t, <-screen.events, Event(EventRune{rune: 'x'}))
+
+	// "ö" split in the middle
+	_, err = ttyInWriter.Write([]byte("\x1b[200~q\xc3"))
+	assert.NilError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = ttyInWriter.Write([]byte("\xb6\x1b[201~j"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, <-screen.events, Event(EventPaste{text: "qö"}))
+	assert.Equal(t, <-screen.events, Event(EventRune{rune: 'j'}))
+
+	// Start marker split in the middle, after some invalid UTF-8
+	_, err = ttyInWriter.Write([]byte("a\xffb\x1b[20"))
+	assert.NilError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = ttyInWriter.Write([]byte("0~q\x1b[201~"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, <-screen.events, Event(EventRune{rune: 'a'}))
+	assert.Equal(t, <-screen.events, Event(EventRune{rune: 'b'}))
+	assert.Equal(t, <-screen.events, Event(EventPaste{text: "q"}))
+
+	// "ö" split in the middle, outside of any paste
+	_, err = ttyInWriter.Write([]byte("\xc3"))
+	assert.NilError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = ttyInWriter.Write([]byte("\xb6"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, <-screen.events, Event(EventRune{rune: 'ö'}))
+}
+
+func TestSplitX10Mouse(t *testing.T) {
+	screen, ttyInWriter, _ := newTestUnixScreen(t)
+
+	ttyInReader, err := newInterruptableReader(screen.ttyIn)
+	assert.NilError(t, err)
+	screen.startMainLoop(ttyInReader)
+	defer func() {
+		screen.ttyInReaderLock.Lock()
+		screen.ttyInReader = nil
+		screen.ttyInReaderLock.Unlock()
+		ttyInReader.Interrupt()
+		<-screen.mainLoopDone
+	}()
+
+	// Stop the main loop from waiting for terminal query responses
+	_, err = ttyInWriter.Write([]byte("x"))
+	assert.NilError(t, err)
+	assert.Equal(t, <-screen.events, Event(EventRune{rune: 'x'}))
+
+	// Wheel up, split after the column byte
+	_, err = ttyInWriter.Write([]byte("\x1b[M`*"))
+	assert.NilError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = ttyInWriter.Write([]byte("Jj"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, <-screen.events, Event(EventMouse{buttons: MouseWheelUp}))
+	assert.Equal(t, <-screen.events, Event(EventRune{rune: 'j'}))
+}
+
+// The protocol is enabled when showing the screen, and disabled when giving
+// the terminal back
+func TestKittyKeyboardEnableDisable(t *testing.T) {
+	screen, _, writtenSinceLastTime := newTestUnixScreen(t)
+	screen.kittyKeyboardDetected.Store(true)
+	screen.widthAccessFromSizeOnly = 1
+	screen.heightAccessFromSizeOnly = 1
+	screen.cells = [][]StyledRune{{}}
+
+	screen.Show()
+	screen.Show()
+	screen.giveBackTerminal()
+
+	written := writtenSinceLastTime()
+	enable := strings.ReplaceAll(kittyKeyboardEnable, "\x1b", "ESC")
+	disable := strings.ReplaceAll(kittyKeyboardDisable, "\x1b", "ESC")
+	assert.Equal(t, strings.Count(written, enable), 1)
+	assert.Assert(t, strings.HasPrefix(written, enable))
+	assert.Equal(t, strings.Count(written, disable), 1)
+}
+
+func TestSetTitle(t *testing.T) {
+	screen, _, writtenSinceLastTime := newTestUnixScreen(t)
+
+	ttyInReader, err := newInterruptableReader(screen.ttyIn)
+	assert.NilError(t, err)
+	screen.takeTerminal()
+	screen.startMainLoop(ttyInReader)
+	writtenSinceLastTime()
+
+	// The old title should be saved the first time only
+	screen.SetTitle("moor: pager.go")
+	assert.Equal(t, writtenSinceLastTime(), "ESC[22;0tESC]2;moor: pager.go\a")
+	screen.SetTitle("moor: \x1b[2Jscreen.go")
+	assert.Equal(t, writtenSinceLastTime(), "ESC]2;moor: [2Jscreen.go\a")
+
+	// Suspending should restore the old title, and resuming should bring ours
+	// back
+	screen.Suspend()
+	assert.Assert(t, strings.HasSuffix(writtenSinceLastTime(), "ESC[23;0t"))
+	screen.Resume()
+	assert.Assert(t, strings.HasSuffix(writtenSinceLastTime(), "ESC[22;0tESC]2;moor: [2Jscreen.go\a"))
+	assert.Equal(t, <-screen.events, Event(EventResize{}))
+
+	screen.Close()
+	assert.Equal(t, <-screen.events, Event(EventExit{}))
+	assert.Assert(t, strings.HasSuffix(writtenSinceLastTime(), "ESC[23;0t"))
+}
+
+// Inline screens should stay on the normal screen, and clear up after
+// themselves when giving the terminal back
+// Answer the next CursorPosition() query with the given one based row
+func reportCursorRow(t *testing.T, screen *UnixScreen, ttyInWriter *os.File, row int) {
+	go func() {
+		for !screen.cursorPositionRequested.Load() {
+			time.Sleep(time.Millisecond)
+		}
+		_, err := ttyInWriter.WriteString(fmt.Sprintf("\x1b[%d;1R", row))
+		assert.NilError(t, err)
+	}()
+}
+
+// Start an inline screen in a 10x4 terminal, with the cursor on the given one
+// based row
+func startInlineScreen(t *testing.T, cursorRow int) (*UnixScreen, *os.File, func() string) {
+	screen, ttyInWriter, writtenSinceLastTime := newTestUnixScreen(t)
+	screen.inline = true
+	screen.widthAccessFromSizeOnly = 10
+	screen.heightAccessFromSizeOnly = 4
+	screen.cells = resizedCells(nil, 10, 4)
+
+	ttyInReader, err := newInterruptableReader(screen.ttyIn)
+	assert.NilError(t, err)
+	screen.startMainLoop(ttyInReader)
+
+	reportCursorRow(t, screen, ttyInWriter, cursorRow)
+	screen.takeTerminal()
+
+	return screen, ttyInWriter, writtenSinceLastTime
+}
+
+func TestInlineTakeGiveBack(t *testing.T) {
+	// Cursor on the bottom row, scroll up one line to get half the terminal
+	screen, ttyInWriter, writtenSinceLastTime := startInlineScreen(t, 4)
+	assert.Equal(t, writtenSinceLastTime(), "ESC[6n\nESC[3;1HESC[?1007hESC[?2004hESC[?1006;1002lESC[?25l")
+	assert.Equal(t, screen.inlineTop.Load(), int32(2))
+	width, height := screen.Size()
+	assert.Equal(t, width, 10)
+	assert.Equal(t, height, 2)
+
+	screen.Suspend()
+	assert.Equal(t, writtenSinceLastTime(), "ESC[?25hESC[?1003lESC[?1006;1002lESC[?2004lESC[?1007lESC[3;1HESC[J")
+
+	// Giving back the terminal left the cursor where we started drawing, so
+	// no scrolling is needed this time
+	reportCursorRow(t, screen, ttyInWriter, 3)
+	screen.Resume()
+	assert.Equal(t, writtenSinceLastTime(), "ESC[6nESC[3;1HESC[?1007hESC[?2004hESC[?1006;1002lESC[?25l")
+	assert.Equal(t, screen.inlineTop.Load(), int32(2))
+	assert.Equal(t, <-screen.events, Event(EventResize{}))
+
+	screen.Close()
+	assert.Equal(t, <-screen.events, Event(EventExit{}))
+}
+
+// With the shell prompt at the top of the terminal and a short file to show,
+// there's room enough below the prompt. Nothing should scroll, and we should
+// draw below the prompt.
+func TestInlineKeepsPromptRow(t *testing.T) {
+	screen, ttyInWriter, writtenSinceLastTime := startInlineScreen(t, 2)
+	taken := writtenSinceLastTime()
+	assert.Assert(t, !strings.Contains(taken, "\n"), taken)
+	assert.Equal(t, screen.inlineTop.Load(), int32(1))
+
+	_, height := screen.Size()
+	assert.Equal(t, height, 3)
+
+	screen.SetCell(0, 0, NewStyledRune('x', StyleDefault))
+	screen.Show()
+	shown := writtenSinceLastTime()
+	assert.Assert(t, strings.HasPrefix(shown, "ESC[2;1H"), shown)
+	assert.Assert(t, !strings.Contains(shown, "ESC[1;1H"), shown)
+
+	// Clicking the terminal's second row clicks our first
+	_, err := ttyInWriter.WriteString("\x1b[<0;1;2M")
+	assert.NilError(t, err)
+	assert.Equal(t, <-screen.events, Event(EventMouse{buttons: MouseLeftPress, column: 0, row: 0}))
+
+	screen.Close()
+	assert.Equal(t, <-screen.events, Event(EventExit{}))
+	assert.Assert(t, strings.Contains(writtenSinceLastTime(), "ESC[2;1HESC[J"))
+}
+
+// After Close(), SIGTSTP should stop the process the default way again, and
+// our handler should be gone
+func TestStopSuspendNotification(t *testing.T) {
+	screen, _, _ := newTestUnixScreen(t)
+	screen.setupSuspendNotification()
+	sigtstp := screen.sigtstp
+
+	screen.stopSuspendNotification()
+	assert.Assert(t, screen.sigtstp == nil)
+
+	// Closed, so the handler goroutine is done
+	_, open := <-sigtstp
+	assert.Assert(t, !open)
+
+	// Stopping twice is fine
+	screen.stopSuspendNotification()
+}
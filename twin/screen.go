@@ -2,13 +2,16 @@
 package twin
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
 	"regexp"
+	"runtime"
 	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
@@ -26,11 +29,108 @@ const (
 	// on some not.
 	MouseModeSelect
 
-	// Capture mouse events. This makes mouse scrolling work. Special gymnastics
-	// will be required for marking with the mouse to copy text.
+	// Capture mouse events. This makes mouse scrolling work. Selecting text
+	// with the mouse is then up to the application, see MouseLeftPress.
 	MouseModeScroll
+
+	// Like MouseModeScroll, but also report mouse motion. Used for showing
+	// hyperlink targets when hovering them.
+	MouseModeHover
 )
 
+// Implemented by screens that can stop the process until it is continued,
+// like with Ctrl-Z and "fg" in a shell. Check for this using a type assertion.
+type ProcessSuspender interface {
+	// Give the terminal back, stop, and take the terminal back when continued.
+	// Expect an EventResize afterwards.
+	SuspendProcess() error
+}
+
+// Implemented by screens that only redraw what changed since the last Show().
+// Check for this using a type assertion.
+type FullRedrawer interface {
+	// Make the next Show() redraw the whole screen, not just what changed.
+	// Gets rid of whatever other programs may have printed on top of us.
+	RedrawAll()
+}
+
+// Screens that can print rows wider or more numerous than the screen itself,
+// for leaving output in the scrollback after Close().
+type RowPrinter interface {
+	// Can be called after Close()ing the screen to print rows of any length,
+	// each followed by a newline. Useful for printing more than fits on the
+	// screen.
+	ShowRows(rows [][]StyledRune)
+}
+
+// Screens that can reach the system clipboard. UnixScreen does it using OSC
+// 52, which works over SSH too.
+type ClipboardWriter interface {
+	// Put some text on the system clipboard. This works over SSH as well, but
+	// not all terminals support it.
+	CopyToClipboard(text string)
+}
+
+// Screens that can ask the terminal where its cursor is. Not every terminal
+// answers, so callers need a plan for when it doesn't.
+type CursorPositionQuerier interface {
+	// CursorPosition() asks the terminal where the cursor currently is. Zero
+	// based, just like SetCell(). ok is false if the terminal didn't say.
+	CursorPosition() (column int, row int, ok bool)
+}
+
+// Screens with a bell to ring. On screens without one, alerts are silent.
+type Beeper interface {
+	// Ring the terminal bell. Depending on the terminal settings this could be
+	// a sound, a visual flash or nothing at all.
+	Beep()
+}
+
+// Screens that can lend the terminal to another program, like an editor, and
+// take it back when that program exits.
+type Suspender interface {
+	// Suspend() temporarily restores the terminal to its normal state, so that
+	// some other program can use it. Call Resume() when that program is done.
+	//
+	// While suspended, no input is read from the terminal.
+	Suspend()
+
+	// Resume() takes the terminal back after a Suspend(). Expect an EventResize
+	// after this, redraw the screen when you get it.
+	Resume()
+}
+
+// Screens where mouse event reporting can be switched on and off while
+// running, so that the mouse can either scroll or select text.
+type MouseTracker interface {
+	// True if the terminal reports mouse events to us. This makes the mouse
+	// wheel scroll, but selecting text requires holding a modifier key in most
+	// terminals.
+	MouseTrackingEnabled() bool
+
+	// Start or stop having the terminal report mouse events to us. If the
+	// screen is suspended, this takes effect on Resume().
+	//
+	// The alternateScroll mode (see ScreenOptions.AlternateScroll) is left as
+	// is, so that the mouse wheel keeps scrolling also when mouse events aren't
+	// reported.
+	SetMouseTracking(enable bool)
+}
+
+// Screens that detect the terminal's text color, to go with
+// Screen.TerminalBackground().
+type ForegroundReporter interface {
+	// Can be nil if not (yet?) detected
+	TerminalForeground() *Color
+}
+
+// Screens that can change the title of the terminal window they run in.
+type TitleSetter interface {
+	// Set the terminal window title. The previous title is restored when the
+	// screen is closed, by terminals that support that.
+	SetTitle(title string)
+}
+
 type Screen interface {
 	// Close() restores terminal to normal state, must be called after you are
 	// done with your screen
@@ -61,7 +161,9 @@ type Screen interface {
 	Size() (width int, height int)
 
 	// ShowCursorAt() moves the cursor to the given screen position and makes
-	// sure it is visible.
+	// sure it is visible. Zero based, just like SetCell().
+	//
+	// The cursor stays there through Show() calls until the next Clear().
 	//
 	// If the position is outside of the screen, the cursor will be hidden.
 	ShowCursorAt(column int, row int)
@@ -80,13 +182,92 @@ type interruptableReader interface {
 	Interrupt()
 }
 
+type MouseEncoding int
+
+const (
+	// Ask for SGR mouse reporting (1006). Terminals that don't know about SGR
+	// ignore that request and fall back to X10 encoding, which we also parse.
+	MouseEncodingSGR MouseEncoding = iota
+
+	// Legacy X10 mouse reporting only, for terminals that get confused by the
+	// SGR request.
+	MouseEncodingX10
+)
+
+// Optional screen behaviors, see NewScreenWithOptions(). Start from
+// DefaultScreenOptions() and change what you need.
+type ScreenOptions struct {
+	// Enable the alternateScroll mode (1007) when switching to the alternate
+	// screen. This makes the mouse wheel send arrow keys, but some terminals
+	// get confused by it.
+	AlternateScroll bool
+
+	// Which mouse encoding to ask the terminal for
+	MouseEncoding MouseEncoding
+
+	// Ask the terminal for its actual palette colors when creating the screen.
+	// Users can remap the 256 color palette, and knowing the real colors makes
+	// downsampling more accurate.
+	QueryTerminalPalette bool
+
+	// Ask the terminal whether it supports the Kitty keyboard protocol, and use
+	// it if it does. This tells apart keys that look the same otherwise, like
+	// Ctrl-I and Tab. Terminals that don't answer keep working like before.
+	//
+	// Ref: https://sw.kovidgoyal.net/kitty/keyboard-protocol/
+	KittyKeyboard bool
+
+	// Ask the terminal whether it supports synchronized output, and use it if
+	// it does. The terminal then shows each screen update all at once, rather
+	// than as it arrives, which prevents tearing on slow connections.
+	//
+	// Ref: https://gist.github.com/christianparpart/d8a62cc1ab659194337d73e399004036
+	SynchronizedOutput bool
+
+	// What to show instead of a wide rune that doesn't fit before the right
+	// edge of the screen
+	WideRuneAtEdge WideRuneAtEdgeOption
+
+	// How to color the part of a line after its last character
+	TrailerBackground TrailerBackgroundOption
+
+	// When content turns on underlining without specifying an underline
+	// color, some terminals use the text color for the underline and some use
+	// a theme color.
+	//
+	// Set this to true to always emit an explicit default underline color
+	// (SGR 59) when underlining starts. Leave it false to let the terminal
+	// decide.
+	ResetUnderlineColor bool
+}
+
+// The options used by NewScreen() and friends
+func DefaultScreenOptions() ScreenOptions {
+	return ScreenOptions{
+		AlternateScroll:    true,
+		MouseEncoding:      MouseEncodingSGR,
+		SynchronizedOutput: true,
+		WideRuneAtEdge:     WideRuneAtEdgeSpace,
+		TrailerBackground:  TrailerBackgroundInherit,
+	}
+}
+
 type UnixScreen struct {
 	widthAccessFromSizeOnly  int // Access from Size() method only
 	heightAccessFromSizeOnly int // Access from Size() method only
 
-	terminalBackground      *Color
-	terminalBackgroundQuery *time.Time // When we asked for the terminal background color
-	terminalBackgroundLock  sync.Mutex
+	terminalBackground  *Color
+	terminalForeground  *Color
+	terminalColorsQuery *time.Time // When we asked for the terminal colors
+	terminalColorsLock  sync.Mutex
+
+	// Whether we asked the terminal for its palette colors
+	paletteQueried atomic.Bool
+
+	// See CursorPosition()
+	cursorPositionLock      sync.Mutex
+	cursorPositionRequested atomic.Bool
+	cursorPositions         chan cursorPosition
 
 	cells [][]StyledRune
 
@@ -94,9 +275,29 @@ type UnixScreen struct {
 	// not this channel has been signalled
 	sigwinch chan int
 
+	// SIGTSTP notifications, see setupSuspendNotification(). Nil if not set up.
+	sigtstp chan os.Signal
+
 	events chan Event
 
-	ttyInReader interruptableReader
+	// Nil while suspended. Lock ttyInReaderLock before accessing.
+	ttyInReader     interruptableReader
+	ttyInReaderLock sync.Mutex
+
+	// Closed when the main loop reading from ttyInReader exits
+	mainLoopDone chan struct{}
+
+	// As set up by the constructor or SetMouseTracking(), see Resume()
+	mouseTracking       bool
+	mouseMotionTracking bool
+
+	// Draw on the normal screen rather than on the alternate one, see
+	// NewInlineScreenWithMouseModeAndColorCount()
+	inline bool
+
+	// The terminal row our first row is on. Always 0 unless inline, see
+	// makeInlineRoom(). Atomic since mainLoop() needs it for mouse events.
+	inlineTop atomic.Int32
 
 	ttyIn            *os.File
 	oldTerminalState *term.State //nolint Not used on Windows
@@ -106,6 +307,35 @@ type UnixScreen struct {
 	oldTtyOutMode uint32 //nolint Windows only
 
 	terminalColorCount ColorCount
+
+	options ScreenOptions
+
+	// Where Show() should leave the cursor, see ShowCursorAt(). Nil means
+	// hidden.
+	cursorAt *cursorPosition
+
+	// Whether the terminal is currently showing the cursor
+	cursorShown bool
+
+	// As set by SetTitle(), empty means never set
+	title string
+
+	// Whether we have saved the previous terminal title, see SetTitle()
+	titlePushed bool
+
+	// Set by the main loop when the terminal says it supports the Kitty
+	// keyboard protocol. Show() then enables it.
+	kittyKeyboardDetected atomic.Bool
+	kittyKeyboardEnabled  bool
+
+	// Set by the main loop if the terminal supports synchronized output, see
+	// ScreenOptions.SynchronizedOutput
+	synchronizedOutput atomic.Bool
+
+	// The rows Show() last wrote, rendered. Show() then writes only the rows
+	// that have changed since. Nil when we don't know what's on screen.
+	shownRows  []string
+	shownWidth int
 }
 
 // Example event: "\x1b[<65;127;41M"
@@ -115,8 +345,20 @@ type UnixScreen struct {
 //   - "65" says this is Wheel Up. "64" would be Wheel Down.
 //   - "127" is the column number on screen, "1" is the first column.
 //   - "41" is the row number on screen, "1" is the first row.
-//   - "M" marks the end of the mouse event.
-var mouseEventRegex = regexp.MustCompile("^\x1b\\[<([0-9]+);([0-9]+);([0-9]+)M")
+//   - "M" marks the end of the mouse event. "m" would mean a button was
+//     released.
+var mouseEventRegex = regexp.MustCompile("^\x1b\\[<([0-9]+);([0-9]+);([0-9]+)([Mm])")
+
+// Legacy X10 mouse events are "\x1b[M" followed by three bytes: the button
+// code, the column and the row. Each byte has 32 added to it, and the
+// coordinates are one based.
+//
+// Example event: "\x1b[M`*J" is Wheel Up (64) at column 10, row 42.
+const x10MouseEventPrefix = "\x1b[M"
+
+// Bracketed paste markers, see consumePaste()
+const pasteStart = "\x1b[200~"
+const pasteEnd = "\x1b[201~"
 
 // NewScreen() requires Close() to be called after you are done with your new
 // screen, most likely somewhere in your shutdown code.
@@ -134,12 +376,39 @@ func NewScreenWithMouseMode(mouseMode MouseMode) (Screen, error) {
 }
 
 func NewScreenWithMouseModeAndColorCount(mouseMode MouseMode, terminalColorCount ColorCount) (Screen, error) {
+	return NewScreenWithOptions(mouseMode, terminalColorCount, DefaultScreenOptions())
+}
+
+func NewScreenWithOptions(mouseMode MouseMode, terminalColorCount ColorCount, options ScreenOptions) (Screen, error) {
+	return newScreen(mouseMode, terminalColorCount, false, options)
+}
+
+// Like NewScreenWithMouseModeAndColorCount(), but draws on the normal screen
+// below the shell prompt rather than on the alternate screen. The screen starts
+// at the cursor row. If that leaves less than half the terminal, whatever is
+// above is scrolled up to make room.
+//
+// After Close(), the screen is cleared and the cursor is left where the screen
+// started. Use ShowNLines() or ShowRows() to leave some output there.
+func NewInlineScreenWithMouseModeAndColorCount(mouseMode MouseMode, terminalColorCount ColorCount) (Screen, error) {
+	return NewInlineScreenWithOptions(mouseMode, terminalColorCount, DefaultScreenOptions())
+}
+
+// Like NewInlineScreenWithMouseModeAndColorCount(), but with options
+func NewInlineScreenWithOptions(mouseMode MouseMode, terminalColorCount ColorCount, options ScreenOptions) (Screen, error) {
+	return newScreen(mouseMode, terminalColorCount, true, options)
+}
+
+func newScreen(mouseMode MouseMode, terminalColorCount ColorCount, inline bool, options ScreenOptions) (Screen, error) {
 	if !term.IsTerminal(int(os.Stdout.Fd())) {
 		return nil, fmt.Errorf("stdout (fd=%d) must be a terminal for paging to work", os.Stdout.Fd())
 	}
 
 	screen := UnixScreen{
 		terminalColorCount: terminalColorCount,
+		cursorPositions:    make(chan cursorPosition, 1),
+		inline:             inline,
+		options:            options,
 	}
 
 	// The number "80" here is from manual testing on my MacBook:
@@ -156,11 +425,12 @@ func NewScreenWithMouseModeAndColorCount(mouseMode MouseMode, terminalColorCount
 	screen.events = make(chan Event, 160)
 
 	screen.setupSigwinchNotification()
+	screen.setupSuspendNotification()
 	err := screen.setupTtyInTtyOut()
 	if err != nil {
 		return nil, fmt.Errorf("problem setting up TTY: %w", err)
 	}
-	screen.ttyInReader, err = newInterruptableReader(screen.ttyIn)
+	ttyInReader, err := newInterruptableReader(screen.ttyIn)
 	if err != nil {
 		restoreErr := screen.restoreTtyInTtyOut()
 		if restoreErr != nil {
@@ -169,38 +439,51 @@ func NewScreenWithMouseModeAndColorCount(mouseMode MouseMode, terminalColorCount
 		return nil, fmt.Errorf("problem setting up TTY reader: %w", err)
 	}
 
-	screen.setAlternateScreenMode(true)
-
 	if mouseMode == MouseModeAuto {
-		screen.enableMouseTracking(!terminalHasArrowKeysEmulation())
+		screen.mouseTracking = !terminalHasArrowKeysEmulation()
 	} else if mouseMode == MouseModeSelect {
-		screen.enableMouseTracking(false)
+		screen.mouseTracking = false
 	} else if mouseMode == MouseModeScroll {
-		screen.enableMouseTracking(true)
+		screen.mouseTracking = true
+	} else if mouseMode == MouseModeHover {
+		screen.mouseTracking = true
+		screen.mouseMotionTracking = true
 	} else {
 		panic(fmt.Errorf("unknown mouse mode: %d", mouseMode))
 	}
 
-	screen.hideCursor(true)
-
-	go func() {
-		defer func() {
-			panicHandler("NewScreenWithMouseModeAndColorCount()/mainLoop()", recover(), debug.Stack())
-		}()
+	// Started first, so that makeInlineRoom() can get the cursor position
+	screen.startMainLoop(ttyInReader)
+	screen.takeTerminal()
 
-		screen.mainLoop()
-	}()
-
-	// Request terminal background color. The response will be handled in
-	// screen.mainLoop() that we just started ^.
+	// Request terminal background and foreground colors. The responses will be
+	// handled in screen.mainLoop() that we just started ^.
 	//
 	// Ref:
 	// https://stackoverflow.com/questions/2507337/how-to-determine-a-terminals-background-color
-	fmt.Println("\x1b]11;?\x07")
-	screen.terminalBackgroundLock.Lock()
-	defer screen.terminalBackgroundLock.Unlock()
+	fmt.Println("\x1b]11;?\x07\x1b]10;?\x07")
+	screen.terminalColorsLock.Lock()
+	defer screen.terminalColorsLock.Unlock()
 	now := time.Now()
-	screen.terminalBackgroundQuery = &now
+	screen.terminalColorsQuery = &now
+
+	if query := terminalPaletteQuery(terminalColorCount); options.QueryTerminalPalette && query != "" {
+		// Responses are handled by screen.mainLoop() as well. Terminals that
+		// don't support this query just ignore it.
+		screen.paletteQueried.Store(true)
+		screen.write(query)
+	}
+
+	if options.KittyKeyboard {
+		// Handled by screen.mainLoop() too, ignored by terminals without
+		// Kitty keyboard protocol support
+		screen.write(kittyKeyboardQuery)
+	}
+
+	if options.SynchronizedOutput {
+		// Handled by screen.mainLoop() as well
+		screen.write(synchronizedOutputQuery)
+	}
 
 	return &screen, nil
 }
@@ -211,12 +494,110 @@ func (screen *UnixScreen) Close() {
 	// Tell the pager to exit unless it hasn't already
 	screen.events <- EventExit{}
 
+	screen.stopSuspendNotification()
+
 	// Tell our main loop to exit
-	screen.ttyInReader.Interrupt()
+	screen.ttyInReaderLock.Lock()
+	ttyInReader := screen.ttyInReader
+	screen.ttyInReader = nil
+	screen.ttyInReaderLock.Unlock()
+	if ttyInReader == nil {
+		// Suspended, the terminal has already been given back
+		return
+	}
+	ttyInReader.Interrupt()
 
+	screen.giveBackTerminal()
+}
+
+// Suspend() restores the terminal to its normal state and stops reading input
+// from it, so that some other program can use it. Call Resume() when that
+// program is done.
+func (screen *UnixScreen) Suspend() {
+	screen.ttyInReaderLock.Lock()
+	ttyInReader := screen.ttyInReader
+	screen.ttyInReader = nil
+	screen.ttyInReaderLock.Unlock()
+	if ttyInReader == nil {
+		log.Debug("Screen already suspended, never mind")
+		return
+	}
+
+	ttyInReader.Interrupt()
+	if runtime.GOOS != "windows" {
+		// Make sure we don't steal any input from whatever runs while we're
+		// suspended. Not on Windows, where Interrupt() doesn't take effect
+		// until the next keypress.
+		<-screen.mainLoopDone
+	}
+
+	screen.giveBackTerminal()
+}
+
+// Resume() takes the terminal back after Suspend(), and triggers an EventResize
+// to get the screen redrawn.
+func (screen *UnixScreen) Resume() {
+	screen.ttyInReaderLock.Lock()
+	defer screen.ttyInReaderLock.Unlock()
+	if screen.ttyInReader != nil {
+		log.Debug("Screen not suspended, never mind")
+		return
+	}
+
+	err := screen.reenterRawMode()
+	if err != nil {
+		log.Warn("Problem setting TTY to raw mode after resume: ", err)
+	}
+
+	ttyInReader, err := newInterruptableReader(screen.ttyIn)
+	if err != nil {
+		log.Warn("Problem setting up TTY reader after resume: ", err)
+		return
+	}
+
+	screen.startMainLoop(ttyInReader)
+	screen.takeTerminal()
+
+	// The terminal may have been resized while we were suspended, and the
+	// screen contents need redrawing either way
+	screen.onWindowResized()
+}
+
+// Enter the alternate screen, capture the mouse and hide the cursor
+func (screen *UnixScreen) takeTerminal() {
+	screen.shownRows = nil
+	if screen.inline {
+		screen.makeInlineRoom()
+	}
+	screen.setAlternateScreenMode(true)
+	screen.enableMouseTracking(screen.mouseTracking)
+	if screen.mouseTracking && screen.mouseMotionTracking {
+		screen.enableMouseMotionTracking(true)
+	}
+	screen.hideCursor(true)
+	if screen.title != "" {
+		screen.writeTitle()
+	}
+}
+
+// Undo takeTerminal() and restore the TTY state
+func (screen *UnixScreen) giveBackTerminal() {
+	if screen.kittyKeyboardEnabled {
+		screen.write(kittyKeyboardDisable)
+		screen.kittyKeyboardEnabled = false
+	}
 	screen.hideCursor(false)
+	screen.enableMouseMotionTracking(false)
 	screen.enableMouseTracking(false)
 	screen.setAlternateScreenMode(false)
+	if screen.inline {
+		// Clear what we drew, and leave the cursor where we started drawing
+		screen.write(screen.toTerminal(cursorPosition{}).moveCursorTo() + "\x1b[J")
+	}
+	if screen.titlePushed {
+		screen.write(titlePop)
+		screen.titlePushed = false
+	}
 
 	err := screen.restoreTtyInTtyOut()
 	if err != nil {
@@ -228,6 +609,21 @@ func (screen *UnixScreen) Close() {
 	}
 }
 
+func (screen *UnixScreen) startMainLoop(ttyInReader interruptableReader) {
+	screen.ttyInReader = ttyInReader
+	mainLoopDone := make(chan struct{})
+	screen.mainLoopDone = mainLoopDone
+
+	go func() {
+		defer func() {
+			panicHandler("startMainLoop()/mainLoop()", recover(), debug.Stack())
+		}()
+		defer close(mainLoopDone)
+
+		screen.mainLoop(ttyInReader)
+	}()
+}
+
 func (screen *UnixScreen) Events() chan Event {
 	return screen.events
 }
@@ -241,23 +637,81 @@ func (screen *UnixScreen) write(s string) int {
 	return bytesWritten
 }
 
+// Ref: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Operating-System-Commands
+func (screen *UnixScreen) CopyToClipboard(text string) {
+	screen.write("\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07")
+}
+
+func (screen *UnixScreen) Beep() {
+	screen.write("\a")
+}
+
+// Convert a position on our screen into a position in the terminal
+func (screen *UnixScreen) toTerminal(position cursorPosition) cursorPosition {
+	position.row += int(screen.inlineTop.Load())
+	return position
+}
+
+// Make the inline screen start at the cursor row, so that the shell prompt
+// above stays visible. If that leaves less than half the terminal, scroll up
+// just enough to make room for that.
+func (screen *UnixScreen) makeInlineRoom() {
+	width, height := screen.Size()
+	terminalHeight := height + int(screen.inlineTop.Load())
+
+	cursorRow := terminalHeight - 1 // If the terminal doesn't say, assume the bottom row
+	if _, row, ok := screen.CursorPosition(); ok {
+		cursorRow = min(row, terminalHeight-1)
+	}
+
+	wantedHeight := max(terminalHeight/2, 1)
+	scrollCount := max(cursorRow+wantedHeight-terminalHeight, 0)
+	if scrollCount > 0 {
+		// In raw mode, "\n" just moves the cursor down, scrolling at the bottom
+		screen.write(strings.Repeat("\n", terminalHeight-1-cursorRow+scrollCount))
+	}
+
+	inlineTop := cursorRow - scrollCount
+	screen.inlineTop.Store(int32(inlineTop))
+	screen.heightAccessFromSizeOnly = terminalHeight - inlineTop
+	screen.cells = resizedCells(screen.cells, width, screen.heightAccessFromSizeOnly)
+	screen.write(cursorPosition{row: inlineTop}.moveCursorTo())
+}
+
+// In inline mode, this only sets up the modes that go along with the alternate
+// screen, but stays on the normal screen
 func (screen *UnixScreen) setAlternateScreenMode(enable bool) {
 	// Ref: https://stackoverflow.com/a/11024208/473672
 	if enable {
-		screen.write("\x1b[?1049h")
+		if !screen.inline {
+			screen.write("\x1b[?1049h")
+		}
 
 		// Enable alternateScroll mode. This makes the mouse wheel work without
 		// blocking selection.
 		//
 		// Ref: https://github.com/walles/moor/issues/53#issuecomment-3392572761
-		screen.write("\x1b[?1007h")
+		if screen.options.AlternateScroll {
+			screen.write("\x1b[?1007h")
+		}
+
+		// Enable bracketed paste, so that pasted text doesn't trigger
+		// commands. See consumePaste().
+		screen.write("\x1b[?2004h")
 	} else {
-		screen.write("\x1b[?1007l")
-		screen.write("\x1b[?1049l")
+		screen.write("\x1b[?2004l")
+		if screen.options.AlternateScroll {
+			screen.write("\x1b[?1007l")
+		}
+		if !screen.inline {
+			screen.write("\x1b[?1049l")
+		}
 	}
 }
 
 func (screen *UnixScreen) hideCursor(hide bool) {
+	screen.cursorShown = !hide
+
 	// Ref: https://en.wikipedia.org/wiki/ANSI_escape_code#CSI_(Control_Sequence_Introducer)_sequences
 	if hide {
 		screen.write("\x1b[?25l")
@@ -414,44 +868,79 @@ func terminalHasArrowKeysEmulation() bool {
 	return false
 }
 
+// Report mouse button presses and releases, and mouse motion while a button is
+// held down (1002). The latter is for selecting text by dragging.
 func (screen *UnixScreen) enableMouseTracking(enable bool) {
+	modes := "1006;1002"
+	if screen.options.MouseEncoding == MouseEncodingX10 {
+		modes = "1002"
+	}
+
 	if enable {
-		screen.write("\x1b[?1006;1000h")
+		screen.write("\x1b[?" + modes + "h")
 	} else {
-		screen.write("\x1b[?1006;1000l")
+		screen.write("\x1b[?" + modes + "l")
 	}
 }
 
-// ShowCursorAt() moves the cursor to the given screen position and makes sure
-// it is visible.
-//
-// If the position is outside of the screen, the cursor will be hidden.
-func (screen *UnixScreen) ShowCursorAt(column int, row int) {
-	if column < 0 {
-		screen.hideCursor(true)
+func (screen *UnixScreen) MouseTrackingEnabled() bool {
+	return screen.mouseTracking
+}
+
+func (screen *UnixScreen) SetMouseTracking(enable bool) {
+	screen.ttyInReaderLock.Lock()
+	defer screen.ttyInReaderLock.Unlock()
+
+	if enable == screen.mouseTracking {
 		return
 	}
-	if row < 0 {
-		screen.hideCursor(true)
+	screen.mouseTracking = enable
+
+	if screen.ttyInReader == nil {
+		// Suspended, Resume() will take care of this
 		return
 	}
 
-	width, height := screen.Size()
-	if column >= width {
-		screen.hideCursor(true)
-		return
+	if !enable && screen.mouseMotionTracking {
+		screen.enableMouseMotionTracking(false)
 	}
-	if row >= height {
+	screen.enableMouseTracking(enable)
+	if enable && screen.mouseMotionTracking {
+		screen.enableMouseMotionTracking(true)
+	}
+}
+
+// Report all mouse motion, not just button presses.
+//
+// Ref: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h2-Any-event-tracking
+func (screen *UnixScreen) enableMouseMotionTracking(enable bool) {
+	if enable {
+		screen.write("\x1b[?1003h")
+	} else {
+		screen.write("\x1b[?1003l")
+	}
+}
+
+// ShowCursorAt() moves the cursor to the given screen position and makes sure
+// it is visible. Zero based, just like SetCell().
+//
+// The cursor stays there through Show() calls until the next Clear().
+//
+// If the position is outside of the screen, the cursor will be hidden.
+func (screen *UnixScreen) ShowCursorAt(column int, row int) {
+	width, height := screen.Size()
+	if column < 0 || row < 0 || column >= width || row >= height {
+		screen.cursorAt = nil
 		screen.hideCursor(true)
 		return
 	}
 
-	// https://en.wikipedia.org/wiki/ANSI_escape_code#CSI_(Control_Sequence_Introducer)_sequences
-	screen.write(fmt.Sprintf("\x1b[%d;%dH", row, column))
+	screen.cursorAt = &cursorPosition{column: column, row: row}
+	screen.write(screen.toTerminal(*screen.cursorAt).moveCursorTo())
 	screen.hideCursor(false)
 }
 
-func (screen *UnixScreen) mainLoop() {
+func (screen *UnixScreen) mainLoop(ttyInReader interruptableReader) {
 	// "1400" comes from me trying fling scroll operations on my MacBook
 	// trackpad and looking at the high watermark (logged below).
 	//
@@ -462,11 +951,21 @@ func (screen *UnixScreen) mainLoop() {
 	log.Info("Entering Twin main loop...")
 
 	maxBytesRead := 0
-	expectingTerminalBackgroundColor := true
-	var incompleteResponse []byte // To store incomplete terminal background color responses
+	var incompleteResponse []byte // To store incomplete terminal query responses
+	var incompleteCursorPosition []byte
+	var incompleteInput []byte // Pastes, X10 mouse events and characters split across reads
 	for {
-		count, err := screen.ttyInReader.Read(buffer)
+		count, err := ttyInReader.Read(buffer)
 		if err != nil {
+			screen.ttyInReaderLock.Lock()
+			suspended := screen.ttyInReader != ttyInReader
+			screen.ttyInReaderLock.Unlock()
+			if suspended {
+				// Suspend() or Close() interrupted us, this is not an error
+				log.Info("Twin main loop interrupted, exiting")
+				return
+			}
+
 			// Ref:
 			// * https://github.com/walles/moor/issues/145
 			// * https://github.com/walles/moor/issues/149
@@ -477,26 +976,39 @@ func (screen *UnixScreen) mainLoop() {
 			return
 		}
 
-		if expectingTerminalBackgroundColor {
-			incompleteResponse = append(incompleteResponse, buffer[:count]...)
-			// This is the response to our background color request
-			bg, valid := parseTerminalBgColorResponse(incompleteResponse)
-			if valid {
-				if bg != nil {
-					screen.terminalBackgroundLock.Lock()
-					screen.terminalBackground = bg
-					log.Debug("Terminal background color detected as ", bg, " after ", time.Since(*screen.terminalBackgroundQuery))
-					screen.terminalBackgroundLock.Unlock()
-
-					expectingTerminalBackgroundColor = false
-					incompleteResponse = nil
-				}
+		input := buffer[:count]
+		if len(incompleteResponse) > 0 || screen.expectingTerminalResponses() {
+			incompleteResponse = append(incompleteResponse, input...)
+
+			// These are responses to our color and palette queries
+			rest, waitForMore := screen.consumeTerminalResponses(string(incompleteResponse))
+			if waitForMore && screen.expectingTerminalResponses() {
+				incompleteResponse = []byte(rest)
 				continue
 			}
-
-			// Not valid, give up
-			expectingTerminalBackgroundColor = false
 			incompleteResponse = nil
+			if len(rest) == 0 {
+				continue
+			}
+
+			// Something else, the user is probably typing
+			input = []byte(rest)
+		}
+
+		if screen.cursorPositionRequested.Load() || len(incompleteCursorPosition) > 0 {
+			incompleteCursorPosition = append(incompleteCursorPosition, input...)
+
+			// This may be the response to a CursorPosition() call
+			rest, waitForMore := screen.consumeCursorPositionResponse(string(incompleteCursorPosition))
+			if waitForMore {
+				incompleteCursorPosition = []byte(rest)
+				continue
+			}
+			incompleteCursorPosition = nil
+			if len(rest) == 0 {
+				continue
+			}
+			input = []byte(rest)
 		}
 
 		if count > maxBytesRead {
@@ -504,19 +1016,34 @@ func (screen *UnixScreen) mainLoop() {
 			log.Trace("ttyin high watermark bumped to ", maxBytesRead, " bytes")
 		}
 
-		encodedKeyCodeSequences := string(buffer[0:count])
-		if !utf8.ValidString(encodedKeyCodeSequences) {
-			log.Warn("Got invalid UTF-8 sequence on ttyin: ", encodedKeyCodeSequences)
-			continue
-		}
-
+		encodedKeyCodeSequences := string(incompleteInput) + string(input)
+		incompleteInput = nil
 		for len(encodedKeyCodeSequences) > 0 {
+			if isIncompleteX10MouseEvent(encodedKeyCodeSequences) || !utf8.FullRuneInString(encodedKeyCodeSequences) {
+				incompleteInput = []byte(encodedKeyCodeSequences)
+				break
+			}
+
 			var event *Event
-			event, encodedKeyCodeSequences = consumeEncodedEvent(encodedKeyCodeSequences)
+			var waitForMore bool
+			event, encodedKeyCodeSequences, waitForMore = consumePaste(encodedKeyCodeSequences)
+			if waitForMore {
+				incompleteInput = []byte(encodedKeyCodeSequences)
+				break
+			}
+			if event == nil {
+				event, encodedKeyCodeSequences = consumeEncodedEvent(encodedKeyCodeSequences)
+			}
 
 			if event == nil {
-				// No event, go wait for more
-				break
+				// Nothing to post, go on with whatever is left
+				continue
+			}
+
+			if mouseEvent, ok := (*event).(EventMouse); ok {
+				// The terminal counts from its top row, not from ours
+				mouseEvent.row -= int(screen.inlineTop.Load())
+				*event = mouseEvent
 			}
 
 			// Post the event
@@ -532,13 +1059,13 @@ func (screen *UnixScreen) mainLoop() {
 	}
 }
 
-// Turn ESC into <0x1b> and other low ASCII characters into <0xXX> for logging
-// purposes.
-func humanizeLowASCII(withLowAsciis string) string {
+// HumanizeLowASCII turns ESC into <0x1b> and other low ASCII characters into
+// <0xXX>, for logging and debugging purposes.
+func HumanizeLowASCII(withLowAsciis string) string {
 	humanized := ""
 	for _, char := range withLowAsciis {
 		if char < ' ' {
-			humanized += fmt.Sprintf("<0x%2x>", char)
+			humanized += fmt.Sprintf("<0x%02x>", char)
 			continue
 		}
 		humanized += string(char)
@@ -546,70 +1073,191 @@ func humanizeLowASCII(withLowAsciis string) string {
 	return humanized
 }
 
+// Consume an initial bracketed paste from the sequence of encoded keycodes.
+//
+// Returns a nil event if the sequence doesn't start with a paste. If the paste
+// or its start marker hasn't been fully read yet, waitForMore is true and the
+// sequence is returned as is.
+//
+// Ref: https://en.wikipedia.org/wiki/Bracketed-paste
+func consumePaste(encodedEventSequences string) (event *Event, remainder string, waitForMore bool) {
+	if len(encodedEventSequences) > 1 && len(encodedEventSequences) < len(pasteStart) && strings.HasPrefix(pasteStart, encodedEventSequences) {
+		// Start marker split across reads. A lone ESC is the Escape key, so
+		// don't wait for more after one of those.
+		return nil, encodedEventSequences, true
+	}
+
+	pasted, isPaste := strings.CutPrefix(encodedEventSequences, pasteStart)
+	if !isPaste {
+		return nil, encodedEventSequences, false
+	}
+
+	pasted, remainder, complete := strings.Cut(pasted, pasteEnd)
+	if !complete {
+		return nil, encodedEventSequences, true
+	}
+
+	var pasteEvent Event = EventPaste{text: strings.ToValidUTF8(pasted, "\uFFFD")}
+	return &pasteEvent, remainder, false
+}
+
+// True if the sequence starts with an X10 mouse event that hasn't been fully
+// read yet. The rest of the event should arrive with the next read.
+func isIncompleteX10MouseEvent(encodedEventSequences string) bool {
+	if !strings.HasPrefix(encodedEventSequences, x10MouseEventPrefix) {
+		return false
+	}
+
+	return len(encodedEventSequences) < len(x10MouseEventPrefix)+3
+}
+
 // Consume initial key code from the sequence of encoded keycodes.
 //
 // Returns a (possibly nil) event that should be posted, and the remainder of
 // the encoded events sequence.
 func consumeEncodedEvent(encodedEventSequences string) (*Event, string) {
+	if event, remainder := consumeKittyKeyReport(encodedEventSequences); remainder != encodedEventSequences {
+		return event, remainder
+	}
+
 	for singleKeyCodeSequence, keyCode := range escapeSequenceToKeyCode {
 		if !strings.HasPrefix(encodedEventSequences, singleKeyCodeSequence) {
 			continue
 		}
 
 		// Encoded key code sequence found, report it!
-		var event Event = EventKeyCode{keyCode}
+		var event Event = EventKeyCode{keyCode: keyCode}
 		return &event, strings.TrimPrefix(encodedEventSequences, singleKeyCodeSequence)
 	}
 
 	mouseMatch := mouseEventRegex.FindStringSubmatch(encodedEventSequences)
 	if mouseMatch != nil {
-		if mouseMatch[1] == "64" {
-			var event Event = EventMouse{buttons: MouseWheelUp}
-			return &event, strings.TrimPrefix(encodedEventSequences, mouseMatch[0])
-		}
-		if mouseMatch[1] == "65" {
-			var event Event = EventMouse{buttons: MouseWheelDown}
-			return &event, strings.TrimPrefix(encodedEventSequences, mouseMatch[0])
+		remainder := strings.TrimPrefix(encodedEventSequences, mouseMatch[0])
+
+		buttonCode, buttonErr := strconv.Atoi(mouseMatch[1])
+		column, columnErr := strconv.Atoi(mouseMatch[2])
+		row, rowErr := strconv.Atoi(mouseMatch[3])
+		if buttonErr == nil && columnErr == nil && rowErr == nil {
+			if event := mouseEvent(buttonCode, column, row, mouseMatch[4] == "m"); event != nil {
+				return event, remainder
+			}
 		}
 
 		log.Debug(
 			"Unhandled multi character mouse escape sequence(s): {",
-			humanizeLowASCII(encodedEventSequences),
+			HumanizeLowASCII(encodedEventSequences),
+			"}")
+		return nil, ""
+	}
+
+	if strings.HasPrefix(encodedEventSequences, x10MouseEventPrefix) {
+		x10Event := encodedEventSequences[len(x10MouseEventPrefix):]
+		if len(x10Event) >= 3 {
+			buttonCode := int(x10Event[0]) - 32
+			column := int(x10Event[1]) - 32
+			row := int(x10Event[2]) - 32
+			if event := mouseEvent(buttonCode, column, row, false); event != nil {
+				return event, x10Event[3:]
+			}
+		}
+
+		log.Debug(
+			"Unhandled X10 mouse escape sequence(s): {",
+			HumanizeLowASCII(encodedEventSequences),
 			"}")
 		return nil, ""
 	}
 
 	// No escape sequence prefix matched
-	runes := []rune(encodedEventSequences)
-	if len(runes) == 0 {
+	if len(encodedEventSequences) == 0 {
 		return nil, ""
 	}
 
-	if runes[0] == '\x1b' {
-		if len(runes) != 1 {
+	char, size := utf8.DecodeRuneInString(encodedEventSequences)
+	remainder := encodedEventSequences[size:]
+	if char == utf8.RuneError && size == 1 {
+		// Skip the broken byte, but keep whatever comes after it
+		log.Warnf("Got invalid UTF-8 byte on ttyin: 0x%02x", encodedEventSequences[0])
+		return nil, remainder
+	}
+
+	if char == '\x1b' {
+		if remainder != "" {
 			// This means one or more sequences should be added to
 			// escapeSequenceToKeyCode in keys.go.
 			log.Debug(
 				"Unhandled multi character terminal escape sequence(s): {",
-				humanizeLowASCII(encodedEventSequences),
+				HumanizeLowASCII(encodedEventSequences),
 				"}")
 
 			// Mark everything as consumed since we don't know how to proceed otherwise.
 			return nil, ""
 		}
 
-		var event Event = EventKeyCode{KeyEscape}
-		return &event, string(runes[1:])
+		var event Event = EventKeyCode{keyCode: KeyEscape}
+		return &event, remainder
 	}
 
-	if runes[0] == '\r' {
-		var event Event = EventKeyCode{KeyEnter}
-		return &event, string(runes[1:])
+	if char == '\r' {
+		var event Event = EventKeyCode{keyCode: KeyEnter}
+		return &event, remainder
 	}
 
 	// Report the single rune
-	var event Event = EventRune{rune: runes[0]}
-	return &event, string(runes[1:])
+	var event Event = EventRune{rune: char}
+	return &event, remainder
+}
+
+// Turn a decoded SGR or X10 mouse event into an EventMouse. The coordinates are
+// one based. SGR events tell us when a button is released, X10 events use
+// button code 3 for that.
+//
+// Returns nil for events we don't care about.
+func mouseEvent(buttonCode int, column int, row int, released bool) *Event {
+	if buttonCode == 64 {
+		var event Event = EventMouse{buttons: MouseWheelUp}
+		return &event
+	}
+	if buttonCode == 65 {
+		var event Event = EventMouse{buttons: MouseWheelDown}
+		return &event
+	}
+
+	// Tilting the wheel, or scrolling it with Shift held (4), scrolls sideways
+	if buttonCode == 66 || buttonCode == 64|4 {
+		var event Event = EventMouse{buttons: MouseWheelLeft}
+		return &event
+	}
+	if buttonCode == 67 || buttonCode == 65|4 {
+		var event Event = EventMouse{buttons: MouseWheelRight}
+		return &event
+	}
+
+	if buttonCode >= 64 {
+		return nil
+	}
+
+	// Ignore the Shift (4), Meta (8) and Control (16) modifier bits
+	buttonCode &^= 4 | 8 | 16
+
+	var buttons MouseButtonMask
+	switch {
+	case buttonCode == 32:
+		// Motion (32) with the left button (0) held down
+		buttons = MouseLeftDrag
+	case buttonCode&32 != 0:
+		buttons = MouseMotion
+	case buttonCode == 3 || (released && buttonCode == 0):
+		buttons = MouseLeftRelease
+	case buttonCode == 0:
+		buttons = MouseLeftPress
+	default:
+		// Some other button
+		return nil
+	}
+
+	var event Event = EventMouse{buttons: buttons, column: column - 1, row: row - 1}
+	return &event
 }
 
 // Returns screen width and height.
@@ -620,7 +1268,9 @@ func consumeEncodedEvent(encodedEventSequences string) (*Event, string) {
 func (screen *UnixScreen) Size() (width int, height int) {
 	select {
 	case <-screen.sigwinch:
-		// Resize logic needed, see below
+		// Resize logic needed, see below. Whatever was on screen may have
+		// been rearranged by the terminal.
+		screen.shownRows = nil
 	default:
 		// No resize, go with the existing values
 		if screen.widthAccessFromSizeOnly == 0 || screen.heightAccessFromSizeOnly == 0 {
@@ -641,23 +1291,20 @@ func (screen *UnixScreen) Size() (width int, height int) {
 		panic(fmt.Sprintf("Got zero screen size: %d x %d", width, height))
 	}
 
+	// Inline screens go from inlineTop to the bottom of the terminal
+	inlineTop := min(int(screen.inlineTop.Load()), height-1)
+	screen.inlineTop.Store(int32(inlineTop))
+	height -= inlineTop
+
 	if screen.widthAccessFromSizeOnly == width && screen.heightAccessFromSizeOnly == height {
 		// Not sure when this would happen, but if it does this wasn't really a
 		// resize, and we don't need to treat it as such.
 		return screen.widthAccessFromSizeOnly, screen.heightAccessFromSizeOnly
 	}
 
-	newCells := make([][]StyledRune, height)
-	for rowNumber := 0; rowNumber < height; rowNumber++ {
-		newCells[rowNumber] = make([]StyledRune, width)
-	}
-
-	// FIXME: Copy any existing contents over to the new, resized screen array
-	// FIXME: Fill any non-initialized cells with whitespace
-
 	screen.widthAccessFromSizeOnly = width
 	screen.heightAccessFromSizeOnly = height
-	screen.cells = newCells
+	screen.cells = resizedCells(screen.cells, width, height)
 
 	return screen.widthAccessFromSizeOnly, screen.heightAccessFromSizeOnly
 }
@@ -668,47 +1315,192 @@ func (screen *UnixScreen) Size() (width int, height int) {
 //
 // Returns the terminal background color if known, nil otherwise.
 func (screen *UnixScreen) TerminalBackground() *Color {
+	return screen.awaitTerminalColor(&screen.terminalBackground)
+}
+
+// The first time you call this, there may be a delay of up to 50ms while we
+// wait for the terminal to respond to our foreground color query. After that,
+// it will be instant.
+//
+// Returns the terminal foreground color if known, nil otherwise.
+func (screen *UnixScreen) TerminalForeground() *Color {
+	return screen.awaitTerminalColor(&screen.terminalForeground)
+}
+
+// Wait for the main loop to fill in the color, or for the query to time out.
+// The color must be protected by terminalColorsLock.
+func (screen *UnixScreen) awaitTerminalColor(color **Color) *Color {
 	const maxWait = 50 * time.Millisecond
 
 	// Is it already known?
-	screen.terminalBackgroundLock.Lock()
-	if screen.terminalBackground != nil || time.Since(*screen.terminalBackgroundQuery) > maxWait {
+	screen.terminalColorsLock.Lock()
+	if *color != nil || time.Since(*screen.terminalColorsQuery) > maxWait {
 		// Either we know the color or we gave up waiting for it. Return it!
-		background := screen.terminalBackground
-		screen.terminalBackgroundLock.Unlock()
-		return background
+		known := *color
+		screen.terminalColorsLock.Unlock()
+		return known
 	}
-	screen.terminalBackgroundLock.Unlock()
+	screen.terminalColorsLock.Unlock()
 
-	// Wait at most 50ms in total for the background to be detected
-	screen.terminalBackgroundLock.Lock()
-	start := screen.terminalBackgroundQuery
-	screen.terminalBackgroundLock.Unlock()
+	// Wait at most 50ms in total for the color to be detected
+	screen.terminalColorsLock.Lock()
+	start := screen.terminalColorsQuery
+	screen.terminalColorsLock.Unlock()
 
 	for time.Since(*start) < maxWait {
-		screen.terminalBackgroundLock.Lock()
-		if screen.terminalBackground != nil {
+		screen.terminalColorsLock.Lock()
+		if *color != nil {
 			// There it is!
-			background := screen.terminalBackground
-			screen.terminalBackgroundLock.Unlock()
-			return background
+			known := *color
+			screen.terminalColorsLock.Unlock()
+			return known
 		}
 
 		// Unlock so the other goroutine can set it
-		screen.terminalBackgroundLock.Unlock()
+		screen.terminalColorsLock.Unlock()
 
 		// It's not more urgent than this
 		time.Sleep(5 * time.Millisecond)
 	}
 
 	// The wait is over, return whatever we have
-	screen.terminalBackgroundLock.Lock()
-	defer screen.terminalBackgroundLock.Unlock()
-	return screen.terminalBackground
+	screen.terminalColorsLock.Lock()
+	defer screen.terminalColorsLock.Unlock()
+	return *color
 }
 
-func parseTerminalBgColorResponse(responseBytes []byte) (*Color, bool) {
-	prefix := "\x1b]11;rgb:"
+// How long after asking we look for responses to our terminal queries. After
+// this, anything looking like the start of a response is taken to be key
+// presses, so that Alt-] doesn't get stuck waiting for the rest of a response.
+//
+// Longer than the time we wait for the background color in
+// TerminalBackground(), since late responses would otherwise be taken to be
+// key presses.
+const terminalResponsesTimeout = 500 * time.Millisecond
+
+// Are responses to our terminal queries still expected?
+func (screen *UnixScreen) expectingTerminalResponses() bool {
+	screen.terminalColorsLock.Lock()
+	defer screen.terminalColorsLock.Unlock()
+
+	if screen.terminalColorsQuery == nil {
+		// Not asked yet, but about to
+		return true
+	}
+	return time.Since(*screen.terminalColorsQuery) < terminalResponsesTimeout
+}
+
+// Responses to private mode queries, like the Kitty keyboard protocol and the
+// synchronized output ones, start with this
+const privateModeResponsePrefix = "\x1b[?"
+
+var privateModePartialResponseRegex = regexp.MustCompile(`^\x1b\[\?[0-9;$]*$`)
+
+// Are we expecting any responses starting with privateModeResponsePrefix?
+func (screen *UnixScreen) privateModeQueried() bool {
+	return screen.options.KittyKeyboard || screen.options.SynchronizedOutput
+}
+
+// Consume responses to our terminal queries from the start of the input.
+//
+// Returns the input following the responses, and whether we should wait for
+// more input to complete a partial response.
+func (screen *UnixScreen) consumeTerminalResponses(input string) (string, bool) {
+	const fgPrefix = "\x1b]10;"
+	const bgPrefix = "\x1b]11;"
+	const palettePrefix = "\x1b]4;"
+
+	// Longer than this and it's not a response we're waiting for
+	const maxResponseLength = 64
+
+	for len(input) > 0 {
+		if screen.privateModeQueried() && strings.HasPrefix(input, privateModeResponsePrefix) {
+			if response := kittyKeyboardResponseRegex.FindString(input); response != "" {
+				log.Debug("Terminal supports the Kitty keyboard protocol: <", HumanizeLowASCII(response), ">")
+				screen.kittyKeyboardDetected.Store(true)
+				input = input[len(response):]
+				continue
+			}
+
+			if match := synchronizedOutputResponseRegex.FindStringSubmatch(input); match != nil {
+				supported := synchronizedOutputSupported(match[1])
+				log.Debug("Terminal synchronized output support: ", supported, " <", HumanizeLowASCII(match[0]), ">")
+				screen.synchronizedOutput.Store(supported)
+				input = input[len(match[0]):]
+				continue
+			}
+
+			isPartial := len(input) < maxResponseLength && privateModePartialResponseRegex.MatchString(input)
+			return input, isPartial
+		}
+
+		isFg := strings.HasPrefix(input, fgPrefix)
+		isBg := strings.HasPrefix(input, bgPrefix)
+		paletteQueried := screen.paletteQueried.Load()
+		isPalette := paletteQueried && strings.HasPrefix(input, palettePrefix)
+		if !isFg && !isBg && !isPalette {
+			// A lone ESC is the user pressing Escape, anything longer could
+			// be the start of a response
+			isPartialPrefix := len(input) >= 2 && (strings.HasPrefix(fgPrefix, input) || strings.HasPrefix(bgPrefix, input) || (paletteQueried && strings.HasPrefix(palettePrefix, input)) || (screen.privateModeQueried() && strings.HasPrefix(privateModeResponsePrefix, input)))
+			return input, isPartialPrefix
+		}
+
+		end := -1
+		if i := strings.Index(input, "\x07"); i >= 0 {
+			end = i + len("\x07")
+		}
+		if i := strings.Index(input, "\x1b\\"); i >= 0 && (end < 0 || i+len("\x1b\\") < end) {
+			end = i + len("\x1b\\")
+		}
+		if end < 0 {
+			if len(input) < maxResponseLength {
+				log.Trace("Terminal response received so far: <", HumanizeLowASCII(input), ">")
+				return input, true
+			}
+
+			log.Info("Got unterminated response from terminal: <", HumanizeLowASCII(input), ">")
+			return input, false
+		}
+
+		response := input[:end]
+		input = input[end:]
+
+		if isPalette {
+			index, color, valid := parseTerminalPaletteResponse(response)
+			if valid {
+				log.Trace("Terminal palette color ", index, " detected as ", color)
+				setTerminalPaletteColor(index, color)
+			}
+			continue
+		}
+
+		if isFg {
+			fg, valid := parseTerminalColorResponse(10, []byte(response))
+			if valid && fg != nil {
+				screen.terminalColorsLock.Lock()
+				screen.terminalForeground = fg
+				log.Debug("Terminal foreground color detected as ", fg, " after ", time.Since(*screen.terminalColorsQuery))
+				screen.terminalColorsLock.Unlock()
+			}
+			continue
+		}
+
+		bg, valid := parseTerminalColorResponse(11, []byte(response))
+		if valid && bg != nil {
+			screen.terminalColorsLock.Lock()
+			screen.terminalBackground = bg
+			log.Debug("Terminal background color detected as ", bg, " after ", time.Since(*screen.terminalColorsQuery))
+			screen.terminalColorsLock.Unlock()
+		}
+	}
+
+	return "", false
+}
+
+// Parse a response to an OSC 10 (foreground) or OSC 11 (background) color
+// query. The selector says which one we expect.
+func parseTerminalColorResponse(selector int, responseBytes []byte) (*Color, bool) {
+	prefix := "\x1b]" + strconv.Itoa(selector) + ";rgb:"
 	suffix1 := "\x07"
 	suffix2 := "\x1b\\"
 	sampleResponse1 := prefix + "0000/0000/0000" + suffix1
@@ -716,45 +1508,45 @@ func parseTerminalBgColorResponse(responseBytes []byte) (*Color, bool) {
 
 	response := string(responseBytes)
 	if !strings.HasPrefix(response, prefix) {
-		log.Info("Got unexpected prefix in bg color response from terminal: <", humanizeLowASCII(string(responseBytes)), ">")
+		log.Info("Got unexpected prefix in color response from terminal: <", HumanizeLowASCII(string(responseBytes)), ">")
 		return nil, false // Invalid
 	}
 	response = strings.TrimPrefix(response, prefix)
 
 	isComplete := strings.HasSuffix(response, suffix1) || strings.HasSuffix(response, suffix2)
 	if !isComplete && (len(responseBytes) < len(sampleResponse1) || len(responseBytes) < len(sampleResponse2)) {
-		log.Trace("Terminal bg color response received so far: <", humanizeLowASCII(response), ">")
+		log.Trace("Terminal color response received so far: <", HumanizeLowASCII(response), ">")
 		return nil, true // Incomplete but valid
 	}
 
 	if !isComplete {
-		log.Info("Got unexpected suffix in bg color response from terminal: <", humanizeLowASCII(string(responseBytes)), ">")
+		log.Info("Got unexpected suffix in color response from terminal: <", HumanizeLowASCII(string(responseBytes)), ">")
 		return nil, false // Invalid
 	}
 	response = strings.TrimSuffix(response, suffix1)
 	response = strings.TrimSuffix(response, suffix2)
 
 	if len(response) != 14 {
-		log.Info("Got unexpected length bg color response from terminal: <", humanizeLowASCII(string(responseBytes)), ">")
+		log.Info("Got unexpected length color response from terminal: <", HumanizeLowASCII(string(responseBytes)), ">")
 		return nil, false // Invalid
 	}
 
 	// response is now "RRRR/GGGG/BBBB"
 	red, err := strconv.ParseUint(response[0:4], 16, 16)
 	if err != nil {
-		log.Info("Failed parsing red in bg color response from terminal: <", humanizeLowASCII(string(responseBytes)), ">: ", err)
+		log.Info("Failed parsing red in color response from terminal: <", HumanizeLowASCII(string(responseBytes)), ">: ", err)
 		return nil, false // Invalid
 	}
 
 	green, err := strconv.ParseUint(response[5:9], 16, 16)
 	if err != nil {
-		log.Info("Failed parsing green in bg color response from terminal: <", humanizeLowASCII(string(responseBytes)), ">: ", err)
+		log.Info("Failed parsing green in color response from terminal: <", HumanizeLowASCII(string(responseBytes)), ">: ", err)
 		return nil, false // Invalid
 	}
 
 	blue, err := strconv.ParseUint(response[10:14], 16, 16)
 	if err != nil {
-		log.Info("Failed parsing blue in bg color response from terminal: <", humanizeLowASCII(string(responseBytes)), ">: ", err)
+		log.Info("Failed parsing blue in color response from terminal: <", HumanizeLowASCII(string(responseBytes)), ">: ", err)
 		return nil, false // Invalid
 	}
 
@@ -780,8 +1572,8 @@ func (screen *UnixScreen) SetCell(column int, row int, styledRune StyledRune) in
 	}
 
 	if column+styledRune.Width() > width {
-		// This cell is too wide for the screen, write a space instead
-		screen.cells[row][column] = NewStyledRune(' ', styledRune.Style)
+		// This cell is too wide for the screen, write a replacement instead
+		screen.cells[row][column] = screen.options.WideRuneAtEdge.Replacement(styledRune)
 		return styledRune.Width()
 	}
 
@@ -791,6 +1583,8 @@ func (screen *UnixScreen) SetCell(column int, row int, styledRune StyledRune) in
 }
 
 func (screen *UnixScreen) Clear() {
+	screen.cursorAt = nil
+
 	empty := NewStyledRune(' ', StyleDefault)
 
 	width, height := screen.Size()
@@ -801,6 +1595,28 @@ func (screen *UnixScreen) Clear() {
 	}
 }
 
+// Returns a width x height copy of the cells. Cells that didn't fit in the old
+// cells are blank.
+func resizedCells(cells [][]StyledRune, width int, height int) [][]StyledRune {
+	empty := NewStyledRune(' ', StyleDefault)
+
+	newCells := make([][]StyledRune, height)
+	for rowNumber := range height {
+		newRow := make([]StyledRune, width)
+		copied := 0
+		if rowNumber < len(cells) {
+			copied = copy(newRow, cells[rowNumber])
+		}
+		for column := copied; column < width; column++ {
+			newRow[column] = empty
+		}
+
+		newCells[rowNumber] = newRow
+	}
+
+	return newCells
+}
+
 // A cell is considered hidden if it's preceded by a wide character that spans
 // multiple columns.
 func withoutHiddenRunes(runes []StyledRune) []StyledRune {
@@ -818,10 +1634,24 @@ func withoutHiddenRunes(runes []StyledRune) []StyledRune {
 	return result
 }
 
+type TrailerBackgroundOption int
+
+const (
+	// Clear to the end of the line using the background of the trailing
+	// whitespace, so that a colored background continues to the right edge
+	TrailerBackgroundInherit TrailerBackgroundOption = iota
+
+	// Clear to the end of the line using the terminal's default background.
+	// Trailing whitespace with some other background is written out as is.
+	TrailerBackgroundDefault
+)
+
 // Returns the rendered line, plus how many information carrying cells went into
 // it. The width is used to decide whether or not to clear to EOL at the end of
 // the line.
-func renderLine(row []StyledRune, width int, terminalColorCount ColorCount) (string, int) {
+//
+// Of the options, TrailerBackground and ResetUnderlineColor are used.
+func renderLine(row []StyledRune, width int, terminalColorCount ColorCount, options ScreenOptions) (string, int) {
 	row = withoutHiddenRunes(row)
 
 	// Strip trailing whitespace
@@ -830,7 +1660,7 @@ func renderLine(row []StyledRune, width int, terminalColorCount ColorCount) (str
 	lastSignificantCellIndex := len(row) - 1
 	for ; lastSignificantCellIndex >= 0; lastSignificantCellIndex-- {
 		lastCell := row[lastSignificantCellIndex]
-		if lastCell.Rune != ' ' {
+		if lastCell.Rune != ' ' || lastCell.Combining != "" {
 			break
 		}
 
@@ -846,6 +1676,11 @@ func renderLine(row []StyledRune, width int, terminalColorCount ColorCount) (str
 			whiteSpaceBg = lastCell.Style.fg
 		}
 
+		if options.TrailerBackground == TrailerBackgroundDefault && whiteSpaceBg != ColorDefault {
+			// Keep this one, we won't be clearing using its color
+			break
+		}
+
 		if !trailerBgSet {
 			trailerBg = whiteSpaceBg
 			trailerBgSet = true
@@ -877,17 +1712,18 @@ func renderLine(row []StyledRune, width int, terminalColorCount ColorCount) (str
 		}
 
 		if style != lastStyle {
-			builder.WriteString(style.RenderUpdateFrom(lastStyle, terminalColorCount))
+			builder.WriteString(style.renderUpdateFrom(lastStyle, terminalColorCount, options.ResetUnderlineColor))
 			lastStyle = style
 		}
 
 		builder.WriteRune(runeToWrite)
+		builder.WriteString(cell.Combining)
 	}
 
 	lastStyleMinusHyperlink := lastStyle.WithHyperlink(nil)
 	if lastStyleMinusHyperlink != lastStyle {
 		// Remove the hyperlink attribute
-		builder.WriteString(lastStyleMinusHyperlink.RenderUpdateFrom(lastStyle, terminalColorCount))
+		builder.WriteString(lastStyleMinusHyperlink.renderUpdateFrom(lastStyle, terminalColorCount, options.ResetUnderlineColor))
 		lastStyle = lastStyleMinusHyperlink
 	}
 
@@ -897,7 +1733,7 @@ func renderLine(row []StyledRune, width int, terminalColorCount ColorCount) (str
 		//
 		// Note that we can't do this if we're one the last screen column:
 		// https://github.com/microsoft/terminal/issues/18115#issuecomment-2448054645
-		builder.WriteString(StyleDefault.WithBackground(trailerBg).RenderUpdateFrom(lastStyle, terminalColorCount))
+		builder.WriteString(StyleDefault.WithBackground(trailerBg).renderUpdateFrom(lastStyle, terminalColorCount, options.ResetUnderlineColor))
 		builder.WriteString("\x1b[K")
 	}
 
@@ -905,44 +1741,135 @@ func renderLine(row []StyledRune, width int, terminalColorCount ColorCount) (str
 }
 
 func (screen *UnixScreen) Show() {
+	if !screen.kittyKeyboardEnabled && screen.kittyKeyboardDetected.Load() {
+		// Enabled from here rather than from the main loop that detects it,
+		// so that it doesn't end up in the middle of other output
+		screen.write(kittyKeyboardEnable)
+		screen.kittyKeyboardEnabled = true
+	}
+
 	width, height := screen.Size()
 	screen.showNLines(width, height, true)
 }
 
+func (screen *UnixScreen) RedrawAll() {
+	screen.shownRows = nil
+}
+
 func (screen *UnixScreen) ShowNLines(height int) {
 	width, _ := screen.Size()
 	screen.showNLines(width, height, false)
 }
 
+func (screen *UnixScreen) ShowRows(rows [][]StyledRune) {
+	width, _ := screen.Size()
+	screen.shownRows = nil
+	screen.write(renderRows(rows, width, screen.terminalColorCount, screen.options))
+}
+
+func renderRows(rows [][]StyledRune, width int, terminalColorCount ColorCount, options ScreenOptions) string {
+	var builder strings.Builder
+	for _, row := range rows {
+		rendered, _ := renderLine(row, width, terminalColorCount, options)
+		builder.WriteString(rendered)
+		builder.WriteString("\r\n")
+	}
+
+	return builder.String()
+}
+
+// Should a rendered line of lineLength cells be followed by a line break when
+// showing a screen row of rowWidth cells?
+//
+// Lines as wide as the screen need one as well. The terminal doesn't move the
+// cursor down until it gets more output after such a line, and if that output
+// is an empty line, the empty line just disappears.
+//
+// Can be demonstrated using "moor m/pager.go", scroll right once to make the
+// line numbers go away, then make the window narrower until some line before
+// an empty line is just as wide as the window.
+func needsLineBreakAfter(lineLength int, rowWidth int, isLastLine bool) bool {
+	if isLastLine {
+		// Breaking here would scroll the screen
+		return false
+	}
+
+	// NOTE: This <= should *really* be <= and nothing else, see above
+	return lineLength <= rowWidth
+}
+
 func (screen *UnixScreen) showNLines(width int, height int, clearFirst bool) {
 	var builder strings.Builder
 
+	// Only full screen updates are synchronized, ShowNLines() output goes to
+	// the normal screen
+	synchronized := clearFirst && screen.synchronizedOutput.Load()
+	if synchronized {
+		builder.WriteString(synchronizedOutputBegin)
+	}
+
+	if screen.cursorShown {
+		// Don't show the cursor moving around while we draw
+		builder.WriteString("\x1b[?25l")
+		screen.cursorShown = false
+	}
+
+	var full strings.Builder
 	if clearFirst {
-		// Start in the top left corner:
+		// Start in our top left corner:
 		// https://en.wikipedia.org/wiki/ANSI_escape_code#CSI_(Control_Sequence_Introducer)_sequences
-		builder.WriteString("\x1b[1;1H")
+		full.WriteString(screen.toTerminal(cursorPosition{}).moveCursorTo())
 	}
 
+	// Only the rows that differ from what's already on screen, each one
+	// preceded by a move to its start.
+	//
+	// Rows rather than runs of changed cells, since each rendered row starts
+	// from a known style and ends by clearing to the end of the line. Starting
+	// in the middle of a row would need the style in effect there, and
+	// scrolling changes most rows anyway.
+	var changes strings.Builder
+	canShowChanges := clearFirst && screen.shownRows != nil && screen.shownWidth == width && len(screen.shownRows) == height
+
+	rows := make([]string, height)
 	for row := range height {
-		rendered, lineLength := renderLine(screen.cells[row], width, screen.terminalColorCount)
-		builder.WriteString(rendered)
+		rendered, lineLength := renderLine(screen.cells[row], width, screen.terminalColorCount, screen.options)
+		rows[row] = rendered
+		full.WriteString(rendered)
 
 		wasLastLine := row == (height - 1)
+		if needsLineBreakAfter(lineLength, len(screen.cells[row]), wasLastLine) {
+			full.WriteString("\r\n")
+		}
 
-		// NOTE: This <= should *really* be <= and nothing else. Otherwise, if
-		// one line precisely as long as the terminal window goes before one
-		// empty line, the empty line will never be rendered.
-		//
-		// Can be demonstrated using "moor m/pager.go", scroll right once to
-		// make the line numbers go away, then make the window narrower until
-		// some line before an empty line is just as wide as the window.
-		//
-		// With the wrong comparison here, then the empty line just disappears.
-		if lineLength <= len(screen.cells[row]) && !wasLastLine {
-			builder.WriteString("\r\n")
+		if canShowChanges && rendered != screen.shownRows[row] {
+			changes.WriteString(screen.toTerminal(cursorPosition{row: row}).moveCursorTo())
+			changes.WriteString(rendered)
 		}
 	}
 
+	if canShowChanges && changes.Len() < full.Len() {
+		builder.WriteString(changes.String())
+	} else {
+		builder.WriteString(full.String())
+	}
+
+	screen.shownRows = nil
+	if clearFirst {
+		screen.shownRows = rows
+		screen.shownWidth = width
+	}
+
+	if screen.cursorAt != nil {
+		builder.WriteString(screen.toTerminal(*screen.cursorAt).moveCursorTo())
+		builder.WriteString("\x1b[?25h")
+		screen.cursorShown = true
+	}
+
+	if synchronized {
+		builder.WriteString(synchronizedOutputEnd)
+	}
+
 	// Write out what we have
 	screen.write(builder.String())
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"golang.org/x/term"
 	"gotest.tools/v3/assert"
 )
 
@@ -35,6 +36,27 @@ func TestConsumeEncodedEvent(t *testing.T) {
 	assertEncode(t, "\x1b[<64;127;41M", EventMouse{buttons: MouseWheelUp}, "")
 	assertEncode(t, "\x1b[<65;127;41M", EventMouse{buttons: MouseWheelDown}, "")
 
+	// Horizontal scrolling, by wheel tilt or Shift + wheel
+	assertEncode(t, "\x1b[<66;127;41M", EventMouse{buttons: MouseWheelLeft}, "")
+	assertEncode(t, "\x1b[<67;127;41M", EventMouse{buttons: MouseWheelRight}, "")
+	assertEncode(t, "\x1b[<68;127;41M", EventMouse{buttons: MouseWheelLeft}, "")
+	assertEncode(t, "\x1b[<69;127;41M", EventMouse{buttons: MouseWheelRight}, "")
+
+	// Mouse motion without any buttons pressed, coordinates are one based
+	assertEncode(t, "\x1b[<35;10;5M", EventMouse{buttons: MouseMotion, column: 9, row: 4}, "")
+
+	// Left button press, drag and release
+	assertEncode(t, "\x1b[<0;10;5M", EventMouse{buttons: MouseLeftPress, column: 9, row: 4}, "")
+	assertEncode(t, "\x1b[<32;11;5M", EventMouse{buttons: MouseLeftDrag, column: 10, row: 4}, "")
+	assertEncode(t, "\x1b[<0;11;5m", EventMouse{buttons: MouseLeftRelease, column: 10, row: 4}, "")
+
+	// Legacy X10 mouse events, each byte is the value plus 32
+	assertEncode(t, "\x1b[M`*J", EventMouse{buttons: MouseWheelUp}, "")
+	assertEncode(t, "\x1b[Ma*Jx", EventMouse{buttons: MouseWheelDown}, "x")
+	assertEncode(t, "\x1b[MC*%", EventMouse{buttons: MouseMotion, column: 9, row: 4}, "")
+	assertEncode(t, "\x1b[M *%", EventMouse{buttons: MouseLeftPress, column: 9, row: 4}, "")
+	assertEncode(t, "\x1b[M#*%", EventMouse{buttons: MouseLeftRelease, column: 9, row: 4}, "")
+
 	// This happens when users paste.
 	//
 	// Ref: https://github.com/walles/moor/issues/73
@@ -47,12 +69,63 @@ func TestConsumeEncodedEventWithUnsupportedEscapeCode(t *testing.T) {
 	assert.Equal(t, remainder, "")
 }
 
+func TestConsumeEncodedEventWithInvalidUTF8(t *testing.T) {
+	event, remainder := consumeEncodedEvent("\xffb")
+	assert.Assert(t, event == nil)
+	assert.Equal(t, remainder, "b")
+}
+
+func TestConsumeEncodedEventWithIncompleteX10Mouse(t *testing.T) {
+	event, remainder := consumeEncodedEvent("\x1b[M`*")
+	assert.Assert(t, event == nil)
+	assert.Equal(t, remainder, "")
+}
+
+func TestIsIncompleteX10MouseEvent(t *testing.T) {
+	assert.Assert(t, isIncompleteX10MouseEvent("\x1b[M"))
+	assert.Assert(t, isIncompleteX10MouseEvent("\x1b[M`*"))
+	assert.Assert(t, !isIncompleteX10MouseEvent("\x1b[M`*J"))
+	assert.Assert(t, !isIncompleteX10MouseEvent("\x1b[A"))
+	assert.Assert(t, !isIncompleteX10MouseEvent("\x1b"))
+}
+
 func TestConsumeEncodedEventWithNoInput(t *testing.T) {
 	event, remainder := consumeEncodedEvent("")
 	assert.Assert(t, event == nil)
 	assert.Equal(t, remainder, "")
 }
 
+func TestConsumePaste(t *testing.T) {
+	event, remainder, waitForMore := consumePaste("\x1b[200~q\nj\x1b[201~x")
+	assert.Assert(t, !waitForMore)
+	assert.Equal(t, *event, Event(EventPaste{text: "q\nj"}))
+	assert.Equal(t, remainder, "x")
+
+	// Not a paste
+	event, remainder, waitForMore = consumePaste("q")
+	assert.Assert(t, !waitForMore)
+	assert.Assert(t, event == nil)
+	assert.Equal(t, remainder, "q")
+
+	// Paste not done yet
+	event, remainder, waitForMore = consumePaste("\x1b[200~q")
+	assert.Assert(t, waitForMore)
+	assert.Assert(t, event == nil)
+	assert.Equal(t, remainder, "\x1b[200~q")
+
+	// Start marker not done yet
+	event, remainder, waitForMore = consumePaste("\x1b[20")
+	assert.Assert(t, waitForMore)
+	assert.Assert(t, event == nil)
+	assert.Equal(t, remainder, "\x1b[20")
+
+	// The Escape key, not a paste
+	event, remainder, waitForMore = consumePaste("\x1b")
+	assert.Assert(t, !waitForMore)
+	assert.Assert(t, event == nil)
+	assert.Equal(t, remainder, "\x1b")
+}
+
 func TestRenderLine(t *testing.T) {
 	row := []StyledRune{
 		{
@@ -65,22 +138,21 @@ func TestRenderLine(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 2)
 	reset := "\x1b[m"
 	reversed := "\x1b[7m"
-	notReversed := "\x1b[27m"
-	dim := "\x1b[2m"
+	resetToDim := "\x1b[0;2m" // Shorter than "2;27", dim and not reversed
 	clearToEol := "\x1b[K"
 	assert.Equal(t,
 		strings.ReplaceAll(rendered, "\x1b", "ESC"),
-		strings.ReplaceAll(reset+reversed+"<"+dim+notReversed+"f"+reset+clearToEol, "\x1b", "ESC"))
+		strings.ReplaceAll(reset+reversed+"<"+resetToDim+"f"+reset+clearToEol, "\x1b", "ESC"))
 }
 
 func TestRenderLineEmpty(t *testing.T) {
 	row := []StyledRune{}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 0)
 
 	// All lines are expected to stand on their own, so we always need to clear
@@ -96,7 +168,7 @@ func TestRenderLineLastReversed(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 1)
 	reset := "\x1b[m"
 	reversed := "\x1b[7m"
@@ -114,7 +186,7 @@ func TestRenderLineLastNonSpace(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 1)
 	reset := "\x1b[m"
 	clearToEol := "\x1b[K"
@@ -135,7 +207,7 @@ func TestRenderLineLastReversedPlusTrailingSpace(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 1)
 	reset := "\x1b[m"
 	reversed := "\x1b[7m"
@@ -157,7 +229,7 @@ func TestRenderLineOnlyTrailingSpaces(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 0)
 
 	// All lines are expected to stand on their own, so we always need to clear
@@ -173,7 +245,7 @@ func TestRenderLineLastReversedSpaces(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 1)
 	reset := "\x1b[m"
 	reversed := "\x1b[7m"
@@ -183,6 +255,34 @@ func TestRenderLineLastReversedSpaces(t *testing.T) {
 		strings.ReplaceAll(reset+reversed+" "+reset+clearToEol, "\x1b", "ESC"))
 }
 
+func TestRenderLineTrailerBackground(t *testing.T) {
+	row := []StyledRune{
+		{Rune: 'X'},
+		{Rune: ' ', Style: StyleDefault.WithBackground(NewColor16(4))},
+	}
+	reset := "\x1b[m"
+	blueBg := "\x1b[44m"
+	clearToEol := "\x1b[K"
+
+	// The blue background goes all the way to the right edge
+	options := DefaultScreenOptions()
+	options.TrailerBackground = TrailerBackgroundInherit
+	rendered, count := renderLine(row, 33, ColorCount16, options)
+	assert.Equal(t, count, 1)
+	assert.Equal(t,
+		strings.ReplaceAll(rendered, "\x1b", "ESC"),
+		strings.ReplaceAll(reset+"X"+blueBg+clearToEol, "\x1b", "ESC"))
+
+	// The blue space is written, then the rest is cleared with the default
+	// background
+	options.TrailerBackground = TrailerBackgroundDefault
+	rendered, count = renderLine(row, 33, ColorCount16, options)
+	assert.Equal(t, count, 2)
+	assert.Equal(t,
+		strings.ReplaceAll(rendered, "\x1b", "ESC"),
+		strings.ReplaceAll(reset+"X"+blueBg+" "+reset+clearToEol, "\x1b", "ESC"))
+}
+
 func TestRenderLineNonPrintable(t *testing.T) {
 	row := []StyledRune{
 		{
@@ -190,16 +290,25 @@ func TestRenderLineNonPrintable(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 1)
 	reset := "\x1b[m"
-	white := "\x1b[37m"
-	redBg := "\x1b[41m"
-	bold := "\x1b[1m"
+	whiteOnRedBold := "\x1b[37;41;1m"
 	clearToEol := "\x1b[K"
 	assert.Equal(t,
 		strings.ReplaceAll(rendered, "\x1b", "ESC"),
-		strings.ReplaceAll(reset+white+redBg+bold+"?"+reset+clearToEol, "\x1b", "ESC"))
+		strings.ReplaceAll(reset+whiteOnRedBold+"?"+reset+clearToEol, "\x1b", "ESC"))
+}
+
+func TestRenderLineCombining(t *testing.T) {
+	row := []StyledRune{
+		{Rune: 'e', Combining: "\u0301"},
+		{Rune: 'x'},
+	}
+
+	rendered, count := renderLine(row, 2, ColorCount16, DefaultScreenOptions())
+	assert.Equal(t, count, 2)
+	assert.Equal(t, rendered, "\x1b[me\u0301x")
 }
 
 func TestRenderHyperlinkAtEndOfLine(t *testing.T) {
@@ -211,7 +320,7 @@ func TestRenderHyperlinkAtEndOfLine(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 1)
 
 	assert.Equal(t,
@@ -236,7 +345,7 @@ func TestMultiCharHyperlink(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 33, ColorCount16)
+	rendered, count := renderLine(row, 33, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 3)
 
 	assert.Equal(t,
@@ -254,14 +363,14 @@ func TestRenderLineFullWidth(t *testing.T) {
 		},
 	}
 
-	rendered, count := renderLine(row, 2, ColorCount16)
+	rendered, count := renderLine(row, 2, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 2)
 
 	assert.Equal(t,
 		strings.ReplaceAll(rendered, "\x1b", "ESC"),
 		"ESC[mxy", "Expected no clear-to-EOL at the end of a full-width line")
 
-	rendered, count = renderLine(row, 3, ColorCount16)
+	rendered, count = renderLine(row, 3, ColorCount16, DefaultScreenOptions())
 	assert.Equal(t, count, 2)
 
 	assert.Equal(t,
@@ -384,3 +493,257 @@ func TestInterruptableReader_justRead(t *testing.T) {
 	assert.Equal(t, buffer[0], byte(42))
 	assert.Equal(t, len(buffer), 7)
 }
+
+func TestRenderRows(t *testing.T) {
+	rows := [][]StyledRune{
+		{NewStyledRune('a', StyleDefault.WithAttr(AttrBold))},
+		{},
+		{NewStyledRune('b', StyleDefault), NewStyledRune('c', StyleDefault)},
+	}
+
+	rendered := renderRows(rows, 2, ColorCount16, DefaultScreenOptions())
+	assert.Equal(t, strings.ReplaceAll(rendered, "\x1b", "ESC"),
+		"ESC[mESC[1maESC[mESC[K\r\n"+
+			"ESC[mESC[K\r\n"+
+			"ESC[mbc\r\n")
+}
+
+// An empty line following a line exactly as wide as the screen must still be
+// rendered. Ref: the comment in needsLineBreakAfter().
+func TestShowFullWidthLineBeforeEmptyLine(t *testing.T) {
+	ttyOut, err := os.CreateTemp(t.TempDir(), "ttyOut")
+	assert.NilError(t, err)
+	defer func() {
+		assert.NilError(t, ttyOut.Close())
+	}()
+
+	screen := UnixScreen{
+		ttyOut:             ttyOut,
+		terminalColorCount: ColorCount16,
+		cells: [][]StyledRune{
+			{NewStyledRune('a', StyleDefault), NewStyledRune('b', StyleDefault)},
+			{NewStyledRune(' ', StyleDefault), NewStyledRune(' ', StyleDefault)},
+			{NewStyledRune('c', StyleDefault), NewStyledRune(' ', StyleDefault)},
+		},
+	}
+	screen.showNLines(2, 3, true)
+
+	written, err := os.ReadFile(ttyOut.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, strings.ReplaceAll(string(written), "\x1b", "ESC"),
+		"ESC[1;1H"+
+			"ESC[mab\r\n"+
+			"ESC[mESC[K\r\n"+
+			"ESC[mcESC[K")
+
+	assert.Assert(t, needsLineBreakAfter(2, 2, false), "Full width lines need a line break")
+	assert.Assert(t, needsLineBreakAfter(0, 2, false))
+	assert.Assert(t, !needsLineBreakAfter(2, 2, true), "Last line must not scroll the screen")
+}
+
+// The cursor must stay where ShowCursorAt() put it when redrawing, until the
+// screen is cleared
+func TestShowCursorAt(t *testing.T) {
+	ttyOut, err := os.CreateTemp(t.TempDir(), "ttyOut")
+	assert.NilError(t, err)
+	defer func() {
+		assert.NilError(t, ttyOut.Close())
+	}()
+
+	screen := UnixScreen{
+		ttyOut:                   ttyOut,
+		terminalColorCount:       ColorCount16,
+		widthAccessFromSizeOnly:  2,
+		heightAccessFromSizeOnly: 2,
+		cells: [][]StyledRune{
+			{NewStyledRune('a', StyleDefault), NewStyledRune('b', StyleDefault)},
+			{NewStyledRune('c', StyleDefault), NewStyledRune(' ', StyleDefault)},
+		},
+	}
+	screen.ShowCursorAt(1, 1)
+	screen.showNLines(2, 2, true)
+
+	screen.Clear()
+	screen.showNLines(2, 2, true)
+
+	written, err := os.ReadFile(ttyOut.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, strings.ReplaceAll(string(written), "\x1b", "ESC"),
+		// ShowCursorAt(), one based
+		"ESC[2;2HESC[?25h"+
+
+			// Hide the cursor while drawing, then put it back
+			"ESC[?25lESC[1;1HESC[mab\r\nESC[mcESC[K"+
+			"ESC[2;2HESC[?25h"+
+
+			// After Clear() the cursor stays hidden
+			"ESC[?25lESC[1;1HESC[mESC[K\r\nESC[mESC[K")
+}
+
+func alternateScreenModeOutput(t *testing.T, alternateScroll bool, enable bool) string {
+	ttyOut, err := os.CreateTemp(t.TempDir(), "ttyOut")
+	assert.NilError(t, err)
+	defer func() {
+		assert.NilError(t, ttyOut.Close())
+	}()
+
+	screen := UnixScreen{ttyOut: ttyOut, options: ScreenOptions{AlternateScroll: alternateScroll}}
+	screen.setAlternateScreenMode(enable)
+
+	written, err := os.ReadFile(ttyOut.Name())
+	assert.NilError(t, err)
+	return strings.ReplaceAll(string(written), "\x1b", "ESC")
+}
+
+func TestAlternateScroll(t *testing.T) {
+	assert.Equal(t, alternateScreenModeOutput(t, true, true), "ESC[?1049hESC[?1007hESC[?2004h")
+	assert.Equal(t, alternateScreenModeOutput(t, true, false), "ESC[?2004lESC[?1007lESC[?1049l")
+
+	assert.Equal(t, alternateScreenModeOutput(t, false, true), "ESC[?1049hESC[?2004h")
+	assert.Equal(t, alternateScreenModeOutput(t, false, false), "ESC[?2004lESC[?1049l")
+}
+
+// A row with a new color for every cell, like a gradient
+func createColorfulRow(width int) []StyledRune {
+	row := make([]StyledRune, 0, width)
+	for i := range width {
+		color := NewColor24Bit(uint8(i*3), uint8(255-i*2), uint8(i*7))
+		row = append(row, NewStyledRune('x', StyleDefault.WithForeground(color)))
+	}
+	return row
+}
+
+func benchmarkRenderColorfulLine(b *testing.B, terminalColorCount ColorCount) {
+	row := createColorfulRow(80)
+
+	bytesCount := 0
+	b.ResetTimer()
+	for range b.N {
+		rendered, _ := renderLine(row, 100, terminalColorCount, DefaultScreenOptions())
+		bytesCount = len(rendered)
+	}
+
+	// Over slow connections, this matters more than the speed
+	b.ReportMetric(float64(bytesCount), "bytes/line")
+}
+
+func BenchmarkRenderColorfulLine24Bit(b *testing.B) {
+	benchmarkRenderColorfulLine(b, ColorCount24bit)
+}
+
+// Same as BenchmarkRenderColorfulLine24Bit, but with --colors=256
+func BenchmarkRenderColorfulLine256(b *testing.B) {
+	benchmarkRenderColorfulLine(b, ColorCount256)
+}
+
+// Only rows that changed since the last time should be written
+func TestShowOnlyChanges(t *testing.T) {
+	screen, _, writtenSinceLastTime := newTestUnixScreen(t)
+	screen.widthAccessFromSizeOnly = 3
+	screen.heightAccessFromSizeOnly = 3
+	screen.cells = [][]StyledRune{
+		{NewStyledRune('a', StyleDefault)},
+		{NewStyledRune('b', StyleDefault)},
+		{NewStyledRune('c', StyleDefault)},
+	}
+
+	screen.showNLines(3, 3, true)
+	assert.Equal(t, writtenSinceLastTime(), "ESC[1;1HESC[maESC[K\r\nESC[mbESC[K\r\nESC[mcESC[K")
+
+	screen.cells[1][0] = NewStyledRune('x', StyleDefault)
+	screen.showNLines(3, 3, true)
+	assert.Equal(t, writtenSinceLastTime(), "ESC[2;1HESC[mxESC[K")
+
+	// Nothing changed, nothing written
+	screen.showNLines(3, 3, true)
+	assert.Equal(t, writtenSinceLastTime(), "")
+
+	// After output we don't keep track of, everything gets written again
+	screen.ShowRows([][]StyledRune{{NewStyledRune('y', StyleDefault)}})
+	writtenSinceLastTime()
+	screen.showNLines(3, 3, true)
+	assert.Equal(t, writtenSinceLastTime(), "ESC[1;1HESC[maESC[K\r\nESC[mxESC[K\r\nESC[mcESC[K")
+
+	// Asking for a full redraw, like on CTRL-l
+	screen.RedrawAll()
+	screen.showNLines(3, 3, true)
+	assert.Equal(t, writtenSinceLastTime(), "ESC[1;1HESC[maESC[K\r\nESC[mxESC[K\r\nESC[mcESC[K")
+}
+
+func TestResizedCells(t *testing.T) {
+	a := NewStyledRune('a', StyleDefault)
+	b := NewStyledRune('b', StyleDefault)
+	c := NewStyledRune('c', StyleDefault)
+	d := NewStyledRune('d', StyleDefault)
+	empty := NewStyledRune(' ', StyleDefault)
+
+	cells := [][]StyledRune{{a, b}, {c, d}}
+
+	cells = resizedCells(cells, 1, 1)
+	assert.DeepEqual(t, cells, [][]StyledRune{{a}})
+
+	// What didn't fit when smaller is gone, the rest is blank
+	cells = resizedCells(cells, 3, 2)
+	assert.DeepEqual(t, cells, [][]StyledRune{{a, empty, empty}, {empty, empty, empty}})
+}
+
+func TestConsumeTerminalColorResponses(t *testing.T) {
+	now := time.Now()
+	screen := UnixScreen{terminalColorsQuery: &now}
+
+	rest, waitForMore := screen.consumeTerminalResponses("\x1b]11;rgb:0000/0000/0000\x07\x1b]10;rgb:ffff/8080/0000\x1b\\q")
+	assert.Assert(t, !waitForMore)
+	assert.Equal(t, rest, "q")
+	assert.Equal(t, *screen.TerminalBackground(), NewColor24Bit(0x00, 0x00, 0x00))
+	assert.Equal(t, *screen.TerminalForeground(), NewColor24Bit(0xff, 0x80, 0x00))
+}
+
+func TestParseTerminalColorResponse(t *testing.T) {
+	color, valid := parseTerminalColorResponse(10, []byte("\x1b]10;rgb:1212/3434/5656\x07"))
+	assert.Assert(t, valid)
+	assert.Equal(t, *color, NewColor24Bit(0x12, 0x34, 0x56))
+
+	// Background response when expecting foreground
+	_, valid = parseTerminalColorResponse(10, []byte("\x1b]11;rgb:1212/3434/5656\x07"))
+	assert.Assert(t, !valid)
+}
+
+// Creates a screen reading from a pipe and writing to a temporary file.
+//
+// Returns the screen, the writing end of its input pipe, and a function
+// returning what the screen has written since that function was last called.
+func newTestUnixScreen(t *testing.T) (*UnixScreen, *os.File, func() string) {
+	ttyIn, ttyInWriter, err := os.Pipe()
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, ttyInWriter.Close())
+	})
+
+	ttyOut, err := os.CreateTemp(t.TempDir(), "ttyOut")
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, ttyOut.Close())
+	})
+
+	screen := &UnixScreen{
+		options:            DefaultScreenOptions(),
+		events:             make(chan Event, 80),
+		sigwinch:           make(chan int, 1),
+		cursorPositions:    make(chan cursorPosition, 1),
+		ttyIn:              ttyIn,
+		ttyOut:             ttyOut,
+		oldTerminalState:   &term.State{}, // Restoring this on a pipe fails, which is fine
+		terminalColorCount: ColorCount16,
+	}
+
+	written := 0
+	writtenSinceLastTime := func() string {
+		bytes, err := os.ReadFile(ttyOut.Name())
+		assert.NilError(t, err)
+		result := strings.ReplaceAll(string(bytes[written:]), "\x1b", "ESC")
+		written = len(bytes)
+		return result
+	}
+
+	return screen, ttyInWriter, writtenSinceLastTime
+}
@@ -13,6 +13,10 @@ import (
 type StyledRune struct {
 	Rune  rune
 	Style Style
+
+	// Combining marks to draw on top of Rune, like the accent in an "e" followed
+	// by U+0301. These don't affect the width.
+	Combining string
 }
 
 func NewStyledRune(char rune, style Style) StyledRune {
@@ -23,7 +27,7 @@ func NewStyledRune(char rune, style Style) StyledRune {
 }
 
 func (styledRune StyledRune) String() string {
-	return fmt.Sprint("rune='", string(styledRune.Rune), "' ", styledRune.Style)
+	return fmt.Sprint("rune='", string(styledRune.Rune)+styledRune.Combining, "' ", styledRune.Style)
 }
 
 // How many screen cells will this rune cover? Most runes cover one, but some
@@ -32,6 +36,32 @@ func (styledRune StyledRune) Width() int {
 	return uniseg.StringWidth(string(styledRune.Rune))
 }
 
+type WideRuneAtEdgeOption int
+
+const (
+	// A space in the style of the wide rune
+	WideRuneAtEdgeSpace WideRuneAtEdgeOption = iota
+
+	// A space in the default style, as if nothing was written there
+	WideRuneAtEdgeBlank
+
+	// A '…' in the style of the wide rune, hinting that something didn't fit
+	WideRuneAtEdgeHint
+)
+
+// Returns the single-cell replacement for a wide rune that got cut off by the
+// right edge of the screen
+func (option WideRuneAtEdgeOption) Replacement(styledRune StyledRune) StyledRune {
+	switch option {
+	case WideRuneAtEdgeBlank:
+		return NewStyledRune(' ', StyleDefault)
+	case WideRuneAtEdgeHint:
+		return NewStyledRune('…', styledRune.Style)
+	default:
+		return NewStyledRune(' ', styledRune.Style)
+	}
+}
+
 // Returns a slice of cells with trailing whitespace cells removed
 func TrimSpaceRight(runes []StyledRune) []StyledRune {
 	for i := len(runes) - 1; i >= 0; i-- {
@@ -62,6 +92,17 @@ func TrimSpaceLeft(runes []StyledRune) []StyledRune {
 	return []StyledRune{}
 }
 
+// Combining marks, like U+0301 COMBINING ACUTE ACCENT, modify the preceding
+// character rather than taking up a screen cell of their own.
+func IsCombining(char rune) bool {
+	if char < 0x300 {
+		// Shortcut for the common case, U+0300 is the first combining mark
+		return false
+	}
+
+	return unicode.In(char, unicode.Mn, unicode.Me)
+}
+
 func Printable(char rune) bool {
 	if unicode.IsPrint(char) {
 		return true
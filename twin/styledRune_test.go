@@ -41,3 +41,25 @@ func TestRuneWidth(t *testing.T) {
 	assert.Equal(t, NewStyledRune('x', Style{}).Width(), 1)
 	assert.Equal(t, NewStyledRune('午', Style{}).Width(), 2)
 }
+
+func TestSetCellWideRuneAtLastColumn(t *testing.T) {
+	style := StyleDefault.WithForeground(NewColor16(2))
+	wide := NewStyledRune('午', style)
+
+	for option, expected := range map[WideRuneAtEdgeOption]StyledRune{
+		WideRuneAtEdgeSpace: NewStyledRune(' ', style),
+		WideRuneAtEdgeBlank: NewStyledRune(' ', StyleDefault),
+		WideRuneAtEdgeHint:  NewStyledRune('…', style),
+	} {
+		screen := UnixScreen{options: ScreenOptions{WideRuneAtEdge: option}}
+		screen.widthAccessFromSizeOnly = 3
+		screen.heightAccessFromSizeOnly = 1
+		screen.cells = [][]StyledRune{make([]StyledRune, 3)}
+		assert.Equal(t, screen.SetCell(2, 0, wide), 2)
+		assert.Equal(t, screen.cells[0][2], expected, "Option %d", option)
+
+		// Wide runes that fit should not be affected
+		assert.Equal(t, screen.SetCell(0, 0, wide), 2)
+		assert.Equal(t, screen.cells[0][0], wide, "Option %d", option)
+	}
+}
@@ -159,6 +159,33 @@ func (style Style) Background() Color {
 	return style.bg
 }
 
+// Inverted returns this style with foreground and background swapped.
+//
+// The default colors are what ColorDefault looks like on screen. If either of
+// them is unknown (ColorDefault), the terminal is asked to do the swapping
+// using AttrReverse instead.
+func (style Style) Inverted(defaultFg Color, defaultBg Color) Style {
+	if style.attrs.has(AttrReverse) {
+		// Already inverted, un-invert
+		return style.WithoutAttr(AttrReverse)
+	}
+
+	if defaultFg == ColorDefault || defaultBg == ColorDefault {
+		return style.WithAttr(AttrReverse)
+	}
+
+	fg := style.fg
+	if fg == ColorDefault {
+		fg = defaultFg
+	}
+	bg := style.bg
+	if bg == ColorDefault {
+		bg = defaultBg
+	}
+
+	return style.WithForeground(bg).WithBackground(fg)
+}
+
 func (style Style) WithoutAttr(attr AttrMask) Style {
 	return Style{
 		fg:             style.fg,
@@ -206,8 +233,18 @@ func (style Style) WithUnderlineColor(color Color) Style {
 // Emit an ANSI escape sequence switching from a previous style to the current
 // one.
 //
+// Only what differs is updated, unless resetting everything and then setting
+// the new style is shorter.
+//
 //revive:disable-next-line:receiver-naming
 func (style Style) RenderUpdateFrom(previous Style, terminalColorCount ColorCount) string {
+	return style.renderUpdateFrom(previous, terminalColorCount, false)
+}
+
+// Like RenderUpdateFrom(), but with ScreenOptions.ResetUnderlineColor.
+//
+//revive:disable-next-line:receiver-naming
+func (style Style) renderUpdateFrom(previous Style, terminalColorCount ColorCount, resetUnderlineColor bool) string {
 	if style == previous {
 		// Shortcut for the common case
 		return ""
@@ -219,16 +256,63 @@ func (style Style) RenderUpdateFrom(previous Style, terminalColorCount ColorCoun
 	}
 
 	var builder strings.Builder
+
+	params := style.sgrParamsFrom(previous, terminalColorCount, resetUnderlineColor)
+	fromScratch := append([]string{"0"}, style.sgrParamsFrom(StyleDefault, terminalColorCount, resetUnderlineColor)...)
+	if len(strings.Join(fromScratch, ";")) < len(strings.Join(params, ";")) {
+		params = fromScratch
+	}
+	if len(params) > 0 {
+		builder.WriteString("\x1b[")
+		builder.WriteString(strings.Join(params, ";"))
+		builder.WriteString("m")
+	}
+
+	if style.hyperlinkURL != previous.hyperlinkURL {
+		newURL := ""
+		if style.hyperlinkURL != nil {
+			newURL = *style.hyperlinkURL
+		}
+
+		previousURL := ""
+		if previous.hyperlinkURL != nil {
+			previousURL = *previous.hyperlinkURL
+		}
+
+		if newURL != previousURL {
+			builder.WriteString("\x1b]8;;")
+			builder.WriteString(newURL)
+			builder.WriteString("\x1b\\")
+		}
+	}
+
+	return builder.String()
+}
+
+// The SGR parameters for switching from a previous style to the current one,
+// toggling only what changed. Hyperlinks are not SGR, and not included.
+//
+// Ref: https://en.wikipedia.org/wiki/ANSI_escape_code#SGR_(Select_Graphic_Rendition)_parameters
+//
+//revive:disable-next-line:receiver-naming
+func (style Style) sgrParamsFrom(previous Style, terminalColorCount ColorCount, resetUnderlineColor bool) []string {
+	params := []string{}
+	addColor := func(color Color, cType colorType) {
+		if colorParams := color.sgrParams(cType, terminalColorCount); colorParams != "" {
+			params = append(params, colorParams)
+		}
+	}
+
 	if style.fg != previous.fg {
-		builder.WriteString(style.fg.ansiString(colorTypeForeground, terminalColorCount))
+		addColor(style.fg, colorTypeForeground)
 	}
 
 	if style.bg != previous.bg {
-		builder.WriteString(style.bg.ansiString(colorTypeBackground, terminalColorCount))
+		addColor(style.bg, colorTypeBackground)
 	}
 
 	if style.underlineColor != previous.underlineColor {
-		builder.WriteString(style.underlineColor.ansiString(colorTypeUnderline, terminalColorCount))
+		addColor(style.underlineColor, colorTypeUnderline)
 	}
 
 	// Handle AttrDim / AttrBold changes
@@ -236,78 +320,65 @@ func (style Style) RenderUpdateFrom(previous Style, terminalColorCount ColorCoun
 	currentBoldDim := style.attrs & (AttrBold | AttrDim)
 	if currentBoldDim != previousBoldDim {
 		if previousBoldDim != 0 {
-			builder.WriteString("\x1b[22m") // Reset to neither bold nor dim
+			params = append(params, "22") // Reset to neither bold nor dim
 		}
 		if style.attrs.has(AttrBold) {
-			builder.WriteString("\x1b[1m")
+			params = append(params, "1")
 		}
 		if style.attrs.has(AttrDim) {
-			builder.WriteString("\x1b[2m")
+			params = append(params, "2")
 		}
 	}
 
 	// Handle AttrBlink changes
 	if style.attrs.has(AttrBlink) != previous.attrs.has(AttrBlink) {
 		if style.attrs.has(AttrBlink) {
-			builder.WriteString("\x1b[5m")
+			params = append(params, "5")
 		} else {
-			builder.WriteString("\x1b[25m")
+			params = append(params, "25")
 		}
 	}
 
 	// Handle AttrReverse changes
 	if style.attrs.has(AttrReverse) != previous.attrs.has(AttrReverse) {
 		if style.attrs.has(AttrReverse) {
-			builder.WriteString("\x1b[7m")
+			params = append(params, "7")
 		} else {
-			builder.WriteString("\x1b[27m")
+			params = append(params, "27")
 		}
 	}
 
 	// Handle AttrUnderline changes
 	if style.attrs.has(AttrUnderline) != previous.attrs.has(AttrUnderline) {
 		if style.attrs.has(AttrUnderline) {
-			builder.WriteString("\x1b[4m")
+			params = append(params, "4")
+
+			alreadyReset := style.underlineColor != previous.underlineColor
+			if resetUnderlineColor && style.underlineColor == ColorDefault && !alreadyReset {
+				params = append(params, "59")
+			}
 		} else {
-			builder.WriteString("\x1b[24m")
+			params = append(params, "24")
 		}
 	}
 
 	// Handle AttrItalic changes
 	if style.attrs.has(AttrItalic) != previous.attrs.has(AttrItalic) {
 		if style.attrs.has(AttrItalic) {
-			builder.WriteString("\x1b[3m")
+			params = append(params, "3")
 		} else {
-			builder.WriteString("\x1b[23m")
+			params = append(params, "23")
 		}
 	}
 
 	// Handle AttrStrikeThrough changes
 	if style.attrs.has(AttrStrikeThrough) != previous.attrs.has(AttrStrikeThrough) {
 		if style.attrs.has(AttrStrikeThrough) {
-			builder.WriteString("\x1b[9m")
+			params = append(params, "9")
 		} else {
-			builder.WriteString("\x1b[29m")
+			params = append(params, "29")
 		}
 	}
 
-	if style.hyperlinkURL != previous.hyperlinkURL {
-		newURL := ""
-		if style.hyperlinkURL != nil {
-			newURL = *style.hyperlinkURL
-		}
-
-		previousURL := ""
-		if previous.hyperlinkURL != nil {
-			previousURL = *previous.hyperlinkURL
-		}
-
-		if newURL != previousURL {
-			builder.WriteString("\x1b]8;;")
-			builder.WriteString(newURL)
-			builder.WriteString("\x1b\\")
-		}
-	}
-
-	return builder.String()
+	return params
 }
@@ -48,3 +48,72 @@ func TestBoldNoLinkToBoldLink(t *testing.T) {
 	output := boldWithLink.RenderUpdateFrom(boldNoLink, ColorCount16)
 	assert.Equal(t, strings.ReplaceAll(output, "\x1b", "ESC"), "ESC]8;;"+url+"ESC\\")
 }
+
+func TestUnderlineColorReset(t *testing.T) {
+	underlined := StyleDefault.WithAttr(AttrUnderline)
+	output := underlined.RenderUpdateFrom(StyleDefault, ColorCount256)
+	assert.Equal(t, strings.ReplaceAll(output, "\x1b", "ESC"), "ESC[4m")
+
+	output = underlined.renderUpdateFrom(StyleDefault, ColorCount256, true)
+	assert.Equal(t, strings.ReplaceAll(output, "\x1b", "ESC"), "ESC[4;59m")
+
+	// Colored underline, no reset needed
+	colored := underlined.WithUnderlineColor(NewColor256(1))
+	output = colored.renderUpdateFrom(StyleDefault, ColorCount256, true)
+	assert.Equal(t, strings.ReplaceAll(output, "\x1b", "ESC"), "ESC[58;5;1;4m")
+
+	// Colored underline to uncolored, 59 should be emitted only once
+	output = underlined.renderUpdateFrom(colored.WithoutAttr(AttrUnderline), ColorCount256, true)
+	assert.Equal(t, strings.ReplaceAll(output, "\x1b", "ESC"), "ESC[59;4m")
+}
+
+func testRenderUpdate(t *testing.T, from Style, to Style, expected string) {
+	t.Helper()
+	output := to.RenderUpdateFrom(from, ColorCount256)
+	assert.Equal(t, strings.ReplaceAll(output, "\x1b", "ESC"), expected)
+}
+
+func TestRenderUpdateFromIsMinimal(t *testing.T) {
+	red := NewColor16(1)
+	bold := StyleDefault.WithAttr(AttrBold)
+
+	// All changes go into one sequence
+	testRenderUpdate(t, StyleDefault, bold.WithAttr(AttrUnderline).WithForeground(red), "ESC[31;1;4m")
+
+	// Only what changed is updated
+	testRenderUpdate(t, bold, bold.WithForeground(red), "ESC[31m")
+	testRenderUpdate(t, bold.WithForeground(red), bold, "ESC[39m")
+	testRenderUpdate(t, bold.WithAttr(AttrItalic), bold, "ESC[23m")
+
+	// Bold to dim requires resetting both first
+	testRenderUpdate(t, bold.WithForeground(red), StyleDefault.WithAttr(AttrDim).WithForeground(red), "ESC[22;2m")
+	testRenderUpdate(t, bold, StyleDefault.WithAttr(AttrDim), "ESC[0;2m")
+
+	// Resetting is shorter than turning off three attributes one by one
+	testRenderUpdate(t,
+		bold.WithAttr(AttrItalic).WithAttr(AttrUnderline).WithForeground(red),
+		StyleDefault.WithForeground(red),
+		"ESC[0;31m")
+
+	// Switching to the default style is always a plain reset
+	testRenderUpdate(t, bold.WithForeground(red), StyleDefault, "ESC[m")
+}
+
+func TestInverted(t *testing.T) {
+	red := NewColor16(1)
+	white := NewColor16(7)
+	black := NewColor16(0)
+
+	// Explicit colors are swapped
+	style := StyleDefault.WithForeground(red).WithBackground(black)
+	assert.Equal(t, style.Inverted(white, black), StyleDefault.WithForeground(black).WithBackground(red))
+
+	// Default colors are replaced by the terminal colors before swapping
+	assert.Equal(t, StyleDefault.Inverted(white, black), StyleDefault.WithForeground(black).WithBackground(white))
+
+	// Unknown terminal colors, let the terminal do the swapping
+	assert.Equal(t, style.Inverted(ColorDefault, black), style.WithAttr(AttrReverse))
+
+	// Reversed text becomes un-reversed
+	assert.Equal(t, style.WithAttr(AttrReverse).Inverted(white, black), style)
+}
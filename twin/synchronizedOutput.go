@@ -0,0 +1,20 @@
+package twin
+
+import "regexp"
+
+// Request the state of mode 2026 (DECRQM)
+const synchronizedOutputQuery = "\x1b[?2026$p"
+
+const synchronizedOutputBegin = "\x1b[?2026h"
+const synchronizedOutputEnd = "\x1b[?2026l"
+
+// Example response: "\x1b[?2026;2$y"
+//
+// The number after the semicolon is 1 if the mode is set, 2 if it is reset, 0
+// if the terminal doesn't know about it and 4 if it can't be changed.
+var synchronizedOutputResponseRegex = regexp.MustCompile(`^\x1b\[\?2026;([0-9])\$y`)
+
+// Does the response to synchronizedOutputQuery say that it's supported?
+func synchronizedOutputSupported(setting string) bool {
+	return setting == "1" || setting == "2"
+}
@@ -0,0 +1,39 @@
+package twin
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConsumeSynchronizedOutputResponse(t *testing.T) {
+	screen := UnixScreen{options: DefaultScreenOptions()}
+
+	rest, waitForMore := screen.consumeTerminalResponses("\x1b[?2026;")
+	assert.Assert(t, waitForMore)
+
+	rest, waitForMore = screen.consumeTerminalResponses(rest + "2$yq")
+	assert.Assert(t, !waitForMore)
+	assert.Equal(t, rest, "q")
+	assert.Assert(t, screen.synchronizedOutput.Load())
+
+	// Not recognized by the terminal
+	_, _ = screen.consumeTerminalResponses("\x1b[?2026;0$y")
+	assert.Assert(t, !screen.synchronizedOutput.Load())
+}
+
+func TestSynchronizedOutput(t *testing.T) {
+	screen, _, writtenSinceLastTime := newTestUnixScreen(t)
+	screen.widthAccessFromSizeOnly = 1
+	screen.heightAccessFromSizeOnly = 1
+	screen.cells = [][]StyledRune{{NewStyledRune('a', StyleDefault)}}
+	screen.synchronizedOutput.Store(true)
+	screen.showNLines(1, 1, true)
+
+	// Not synchronized when not redrawing the whole screen
+	screen.showNLines(1, 1, false)
+
+	assert.Equal(t, writtenSinceLastTime(),
+		"ESC[?2026hESC[1;1HESC[maESC[?2026l"+
+			"ESC[ma")
+}
@@ -0,0 +1,139 @@
+package twin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Palette colors reported by the terminal. Indices not in here are assumed to
+// have their standard color values.
+var terminalPalette = map[uint8]Color{}
+var terminalPaletteLock sync.Mutex
+
+// Which palette indices will downsampling to this many colors pick from?
+func paletteScanRange(terminalColorCount ColorCount) (first int, last int) {
+	switch terminalColorCount {
+	case ColorCount8:
+		return 0, 7
+	case ColorCount16:
+		return 0, 15
+	case ColorCount256:
+		// Colors 0-15 can be customized by the user, so we skip them and use
+		// only the well defined ones
+		return 16, 255
+	}
+
+	panic(fmt.Errorf("unhandled terminal color count %#v", terminalColorCount))
+}
+
+// The OSC 4 queries for all palette colors we might downsample to, or an empty
+// string if we won't be downsampling.
+//
+// Ref: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Operating-System-Commands
+func terminalPaletteQuery(terminalColorCount ColorCount) string {
+	if terminalColorCount == ColorCount24bit || terminalColorCount == ColorCountDefault {
+		return ""
+	}
+
+	first, last := paletteScanRange(terminalColorCount)
+	query := strings.Builder{}
+	for i := first; i <= last; i++ {
+		query.WriteString(fmt.Sprintf("\x1b]4;%d;?\x07", i))
+	}
+	return query.String()
+}
+
+// The RGB values of a palette color, as reported by the terminal if it did,
+// otherwise the standard ones.
+//
+// Must be called with terminalPaletteLock held.
+func paletteColorLocked(index uint8) Color {
+	color, found := terminalPalette[index]
+	if found {
+		return color
+	}
+
+	r, g, b := color256ToRGB(index)
+	return NewColor24Bit(r, g, b)
+}
+
+func setTerminalPaletteColor(index uint8, color Color) {
+	terminalPaletteLock.Lock()
+	terminalPalette[index] = color
+	terminalPaletteLock.Unlock()
+
+	// Anything downsampled so far may have used the old color
+	downsampleCacheLock.Lock()
+	downsampleCache = map[downsampleCacheKey]Color{}
+	downsampleCacheLock.Unlock()
+}
+
+// Parse a complete OSC 4 response like "\x1b]4;16;rgb:0000/0000/0000\x07" into
+// a palette index and a color.
+func parseTerminalPaletteResponse(response string) (uint8, Color, bool) {
+	prefix := "\x1b]4;"
+	if !strings.HasPrefix(response, prefix) {
+		log.Info("Got unexpected prefix in palette response from terminal: <", HumanizeLowASCII(response), ">")
+		return 0, ColorDefault, false
+	}
+	body := strings.TrimPrefix(response, prefix)
+
+	if strings.HasSuffix(body, "\x07") {
+		body = strings.TrimSuffix(body, "\x07")
+	} else if strings.HasSuffix(body, "\x1b\\") {
+		body = strings.TrimSuffix(body, "\x1b\\")
+	} else {
+		log.Info("Got unexpected suffix in palette response from terminal: <", HumanizeLowASCII(response), ">")
+		return 0, ColorDefault, false
+	}
+
+	// body is now "16;rgb:RRRR/GGGG/BBBB"
+	indexString, spec, found := strings.Cut(body, ";rgb:")
+	if !found {
+		log.Info("Got unexpected format in palette response from terminal: <", HumanizeLowASCII(response), ">")
+		return 0, ColorDefault, false
+	}
+
+	index, err := strconv.ParseUint(indexString, 10, 8)
+	if err != nil {
+		log.Info("Failed parsing index in palette response from terminal: <", HumanizeLowASCII(response), ">: ", err)
+		return 0, ColorDefault, false
+	}
+
+	components := strings.Split(spec, "/")
+	if len(components) != 3 {
+		log.Info("Got unexpected color in palette response from terminal: <", HumanizeLowASCII(response), ">")
+		return 0, ColorDefault, false
+	}
+
+	rgb := [3]uint8{}
+	for i, component := range components {
+		rgb[i], err = parseColorComponent(component)
+		if err != nil {
+			log.Info("Failed parsing color in palette response from terminal: <", HumanizeLowASCII(response), ">: ", err)
+			return 0, ColorDefault, false
+		}
+	}
+
+	return uint8(index), NewColor24Bit(rgb[0], rgb[1], rgb[2]), true
+}
+
+// Terminals report color components as one to four hex digits, scale those to
+// 0-255.
+func parseColorComponent(component string) (uint8, error) {
+	if len(component) < 1 || len(component) > 4 {
+		return 0, fmt.Errorf("Color component must be 1-4 hex digits: <%s>", component)
+	}
+
+	value, err := strconv.ParseUint(component, 16, 16)
+	if err != nil {
+		return 0, err
+	}
+
+	maxValue := uint64(1)<<(4*len(component)) - 1
+	return uint8(value * 255 / maxValue), nil
+}
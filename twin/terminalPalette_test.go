@@ -0,0 +1,94 @@
+package twin
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+// Forget about any palette colors set by a test
+func resetTerminalPalette() {
+	terminalPaletteLock.Lock()
+	terminalPalette = map[uint8]Color{}
+	terminalPaletteLock.Unlock()
+
+	downsampleCacheLock.Lock()
+	downsampleCache = map[downsampleCacheKey]Color{}
+	downsampleCacheLock.Unlock()
+}
+
+func TestParseTerminalPaletteResponse(t *testing.T) {
+	index, color, valid := parseTerminalPaletteResponse("\x1b]4;16;rgb:1212/3434/5656\x07")
+	assert.Assert(t, valid)
+	assert.Equal(t, index, uint8(16))
+	assert.Equal(t, color, NewColor24Bit(0x12, 0x34, 0x56))
+
+	// Two digit components, ESC \ terminator
+	index, color, valid = parseTerminalPaletteResponse("\x1b]4;255;rgb:ff/80/00\x1b\\")
+	assert.Assert(t, valid)
+	assert.Equal(t, index, uint8(255))
+	assert.Equal(t, color, NewColor24Bit(0xff, 0x80, 0x00))
+
+	_, _, valid = parseTerminalPaletteResponse("\x1b]4;256;rgb:ff/80/00\x07")
+	assert.Assert(t, !valid)
+
+	_, _, valid = parseTerminalPaletteResponse("\x1b]4;16;rgb:ff/80\x07")
+	assert.Assert(t, !valid)
+}
+
+func TestTerminalPaletteQuery(t *testing.T) {
+	assert.Equal(t, terminalPaletteQuery(ColorCount24bit), "")
+	assert.Equal(t, terminalPaletteQuery(ColorCount8),
+		"\x1b]4;0;?\x07\x1b]4;1;?\x07\x1b]4;2;?\x07\x1b]4;3;?\x07"+
+			"\x1b]4;4;?\x07\x1b]4;5;?\x07\x1b]4;6;?\x07\x1b]4;7;?\x07")
+}
+
+func TestConsumeTerminalResponses(t *testing.T) {
+	defer resetTerminalPalette()
+
+	now := time.Now()
+	screen := UnixScreen{terminalColorsQuery: &now}
+	screen.paletteQueried.Store(true)
+
+	// Partial response, should wait for more
+	rest, waitForMore := screen.consumeTerminalResponses("\x1b]11;rgb:ffff/ffff/ffff\x07\x1b]4;16;rgb:00")
+	assert.Assert(t, waitForMore)
+	assert.Equal(t, *screen.TerminalBackground(), NewColor24Bit(0xff, 0xff, 0xff))
+
+	// Complete response followed by a key press
+	rest, waitForMore = screen.consumeTerminalResponses(rest + "00/0000/8080\x07q")
+	assert.Assert(t, !waitForMore)
+	assert.Equal(t, rest, "q")
+
+	// The palette color should now be used for downsampling
+	assert.Equal(t, NewColor24Bit(0x00, 0x00, 0x80).downsampleTo(ColorCount256), NewColor256(16))
+
+	// The Escape key is not a response
+	rest, waitForMore = screen.consumeTerminalResponses("\x1b")
+	assert.Assert(t, !waitForMore)
+	assert.Equal(t, rest, "\x1b")
+}
+
+// Without a palette query, Alt-] followed by "4;" is key presses
+func TestConsumeTerminalResponsesNoPaletteQuery(t *testing.T) {
+	now := time.Now()
+	screen := UnixScreen{terminalColorsQuery: &now}
+
+	rest, waitForMore := screen.consumeTerminalResponses("\x1b]4;")
+	assert.Assert(t, !waitForMore)
+	assert.Equal(t, rest, "\x1b]4;")
+}
+
+func TestExpectingTerminalResponses(t *testing.T) {
+	screen := UnixScreen{}
+	assert.Assert(t, screen.expectingTerminalResponses(), "Not asked yet")
+
+	now := time.Now()
+	screen.terminalColorsQuery = &now
+	assert.Assert(t, screen.expectingTerminalResponses())
+
+	longAgo := now.Add(-terminalResponsesTimeout)
+	screen.terminalColorsQuery = &longAgo
+	assert.Assert(t, !screen.expectingTerminalResponses())
+}
@@ -0,0 +1,51 @@
+package twin
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Save and restore the terminal title using the terminal's title stack. The
+// "0" means both the icon and the window titles. Terminals without a title
+// stack ignore these, and keep showing our title after we exit.
+//
+// Ref: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Functions-using-CSI-_-ordered-by-the-final-character_s_
+const titlePush = "\x1b[22;0t"
+const titlePop = "\x1b[23;0t"
+
+// Set the window title using OSC 2
+func titleSequence(title string) string {
+	// Control characters could end the sequence early and inject other
+	// sequences, and file names can contain those
+	title = strings.Map(func(char rune) rune {
+		if unicode.IsControl(char) {
+			return -1
+		}
+		return char
+	}, title)
+
+	return "\x1b]2;" + title + "\x07"
+}
+
+func (screen *UnixScreen) SetTitle(title string) {
+	screen.ttyInReaderLock.Lock()
+	defer screen.ttyInReaderLock.Unlock()
+
+	screen.title = title
+
+	if screen.ttyInReader == nil {
+		// Suspended, Resume() will take care of this
+		return
+	}
+
+	screen.writeTitle()
+}
+
+// Set the terminal title to screen.title, saving the previous title first
+func (screen *UnixScreen) writeTitle() {
+	if !screen.titlePushed {
+		screen.write(titlePush)
+		screen.titlePushed = true
+	}
+	screen.write(titleSequence(screen.title))
+}